@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+)
+
+// backendFeature is one row of the dbh backends matrix.
+type backendFeature struct {
+	Name      string
+	Supported func(discovery.BackendCapabilities) bool
+}
+
+var backendFeatures = []backendFeature{
+	{"schemas level", func(c discovery.BackendCapabilities) bool { return c.Schemas }},
+	{"row estimates (--order-by-size)", func(c discovery.BackendCapabilities) bool { return c.RowEstimates }},
+	{"modification times (--only-changed)", func(c discovery.BackendCapabilities) bool { return c.LastModified }},
+	{"spread sampling (--sample-spread)", func(c discovery.BackendCapabilities) bool { return c.SpreadSampling }},
+	{"column comments (comments push)", func(c discovery.BackendCapabilities) bool { return c.ColumnComments }},
+	{"approximate distinct (--approx-distinct)", func(c discovery.BackendCapabilities) bool { return c.ApproxDistinct }},
+	{"block sampling (--tablesample-pct)", func(c discovery.BackendCapabilities) bool { return c.Tablesample }},
+	{"array elements (--unnest-arrays)", func(c discovery.BackendCapabilities) bool { return c.ArrayElements }},
+	{"percentiles (--percentiles)", func(c discovery.BackendCapabilities) bool { return c.Percentiles }},
+	{"catalog stats (--use-catalog-stats)", func(c discovery.BackendCapabilities) bool { return c.CatalogStats }},
+	{"SHOW commands (--show-commands)", func(c discovery.BackendCapabilities) bool { return c.ShowCommands }},
+}
+
+func runBackends(args []string) {
+	flags := flag.NewFlagSet("backends", flag.ExitOnError)
+	_ = flags.Parse(args)
+
+	if flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "backends does not accept positional arguments")
+		os.Exit(2)
+	}
+	printBackendMatrix(os.Stdout, discovery.Backends())
+}
+
+// printBackendMatrix writes one row per feature and one column per backend.
+func printBackendMatrix(w io.Writer, backends []discovery.BackendCapabilities) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := []string{"FEATURE"}
+	for _, backend := range backends {
+		header = append(header, backend.Type)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, feature := range backendFeatures {
+		row := []string{feature.Name}
+		for _, backend := range backends {
+			if feature.Supported(backend) {
+				row = append(row, "yes")
+			} else {
+				row = append(row, "-")
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	tw.Flush()
+}
+
+// dropUnsupportedEnrichment turns off the enrichment options the backend
+// cannot apply and returns a note for each, so dbh columns profiles the
+// rest instead of failing.
+func dropUnsupportedEnrichment(capabilities discovery.BackendCapabilities, opts discovery.EnrichmentOptions) (discovery.EnrichmentOptions, []string) {
+	var notes []string
+	drop := func(flag string) {
+		notes = append(notes, fmt.Sprintf("%s is not supported on %s; ignoring it.", flag, capabilities.Type))
+	}
+	if opts.ApproxDistinct && !capabilities.ApproxDistinct {
+		opts.ApproxDistinct = false
+		drop("--approx-distinct")
+	}
+	if opts.TablesamplePct > 0 && opts.TablesamplePct < 100 && !capabilities.Tablesample {
+		opts.TablesamplePct = 0
+		drop("--tablesample-pct")
+	}
+	if opts.UnnestArrays && !capabilities.ArrayElements {
+		opts.UnnestArrays = false
+		drop("--unnest-arrays")
+	}
+	if opts.Percentiles && !capabilities.Percentiles {
+		opts.Percentiles = false
+		drop("--percentiles")
+	}
+	return opts, notes
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+)
+
+func TestDropUnsupportedEnrichment(t *testing.T) {
+	opts := discovery.EnrichmentOptions{
+		ApproxDistinct: true,
+		TablesamplePct: 10,
+		UnnestArrays:   true,
+		Percentiles:    true,
+	}
+
+	got, notes := dropUnsupportedEnrichment(discovery.Capabilities("mysql"), opts)
+	if got.ApproxDistinct || got.TablesamplePct != 0 || got.UnnestArrays || got.Percentiles {
+		t.Errorf("mysql options = %+v, want every unsupported option off", got)
+	}
+	if len(notes) != 4 || !strings.Contains(notes[0], "--approx-distinct is not supported on mysql") {
+		t.Errorf("mysql notes = %q", notes)
+	}
+
+	got, notes = dropUnsupportedEnrichment(discovery.Capabilities("postgres"), opts)
+	if got.ApproxDistinct || got.TablesamplePct != 10 || !got.UnnestArrays || !got.Percentiles {
+		t.Errorf("postgres options = %+v, want only --approx-distinct off", got)
+	}
+	if len(notes) != 1 {
+		t.Errorf("postgres notes = %q, want one", notes)
+	}
+}
+
+func TestPrintBackendMatrix(t *testing.T) {
+	var out bytes.Buffer
+	printBackendMatrix(&out, []discovery.BackendCapabilities{
+		discovery.Capabilities("snowflake"),
+		discovery.Capabilities("sqlite"),
+	})
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != len(backendFeatures)+1 {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(backendFeatures)+1, out.String())
+	}
+	if fields := strings.Fields(lines[0]); len(fields) != 3 || fields[1] != "snowflake" || fields[2] != "sqlite" {
+		t.Errorf("header = %q", lines[0])
+	}
+	last := strings.Fields(lines[len(lines)-1])
+	if last[len(last)-2] != "yes" || last[len(last)-1] != "-" {
+		t.Errorf("SHOW commands row = %q, want yes for snowflake only", lines[len(lines)-1])
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// columnBudget bounds the total runtime of dbh columns (--budget), across
+// every database of the run. Each table is given a share of the remaining
+// time proportional to its column count among the database's remaining
+// columns, or more when the ETA from the columns profiled so far says it
+// needs more and the budget still has room. Tables whose ETA exceeds the
+// remaining budget are skipped. A zero budget is unlimited.
+type columnBudget struct {
+	total     time.Duration
+	startedAt time.Time
+	now       func() time.Time
+
+	// profiled counts the columns profiled in the run, for the ETA.
+	profiled int
+}
+
+func newColumnBudget(total time.Duration, startedAt time.Time) *columnBudget {
+	return &columnBudget{total: total, startedAt: startedAt, now: time.Now}
+}
+
+// limited reports whether a budget was set.
+func (b *columnBudget) limited() bool {
+	return b.total > 0
+}
+
+// remaining returns the unused budget, never negative.
+func (b *columnBudget) remaining() time.Duration {
+	left := b.total - b.now().Sub(b.startedAt)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// columnProfiled records one more profiled column for the ETA.
+func (b *columnBudget) columnProfiled() {
+	b.profiled++
+}
+
+// tableAllowance decides whether a table of tableColumns columns fits in the
+// remaining budget and how long it may take. remainingColumns counts the
+// columns of this and every later table of the database.
+func (b *columnBudget) tableAllowance(tableColumns, remainingColumns int) (time.Duration, bool) {
+	if !b.limited() {
+		return 0, true
+	}
+	left := b.remaining()
+	if left <= 0 || tableColumns <= 0 {
+		return 0, left > 0
+	}
+
+	allowance := left
+	if remainingColumns > tableColumns {
+		allowance = left * time.Duration(tableColumns) / time.Duration(remainingColumns)
+	}
+
+	estimate := estimateRemainingDuration(b.now().Sub(b.startedAt), b.profiled, tableColumns)
+	if estimate > left {
+		return 0, false
+	}
+	if estimate > allowance {
+		allowance = estimate
+	}
+	return allowance, true
+}
+
+// columnTimeout returns the timeout for the next column of a table that
+// started at tableStart with the given allowance: the per-column default,
+// shortened so the table stays within its allowance. It returns zero when
+// the allowance is used up.
+func (b *columnBudget) columnTimeout(tableStart time.Time, allowance time.Duration) time.Duration {
+	if !b.limited() {
+		return columnEnrichmentTimeout
+	}
+	left := allowance - b.now().Sub(tableStart)
+	if left <= 0 {
+		return 0
+	}
+	if left < columnEnrichmentTimeout {
+		return left
+	}
+	return columnEnrichmentTimeout
+}
+
+// parseBudgetFlag parses --budget. An empty value means no budget.
+func parseBudgetFlag(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	budget, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --budget %q: use a duration such as 30m or 1h30m", value)
+	}
+	if budget <= 0 {
+		return 0, fmt.Errorf("--budget must be positive, got %s", value)
+	}
+	return budget, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestColumnBudget(total time.Duration) (*columnBudget, *time.Time) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	budget := newColumnBudget(total, start)
+	budget.now = func() time.Time { return now }
+	return budget, &now
+}
+
+func TestColumnBudgetUnlimited(t *testing.T) {
+	budget, _ := newTestColumnBudget(0)
+
+	if _, fits := budget.tableAllowance(500, 1000); !fits {
+		t.Fatal("tableAllowance() fits = false without a budget")
+	}
+	if got := budget.columnTimeout(time.Now(), 0); got != columnEnrichmentTimeout {
+		t.Fatalf("columnTimeout() = %s, want %s", got, columnEnrichmentTimeout)
+	}
+}
+
+func TestColumnBudgetAllocatesProportionally(t *testing.T) {
+	budget, now := newTestColumnBudget(10 * time.Minute)
+
+	// No history yet: a quarter of the columns gets a quarter of the budget.
+	allowance, fits := budget.tableAllowance(25, 100)
+	if !fits || allowance != 150*time.Second {
+		t.Fatalf("tableAllowance(25, 100) = %s, %v; want 2m30s, true", allowance, fits)
+	}
+
+	// The last table gets everything that is left.
+	*now = now.Add(4 * time.Minute)
+	budget.profiled = 400
+	allowance, fits = budget.tableAllowance(10, 10)
+	if !fits || allowance != 6*time.Minute {
+		t.Fatalf("tableAllowance(last table) = %s, %v; want 6m0s, true", allowance, fits)
+	}
+}
+
+func TestColumnBudgetUsesETA(t *testing.T) {
+	budget, now := newTestColumnBudget(10 * time.Minute)
+	*now = now.Add(5 * time.Minute)
+	for range 50 {
+		budget.columnProfiled()
+	}
+
+	// 50 columns took 5m (6s each). A 20-column table needs ~2m, more than
+	// its proportional share of the remaining 5m but still within it.
+	allowance, fits := budget.tableAllowance(20, 100)
+	if !fits || allowance != 2*time.Minute {
+		t.Fatalf("tableAllowance(20, 100) = %s, %v; want 2m0s, true", allowance, fits)
+	}
+
+	// A 60-column table needs ~6m and does not fit.
+	if _, fits := budget.tableAllowance(60, 100); fits {
+		t.Fatal("tableAllowance(60, 100) fits = true, want false")
+	}
+
+	*now = now.Add(5 * time.Minute)
+	if _, fits := budget.tableAllowance(1, 1); fits {
+		t.Fatal("tableAllowance() fits = true after the budget ran out")
+	}
+}
+
+func TestColumnBudgetColumnTimeout(t *testing.T) {
+	budget, now := newTestColumnBudget(10 * time.Minute)
+	tableStart := *now
+
+	if got := budget.columnTimeout(tableStart, 5*time.Minute); got != columnEnrichmentTimeout {
+		t.Fatalf("columnTimeout() = %s, want the per-column default %s", got, columnEnrichmentTimeout)
+	}
+	*now = now.Add(4 * time.Minute)
+	if got := budget.columnTimeout(tableStart, 5*time.Minute); got != time.Minute {
+		t.Fatalf("columnTimeout() = %s, want the 1m left in the allowance", got)
+	}
+	*now = now.Add(2 * time.Minute)
+	if got := budget.columnTimeout(tableStart, 5*time.Minute); got != 0 {
+		t.Fatalf("columnTimeout() = %s, want 0 once the allowance is used", got)
+	}
+}
+
+func TestParseBudgetFlag(t *testing.T) {
+	if got, err := parseBudgetFlag(""); err != nil || got != 0 {
+		t.Fatalf("parseBudgetFlag(\"\") = %s, %v; want 0, nil", got, err)
+	}
+	if got, err := parseBudgetFlag("1h30m"); err != nil || got != 90*time.Minute {
+		t.Fatalf("parseBudgetFlag(1h30m) = %s, %v; want 1h30m0s, nil", got, err)
+	}
+	for _, value := range []string{"30", "-5m", "0s"} {
+		if _, err := parseBudgetFlag(value); err == nil {
+			t.Fatalf("parseBudgetFlag(%q) error = nil, want error", value)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/genesisdayrit/dbharness/internal/contextgen"
+)
+
+// Table statuses in a dbh columns --json report.
+const (
+	columnsTableWritten = "written"
+	columnsTableFailed  = "failed"
+)
+
+// columnsReport is the result of a dbh columns run, printed as JSON with
+// --json so wrappers do not have to parse the progress output. Error is set
+// when the run stopped before profiling every selected database.
+type columnsReport struct {
+	Connection string                   `json:"connection"`
+	Error      string                   `json:"error,omitempty"`
+	Databases  []*columnsDatabaseReport `json:"databases"`
+}
+
+// columnsDatabaseReport is the outcome for one database. Error is set when
+// the database could not be profiled at all.
+type columnsDatabaseReport struct {
+	Database         string               `json:"database"`
+	Error            string               `json:"error,omitempty"`
+	TablesWritten    int                  `json:"tables_written"`
+	TablesFailed     int                  `json:"tables_failed"`
+	ColumnsProcessed int                  `json:"columns_processed"`
+	ColumnsTotal     int                  `json:"columns_total"`
+	DurationMS       int64                `json:"duration_ms"`
+	Tables           []columnsTableReport `json:"tables"`
+}
+
+// columnsTableReport is the outcome for one table. Path and DurationMS are
+// only set for written tables, Reason only for failed ones.
+type columnsTableReport struct {
+	Schema     string `json:"schema"`
+	Table      string `json:"table"`
+	Status     string `json:"status"`
+	Columns    int    `json:"columns,omitempty"`
+	DurationMS int64  `json:"duration_ms,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// addDatabase starts the report for database. It is nil-safe so callers do
+// not check whether --json was passed.
+func (r *columnsReport) addDatabase(database string) *columnsDatabaseReport {
+	if r == nil {
+		return nil
+	}
+	report := &columnsDatabaseReport{Database: database, Tables: []columnsTableReport{}}
+	r.Databases = append(r.Databases, report)
+	return report
+}
+
+func (r *columnsDatabaseReport) fail(message string) {
+	if r != nil {
+		r.Error = message
+	}
+}
+
+func (r *columnsDatabaseReport) tableWritten(schema, table string, columns int, took time.Duration, path string) {
+	if r == nil {
+		return
+	}
+	r.TablesWritten++
+	r.Tables = append(r.Tables, columnsTableReport{
+		Schema:     schema,
+		Table:      table,
+		Status:     columnsTableWritten,
+		Columns:    columns,
+		DurationMS: took.Milliseconds(),
+		Path:       path,
+	})
+}
+
+// finish records the failed tables and the run totals.
+func (r *columnsDatabaseReport) finish(failures []contextgen.EnrichFailure, processedColumns, totalColumns int, took time.Duration) {
+	if r == nil {
+		return
+	}
+	for _, failure := range failures {
+		r.TablesFailed++
+		r.Tables = append(r.Tables, columnsTableReport{
+			Schema: failure.Schema,
+			Table:  failure.Table,
+			Status: columnsTableFailed,
+			Reason: failure.Reason,
+		})
+	}
+	r.ColumnsProcessed = processedColumns
+	r.ColumnsTotal = totalColumns
+	r.DurationMS = took.Milliseconds()
+}
+
+// abort prints the message to stderr and exits with code. With --json it
+// first prints the report so far with the message as its error, so stdout
+// still holds one JSON object. It is nil-safe like addDatabase.
+func (r *columnsReport) abort(code int, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	fmt.Fprintln(os.Stderr, message)
+	if r != nil {
+		r.Error = message
+		r.write(os.Stdout)
+	}
+	exit(code)
+}
+
+// write prints the report to w, reporting a failure on stderr.
+func (r *columnsReport) write(w io.Writer) {
+	if err := writeColumnsReport(w, r); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func writeColumnsReport(w io.Writer, report *columnsReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/genesisdayrit/dbharness/internal/contextgen"
+)
+
+func TestColumnsReport(t *testing.T) {
+	report := &columnsReport{Connection: "dev", Databases: []*columnsDatabaseReport{}}
+
+	db := report.addDatabase("analytics")
+	db.tableWritten("public", "users", 3, 1500*time.Millisecond, "/tmp/users__columns.yml")
+	db.finish([]contextgen.EnrichFailure{{Schema: "public", Table: "events", Reason: "ran out of budget"}}, 3, 7, 2*time.Second)
+	report.addDatabase("archive").fail("connect: refused")
+
+	var buf bytes.Buffer
+	if err := writeColumnsReport(&buf, report); err != nil {
+		t.Fatalf("writeColumnsReport() error = %v", err)
+	}
+
+	var decoded struct {
+		Connection string `json:"connection"`
+		Databases  []struct {
+			Database         string `json:"database"`
+			Error            string `json:"error"`
+			TablesWritten    int    `json:"tables_written"`
+			TablesFailed     int    `json:"tables_failed"`
+			ColumnsProcessed int    `json:"columns_processed"`
+			ColumnsTotal     int    `json:"columns_total"`
+			DurationMS       int64  `json:"duration_ms"`
+			Tables           []struct {
+				Table      string `json:"table"`
+				Status     string `json:"status"`
+				Columns    int    `json:"columns"`
+				DurationMS int64  `json:"duration_ms"`
+				Path       string `json:"path"`
+				Reason     string `json:"reason"`
+			} `json:"tables"`
+		} `json:"databases"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("report is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if decoded.Connection != "dev" || len(decoded.Databases) != 2 {
+		t.Fatalf("report = %s", buf.String())
+	}
+
+	analytics := decoded.Databases[0]
+	if analytics.TablesWritten != 1 || analytics.TablesFailed != 1 || analytics.ColumnsProcessed != 3 || analytics.ColumnsTotal != 7 || analytics.DurationMS != 2000 {
+		t.Fatalf("analytics totals = %+v", analytics)
+	}
+	written, failed := analytics.Tables[0], analytics.Tables[1]
+	if written.Status != "written" || written.Columns != 3 || written.DurationMS != 1500 || written.Path != "/tmp/users__columns.yml" {
+		t.Fatalf("written table = %+v", written)
+	}
+	if failed.Status != "failed" || failed.Table != "events" || failed.Reason != "ran out of budget" {
+		t.Fatalf("failed table = %+v", failed)
+	}
+
+	archive := decoded.Databases[1]
+	if archive.Error != "connect: refused" || archive.Tables == nil {
+		t.Fatalf("archive = %+v, want the error and an empty tables list", archive)
+	}
+}
+
+func TestColumnsReportNilIsNoop(t *testing.T) {
+	var report *columnsReport
+	db := report.addDatabase("analytics")
+	db.tableWritten("public", "users", 1, time.Second, "path")
+	db.finish(nil, 1, 1, time.Second)
+	db.fail("boom")
+	if db != nil {
+		t.Fatalf("addDatabase on a nil report = %+v, want nil", db)
+	}
+}
+
+func TestColumnsReportError(t *testing.T) {
+	report := &columnsReport{Connection: "dev", Databases: []*columnsDatabaseReport{}, Error: `connection "dev" not found`}
+
+	var buf bytes.Buffer
+	report.write(&buf)
+
+	var decoded struct {
+		Error     string            `json:"error"`
+		Databases []json.RawMessage `json:"databases"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("report is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if decoded.Error != `connection "dev" not found` || decoded.Databases == nil {
+		t.Fatalf("report = %s, want the error and an empty databases list", buf.String())
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/genesisdayrit/dbharness/internal/contextgen"
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+)
+
+// commentStatementTimeout bounds each comment statement, including the
+// SHOW CREATE TABLE lookup MySQL needs to build it.
+const commentStatementTimeout = time.Minute
+
+// columnComment is one ai_description to write to the database.
+type columnComment struct {
+	Schema  string
+	Table   string
+	Column  string
+	Comment string
+}
+
+func runComments(args []string) {
+	if len(args) == 0 || args[0] != "push" {
+		fmt.Fprintln(os.Stderr, "Usage: dbh comments push [-s name] [--databases a,b] [--dry-run] [--overwrite] [--yes]")
+		os.Exit(2)
+	}
+	runCommentsPush(args[1:])
+}
+
+// runCommentsPush writes the ai_description of each column in the generated
+// columns files to the database as the column's comment.
+func runCommentsPush(args []string) {
+	flags := flag.NewFlagSet("comments push", flag.ExitOnError)
+	shortName := flags.String("s", "", "Connection name from config.json.")
+	longName := flags.String("name", "", "Connection name from config.json.")
+	databasesFlag := flags.String("databases", "", "Comma-separated databases to push (default: all generated databases).")
+	dryRun := flags.Bool("dry-run", false, "Print the statements without running them.")
+	overwrite := flags.Bool("overwrite", false, "Also replace columns that already have a database comment (db_description).")
+	shortYes := flags.Bool("y", false, "Skip the confirmation prompt.")
+	longYes := flags.Bool("yes", false, "Skip the confirmation prompt.")
+	_ = flags.Parse(args)
+
+	assumeYes := *shortYes || *longYes
+	if !assumeYes && !*dryRun && !stdinIsTerminal() {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("%w (dbh comments push asks for confirmation before writing)", errNoTTY))
+		os.Exit(1)
+	}
+
+	name := *shortName
+	if name == "" {
+		name = *longName
+	}
+
+	baseDir := filepath.Join(".", ".dbharness")
+	configPath := resolveConfigPath()
+	cfg, err := readConfig(configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var dbCfg databaseConfig
+	if name == "" {
+		dbCfg, err = findPrimaryConnection(cfg, "")
+	} else {
+		dbCfg, err = findDatabaseConfig(cfg, name)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	switch dbCfg.Type {
+	case "postgres", "redshift", "mysql", "snowflake":
+	default:
+		fmt.Fprintf(os.Stderr, "dbh comments push does not support %s connections (supported: postgres, redshift, mysql, snowflake)\n", dbCfg.Type)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Using connection %q (%s)\n\n", dbCfg.Name, dbCfg.Type)
+
+	databases := parseListFlag(*databasesFlag)
+	if len(databases) == 0 {
+		databases, err = exportDatabaseNames(baseDir, dbCfg, false)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	failed := 0
+	for _, database := range databases {
+		fmt.Printf("--- Database: %s ---\n", database)
+		if err := pushDatabaseComments(baseDir, dbCfg, database, *dryRun, *overwrite, assumeYes); err != nil {
+			failed++
+			fmt.Printf("Could not push comments for database %q: %v\n", database, err)
+		}
+		fmt.Println()
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d database(s) failed\n", failed, len(databases))
+		os.Exit(1)
+	}
+}
+
+func pushDatabaseComments(baseDir string, dbCfg databaseConfig, database string, dryRun, overwrite, assumeYes bool) error {
+	dbContext, err := contextgen.LoadDatabaseContext(contextgen.Options{
+		ConnectionName: dbCfg.Name,
+		DatabaseName:   database,
+		DatabaseType:   dbCfg.Type,
+		BaseDir:        baseDir,
+		PreserveCase:   dbCfg.PreserveCase,
+	})
+	if err != nil {
+		return err
+	}
+
+	comments, kept := pendingColumnComments(dbContext, overwrite)
+	if kept > 0 {
+		fmt.Printf("Keeping %d existing database comment(s); pass --overwrite to replace them.\n", kept)
+	}
+	if len(comments) == 0 {
+		fmt.Println("No ai_description values to push.")
+		return nil
+	}
+
+	dbCfgCopy := dbCfg
+	dbCfgCopy.Database = database
+	announceConnection(os.Stdout, dbCfg)
+	disc, err := discovery.New(toDiscoveryConfig(dbCfgCopy))
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer disc.Close()
+
+	commenter, ok := disc.(discovery.ColumnCommenter)
+	if !ok {
+		return fmt.Errorf("%s connections cannot write column comments", dbCfg.Type)
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run: %d statement(s) would run:\n", len(comments))
+		return printColumnCommentStatements(os.Stdout, commenter, comments)
+	}
+
+	fmt.Printf("%d column comment(s) will be written:\n", len(comments))
+	for _, c := range comments {
+		fmt.Printf("  %s.%s.%s\n", c.Schema, c.Table, c.Column)
+	}
+	if !assumeYes {
+		label := fmt.Sprintf("Write %d column comment(s) to database %q on connection %q?", len(comments), database, dbCfg.Name)
+		if isProductionEnvironment(dbCfg.Environment) {
+			label = fmt.Sprintf("Connection %q is labeled %q. %s", dbCfg.Name, strings.TrimSpace(dbCfg.Environment), label)
+		}
+		if !promptYesNoDefaultNo(label) {
+			fmt.Println("Aborted.")
+			return nil
+		}
+	}
+
+	written, failed := 0, 0
+	for _, c := range comments {
+		ctx, cancel := context.WithTimeout(context.Background(), commentStatementTimeout)
+		err := commenter.SetColumnComment(ctx, c.Schema, c.Table, c.Column, c.Comment)
+		cancel()
+		if err != nil {
+			failed++
+			fmt.Printf("  Failed %s.%s.%s: %v\n", c.Schema, c.Table, c.Column, err)
+			continue
+		}
+		written++
+	}
+	fmt.Printf("Wrote %d column comment(s).\n", written)
+	if failed > 0 {
+		return fmt.Errorf("%d column comment(s) failed", failed)
+	}
+	return nil
+}
+
+// pendingColumnComments returns the columns whose ai_description should be
+// written: non-empty and different from db_description. Columns that already
+// have a different db_description are only included with overwrite; kept
+// counts the ones left alone.
+func pendingColumnComments(dbContext *contextgen.DatabaseContext, overwrite bool) (comments []columnComment, kept int) {
+	for _, schema := range dbContext.Schemas {
+		for _, table := range schema.Tables {
+			for _, column := range table.Columns {
+				comment := strings.TrimSpace(column.AIDescription)
+				existing := strings.TrimSpace(column.DBDescription)
+				if comment == "" || comment == existing {
+					continue
+				}
+				if existing != "" && !overwrite {
+					kept++
+					continue
+				}
+				comments = append(comments, columnComment{
+					Schema:  schema.Name,
+					Table:   table.Name,
+					Column:  column.Name,
+					Comment: comment,
+				})
+			}
+		}
+	}
+	return comments, kept
+}
+
+func printColumnCommentStatements(w io.Writer, commenter discovery.ColumnCommenter, comments []columnComment) error {
+	for _, c := range comments {
+		ctx, cancel := context.WithTimeout(context.Background(), commentStatementTimeout)
+		statement, err := commenter.ColumnCommentStatement(ctx, c.Schema, c.Table, c.Column, c.Comment)
+		cancel()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s;\n", statement)
+	}
+	return nil
+}
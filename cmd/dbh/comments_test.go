@@ -0,0 +1,37 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/genesisdayrit/dbharness/internal/contextgen"
+)
+
+func TestPendingColumnComments(t *testing.T) {
+	dbContext := &contextgen.DatabaseContext{
+		Schemas: []contextgen.SchemaContext{{
+			Name: "public",
+			Tables: []contextgen.TableContext{{
+				Name: "users",
+				Columns: []contextgen.EnrichedColumnsFileItem{
+					{Name: "id"},
+					{Name: "email", AIDescription: " Login e-mail "},
+					{Name: "name", AIDescription: "Display name", DBDescription: "Display name"},
+					{Name: "status", AIDescription: "Account state", DBDescription: "legacy"},
+				},
+			}},
+		}},
+	}
+
+	comments, kept := pendingColumnComments(dbContext, false)
+	want := []columnComment{{Schema: "public", Table: "users", Column: "email", Comment: "Login e-mail"}}
+	if !reflect.DeepEqual(comments, want) || kept != 1 {
+		t.Fatalf("pendingColumnComments(overwrite=false) = %+v, %d; want %+v, 1", comments, kept, want)
+	}
+
+	comments, kept = pendingColumnComments(dbContext, true)
+	want = append(want, columnComment{Schema: "public", Table: "users", Column: "status", Comment: "Account state"})
+	if !reflect.DeepEqual(comments, want) || kept != 0 {
+		t.Fatalf("pendingColumnComments(overwrite=true) = %+v, %d; want %+v, 0", comments, kept, want)
+	}
+}
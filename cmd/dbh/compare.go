@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+	"github.com/genesisdayrit/dbharness/pkg/dbharness"
+)
+
+// envSnapshot is the live table and column layout of one connection, keyed
+// by "schema.table".
+type envSnapshot struct {
+	Connection string
+	Tables     map[string][]discovery.ColumnInfo
+}
+
+// envComparison is the drift report written by dbh schemas --compare-env.
+// Left and right are the two connections in the order given.
+type envComparison struct {
+	Left              string            `json:"left"`
+	Right             string            `json:"right"`
+	TablesOnlyInLeft  []string          `json:"tables_only_in_left"`
+	TablesOnlyInRight []string          `json:"tables_only_in_right"`
+	ColumnsOnlyLeft   []envColumn       `json:"columns_only_in_left"`
+	ColumnsOnlyRight  []envColumn       `json:"columns_only_in_right"`
+	TypeMismatches    []envTypeMismatch `json:"type_mismatches"`
+
+	// Changes lists every difference above as one entry, categorized with
+	// left as the baseline, for tools that gate on the kind of change.
+	Changes []envChange `json:"changes"`
+}
+
+// envChangeKind categorizes a difference, reading left as the baseline and
+// right as the connection compared against it.
+type envChangeKind string
+
+const (
+	envChangeAdded   envChangeKind = "added"
+	envChangeRemoved envChangeKind = "removed"
+	envChangeChanged envChangeKind = "changed"
+)
+
+// envChange is one entry of envComparison.Changes. Object is "table" or
+// "column"; Column is empty for tables.
+type envChange struct {
+	Kind      envChangeKind `json:"kind"`
+	Object    string        `json:"object"`
+	Table     string        `json:"table"`
+	Column    string        `json:"column,omitempty"`
+	LeftType  string        `json:"left_type,omitempty"`
+	RightType string        `json:"right_type,omitempty"`
+}
+
+type envColumn struct {
+	Table    string `json:"table"`
+	Column   string `json:"column"`
+	DataType string `json:"data_type"`
+}
+
+type envTypeMismatch struct {
+	Table     string `json:"table"`
+	Column    string `json:"column"`
+	LeftType  string `json:"left_type"`
+	RightType string `json:"right_type"`
+}
+
+// parseCompareEnvFlag splits the --compare-env value into exactly two
+// distinct connection names.
+func parseCompareEnvFlag(value string) (string, string, error) {
+	names := parseListFlag(value)
+	if len(names) != 2 {
+		return "", "", fmt.Errorf("--compare-env needs exactly two connection names, e.g. staging,prod")
+	}
+	if names[0] == names[1] {
+		return "", "", fmt.Errorf("--compare-env needs two different connections, got %q twice", names[0])
+	}
+	return names[0], names[1], nil
+}
+
+// parseFailOnFlag parses the --fail-on list of change kinds.
+func parseFailOnFlag(value string) ([]envChangeKind, error) {
+	var kinds []envChangeKind
+	for _, name := range parseListFlag(value) {
+		kind := envChangeKind(strings.ToLower(name))
+		switch kind {
+		case envChangeAdded, envChangeRemoved, envChangeChanged:
+			kinds = append(kinds, kind)
+		default:
+			return nil, fmt.Errorf("unsupported --fail-on kind %q (use added, removed, or changed)", name)
+		}
+	}
+	return kinds, nil
+}
+
+// errSchemaDrift is returned by runCompareEnv when the comparison has a
+// change of a kind listed in --fail-on.
+type errSchemaDrift struct {
+	counts map[envChangeKind]int
+	kinds  []envChangeKind
+}
+
+func (e errSchemaDrift) Error() string {
+	parts := make([]string, 0, len(e.kinds))
+	for _, kind := range e.kinds {
+		if e.counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", e.counts[kind], kind))
+		}
+	}
+	return fmt.Sprintf("schema drift matches --fail-on: %s", strings.Join(parts, ", "))
+}
+
+// checkFailOn returns an errSchemaDrift when changes include any of kinds.
+func checkFailOn(changes []envChange, kinds []envChangeKind) error {
+	counts := make(map[envChangeKind]int)
+	failed := false
+	for _, change := range changes {
+		if slices.Contains(kinds, change.Kind) {
+			counts[change.Kind]++
+			failed = true
+		}
+	}
+	if !failed {
+		return nil
+	}
+	return errSchemaDrift{counts: counts, kinds: kinds}
+}
+
+// runCompareEnv discovers both connections and prints their schema drift.
+// After printing, it fails with errSchemaDrift when the drift includes a
+// change of one of failOn's kinds.
+func runCompareEnv(cfg config, leftName, rightName string, asJSON bool, failOn []envChangeKind, w io.Writer) error {
+	snapshots := make([]envSnapshot, 0, 2)
+	for _, name := range []string{leftName, rightName} {
+		dbCfg, err := findDatabaseConfig(cfg, name)
+		if err != nil {
+			return err
+		}
+		snapshot, err := snapshotEnv(dbCfg)
+		if err != nil {
+			return fmt.Errorf("connection %q: %w", name, err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	comparison := compareEnvSnapshots(snapshots[0], snapshots[1])
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(comparison); err != nil {
+			return err
+		}
+	} else {
+		printEnvComparison(w, comparison)
+	}
+	return checkFailOn(comparison.Changes, failOn)
+}
+
+// snapshotEnv reads every table and its columns from the connection's
+// default database.
+func snapshotEnv(dbCfg databaseConfig) (envSnapshot, error) {
+	if requiresExplicitDatabaseSelection(dbCfg.Type) && strings.TrimSpace(dbCfg.Database) == "" {
+		return envSnapshot{}, fmt.Errorf("no default database configured; run dbh schemas -s %s to pick one first", dbCfg.Name)
+	}
+
+	fmt.Fprintf(os.Stderr, "Discovering connection %q (%s)...\n", dbCfg.Name, dbCfg.Type)
+	if usesBrowserLogin(dbCfg) {
+		// Keep stdout clean for --json.
+		fmt.Fprintln(os.Stderr, "Opening browser for SSO authentication...")
+	}
+
+	disc, err := discovery.NewTableDetailDiscoverer(toDiscoveryConfig(dbCfg))
+	if err != nil {
+		return envSnapshot{}, fmt.Errorf("connect: %w", err)
+	}
+	defer disc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout(dbCfg, tableSchemaDiscoveryTimeout))
+	schemas, err := disc.Discover(ctx)
+	cancel()
+	if err != nil {
+		return envSnapshot{}, fmt.Errorf("discover schemas: %w", explainLoginTimeout(dbCfg, err))
+	}
+	noteCatalogFallback(os.Stderr, dbharness.CatalogFallback(disc))
+	warnNoAllowlistedSchemas(os.Stderr, dbCfg, schemas)
+	disc = discovery.RestrictToDiscovered(disc, schemas)
+
+	snapshot := envSnapshot{Connection: dbCfg.Name, Tables: make(map[string][]discovery.ColumnInfo)}
+	for _, schema := range schemas {
+		for _, table := range schema.Tables {
+			ctx, cancel := context.WithTimeout(context.Background(), tableColumnsQueryTimeout)
+			columns, err := disc.GetColumns(ctx, schema.Name, table.Name)
+			cancel()
+			if err != nil {
+				return envSnapshot{}, fmt.Errorf("columns for %s.%s: %w", schema.Name, table.Name, err)
+			}
+			snapshot.Tables[schema.Name+"."+table.Name] = columns
+		}
+	}
+	return snapshot, nil
+}
+
+// compareEnvSnapshots reports tables and columns present on only one side
+// and shared columns whose data types differ. Data types are compared
+// case-insensitively.
+func compareEnvSnapshots(left, right envSnapshot) envComparison {
+	comparison := envComparison{
+		Left:              left.Connection,
+		Right:             right.Connection,
+		TablesOnlyInLeft:  []string{},
+		TablesOnlyInRight: []string{},
+		ColumnsOnlyLeft:   []envColumn{},
+		ColumnsOnlyRight:  []envColumn{},
+		TypeMismatches:    []envTypeMismatch{},
+		Changes:           []envChange{},
+	}
+
+	for _, table := range sortedTableKeys(left.Tables) {
+		rightColumns, ok := right.Tables[table]
+		if !ok {
+			comparison.TablesOnlyInLeft = append(comparison.TablesOnlyInLeft, table)
+			continue
+		}
+
+		rightByName := columnsByName(rightColumns)
+		leftByName := columnsByName(left.Tables[table])
+		for _, column := range left.Tables[table] {
+			other, ok := rightByName[column.Name]
+			if !ok {
+				comparison.ColumnsOnlyLeft = append(comparison.ColumnsOnlyLeft, envColumn{Table: table, Column: column.Name, DataType: column.DataType})
+				continue
+			}
+			if !strings.EqualFold(strings.TrimSpace(column.DataType), strings.TrimSpace(other.DataType)) {
+				comparison.TypeMismatches = append(comparison.TypeMismatches, envTypeMismatch{
+					Table:     table,
+					Column:    column.Name,
+					LeftType:  column.DataType,
+					RightType: other.DataType,
+				})
+			}
+		}
+		for _, column := range rightColumns {
+			if _, ok := leftByName[column.Name]; !ok {
+				comparison.ColumnsOnlyRight = append(comparison.ColumnsOnlyRight, envColumn{Table: table, Column: column.Name, DataType: column.DataType})
+			}
+		}
+	}
+	for _, table := range sortedTableKeys(right.Tables) {
+		if _, ok := left.Tables[table]; !ok {
+			comparison.TablesOnlyInRight = append(comparison.TablesOnlyInRight, table)
+		}
+	}
+
+	for _, table := range comparison.TablesOnlyInLeft {
+		comparison.Changes = append(comparison.Changes, envChange{Kind: envChangeRemoved, Object: "table", Table: table})
+	}
+	for _, table := range comparison.TablesOnlyInRight {
+		comparison.Changes = append(comparison.Changes, envChange{Kind: envChangeAdded, Object: "table", Table: table})
+	}
+	for _, column := range comparison.ColumnsOnlyLeft {
+		comparison.Changes = append(comparison.Changes, envChange{Kind: envChangeRemoved, Object: "column", Table: column.Table, Column: column.Column, LeftType: column.DataType})
+	}
+	for _, column := range comparison.ColumnsOnlyRight {
+		comparison.Changes = append(comparison.Changes, envChange{Kind: envChangeAdded, Object: "column", Table: column.Table, Column: column.Column, RightType: column.DataType})
+	}
+	for _, mismatch := range comparison.TypeMismatches {
+		comparison.Changes = append(comparison.Changes, envChange{Kind: envChangeChanged, Object: "column", Table: mismatch.Table, Column: mismatch.Column, LeftType: mismatch.LeftType, RightType: mismatch.RightType})
+	}
+	return comparison
+}
+
+// hasDifferences reports whether the comparison found any drift.
+func (c envComparison) hasDifferences() bool {
+	return len(c.TablesOnlyInLeft) > 0 ||
+		len(c.TablesOnlyInRight) > 0 ||
+		len(c.ColumnsOnlyLeft) > 0 ||
+		len(c.ColumnsOnlyRight) > 0 ||
+		len(c.TypeMismatches) > 0
+}
+
+func printEnvComparison(w io.Writer, c envComparison) {
+	fmt.Fprintf(w, "Comparing %q with %q\n", c.Left, c.Right)
+	if !c.hasDifferences() {
+		fmt.Fprintln(w, "No differences found.")
+		return
+	}
+
+	printEnvTables(w, fmt.Sprintf("Tables only in %s", c.Left), c.TablesOnlyInLeft)
+	printEnvTables(w, fmt.Sprintf("Tables only in %s", c.Right), c.TablesOnlyInRight)
+	printEnvColumns(w, fmt.Sprintf("Columns only in %s", c.Left), c.ColumnsOnlyLeft)
+	printEnvColumns(w, fmt.Sprintf("Columns only in %s", c.Right), c.ColumnsOnlyRight)
+	if len(c.TypeMismatches) > 0 {
+		fmt.Fprintf(w, "\nType mismatches (%d):\n", len(c.TypeMismatches))
+		for _, mismatch := range c.TypeMismatches {
+			fmt.Fprintf(w, "  %s.%s: %s=%s, %s=%s\n", mismatch.Table, mismatch.Column, c.Left, mismatch.LeftType, c.Right, mismatch.RightType)
+		}
+	}
+}
+
+func printEnvTables(w io.Writer, heading string, tables []string) {
+	if len(tables) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s (%d):\n", heading, len(tables))
+	for _, table := range tables {
+		fmt.Fprintf(w, "  %s\n", table)
+	}
+}
+
+func printEnvColumns(w io.Writer, heading string, columns []envColumn) {
+	if len(columns) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s (%d):\n", heading, len(columns))
+	for _, column := range columns {
+		fmt.Fprintf(w, "  %s.%s (%s)\n", column.Table, column.Column, column.DataType)
+	}
+}
+
+func sortedTableKeys(tables map[string][]discovery.ColumnInfo) []string {
+	keys := make([]string, 0, len(tables))
+	for key := range tables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func columnsByName(columns []discovery.ColumnInfo) map[string]discovery.ColumnInfo {
+	byName := make(map[string]discovery.ColumnInfo, len(columns))
+	for _, column := range columns {
+		byName[column.Name] = column
+	}
+	return byName
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+)
+
+func TestParseCompareEnvFlag(t *testing.T) {
+	left, right, err := parseCompareEnvFlag(" staging , prod ")
+	if err != nil {
+		t.Fatalf("parseCompareEnvFlag() error = %v", err)
+	}
+	if left != "staging" || right != "prod" {
+		t.Fatalf("parseCompareEnvFlag() = %q, %q, want staging, prod", left, right)
+	}
+
+	for _, value := range []string{"staging", "staging,prod,dev", "prod,prod"} {
+		if _, _, err := parseCompareEnvFlag(value); err == nil {
+			t.Fatalf("parseCompareEnvFlag(%q) error = nil, want error", value)
+		}
+	}
+}
+
+func TestCompareEnvSnapshots(t *testing.T) {
+	left := envSnapshot{
+		Connection: "staging",
+		Tables: map[string][]discovery.ColumnInfo{
+			"public.users": {
+				{Name: "id", DataType: "integer"},
+				{Name: "email", DataType: "text"},
+				{Name: "beta_flag", DataType: "boolean"},
+			},
+			"public.experiments": {{Name: "id", DataType: "integer"}},
+		},
+	}
+	right := envSnapshot{
+		Connection: "prod",
+		Tables: map[string][]discovery.ColumnInfo{
+			"public.users": {
+				{Name: "id", DataType: "bigint"},
+				{Name: "email", DataType: "TEXT"},
+				{Name: "deleted_at", DataType: "timestamp"},
+			},
+			"public.audit_log": {{Name: "id", DataType: "bigint"}},
+		},
+	}
+
+	got := compareEnvSnapshots(left, right)
+	want := envComparison{
+		Left:              "staging",
+		Right:             "prod",
+		TablesOnlyInLeft:  []string{"public.experiments"},
+		TablesOnlyInRight: []string{"public.audit_log"},
+		ColumnsOnlyLeft:   []envColumn{{Table: "public.users", Column: "beta_flag", DataType: "boolean"}},
+		ColumnsOnlyRight:  []envColumn{{Table: "public.users", Column: "deleted_at", DataType: "timestamp"}},
+		TypeMismatches:    []envTypeMismatch{{Table: "public.users", Column: "id", LeftType: "integer", RightType: "bigint"}},
+		Changes: []envChange{
+			{Kind: envChangeRemoved, Object: "table", Table: "public.experiments"},
+			{Kind: envChangeAdded, Object: "table", Table: "public.audit_log"},
+			{Kind: envChangeRemoved, Object: "column", Table: "public.users", Column: "beta_flag", LeftType: "boolean"},
+			{Kind: envChangeAdded, Object: "column", Table: "public.users", Column: "deleted_at", RightType: "timestamp"},
+			{Kind: envChangeChanged, Object: "column", Table: "public.users", Column: "id", LeftType: "integer", RightType: "bigint"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("compareEnvSnapshots() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRunCompareEnvSQLite(t *testing.T) {
+	dir := t.TempDir()
+	stagingPath := createCompareTestDatabase(t, filepath.Join(dir, "staging.db"),
+		"CREATE TABLE users (id INTEGER, email TEXT, beta_flag INTEGER)")
+	prodPath := createCompareTestDatabase(t, filepath.Join(dir, "prod.db"),
+		"CREATE TABLE users (id INTEGER, email VARCHAR(255))")
+
+	cfg := config{Connections: []databaseConfig{
+		{Name: "staging", Type: "sqlite", Database: stagingPath},
+		{Name: "prod", Type: "sqlite", Database: prodPath},
+	}}
+
+	var text bytes.Buffer
+	if err := runCompareEnv(cfg, "staging", "prod", false, nil, &text); err != nil {
+		t.Fatalf("runCompareEnv() error = %v", err)
+	}
+	for _, want := range []string{
+		"Columns only in staging (1):\n  main.users.beta_flag (INTEGER)",
+		"main.users.email: staging=TEXT, prod=VARCHAR(255)",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Fatalf("report = %q, want it to contain %q", text.String(), want)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := runCompareEnv(cfg, "staging", "prod", true, nil, &out); err != nil {
+		t.Fatalf("runCompareEnv(json) error = %v", err)
+	}
+	var report envComparison
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("parse JSON report: %v\n%s", err, out.String())
+	}
+	if len(report.ColumnsOnlyLeft) != 1 || len(report.TypeMismatches) != 1 || len(report.TablesOnlyInLeft) != 0 {
+		t.Fatalf("JSON report = %+v, want one left-only column and one type mismatch", report)
+	}
+
+	if err := runCompareEnv(cfg, "staging", "missing", false, nil, &bytes.Buffer{}); err == nil {
+		t.Fatal("runCompareEnv(unknown connection) error = nil, want error")
+	}
+
+	// staging has beta_flag and prod does not, so prod removed a column.
+	if err := runCompareEnv(cfg, "staging", "prod", true, []envChangeKind{envChangeAdded}, &bytes.Buffer{}); err != nil {
+		t.Fatalf("runCompareEnv(--fail-on added) error = %v, want nil", err)
+	}
+	var drift errSchemaDrift
+	err := runCompareEnv(cfg, "staging", "prod", true, []envChangeKind{envChangeRemoved, envChangeChanged}, &bytes.Buffer{})
+	if !errors.As(err, &drift) || err.Error() != "schema drift matches --fail-on: 1 removed, 1 changed" {
+		t.Fatalf("runCompareEnv(--fail-on removed,changed) error = %v, want schema drift", err)
+	}
+}
+
+func TestParseFailOnFlag(t *testing.T) {
+	kinds, err := parseFailOnFlag("Removed, changed")
+	if err != nil || !reflect.DeepEqual(kinds, []envChangeKind{envChangeRemoved, envChangeChanged}) {
+		t.Fatalf("parseFailOnFlag() = %v, %v", kinds, err)
+	}
+	if kinds, err := parseFailOnFlag(""); err != nil || kinds != nil {
+		t.Fatalf("parseFailOnFlag(empty) = %v, %v, want nil", kinds, err)
+	}
+	if _, err := parseFailOnFlag("added,renamed"); err == nil {
+		t.Fatal("parseFailOnFlag(renamed) error = nil, want error")
+	}
+}
+
+func createCompareTestDatabase(t *testing.T, path, ddl string) string {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(ddl); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	return path
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/genesisdayrit/dbharness/internal/contextgen"
+	"gopkg.in/yaml.v3"
+)
+
+// completionCommands maps each dbh command to its subcommands.
+var completionCommands = map[string][]string{
+	"init":            nil,
+	"workspace":       {"create"},
+	"test-connection": nil,
+	"snapshot":        {"config"},
+	"ls":              nil,
+	"set-default":     nil,
+	"sync":            nil,
+	"watch":           nil,
+	"databases":       nil,
+	"schemas":         nil,
+	"update-schemas":  nil,
+	"tables":          nil,
+	"columns":         nil,
+	"export":          nil,
+	"merge":           nil,
+	"comments":        {"push"},
+	"lint":            {"schema"},
+	"backends":        nil,
+	"config":          {"get", "set"},
+	"completion":      {"bash", "zsh", "fish"},
+}
+
+// completionScripts are the shell functions printed by dbh completion. Each
+// one hands the words typed so far to dbh __complete, so completions follow
+// the installed binary and the current directory's .dbharness.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for dbh
+_dbh() {
+	local IFS=$'\n'
+	COMPREPLY=($(dbh __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _dbh dbh
+`,
+	"zsh": `#compdef dbh
+# zsh completion for dbh
+_dbh() {
+	local -a candidates
+	candidates=("${(@f)$(dbh __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	compadd -- ${candidates:#}
+}
+compdef _dbh dbh
+`,
+	"fish": `# fish completion for dbh
+function __dbh_complete
+	set -l tokens (commandline -opc)
+	set -e tokens[1]
+	dbh __complete $tokens (commandline -ct) 2>/dev/null
+end
+complete -c dbh -f -a '(__dbh_complete)'
+`,
+}
+
+func runCompletion(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: dbh completion bash|zsh|fish")
+		os.Exit(2)
+	}
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unsupported shell %q (use bash, zsh, or fish)\n", args[0])
+		os.Exit(2)
+	}
+	fmt.Print(script)
+}
+
+// runComplete prints the completions for the words typed after dbh, one per
+// line. The last word is the one being completed and may be empty. It is
+// called by the completion scripts and prints nothing on errors.
+func runComplete(args []string) {
+	source := completionSource{
+		connections:  configuredConnectionNames,
+		flags:        commandFlagNames,
+		schemaTables: contextSchemaTables,
+	}
+	for _, candidate := range source.complete(args) {
+		fmt.Println(candidate)
+	}
+}
+
+// completionSource supplies the names dbh __complete offers, so complete can
+// be tested without a config or a dbh binary.
+type completionSource struct {
+	// connections returns the configured connection names.
+	connections func() []string
+	// flags returns the flags of a command and its subcommand, as typed.
+	flags func(command []string) []string
+	// schemaTables returns the schemas in the context tree of a connection
+	// ("" for the primary) mapped to their table names.
+	schemaTables func(connection string) map[string][]string
+}
+
+// complete returns the sorted candidates for the last of words.
+func (s completionSource) complete(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+	previous := words[:len(words)-1]
+
+	if len(previous) == 0 {
+		names := make([]string, 0, len(completionCommands))
+		for name := range completionCommands {
+			names = append(names, name)
+		}
+		return filterCompletions(names, current)
+	}
+
+	command := previous[:1]
+	subcommands := completionCommands[previous[0]]
+	if len(subcommands) > 0 {
+		if len(previous) == 1 {
+			return filterCompletions(subcommands, current)
+		}
+		command = previous[:2]
+	}
+
+	last := previous[len(previous)-1]
+	switch {
+	case last == "-s" || last == "--s" || last == "-name" || last == "--name":
+		if command[0] == "workspace" {
+			return nil
+		}
+		return filterCompletions(s.connections(), current)
+	case last == "-schemas" || last == "--schemas":
+		schemas := s.schemaTables(completionConnection(previous))
+		names := make([]string, 0, len(schemas))
+		for schema := range schemas {
+			names = append(names, schema)
+		}
+		return completeListElement(names, current)
+	case last == "-tables" || last == "--tables":
+		var names []string
+		for schema, tables := range s.schemaTables(completionConnection(previous)) {
+			for _, table := range tables {
+				names = append(names, schema+"."+table)
+			}
+		}
+		return completeListElement(names, current)
+	case strings.HasPrefix(current, "-"):
+		return filterCompletions(s.flags(command), current)
+	case command[0] == "config" && len(command) == 2 && len(previous) == 2:
+		return s.completeConfigKey(current)
+	}
+	return nil
+}
+
+// completeConfigKey completes the <connection>.<field> argument of dbh
+// config get and set.
+func (s completionSource) completeConfigKey(current string) []string {
+	connection, _, hasField := strings.Cut(current, ".")
+	if !hasField {
+		var names []string
+		for _, name := range s.connections() {
+			names = append(names, name+".")
+		}
+		return filterCompletions(names, current)
+	}
+	var keys []string
+	for _, field := range connectionFieldNames() {
+		keys = append(keys, connection+"."+field)
+	}
+	return filterCompletions(keys, current)
+}
+
+// completionConnection returns the -s or --name value among words, or "" for
+// the primary connection.
+func completionConnection(words []string) string {
+	for i := 0; i < len(words)-1; i++ {
+		switch words[i] {
+		case "-s", "--s", "-name", "--name":
+			return words[i+1]
+		}
+	}
+	for _, word := range words {
+		for _, prefix := range []string{"-s=", "--s=", "-name=", "--name="} {
+			if value, ok := strings.CutPrefix(word, prefix); ok {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// completeListElement completes the last element of a comma-separated list,
+// keeping the elements already typed.
+func completeListElement(names []string, current string) []string {
+	done, element := "", current
+	if i := strings.LastIndex(current, ","); i >= 0 {
+		done, element = current[:i+1], current[i+1:]
+	}
+	matches := filterCompletions(names, element)
+	for i, match := range matches {
+		matches[i] = done + match
+	}
+	return matches
+}
+
+// filterCompletions returns the sorted, de-duplicated names starting with
+// prefix.
+func filterCompletions(names []string, prefix string) []string {
+	var matches []string
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) && !seen[name] {
+			seen[name] = true
+			matches = append(matches, name)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// configuredConnectionNames returns the connection names in config.json
+// without resolving secret references.
+func configuredConnectionNames() []string {
+	cfg, err := readConfig(resolveConfigPath())
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(cfg.Connections))
+	for _, entry := range cfg.Connections {
+		names = append(names, entry.Name)
+	}
+	return names
+}
+
+// flagNamePattern matches the flag lines flag.PrintDefaults writes.
+var flagNamePattern = regexp.MustCompile(`(?m)^  -([^\s=]+)`)
+
+// commandFlagNames lists the flags of a command by running it with -h, so
+// the completions cannot drift from the flags it defines.
+func commandFlagNames(command []string) []string {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil
+	}
+	var output bytes.Buffer
+	cmd := exec.Command(executable, append(append([]string{}, command...), "-h")...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	_ = cmd.Run()
+	return parseFlagNames(output.String())
+}
+
+// parseFlagNames returns the flags in flag.PrintDefaults output, as -x for
+// one-letter names and --name otherwise.
+func parseFlagNames(usage string) []string {
+	var names []string
+	for _, match := range flagNamePattern.FindAllStringSubmatch(usage, -1) {
+		if len(match[1]) == 1 {
+			names = append(names, "-"+match[1])
+		} else {
+			names = append(names, "--"+match[1])
+		}
+	}
+	return names
+}
+
+// contextSchemaTables reads the schemas and table names of a connection's
+// default database from its context files.
+func contextSchemaTables(connection string) map[string][]string {
+	cfg, err := readConfig(resolveConfigPath())
+	if err != nil {
+		return nil
+	}
+	var dbCfg databaseConfig
+	if connection == "" {
+		dbCfg, err = lookupPrimaryConnection(cfg, "")
+	} else {
+		dbCfg, err = lookupDatabaseConfig(cfg, connection)
+	}
+	if err != nil {
+		return nil
+	}
+
+	schemasDir, err := contextgen.SchemasDir(contextgen.Options{
+		ConnectionName: dbCfg.Name,
+		DatabaseName:   contextDatabaseNameForConnection(dbCfg),
+		DatabaseType:   dbCfg.Type,
+		BaseDir:        filepath.Join(".", ".dbharness"),
+	})
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(schemasDir, "_schemas.yml"))
+	if err != nil {
+		return nil
+	}
+	var file contextgen.SchemasFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil
+	}
+
+	schemas := make(map[string][]string, len(file.Schemas))
+	for _, schema := range file.Schemas {
+		for _, table := range schema.Tables {
+			schemas[schema.Name] = append(schemas[schema.Name], table.Name)
+		}
+		if _, ok := schemas[schema.Name]; !ok {
+			schemas[schema.Name] = nil
+		}
+	}
+	return schemas
+}
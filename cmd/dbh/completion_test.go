@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompletionSourceComplete(t *testing.T) {
+	source := completionSource{
+		connections: func() []string { return []string{"dev", "prod", "dev"} },
+		flags: func(command []string) []string {
+			if strings.Join(command, " ") != "comments push" && command[0] != "tables" {
+				t.Errorf("flags(%q) called for an unexpected command", command)
+			}
+			return []string{"-s", "--name", "--schemas", "--yes"}
+		},
+		schemaTables: func(connection string) map[string][]string {
+			if connection == "prod" {
+				return map[string][]string{"sales": {"orders"}}
+			}
+			return map[string][]string{
+				"public":    {"users", "orders"},
+				"analytics": {"events"},
+			}
+		},
+	}
+
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{words: []string{"comm"}, want: []string{"comments"}},
+		{words: []string{"comments", ""}, want: []string{"push"}},
+		{words: []string{"comments", "push", "--"}, want: []string{"--name", "--schemas", "--yes"}},
+		{words: []string{"tables", "-s", ""}, want: []string{"dev", "prod"}},
+		{words: []string{"tables", "--schemas", ""}, want: []string{"analytics", "public"}},
+		{words: []string{"tables", "--schemas", "analytics,p"}, want: []string{"analytics,public"}},
+		{words: []string{"tables", "-s", "prod", "--schemas", ""}, want: []string{"sales"}},
+		{words: []string{"columns", "--tables", "public.o"}, want: []string{"public.orders"}},
+		{words: []string{"config", "set", "p"}, want: []string{"prod."}},
+		{words: []string{"config", "get", "dev.por"}, want: []string{"dev.port"}},
+		{words: []string{"workspace", "create", "--name", ""}, want: nil},
+		{words: []string{"tables", "sales"}, want: nil},
+	}
+	for _, tt := range tests {
+		if got := source.complete(tt.words); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("complete(%q) = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+}
+
+func TestParseFlagNames(t *testing.T) {
+	flags := flag.NewFlagSet("tables", flag.ContinueOnError)
+	var output strings.Builder
+	flags.SetOutput(&output)
+	flags.String("s", "", "Connection name.")
+	flags.String("schemas", "", "Comma-separated schemas.")
+	flags.Bool("yes", false, "Skip the confirmation prompt.")
+	flags.PrintDefaults()
+
+	got := parseFlagNames(output.String())
+	want := []string{"-s", "--schemas", "--yes"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFlagNames() = %q, want %q", got, want)
+	}
+}
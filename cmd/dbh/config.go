@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/genesisdayrit/dbharness/internal/secrets"
+)
+
+// secretConfigFields are the connection fields dbh config get never prints,
+// including inside environments overrides. A field holding a ${scheme:ref}
+// reference prints the reference instead.
+var secretConfigFields = map[string]bool{"password": true}
+
+// connectionTypes are the values dbh config set accepts for type.
+var connectionTypes = []string{"postgres", "redshift", "snowflake", "mysql", "bigquery", "databricks", "sqlite"}
+
+// runConfig reads or changes one connection field in config.json. The file
+// is read without resolving secret references, so they are kept as written.
+func runConfig(args []string) {
+	configPath := resolveConfigPath()
+
+	switch {
+	case len(args) == 2 && args[0] == "get":
+		cfg, err := readConfig(configPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		value, err := getConfigField(cfg, args[1])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println(value)
+	case len(args) == 3 && args[0] == "set":
+		cfg, err := readConfig(configPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err := setConfigField(&cfg, args[1], args[2]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err := writeConfig(configPath, cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Set %s\n", args[1])
+	default:
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  dbh config get <connection>.<field>")
+		fmt.Fprintln(os.Stderr, "  dbh config set <connection>.<field> <value>")
+		os.Exit(2)
+	}
+}
+
+// getConfigField returns the value of a <connection>.<field> key as
+// config.json stores it. Lists are comma-separated and environments are
+// JSON. Secret fields are hidden unless they hold a single secret
+// reference.
+func getConfigField(cfg config, key string) (string, error) {
+	index, field, err := resolveConfigKey(cfg, key)
+	if err != nil {
+		return "", err
+	}
+	value, err := connectionField(&cfg.Connections[index], field)
+	if err != nil {
+		return "", err
+	}
+
+	if overrides, ok := value.Interface().(map[string]connectionOverride); ok {
+		return formatEnvironmentOverrides(overrides)
+	}
+	text := formatConfigValue(value)
+	if secretConfigFields[field] {
+		return hideSecret(text), nil
+	}
+	return text, nil
+}
+
+// hideSecret returns "(hidden)" for a set secret that is not a single
+// secret reference, and text otherwise.
+func hideSecret(text string) string {
+	if text != "" && !secrets.IsReference(text) {
+		return "(hidden)"
+	}
+	return text
+}
+
+// formatEnvironmentOverrides renders the environments of a connection as
+// JSON with their passwords hidden.
+func formatEnvironmentOverrides(overrides map[string]connectionOverride) (string, error) {
+	hidden := make(map[string]connectionOverride, len(overrides))
+	for name, override := range overrides {
+		override.Password = hideSecret(override.Password)
+		hidden[name] = override
+	}
+	data, err := json.Marshal(hidden)
+	if err != nil {
+		return "", fmt.Errorf("format environments: %w", err)
+	}
+	return string(data), nil
+}
+
+// setConfigField parses value for the type of the field named by key and
+// stores it. Unknown fields and values of the wrong type are rejected.
+func setConfigField(cfg *config, key, value string) error {
+	index, field, err := resolveConfigKey(*cfg, key)
+	if err != nil {
+		return err
+	}
+	entry := &cfg.Connections[index]
+	target, err := connectionField(entry, field)
+	if err != nil {
+		return err
+	}
+
+	switch field {
+	case "name":
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return fmt.Errorf("name cannot be empty")
+		}
+		for i, other := range cfg.Connections {
+			if i != index && other.Name == value {
+				return fmt.Errorf("connection %q already exists", value)
+			}
+		}
+	case "type":
+		if !slices.Contains(connectionTypes, value) {
+			return fmt.Errorf("invalid type %q (valid: %s)", value, strings.Join(connectionTypes, ", "))
+		}
+	case "primary":
+		primary, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for primary: want true or false", value)
+		}
+		if primary {
+			// Only one connection can be primary.
+			_, _, err := setPrimaryConnection(cfg, entry.Name)
+			return err
+		}
+	}
+
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid value %q for %s: want a non-negative integer", value, field)
+		}
+		target.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid value %q for %s: want true or false", value, field)
+		}
+		target.SetBool(b)
+	case reflect.Slice:
+		target.Set(reflect.ValueOf(parseListFlag(value)))
+	default:
+		return fmt.Errorf("field %s cannot be set with dbh config set", field)
+	}
+	return nil
+}
+
+// resolveConfigKey splits a <connection>.<field> key at its last dot, so
+// connection names may contain dots, and finds the connection.
+func resolveConfigKey(cfg config, key string) (int, string, error) {
+	dot := strings.LastIndex(key, ".")
+	if dot <= 0 || dot == len(key)-1 {
+		return 0, "", fmt.Errorf("invalid key %q: want <connection>.<field>", key)
+	}
+	connection, field := key[:dot], key[dot+1:]
+	for i, entry := range cfg.Connections {
+		if entry.Name == connection {
+			return i, field, nil
+		}
+	}
+	return 0, "", fmt.Errorf("connection %q not found in config", connection)
+}
+
+// connectionField returns the field of entry stored under name in
+// config.json.
+func connectionField(entry *databaseConfig, name string) (reflect.Value, error) {
+	value := reflect.ValueOf(entry).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		if connectionFieldName(value.Type().Field(i)) == name {
+			return value.Field(i), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("unknown field %q (valid fields: %s)", name, strings.Join(connectionFieldNames(), ", "))
+}
+
+// connectionFieldNames returns the sorted config.json names of the
+// connection fields.
+func connectionFieldNames() []string {
+	fields := reflect.TypeOf(databaseConfig{})
+	var names []string
+	for i := 0; i < fields.NumField(); i++ {
+		if name := connectionFieldName(fields.Field(i)); name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// connectionFieldName returns the config.json name of field, or "" when it
+// is not stored.
+func connectionFieldName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if tag == "-" {
+		return ""
+	}
+	return tag
+}
+
+func formatConfigValue(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.Slice:
+		return strings.Join(value.Interface().([]string), ",")
+	default:
+		return fmt.Sprint(value.Interface())
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configPathEnvVar names the config file to read, like --config. main sets
+// it from the flag so dbh sync and dbh watch stages inherit the choice.
+const configPathEnvVar = "DBH_CONFIG"
+
+// resolveConfigPath returns the config.json that connections are read from, the
+// first of:
+//
+//  1. --config or DBH_CONFIG
+//  2. ./.dbharness/config.json, when ./.dbharness exists
+//  3. $XDG_CONFIG_HOME/dbharness/config.json
+//  4. $HOME/.dbharness/config.json
+//
+// The global files are only used when they exist, so without any config the
+// local path is returned and errors name it. Context files are always
+// written under ./.dbharness, wherever the config came from.
+func resolveConfigPath() string {
+	if path := strings.TrimSpace(os.Getenv(configPathEnvVar)); path != "" {
+		return path
+	}
+	local := filepath.Join(".", ".dbharness", "config.json")
+	if info, err := os.Stat(filepath.Dir(local)); err == nil && info.IsDir() {
+		return local
+	}
+	for _, path := range globalConfigPaths() {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return local
+}
+
+// globalConfigPaths lists the per-user config locations in lookup order.
+// XDG_CONFIG_HOME defaults to ~/.config, as the XDG spec says.
+func globalConfigPaths() []string {
+	home, _ := os.UserHomeDir()
+	configHome := strings.TrimSpace(os.Getenv("XDG_CONFIG_HOME"))
+	if configHome == "" && home != "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	var paths []string
+	if configHome != "" {
+		paths = append(paths, filepath.Join(configHome, "dbharness", "config.json"))
+	}
+	if home != "" {
+		paths = append(paths, filepath.Join(home, ".dbharness", "config.json"))
+	}
+	return paths
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveConfigPath(t *testing.T) {
+	projectDir := t.TempDir()
+	home := t.TempDir()
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("get cwd: %v", err)
+	}
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir to temp project: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(originalWD)
+	}()
+
+	t.Setenv(configPathEnvVar, "")
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	local := filepath.Join(".", ".dbharness", "config.json")
+	writeFile := func(path string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(`{"connections":[]}`), 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+
+	if got := resolveConfigPath(); got != local {
+		t.Fatalf("resolveConfigPath() with no config = %q, want %q", got, local)
+	}
+
+	homeConfig := filepath.Join(home, ".dbharness", "config.json")
+	writeFile(homeConfig)
+	if got := resolveConfigPath(); got != homeConfig {
+		t.Fatalf("resolveConfigPath() = %q, want the home config %q", got, homeConfig)
+	}
+
+	xdgConfig := filepath.Join(home, ".config", "dbharness", "config.json")
+	writeFile(xdgConfig)
+	if got := resolveConfigPath(); got != xdgConfig {
+		t.Fatalf("resolveConfigPath() = %q, want the default XDG config %q", got, xdgConfig)
+	}
+
+	// A local .dbharness wins even before it has a config.json.
+	if err := os.Mkdir(".dbharness", 0o755); err != nil {
+		t.Fatalf("mkdir .dbharness: %v", err)
+	}
+	if got := resolveConfigPath(); got != local {
+		t.Fatalf("resolveConfigPath() with local .dbharness = %q, want %q", got, local)
+	}
+
+	t.Setenv(configPathEnvVar, "/etc/dbh/config.json")
+	if got := resolveConfigPath(); got != "/etc/dbh/config.json" {
+		t.Fatalf("resolveConfigPath() with %s set = %q, want /etc/dbh/config.json", configPathEnvVar, got)
+	}
+}
+
+func TestExtractGlobalFlagConfig(t *testing.T) {
+	args, path, err := extractGlobalFlag([]string{"tables", "--config", "shared.json", "-s", "app"}, "config")
+	if err != nil || path != "shared.json" || len(args) != 3 || args[0] != "tables" || args[1] != "-s" {
+		t.Fatalf("extractGlobalFlag(--config) = %q, %q, %v", args, path, err)
+	}
+
+	// dbh config get is a command, not the flag.
+	args, path, err = extractGlobalFlag([]string{"config", "get", "app.host"}, "config")
+	if err != nil || path != "" || len(args) != 3 {
+		t.Fatalf("extractGlobalFlag(config get) = %q, %q, %v", args, path, err)
+	}
+}
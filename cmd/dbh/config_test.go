@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testEditConfig() config {
+	return config{Connections: []databaseConfig{
+		{Name: "warehouse", Type: "postgres", Primary: true, Host: "db.internal", Port: 5432, Password: "hunter2"},
+		{Name: "prod.us", Type: "mysql", Password: "${env:PROD_PASSWORD}", Environments: map[string]connectionOverride{
+			"staging": {Host: "staging.internal", Password: "s3cret"},
+			"dev":     {Password: "${env:DEV_PASSWORD}"},
+		}},
+	}}
+}
+
+func TestGetConfigField(t *testing.T) {
+	cfg := testEditConfig()
+
+	tests := map[string]string{
+		"warehouse.host":     "db.internal",
+		"warehouse.port":     "5432",
+		"warehouse.primary":  "true",
+		"warehouse.password": "(hidden)",
+		"prod.us.password":   "${env:PROD_PASSWORD}",
+		"prod.us.host":       "",
+		"prod.us.environments": `{"dev":{"password":"${env:DEV_PASSWORD}"},` +
+			`"staging":{"host":"staging.internal","password":"(hidden)"}}`,
+	}
+	for key, want := range tests {
+		got, err := getConfigField(cfg, key)
+		if err != nil {
+			t.Fatalf("getConfigField(%q) error = %v", key, err)
+		}
+		if got != want {
+			t.Fatalf("getConfigField(%q) = %q, want %q", key, got, want)
+		}
+	}
+
+	for _, key := range []string{"warehouse", "missing.host", "warehouse.hostname", "warehouse."} {
+		if _, err := getConfigField(cfg, key); err == nil {
+			t.Fatalf("getConfigField(%q) succeeded, want an error", key)
+		}
+	}
+}
+
+func TestSetConfigField(t *testing.T) {
+	cfg := testEditConfig()
+
+	for key, value := range map[string]string{
+		"warehouse.port":      "6543",
+		"warehouse.password":  "${env:PGPASSWORD}",
+		"warehouse.databases": "analytics, raw",
+		"prod.us.primary":     "true",
+	} {
+		if err := setConfigField(&cfg, key, value); err != nil {
+			t.Fatalf("setConfigField(%q, %q) error = %v", key, value, err)
+		}
+	}
+
+	warehouse := cfg.Connections[0]
+	if warehouse.Port != 6543 || warehouse.Password != "${env:PGPASSWORD}" {
+		t.Fatalf("warehouse = %+v, want port 6543 and the password reference", warehouse)
+	}
+	if !reflect.DeepEqual(warehouse.Databases, []string{"analytics", "raw"}) {
+		t.Fatalf("warehouse databases = %v, want [analytics raw]", warehouse.Databases)
+	}
+	if warehouse.Primary || !cfg.Connections[1].Primary {
+		t.Fatal("setting prod.us.primary should move primary from warehouse to prod.us")
+	}
+
+	tests := []struct {
+		key, value, wantErr string
+	}{
+		{key: "warehouse.port", value: "abc", wantErr: "non-negative integer"},
+		{key: "warehouse.resume_warehouse", value: "maybe", wantErr: "true or false"},
+		{key: "warehouse.colour", value: "red", wantErr: "unknown field"},
+		{key: "warehouse.type", value: "oracle", wantErr: "invalid type"},
+		{key: "warehouse.name", value: "prod.us", wantErr: "already exists"},
+	}
+	for _, tt := range tests {
+		err := setConfigField(&cfg, tt.key, tt.value)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Fatalf("setConfigField(%q, %q) error = %v, want %q", tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+}
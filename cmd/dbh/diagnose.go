@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	gcpbigquery "cloud.google.com/go/bigquery"
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+const diagnosePhaseTimeout = 10 * time.Second
+
+// diagnosticTLS is how a backend negotiates TLS, which decides how dbh
+// test-connection --diagnose times the handshake on its own.
+type diagnosticTLS int
+
+const (
+	// diagnosticTLSNone means the connection is not encrypted.
+	diagnosticTLSNone diagnosticTLS = iota
+	// diagnosticTLSPostgres upgrades the TCP connection after a Postgres
+	// SSLRequest message, as Postgres and Redshift do.
+	diagnosticTLSPostgres
+	// diagnosticTLSDirect starts TLS right after connecting (HTTPS).
+	diagnosticTLSDirect
+	// diagnosticTLSInDriver means TLS is negotiated inside the driver's
+	// handshake and is only timed as part of connecting.
+	diagnosticTLSInDriver
+)
+
+// diagnosticEndpoint is the server a connection talks to.
+type diagnosticEndpoint struct {
+	Host string
+	Port int
+	TLS  diagnosticTLS
+}
+
+// diagnosticPhase is one timed step of dbh test-connection --diagnose.
+type diagnosticPhase struct {
+	Name     string
+	Duration time.Duration
+	Note     string
+	Err      error
+}
+
+// connectionDiagnostics runs phases in order and stops at the first one
+// that fails, since the later ones depend on it.
+type connectionDiagnostics struct {
+	Phases []diagnosticPhase
+}
+
+// run times fn as the phase name. fn returns a note to print next to the
+// timing. run reports whether the phase ran and succeeded.
+func (d *connectionDiagnostics) run(name string, fn func() (string, error)) bool {
+	if d.err() != nil {
+		return false
+	}
+	started := time.Now()
+	note, err := fn()
+	d.Phases = append(d.Phases, diagnosticPhase{Name: name, Duration: time.Since(started), Note: note, Err: err})
+	return err == nil
+}
+
+// skip records a phase that is not timed separately.
+func (d *connectionDiagnostics) skip(name, reason string) {
+	if d.err() != nil {
+		return
+	}
+	d.Phases = append(d.Phases, diagnosticPhase{Name: name, Duration: -1, Note: reason})
+}
+
+// err returns the failed phase's error, or nil.
+func (d *connectionDiagnostics) err() error {
+	for _, phase := range d.Phases {
+		if phase.Err != nil {
+			return fmt.Errorf("%s: %w", strings.ToLower(phase.Name), phase.Err)
+		}
+	}
+	return nil
+}
+
+// print writes one line per phase and the total time of the timed phases.
+func (d *connectionDiagnostics) print(w io.Writer) {
+	var total time.Duration
+	for _, phase := range d.Phases {
+		timing := "-"
+		if phase.Duration >= 0 {
+			timing = phase.Duration.Round(time.Millisecond).String()
+			total += phase.Duration
+		}
+		detail := phase.Note
+		if phase.Err != nil {
+			timing += " FAILED"
+			detail = phase.Err.Error()
+		}
+		line := fmt.Sprintf("  %-22s %-12s %s", phase.Name, timing, detail)
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+	fmt.Fprintf(w, "  %-22s %s\n", "Total", total.Round(time.Millisecond))
+}
+
+// diagnoseConnection times each step of connecting to entry: DNS lookup,
+// TCP connect, and TLS handshake to its server, then the driver's connect
+// and login, then a first query.
+func diagnoseConnection(entry databaseConfig) *connectionDiagnostics {
+	d := &connectionDiagnostics{}
+	if endpoint, ok := connectionEndpoint(entry); ok {
+		diagnoseNetwork(d, endpoint)
+	}
+
+	if strings.EqualFold(entry.Type, "bigquery") {
+		diagnoseBigQuery(d, entry)
+		return d
+	}
+
+	var db *sql.DB
+	if !d.run("Open driver", func() (string, error) {
+		var err error
+		db, err = openForTest(entry)
+		return "", err
+	}) {
+		return d
+	}
+	defer db.Close()
+
+	connectPhase, connectNote := "Connect + auth", "driver connection, including TCP, TLS, and login"
+	switch {
+	case strings.EqualFold(entry.Type, "snowflake"):
+		connectPhase, connectNote = "Authentication", "Snowflake login session"
+		if entry.Authenticator == "externalbrowser" {
+			connectNote += "; includes the time spent in the browser"
+		}
+	case strings.EqualFold(entry.Type, "databricks"):
+		connectPhase, connectNote = "Authentication", "reads the SQL warehouse with the access token"
+	case strings.EqualFold(entry.Type, "sqlite"):
+		connectPhase, connectNote = "Open file", ""
+	}
+	if !d.run(connectPhase, func() (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), connectTimeout(entry, diagnosePhaseTimeout))
+		defer cancel()
+		return connectNote, explainLoginTimeout(entry, db.PingContext(ctx))
+	}) {
+		return d
+	}
+
+	d.run("First query", func() (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), diagnosePhaseTimeout)
+		defer cancel()
+		var one int
+		return "SELECT 1", db.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+	})
+	return d
+}
+
+// diagnoseNetwork times the DNS lookup, TCP connect, and TLS handshake to
+// endpoint. The TLS handshake does not verify the server certificate; it
+// is only timed, and the driver verifies as usual afterwards.
+func diagnoseNetwork(d *connectionDiagnostics, endpoint diagnosticEndpoint) {
+	var addrs []string
+	if !d.run("DNS lookup", func() (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), diagnosePhaseTimeout)
+		defer cancel()
+		var err error
+		addrs, err = net.DefaultResolver.LookupHost(ctx, endpoint.Host)
+		if err != nil {
+			return "", err
+		}
+		return endpoint.Host + " -> " + strings.Join(addrs, ", "), nil
+	}) {
+		return
+	}
+
+	var conn net.Conn
+	address := net.JoinHostPort(addrs[0], strconv.Itoa(endpoint.Port))
+	if !d.run("TCP connect", func() (string, error) {
+		var err error
+		conn, err = net.DialTimeout("tcp", address, diagnosePhaseTimeout)
+		return address, err
+	}) {
+		return
+	}
+	defer conn.Close()
+
+	switch endpoint.TLS {
+	case diagnosticTLSNone:
+		d.skip("TLS handshake", "not used by this connection")
+	case diagnosticTLSInDriver:
+		d.skip("TLS handshake", "negotiated inside the driver's handshake; included in connect")
+	case diagnosticTLSPostgres, diagnosticTLSDirect:
+		d.run("TLS handshake", func() (string, error) {
+			_ = conn.SetDeadline(time.Now().Add(diagnosePhaseTimeout))
+			if endpoint.TLS == diagnosticTLSPostgres {
+				accepted, err := requestPostgresTLS(conn)
+				if err != nil {
+					return "", err
+				}
+				if !accepted {
+					return "server does not offer TLS", nil
+				}
+			}
+			tlsConn := tls.Client(conn, &tls.Config{ServerName: endpoint.Host, InsecureSkipVerify: true})
+			if err := tlsConn.Handshake(); err != nil {
+				return "", err
+			}
+			return tls.VersionName(tlsConn.ConnectionState().Version), nil
+		})
+	}
+}
+
+// requestPostgresTLS sends the SSLRequest message that starts TLS on a
+// Postgres or Redshift connection and reports whether the server agreed.
+func requestPostgresTLS(conn net.Conn) (bool, error) {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], 80877103)
+	if _, err := conn.Write(request); err != nil {
+		return false, fmt.Errorf("send SSLRequest: %w", err)
+	}
+	reply := make([]byte, 1)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return false, fmt.Errorf("read SSLRequest reply: %w", err)
+	}
+	switch reply[0] {
+	case 'S':
+		return true, nil
+	case 'N':
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected SSLRequest reply %q", reply[0])
+	}
+}
+
+// diagnoseBigQuery times creating the client and the first API request,
+// which is also where the OAuth token is fetched.
+func diagnoseBigQuery(d *connectionDiagnostics, entry databaseConfig) {
+	projectID := strings.TrimSpace(entry.ProjectID)
+	if projectID == "" {
+		projectID = strings.TrimSpace(entry.Database)
+	}
+	var clientOptions []option.ClientOption
+	if credentialsFile := strings.TrimSpace(entry.CredentialsFile); credentialsFile != "" {
+		clientOptions = append(clientOptions, option.WithCredentialsFile(credentialsFile))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*diagnosePhaseTimeout)
+	defer cancel()
+
+	var client *gcpbigquery.Client
+	if !d.run("Client setup", func() (string, error) {
+		if projectID == "" {
+			return "", discovery.ErrMissingProject
+		}
+		var err error
+		client, err = gcpbigquery.NewClient(ctx, projectID, clientOptions...)
+		return "loads credentials", err
+	}) {
+		return
+	}
+	defer client.Close()
+
+	d.run("First request", func() (string, error) {
+		if _, err := client.Datasets(ctx).Next(); err != nil && !errors.Is(err, iterator.Done) {
+			return "", err
+		}
+		return "lists datasets; includes fetching the OAuth token", nil
+	})
+}
+
+// connectionEndpoint returns the server entry connects to. ok is false when
+// there is no network connection (SQLite) or no host to reach.
+func connectionEndpoint(entry databaseConfig) (diagnosticEndpoint, bool) {
+	host := strings.TrimSpace(entry.Host)
+	switch strings.ToLower(strings.TrimSpace(entry.Type)) {
+	case "postgres":
+		endpoint := diagnosticEndpoint{Host: host, Port: entry.Port, TLS: diagnosticTLSPostgres}
+		if endpoint.Port <= 0 {
+			endpoint.Port = 5432
+		}
+		if entry.SSLMode == "" || entry.SSLMode == "disable" {
+			endpoint.TLS = diagnosticTLSNone
+		}
+		return endpoint, host != ""
+	case "redshift":
+		endpoint := diagnosticEndpoint{Host: host, Port: entry.Port, TLS: diagnosticTLSPostgres}
+		if endpoint.Port <= 0 {
+			endpoint.Port = defaultRedshiftPort
+		}
+		if entry.SSLMode == "disable" {
+			endpoint.TLS = diagnosticTLSNone
+		}
+		return endpoint, host != ""
+	case "mysql":
+		endpoint := diagnosticEndpoint{Host: host, Port: entry.Port, TLS: diagnosticTLSInDriver}
+		if endpoint.Port <= 0 {
+			endpoint.Port = 3306
+		}
+		if tlsMode := strings.TrimSpace(entry.TLS); tlsMode == "" || tlsMode == "false" {
+			endpoint.TLS = diagnosticTLSNone
+		}
+		return endpoint, host != ""
+	case "snowflake":
+		if host := snowflakeHost(entry); host != "" {
+			endpoint := diagnosticEndpoint{Host: host, Port: entry.Port, TLS: diagnosticTLSDirect}
+			if endpoint.Port <= 0 {
+				endpoint.Port = 443
+			}
+			return endpoint, true
+		}
+		host := strings.TrimSpace(entry.Account)
+		host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+		host = strings.TrimSuffix(host, "/")
+		if host != "" && !strings.HasSuffix(host, ".snowflakecomputing.com") {
+			host += ".snowflakecomputing.com"
+		}
+		return diagnosticEndpoint{Host: host, Port: 443, TLS: diagnosticTLSDirect}, host != ""
+	case "bigquery":
+		return diagnosticEndpoint{Host: "bigquery.googleapis.com", Port: 443, TLS: diagnosticTLSDirect}, true
+	case "databricks":
+		host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+		host = strings.TrimSuffix(host, "/")
+		return diagnosticEndpoint{Host: host, Port: 443, TLS: diagnosticTLSDirect}, host != ""
+	}
+	return diagnosticEndpoint{}, false
+}
+
+// openForTest opens the database/sql pool dbh test-connection pings for
+// entry, without connecting.
+func openForTest(entry databaseConfig) (*sql.DB, error) {
+	switch entry.Type {
+	case "postgres":
+		return openPostgresForTest(entry)
+	case "redshift":
+		return openRedshiftForTest(entry)
+	case "snowflake":
+		return openSnowflakeForTest(entry)
+	case "mysql":
+		return openMySQLForTest(entry)
+	case "databricks":
+		return openDatabricksForTest(entry)
+	case "sqlite":
+		return openSQLiteForTest(entry)
+	default:
+		return nil, fmt.Errorf("%w %q", discovery.ErrUnsupportedType, entry.Type)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiagnoseConnectionSQLite(t *testing.T) {
+	path := createCompareTestDatabase(t, filepath.Join(t.TempDir(), "app.db"), "CREATE TABLE users (id INTEGER)")
+
+	d := diagnoseConnection(databaseConfig{Name: "local", Type: "sqlite", Database: path})
+	if err := d.err(); err != nil {
+		t.Fatalf("diagnoseConnection() error = %v", err)
+	}
+	var names []string
+	for _, phase := range d.Phases {
+		names = append(names, phase.Name)
+	}
+	if got, want := strings.Join(names, ","), "Open driver,Open file,First query"; got != want {
+		t.Fatalf("phases = %s, want %s", got, want)
+	}
+
+	var out bytes.Buffer
+	d.print(&out)
+	if !strings.Contains(out.String(), "Total") {
+		t.Fatalf("print() output missing total:\n%s", out.String())
+	}
+}
+
+func TestDiagnoseNetworkPostgresWithoutTLS(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		request := make([]byte, 8)
+		if _, err := io.ReadFull(conn, request); err == nil {
+			_, _ = conn.Write([]byte("N"))
+		}
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	d := &connectionDiagnostics{}
+	diagnoseNetwork(d, diagnosticEndpoint{Host: "127.0.0.1", Port: port, TLS: diagnosticTLSPostgres})
+	if err := d.err(); err != nil {
+		t.Fatalf("diagnoseNetwork() error = %v", err)
+	}
+	if len(d.Phases) != 3 {
+		t.Fatalf("phases = %+v, want DNS lookup, TCP connect, and TLS handshake", d.Phases)
+	}
+	if tlsPhase := d.Phases[2]; tlsPhase.Name != "TLS handshake" || tlsPhase.Note != "server does not offer TLS" {
+		t.Fatalf("TLS phase = %+v, want the server's refusal noted", tlsPhase)
+	}
+}
+
+func TestDiagnoseNetworkStopsAtFailedPhase(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	d := &connectionDiagnostics{}
+	diagnoseNetwork(d, diagnosticEndpoint{Host: "127.0.0.1", Port: port, TLS: diagnosticTLSDirect})
+	if d.err() == nil || !strings.HasPrefix(d.err().Error(), "tcp connect: ") {
+		t.Fatalf("err() = %v, want a TCP connect failure", d.err())
+	}
+	if last := d.Phases[len(d.Phases)-1]; last.Name != "TCP connect" {
+		t.Fatalf("last phase = %q, want phases to stop after TCP connect", last.Name)
+	}
+}
+
+func TestConnectionEndpoint(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry databaseConfig
+		want  diagnosticEndpoint
+		ok    bool
+	}{
+		{
+			name:  "postgres default port without TLS",
+			entry: databaseConfig{Type: "postgres", Host: "db.internal"},
+			want:  diagnosticEndpoint{Host: "db.internal", Port: 5432, TLS: diagnosticTLSNone},
+			ok:    true,
+		},
+		{
+			name:  "redshift requires TLS by default",
+			entry: databaseConfig{Type: "redshift", Host: "cluster.example.com"},
+			want:  diagnosticEndpoint{Host: "cluster.example.com", Port: defaultRedshiftPort, TLS: diagnosticTLSPostgres},
+			ok:    true,
+		},
+		{
+			name:  "mysql TLS happens in the driver",
+			entry: databaseConfig{Type: "mysql", Host: "mysql.internal", Port: 3307, TLS: "true"},
+			want:  diagnosticEndpoint{Host: "mysql.internal", Port: 3307, TLS: diagnosticTLSInDriver},
+			ok:    true,
+		},
+		{
+			name:  "snowflake account host",
+			entry: databaseConfig{Type: "snowflake", Account: "acme-org"},
+			want:  diagnosticEndpoint{Host: "acme-org.snowflakecomputing.com", Port: 443, TLS: diagnosticTLSDirect},
+			ok:    true,
+		},
+		{
+			name:  "snowflake explicit host",
+			entry: databaseConfig{Type: "snowflake", Account: "acme-org", Host: "acme-org.privatelink.snowflakecomputing.com"},
+			want:  diagnosticEndpoint{Host: "acme-org.privatelink.snowflakecomputing.com", Port: 443, TLS: diagnosticTLSDirect},
+			ok:    true,
+		},
+		{
+			name:  "sqlite has no network",
+			entry: databaseConfig{Type: "sqlite", Database: "app.db"},
+			ok:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := connectionEndpoint(tt.entry)
+			if ok != tt.ok || (ok && got != tt.want) {
+				t.Fatalf("connectionEndpoint() = %+v, %v; want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
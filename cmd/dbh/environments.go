@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// connectionEnvVar selects an entry of the connection's environments map,
+// like --env. main sets it from the flag so dbh sync and dbh watch stages
+// inherit the choice.
+const connectionEnvVar = "DBH_ENV"
+
+// connectionOverride is one entry of a connection's environments map. Set
+// fields replace the connection's own values; empty ones keep them.
+type connectionOverride struct {
+	Host      string `json:"host,omitempty"`
+	Port      int    `json:"port,omitempty"`
+	Database  string `json:"database,omitempty"`
+	User      string `json:"user,omitempty"`
+	Password  string `json:"password,omitempty"`
+	SSLMode   string `json:"sslmode,omitempty"`
+	Account   string `json:"account,omitempty"`
+	Role      string `json:"role,omitempty"`
+	Warehouse string `json:"warehouse,omitempty"`
+	Schema    string `json:"schema,omitempty"`
+	ProjectID string `json:"project_id,omitempty"`
+}
+
+// extractConnectionEnvFlag removes --env <name> (or --connection-env, or
+// the =<name> forms) from args and returns the remaining args and the name.
+// Arguments after "--" are left alone.
+func extractConnectionEnvFlag(args []string) ([]string, string, error) {
+	return extractGlobalFlag(args, "env", "connection-env")
+}
+
+// extractGlobalFlag removes a flag that every command accepts, under any
+// of names and in the -name value, --name value, or --name=value form, and
+// returns the remaining args and the flag's last value. Arguments after
+// "--" are left alone.
+func extractGlobalFlag(args []string, names ...string) ([]string, string, error) {
+	rest := make([]string, 0, len(args))
+	found := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || !slices.Contains(names, name) {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, "", fmt.Errorf("flag needs an argument: %s", arg)
+			}
+			i++
+			value = args[i]
+		}
+		found = strings.TrimSpace(value)
+		if found == "" {
+			return nil, "", fmt.Errorf("%s cannot be empty", arg)
+		}
+	}
+	return rest, found, nil
+}
+
+// extractGlobalBoolFlag removes a boolean flag that every command accepts,
+// in the -name, --name, or --name=true|false form, and reports whether it
+// was set to true. Arguments after "--" are left alone.
+func extractGlobalBoolFlag(args []string, name string) ([]string, bool, error) {
+	rest := make([]string, 0, len(args))
+	set := false
+	for i, arg := range args {
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		flagName, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || flagName != name {
+			rest = append(rest, arg)
+			continue
+		}
+		set = true
+		if hasValue {
+			on, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid boolean value %q for -%s", value, name)
+			}
+			set = on
+		}
+	}
+	return rest, set, nil
+}
+
+// overlayConnectionEnvironment returns base with the overrides of its env
+// entry applied, and Environment set to env. An empty env returns base
+// unchanged.
+func overlayConnectionEnvironment(base databaseConfig, env string) (databaseConfig, error) {
+	if env == "" {
+		return base, nil
+	}
+	override, ok := base.Environments[env]
+	if !ok {
+		names := make([]string, 0, len(base.Environments))
+		for name := range base.Environments {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return databaseConfig{}, fmt.Errorf("connection %q has no environments; add an \"environments\" map to use --env %s", base.Name, env)
+		}
+		return databaseConfig{}, fmt.Errorf("connection %q has no environment %q (available: %s)", base.Name, env, strings.Join(names, ", "))
+	}
+
+	merged := base
+	merged.Environment = env
+	overrideString := func(target *string, value string) {
+		if value != "" {
+			*target = value
+		}
+	}
+	overrideString(&merged.Host, override.Host)
+	overrideString(&merged.Database, override.Database)
+	overrideString(&merged.User, override.User)
+	overrideString(&merged.Password, override.Password)
+	overrideString(&merged.SSLMode, override.SSLMode)
+	overrideString(&merged.Account, override.Account)
+	overrideString(&merged.Role, override.Role)
+	overrideString(&merged.Warehouse, override.Warehouse)
+	overrideString(&merged.Schema, override.Schema)
+	overrideString(&merged.ProjectID, override.ProjectID)
+	if override.Port > 0 {
+		merged.Port = override.Port
+	}
+	return merged, nil
+}
+
+// withSelectedEnvironment applies the environment chosen with --env or
+// DBH_ENV to entry. Secret references are left in place.
+func withSelectedEnvironment(entry databaseConfig) (databaseConfig, error) {
+	return overlayConnectionEnvironment(entry, strings.TrimSpace(os.Getenv(connectionEnvVar)))
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractConnectionEnvFlag(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantArgs []string
+		wantEnv  string
+	}{
+		{args: []string{"tables", "-s", "app"}, wantArgs: []string{"tables", "-s", "app"}},
+		{args: []string{"tables", "--env", "staging", "-s", "app"}, wantArgs: []string{"tables", "-s", "app"}, wantEnv: "staging"},
+		{args: []string{"--env=staging", "schemas"}, wantArgs: []string{"schemas"}, wantEnv: "staging"},
+		{args: []string{"columns", "-connection-env", "dev"}, wantArgs: []string{"columns"}, wantEnv: "dev"},
+		{args: []string{"tables", "--", "--env", "x"}, wantArgs: []string{"tables", "--", "--env", "x"}},
+		{args: []string{"tables", "--environment", "x"}, wantArgs: []string{"tables", "--environment", "x"}},
+	}
+	for _, tt := range tests {
+		args, env, err := extractConnectionEnvFlag(tt.args)
+		if err != nil {
+			t.Fatalf("extractConnectionEnvFlag(%q) error = %v", tt.args, err)
+		}
+		if !reflect.DeepEqual(args, tt.wantArgs) || env != tt.wantEnv {
+			t.Fatalf("extractConnectionEnvFlag(%q) = %q, %q; want %q, %q", tt.args, args, env, tt.wantArgs, tt.wantEnv)
+		}
+	}
+
+	if _, _, err := extractConnectionEnvFlag([]string{"tables", "--env"}); err == nil {
+		t.Fatal("extractConnectionEnvFlag(--env) error = nil, want a missing argument error")
+	}
+}
+
+func TestExtractGlobalBoolFlag(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantArgs []string
+		wantSet  bool
+	}{
+		{args: []string{"tables", "-s", "app"}, wantArgs: []string{"tables", "-s", "app"}},
+		{args: []string{"tables", "--log-queries", "-s", "app"}, wantArgs: []string{"tables", "-s", "app"}, wantSet: true},
+		{args: []string{"-log-queries=true", "columns"}, wantArgs: []string{"columns"}, wantSet: true},
+		{args: []string{"columns", "--log-queries=false"}, wantArgs: []string{"columns"}},
+		{args: []string{"tables", "--", "--log-queries"}, wantArgs: []string{"tables", "--", "--log-queries"}},
+	}
+	for _, tt := range tests {
+		args, set, err := extractGlobalBoolFlag(tt.args, "log-queries")
+		if err != nil {
+			t.Fatalf("extractGlobalBoolFlag(%q) error = %v", tt.args, err)
+		}
+		if !reflect.DeepEqual(args, tt.wantArgs) || set != tt.wantSet {
+			t.Fatalf("extractGlobalBoolFlag(%q) = %q, %v; want %q, %v", tt.args, args, set, tt.wantArgs, tt.wantSet)
+		}
+	}
+
+	if _, _, err := extractGlobalBoolFlag([]string{"tables", "--log-queries=maybe"}, "log-queries"); err == nil {
+		t.Fatal("extractGlobalBoolFlag(--log-queries=maybe) error = nil, want an invalid value error")
+	}
+}
+
+func TestOverlayConnectionEnvironment(t *testing.T) {
+	base := databaseConfig{
+		Name:        "app",
+		Type:        "postgres",
+		Environment: "production",
+		Host:        "db.prod.internal",
+		Port:        5432,
+		Database:    "app",
+		User:        "reader",
+		Password:    "secret",
+		Environments: map[string]connectionOverride{
+			"staging": {Host: "db.staging.internal", Port: 6432},
+			"dev":     {Host: "localhost", Database: "app_dev", Password: "dev"},
+		},
+	}
+
+	unchanged, err := overlayConnectionEnvironment(base, "")
+	if err != nil || !reflect.DeepEqual(unchanged, base) {
+		t.Fatalf("overlay with no env = %+v, %v; want base unchanged", unchanged, err)
+	}
+
+	staging, err := overlayConnectionEnvironment(base, "staging")
+	if err != nil {
+		t.Fatalf("overlay staging error = %v", err)
+	}
+	if staging.Host != "db.staging.internal" || staging.Port != 6432 || staging.Environment != "staging" {
+		t.Fatalf("staging = %+v, want the staging host, port, and environment", staging)
+	}
+	if staging.Database != "app" || staging.User != "reader" || staging.Password != "secret" || staging.Name != "app" {
+		t.Fatalf("staging = %+v, want unset fields kept from the base connection", staging)
+	}
+	if base.Host != "db.prod.internal" {
+		t.Fatalf("base host changed to %q", base.Host)
+	}
+
+	_, err = overlayConnectionEnvironment(base, "qa")
+	if err == nil || !strings.Contains(err.Error(), "available: dev, staging") {
+		t.Fatalf("overlay qa error = %v, want the available environments listed", err)
+	}
+	base.Environments = nil
+	if _, err := overlayConnectionEnvironment(base, "staging"); err == nil {
+		t.Fatal("overlay on a connection without environments error = nil")
+	}
+}
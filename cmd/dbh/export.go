@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/genesisdayrit/dbharness/internal/contextgen"
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+	"github.com/genesisdayrit/dbharness/internal/export"
+)
+
+const (
+	exportFormatMarkdown = "markdown"
+	exportLiveTimeout    = 5 * time.Minute
+)
+
+func runExport(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	shortName := flags.String("s", "", "Connection name from config.json.")
+	longName := flags.String("name", "", "Connection name from config.json.")
+	format := flags.String("format", exportFormatMarkdown, "Export format: markdown.")
+	databasesFlag := flags.String("databases", "", "Comma-separated databases to export (default: all generated databases).")
+	live := flags.Bool("live", false, "Read metadata from the database instead of the generated context files.")
+	_ = flags.Parse(args)
+
+	if err := validateExportFormat(*format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	name := *shortName
+	if name == "" {
+		name = *longName
+	}
+
+	baseDir := filepath.Join(".", ".dbharness")
+	configPath := resolveConfigPath()
+	cfg, err := readConfig(configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var dbCfg databaseConfig
+	if name == "" {
+		dbCfg, err = findPrimaryConnection(cfg, "")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	} else {
+		dbCfg, err = findDatabaseConfig(cfg, name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("Using connection %q (%s)\n\n", dbCfg.Name, dbCfg.Type)
+
+	databases := parseListFlag(*databasesFlag)
+	if len(databases) == 0 {
+		databases, err = exportDatabaseNames(baseDir, dbCfg, *live)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	failed := 0
+	for _, database := range databases {
+		path, err := exportDatabaseDictionary(baseDir, dbCfg, database, *live)
+		if err != nil {
+			failed++
+			fmt.Printf("Could not export database %q: %v\n", database, err)
+			continue
+		}
+		absPath, _ := filepath.Abs(path)
+		fmt.Printf("Wrote %s\n", absPath)
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d database export(s) failed\n", failed, len(databases))
+		os.Exit(1)
+	}
+}
+
+func validateExportFormat(format string) error {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case exportFormatMarkdown:
+		return nil
+	default:
+		return fmt.Errorf("unsupported export format %q (supported: %s)", format, exportFormatMarkdown)
+	}
+}
+
+// exportDatabaseNames returns the databases to export when --databases is not
+// given: every database in _databases.yml that has generated schema context,
+// or the connection's default database for --live.
+func exportDatabaseNames(baseDir string, dbCfg databaseConfig, live bool) ([]string, error) {
+	defaultDatabase := contextDatabaseNameForConnection(dbCfg)
+	if live {
+		return []string{defaultDatabase}, nil
+	}
+
+	databasesPath := filepath.Join(baseDir, "context", "connections", dbCfg.Name, "databases", "_databases.yml")
+	catalog, err := readDatabasesCatalog(databasesPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []string{defaultDatabase}, nil
+		}
+		return nil, err
+	}
+
+	databases := make([]string, 0, len(catalog.Databases))
+	for _, database := range catalog.Databases {
+		schemasDir, err := contextgen.SchemasDir(contextgen.Options{
+			ConnectionName: dbCfg.Name,
+			DatabaseName:   database,
+			DatabaseType:   dbCfg.Type,
+			BaseDir:        baseDir,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(filepath.Join(schemasDir, "_schemas.yml")); err == nil {
+			databases = append(databases, database)
+		}
+	}
+	if len(databases) == 0 {
+		return nil, fmt.Errorf("no generated schema context found for connection %q; run dbh schemas first or pass --live", dbCfg.Name)
+	}
+	return databases, nil
+}
+
+// contextDatabaseNameForConnection returns the database name used for the
+// connection's context directory. SQLite connections store the file path in
+// Database, so their context lives under the "main" database.
+func contextDatabaseNameForConnection(dbCfg databaseConfig) string {
+	if isSQLiteConnectionType(dbCfg.Type) {
+		return "main"
+	}
+	return strings.TrimSpace(dbCfg.Database)
+}
+
+func exportDatabaseDictionary(baseDir string, dbCfg databaseConfig, database string, live bool) (string, error) {
+	opts := contextgen.Options{
+		ConnectionName: dbCfg.Name,
+		DatabaseName:   database,
+		DatabaseType:   dbCfg.Type,
+		BaseDir:        baseDir,
+		PreserveCase:   dbCfg.PreserveCase,
+	}
+
+	var dbContext *contextgen.DatabaseContext
+	var err error
+	if live {
+		dbContext, err = loadLiveDatabaseContext(dbCfg, database)
+	} else {
+		dbContext, err = contextgen.LoadDatabaseContext(opts)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(baseDir, "context", "connections", dbCfg.Name, "databases", sanitizeSchemaName(dbContext.Database))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create database dir: %w", err)
+	}
+
+	path := filepath.Join(dir, export.DictionaryFileName)
+	if err := os.WriteFile(path, export.Markdown(dbContext), 0o644); err != nil {
+		return "", fmt.Errorf("write %s: %w", export.DictionaryFileName, err)
+	}
+	return path, nil
+}
+
+// loadLiveDatabaseContext builds a DatabaseContext by discovering schemas and
+// column metadata directly from the database. No enrichment stats are read.
+func loadLiveDatabaseContext(dbCfg databaseConfig, database string) (*contextgen.DatabaseContext, error) {
+	dbCfgCopy := dbCfg
+	if !isSQLiteConnectionType(dbCfg.Type) {
+		dbCfgCopy.Database = database
+	}
+
+	announceConnection(os.Stdout, dbCfg)
+
+	disc, err := discovery.NewTableDetailDiscoverer(toDiscoveryConfig(dbCfgCopy))
+	if err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+	defer disc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportLiveTimeout)
+	defer cancel()
+
+	schemas, err := discoverSchemasWithProgress(ctx, os.Stdout, disc)
+	if err != nil {
+		return nil, fmt.Errorf("discover schemas: %w", err)
+	}
+	warnNoAllowlistedSchemas(os.Stderr, dbCfg, schemas)
+	disc = discovery.RestrictToDiscovered(disc, schemas)
+
+	dbContext := &contextgen.DatabaseContext{
+		Connection:   dbCfg.Name,
+		Database:     database,
+		DatabaseType: dbCfg.Type,
+		GeneratedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Name < schemas[j].Name })
+	for _, schema := range schemas {
+		schemaContext := contextgen.SchemaContext{Name: schema.Name}
+		tables := append([]discovery.TableInfo(nil), schema.Tables...)
+		sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+		for _, table := range tables {
+			columns, err := disc.GetColumns(ctx, schema.Name, table.Name)
+			if err != nil {
+				return nil, fmt.Errorf("read columns for %s.%s: %w", schema.Name, table.Name, err)
+			}
+
+			tableContext := contextgen.TableContext{Name: table.Name, Type: table.TableType}
+			for _, column := range columns {
+				tableContext.Columns = append(tableContext.Columns, contextgen.EnrichedColumnsFileItem{
+					Name:            column.Name,
+					DataType:        column.DataType,
+					IsNullable:      column.IsNullable,
+					OrdinalPosition: column.OrdinalPosition,
+					ColumnDefault:   column.ColumnDefault,
+					IsGenerated:     column.IsGenerated,
+				})
+			}
+			schemaContext.Tables = append(schemaContext.Tables, tableContext)
+		}
+		dbContext.Schemas = append(dbContext.Schemas, schemaContext)
+	}
+
+	return dbContext, nil
+}
+
+// validateSampleExportFlags checks the dbh tables --sample-export and
+// --sample-export-dir flags, which must be given together.
+func validateSampleExportFlags(format, dir string) error {
+	if format == "" && dir == "" {
+		return nil
+	}
+	if format == "" || dir == "" {
+		return errors.New("--sample-export and --sample-export-dir must be used together")
+	}
+	if !slices.Contains(export.SampleFormats, format) {
+		return fmt.Errorf("unsupported sample export format %q (supported: %s)", format, strings.Join(export.SampleFormats, ", "))
+	}
+	return nil
+}
+
+// writeSampleExport writes the sample rows of one table to
+// <dir>/<database>/<schema>/<table>.<format> and returns the path.
+func writeSampleExport(dir, format, database, schema, table string, sample *discovery.SampleResult) (string, error) {
+	var buf bytes.Buffer
+	if err := export.WriteSample(&buf, sample, format); err != nil {
+		return "", err
+	}
+
+	tableDir := filepath.Join(dir, sanitizeSchemaName(database), sanitizeSchemaName(schema))
+	if err := os.MkdirAll(tableDir, 0o755); err != nil {
+		return "", fmt.Errorf("create sample export dir: %w", err)
+	}
+	path := filepath.Join(tableDir, sanitizeSchemaName(table)+"."+format)
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return "", fmt.Errorf("write sample export: %w", err)
+	}
+	return path, nil
+}
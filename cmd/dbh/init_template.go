@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/genesisdayrit/dbharness/internal/template"
+)
+
+const (
+	templateModeOverlay = "overlay"
+	templateModeReplace = "replace"
+
+	templateFetchTimeout = 5 * time.Minute
+)
+
+func validateTemplateMode(mode string) error {
+	switch mode {
+	case templateModeOverlay, templateModeReplace:
+		return nil
+	default:
+		return fmt.Errorf("unsupported --template-mode %q (use overlay or replace)", mode)
+	}
+}
+
+// initTemplateRoot returns the scaffold dbh init installs: the built-in
+// template when source is empty, and otherwise the template fetched from
+// source, laid over the built-in one in overlay mode. The result is
+// validated before anything is installed. cleanup removes temporary files.
+func initTemplateRoot(source, mode string) (fs.FS, func(), error) {
+	if source == "" {
+		root, err := template.Root()
+		if err != nil {
+			return nil, func() {}, fmt.Errorf("load template: %w", err)
+		}
+		return root, func() {}, nil
+	}
+
+	fmt.Printf("Fetching template from %s...\n", source)
+	ctx, cancel := context.WithTimeout(context.Background(), templateFetchTimeout)
+	defer cancel()
+
+	fetched, err := template.Fetch(ctx, source)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	defer fetched.Close()
+
+	staging, err := os.MkdirTemp("", "dbh-init-")
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("create temp dir: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(staging) }
+
+	if mode == templateModeOverlay {
+		builtin, err := template.Root()
+		if err != nil {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("load template: %w", err)
+		}
+		if err := copyFS(builtin, staging, nil); err != nil {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("copy built-in template: %w", err)
+		}
+	}
+	if err := copyFS(os.DirFS(fetched.Root), staging, nil); err != nil {
+		cleanup()
+		return nil, func() {}, fmt.Errorf("copy template: %w", err)
+	}
+
+	root := os.DirFS(staging)
+	if err := template.Validate(root); err != nil {
+		cleanup()
+		return nil, func() {}, fmt.Errorf("template %s: %w", source, err)
+	}
+	return root, cleanup, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+)
+
+// discoveryLimits holds the --limit-schemas and --limit-tables guards
+// against writing context for a much larger database than intended, for
+// example when a mistyped connection points at a big shared cluster. Zero
+// disables a limit.
+type discoveryLimits struct {
+	Schemas int
+	Tables  int
+}
+
+func (l discoveryLimits) validate() error {
+	if l.Schemas < 0 {
+		return fmt.Errorf("--limit-schemas must not be negative, got %d", l.Schemas)
+	}
+	if l.Tables < 0 {
+		return fmt.Errorf("--limit-tables must not be negative, got %d", l.Tables)
+	}
+	return nil
+}
+
+// exceeded describes each limit that schemas goes over.
+func (l discoveryLimits) exceeded(schemas []discovery.SchemaInfo) []string {
+	var over []string
+	if l.Schemas > 0 && len(schemas) > l.Schemas {
+		over = append(over, fmt.Sprintf("%d schemas (--limit-schemas %d)", len(schemas), l.Schemas))
+	}
+	tables := 0
+	for _, schema := range schemas {
+		tables += len(schema.Tables)
+	}
+	if l.Tables > 0 && tables > l.Tables {
+		over = append(over, fmt.Sprintf("%d tables (--limit-tables %d)", tables, l.Tables))
+	}
+	return over
+}
+
+// confirmDiscoveryLimits asks whether to go on when schemas go over the
+// limits. Without a terminal it returns an error instead of asking. hint
+// tells the user how to narrow the run.
+func confirmDiscoveryLimits(limits discoveryLimits, schemas []discovery.SchemaInfo, hint string) error {
+	over := limits.exceeded(schemas)
+	if len(over) == 0 {
+		return nil
+	}
+
+	found := "found " + strings.Join(over, " and ")
+	if !stdinIsTerminal() {
+		return fmt.Errorf("%s; %s, or raise the limit", found, hint)
+	}
+	fmt.Printf("Discovery %s.\n", found)
+	if !promptYesNoDefaultNo("Continue anyway?") {
+		return fmt.Errorf("stopped: %s; %s, or raise the limit", found, hint)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+)
+
+func TestDiscoveryLimitsExceeded(t *testing.T) {
+	schemas := []discovery.SchemaInfo{
+		{Name: "public", Tables: []discovery.TableInfo{{Name: "users"}, {Name: "orders"}}},
+		{Name: "analytics", Tables: []discovery.TableInfo{{Name: "events"}}},
+	}
+
+	tests := []struct {
+		limits discoveryLimits
+		want   []string
+	}{
+		{limits: discoveryLimits{}, want: nil},
+		{limits: discoveryLimits{Schemas: 2, Tables: 3}, want: nil},
+		{limits: discoveryLimits{Schemas: 1}, want: []string{"2 schemas (--limit-schemas 1)"}},
+		{limits: discoveryLimits{Schemas: 1, Tables: 2}, want: []string{"2 schemas (--limit-schemas 1)", "3 tables (--limit-tables 2)"}},
+	}
+	for _, tt := range tests {
+		got := tt.limits.exceeded(schemas)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Fatalf("exceeded(%+v) = %q, want %q", tt.limits, got, tt.want)
+		}
+	}
+
+	if err := (discoveryLimits{Tables: -1}).validate(); err == nil {
+		t.Fatal("validate() accepted a negative limit")
+	}
+}
+
+func TestConfirmDiscoveryLimitsFailsWithoutTTY(t *testing.T) {
+	original := stdinIsTerminal
+	stdinIsTerminal = func() bool { return false }
+	t.Cleanup(func() { stdinIsTerminal = original })
+
+	schemas := []discovery.SchemaInfo{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	err := confirmDiscoveryLimits(discoveryLimits{Schemas: 2}, schemas, "narrow the selection with --schemas")
+	if err == nil || !strings.Contains(err.Error(), "found 3 schemas (--limit-schemas 2); narrow the selection with --schemas") {
+		t.Fatalf("confirmDiscoveryLimits() error = %v, want the limit and hint", err)
+	}
+
+	if err := confirmDiscoveryLimits(discoveryLimits{Schemas: 3}, schemas, "unused"); err != nil {
+		t.Fatalf("confirmDiscoveryLimits() within the limit error = %v", err)
+	}
+}
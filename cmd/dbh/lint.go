@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+	"github.com/genesisdayrit/dbharness/pkg/dbharness"
+)
+
+// duplicateTableName is a table name found in more than one schema.
+type duplicateTableName struct {
+	Name      string
+	Locations []string // "schema.table", sorted
+}
+
+func runLint(args []string) {
+	if len(args) == 0 || args[0] != "schema" {
+		fmt.Fprintln(os.Stderr, "Usage: dbh lint schema [-s name] [--database name]")
+		os.Exit(2)
+	}
+	runLintSchema(args[1:])
+}
+
+// runLintSchema discovers the connection's schemas and reports findings
+// that tend to confuse agents reading the context. It never writes files.
+func runLintSchema(args []string) {
+	flags := flag.NewFlagSet("lint schema", flag.ExitOnError)
+	shortName := flags.String("s", "", "Connection name from config.json.")
+	longName := flags.String("name", "", "Connection name from config.json.")
+	database := flags.String("database", "", "Database to lint (default: the connection's database).")
+	_ = flags.Parse(args)
+
+	name := *shortName
+	if name == "" {
+		name = *longName
+	}
+
+	cfg, err := readConfig(resolveConfigPath())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var dbCfg databaseConfig
+	if name == "" {
+		dbCfg, err = findPrimaryConnection(cfg, "")
+	} else {
+		dbCfg, err = findDatabaseConfig(cfg, name)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if db := strings.TrimSpace(*database); db != "" && !isSQLiteConnectionType(dbCfg.Type) {
+		dbCfg.Database = db
+	}
+
+	fmt.Printf("Linting connection %q (%s)...\n", dbCfg.Name, dbCfg.Type)
+	announceConnection(os.Stdout, dbCfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout(dbCfg, tableSchemaDiscoveryTimeout))
+	defer cancel()
+
+	schemas, fallback, err := dbharness.DiscoverSchemasWithFallback(ctx, toDiscoveryConfig(dbCfg))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, explainLoginTimeout(dbCfg, err))
+		os.Exit(1)
+	}
+	noteCatalogFallback(os.Stdout, fallback)
+	warnNoAllowlistedSchemas(os.Stderr, dbCfg, schemas)
+
+	tableCount := 0
+	for _, schema := range schemas {
+		tableCount += len(schema.Tables)
+	}
+	fmt.Printf("Checked %d table(s) across %d schema(s).\n\n", tableCount, len(schemas))
+	printDuplicateTableNames(os.Stdout, findDuplicateTableNames(schemas))
+}
+
+// findDuplicateTableNames returns the table and view names that appear in
+// more than one schema, compared ignoring case. Names in the most schemas
+// come first, ties by name.
+func findDuplicateTableNames(schemas []discovery.SchemaInfo) []duplicateTableName {
+	byName := make(map[string]*duplicateTableName)
+	for _, schema := range schemas {
+		for _, table := range schema.Tables {
+			key := strings.ToLower(table.Name)
+			if byName[key] == nil {
+				byName[key] = &duplicateTableName{Name: table.Name}
+			}
+			byName[key].Locations = append(byName[key].Locations, schema.Name+"."+table.Name)
+		}
+	}
+
+	var duplicates []duplicateTableName
+	for _, duplicate := range byName {
+		if len(duplicate.Locations) < 2 {
+			continue
+		}
+		sort.Strings(duplicate.Locations)
+		duplicates = append(duplicates, *duplicate)
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		if a, b := len(duplicates[i].Locations), len(duplicates[j].Locations); a != b {
+			return a > b
+		}
+		return strings.ToLower(duplicates[i].Name) < strings.ToLower(duplicates[j].Name)
+	})
+	return duplicates
+}
+
+func printDuplicateTableNames(w io.Writer, duplicates []duplicateTableName) {
+	if len(duplicates) == 0 {
+		fmt.Fprintln(w, "No table name appears in more than one schema.")
+		return
+	}
+	fmt.Fprintf(w, "%d table name(s) appear in more than one schema:\n", len(duplicates))
+	for _, duplicate := range duplicates {
+		fmt.Fprintf(w, "  %-30s %d schemas: %s\n", duplicate.Name, len(duplicate.Locations), strings.Join(duplicate.Locations, ", "))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Agents may pick the wrong one when a question names only the table. If the copies are intentional, say which schema to use in the schemas' ai_description.")
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+)
+
+func TestFindDuplicateTableNames(t *testing.T) {
+	schemas := []discovery.SchemaInfo{
+		{Name: "tenant_b", Tables: []discovery.TableInfo{{Name: "orders"}, {Name: "users"}}},
+		{Name: "public", Tables: []discovery.TableInfo{{Name: "orders"}, {Name: "events"}, {Name: "Users"}}},
+		{Name: "tenant_a", Tables: []discovery.TableInfo{{Name: "orders"}, {Name: "invoices"}}},
+	}
+
+	got := findDuplicateTableNames(schemas)
+	want := []duplicateTableName{
+		{Name: "orders", Locations: []string{"public.orders", "tenant_a.orders", "tenant_b.orders"}},
+		{Name: "users", Locations: []string{"public.Users", "tenant_b.users"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("findDuplicateTableNames() = %+v, want %+v", got, want)
+	}
+
+	if got := findDuplicateTableNames(schemas[:1]); len(got) != 0 {
+		t.Fatalf("findDuplicateTableNames(one schema) = %+v, want none", got)
+	}
+}
+
+func TestPrintDuplicateTableNames(t *testing.T) {
+	var out bytes.Buffer
+	printDuplicateTableNames(&out, nil)
+	if !strings.Contains(out.String(), "No table name appears in more than one schema") {
+		t.Fatalf("output = %q", out.String())
+	}
+
+	out.Reset()
+	printDuplicateTableNames(&out, []duplicateTableName{{Name: "orders", Locations: []string{"a.orders", "b.orders"}}})
+	if !strings.Contains(out.String(), "2 schemas: a.orders, b.orders") {
+		t.Fatalf("output = %q", out.String())
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// contextLockFileName is the file in the base dir that commands writing
+// context hold a lock on while they run.
+const contextLockFileName = ".lock"
+
+// errContextLocked is returned by tryLockFile when another process holds
+// the lock.
+var errContextLocked = errors.New("context directory is locked")
+
+// contextWriteCommands are the commands that write to the base dir and so
+// take its lock. sync and watch only start other dbh commands, which lock
+// for themselves; init may replace the whole directory, lock file included.
+var contextWriteCommands = map[string]bool{
+	"workspace":      true,
+	"set-default":    true,
+	"databases":      true,
+	"schemas":        true,
+	"update-schemas": true,
+	"tables":         true,
+	"columns":        true,
+	"export":         true,
+	"merge":          true,
+}
+
+// commandWritesContext reports whether the command in args (os.Args[1:])
+// writes context files. Of the config subcommands only set writes, and
+// asking for a command's help writes nothing.
+func commandWritesContext(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	for _, arg := range args[1:] {
+		if arg == "-h" || arg == "-help" || arg == "--help" {
+			return false
+		}
+	}
+	if args[0] == "config" {
+		return len(args) > 1 && args[1] == "set"
+	}
+	return contextWriteCommands[args[0]]
+}
+
+// lockContextDir takes the write lock on baseDir without waiting, so a
+// second dbh command writing the same directory fails fast instead of
+// corrupting the first one's files. The lock is released by the returned
+// func or, since commands exit through os.Exit, when the process ends. A
+// missing baseDir is not locked; the command reports it as usual.
+func lockContextDir(baseDir string, command string) (func(), error) {
+	if info, err := os.Stat(baseDir); err != nil || !info.IsDir() {
+		return func() {}, nil
+	}
+
+	path := filepath.Join(baseDir, contextLockFileName)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+	if err := tryLockFile(f); err != nil {
+		holder := readLockHolder(f)
+		f.Close()
+		if errors.Is(err, errContextLocked) {
+			return nil, fmt.Errorf("another dbh command (%s) is writing %s; try again when it finishes", holder, baseDir)
+		}
+		return nil, fmt.Errorf("lock %s: %w", path, err)
+	}
+
+	// The holder is informational; a failed write does not matter.
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt([]byte(fmt.Sprintf("dbh %s (pid %d)\n", command, os.Getpid())), 0)
+	}
+	return func() {
+		_ = unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// readLockHolder returns the command recorded in a lock file, or "unknown"
+// when it cannot be read.
+func readLockHolder(f *os.File) string {
+	data, err := io.ReadAll(io.NewSectionReader(f, 0, 1024))
+	if err != nil || strings.TrimSpace(string(data)) == "" {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(data))
+}
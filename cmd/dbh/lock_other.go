@@ -0,0 +1,15 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// dbh is released for Linux and macOS only; elsewhere commands run without
+// the write lock.
+func tryLockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommandWritesContext(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{args: []string{"tables", "-s", "dev"}, want: true},
+		{args: []string{"columns"}, want: true},
+		{args: []string{"config", "set", "dev.port", "5433"}, want: true},
+		{args: []string{"tables", "-h"}, want: false},
+		{args: []string{"config", "get", "dev.port"}, want: false},
+		{args: []string{"ls", "-c"}, want: false},
+		{args: []string{"sync"}, want: false},
+		{args: []string{"init", "--force"}, want: false},
+		{args: nil, want: false},
+	}
+	for _, tt := range tests {
+		if got := commandWritesContext(tt.args); got != tt.want {
+			t.Errorf("commandWritesContext(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestLockContextDirRejectsSecondWriter(t *testing.T) {
+	baseDir := filepath.Join(t.TempDir(), ".dbharness")
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	release, err := lockContextDir(baseDir, "tables")
+	if err != nil {
+		t.Fatalf("lockContextDir() error = %v", err)
+	}
+
+	_, err = lockContextDir(baseDir, "columns")
+	if err == nil {
+		t.Fatal("second lockContextDir() succeeded while the lock was held")
+	}
+	if !strings.Contains(err.Error(), "dbh tables (pid") {
+		t.Fatalf("error = %q, want it to name the holding command", err)
+	}
+
+	release()
+	release, err = lockContextDir(baseDir, "columns")
+	if err != nil {
+		t.Fatalf("lockContextDir() after release error = %v", err)
+	}
+	release()
+}
+
+func TestLockContextDirSkipsMissingBaseDir(t *testing.T) {
+	baseDir := filepath.Join(t.TempDir(), ".dbharness")
+	release, err := lockContextDir(baseDir, "tables")
+	if err != nil {
+		t.Fatalf("lockContextDir() error = %v", err)
+	}
+	release()
+	if _, err := os.Stat(baseDir); !os.IsNotExist(err) {
+		t.Fatalf("base dir was created: %v", err)
+	}
+}
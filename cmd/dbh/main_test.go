@@ -3,14 +3,22 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/genesisdayrit/dbharness/internal/contextgen"
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+	"github.com/genesisdayrit/dbharness/internal/template"
 	"gopkg.in/yaml.v3"
 )
 
@@ -92,6 +100,31 @@ func TestInstallTemplateForceCreatesFullSnapshot(t *testing.T) {
 	}
 }
 
+func TestEnsureGitignoreAddsMissingEntries(t *testing.T) {
+	originalWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("get cwd: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("chdir to temp project: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(originalWD)
+	}()
+
+	if err := os.WriteFile(".gitignore", []byte("node_modules/\n"+gitignoreSnapshots), 0o644); err != nil {
+		t.Fatalf("write .gitignore: %v", err)
+	}
+	added := ensureGitignore(gitignoreSnapshots, gitignoreContext)
+	if len(added) != 1 || added[0] != gitignoreContext {
+		t.Fatalf("ensureGitignore() added %v, want [%s]", added, gitignoreContext)
+	}
+	if added := ensureGitignore(gitignoreSnapshots, gitignoreContext); len(added) != 0 {
+		t.Fatalf("second ensureGitignore() added %v, want nothing", added)
+	}
+	assertFileContent(t, ".gitignore", "node_modules/\n.dbharness-snapshots/\n.dbharness/context/\n")
+}
+
 func TestInstallTemplateFreshIncludesAgentsGuide(t *testing.T) {
 	projectDir := t.TempDir()
 	originalWD, err := os.Getwd()
@@ -120,6 +153,30 @@ func TestInstallTemplateFreshIncludesAgentsGuide(t *testing.T) {
 	assertDirectoryEmpty(t, filepath.Join(targetDir, "context", "workspaces", defaultWorkspaceName, "diary"))
 }
 
+func TestInstallTemplateMinimalSkipsDocs(t *testing.T) {
+	root, err := template.Root()
+	if err != nil {
+		t.Fatalf("template.Root() error = %v", err)
+	}
+
+	targetDir := filepath.Join(t.TempDir(), ".dbharness")
+	if _, err := installTemplateFS(targetDir, false, root, skipMinimalTemplatePath); err != nil {
+		t.Fatalf("installTemplateFS(minimal) error = %v", err)
+	}
+
+	for _, path := range []string{"AGENTS.md", "README.md", filepath.Join("context", "README.md")} {
+		if _, err := os.Stat(filepath.Join(targetDir, path)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("minimal install has %s (stat error %v), want it skipped", path, err)
+		}
+	}
+	for _, path := range []string{"config.json", ".gitignore"} {
+		if _, err := os.Stat(filepath.Join(targetDir, path)); err != nil {
+			t.Errorf("minimal install is missing %s: %v", path, err)
+		}
+	}
+	assertDirectoryEmpty(t, filepath.Join(targetDir, "context", "workspaces", defaultWorkspaceName, "diary"))
+}
+
 func TestEnsureConnectionMemoryFileCreatesTemplate(t *testing.T) {
 	baseDir := filepath.Join(t.TempDir(), ".dbharness")
 	connectionName := "analytics"
@@ -505,6 +562,7 @@ func TestPrintConnections(t *testing.T) {
 				Name:    "warehouse",
 				Type:    "snowflake",
 				Account: "acme-org",
+				Tags:    []string{"analytics", "finance"},
 			},
 			{
 				Name: "local",
@@ -518,10 +576,10 @@ func TestPrintConnections(t *testing.T) {
 
 	got := out.String()
 	expectedLines := []string{
-		"NAME\tTYPE\tHOST_URL",
-		"primary\tpostgres\tdb.internal:5432",
-		"warehouse\tsnowflake\thttps://acme-org.snowflakecomputing.com",
-		"local\tsqlite\t-",
+		"NAME\tTYPE\tHOST_URL\tTAGS",
+		"primary\tpostgres\tdb.internal:5432\t-",
+		"warehouse\tsnowflake\thttps://acme-org.snowflakecomputing.com\tanalytics,finance",
+		"local\tsqlite\t-\t-",
 	}
 	for _, line := range expectedLines {
 		if !strings.Contains(got, line) {
@@ -588,6 +646,15 @@ func TestConnectionHostURL(t *testing.T) {
 			},
 			want: "https://my-org.snowflakecomputing.com",
 		},
+		{
+			name: "snowflake renders explicit privatelink host",
+			entry: databaseConfig{
+				Type:    "snowflake",
+				Account: "my-org",
+				Host:    "my-org.privatelink.snowflakecomputing.com",
+			},
+			want: "https://my-org.privatelink.snowflakecomputing.com",
+		},
 		{
 			name: "unknown type without host",
 			entry: databaseConfig{
@@ -696,8 +763,8 @@ func TestPingDatabaseSQLiteRequiresFilePath(t *testing.T) {
 	if err == nil {
 		t.Fatalf("pingDatabase(sqlite) error = nil, want non-nil")
 	}
-	if !strings.Contains(err.Error(), "file path") {
-		t.Fatalf("pingDatabase(sqlite) error = %q, want file path guidance", err)
+	if !errors.Is(err, discovery.ErrMissingDatabasePath) {
+		t.Fatalf("pingDatabase(sqlite) error = %v, want %v", err, discovery.ErrMissingDatabasePath)
 	}
 }
 
@@ -829,6 +896,27 @@ func TestResolveCurrentDefaultDatabase(t *testing.T) {
 	}
 }
 
+func TestLiveDatabaseExists(t *testing.T) {
+	path := createCompareTestDatabase(t, filepath.Join(t.TempDir(), "app.db"), "CREATE TABLE users (id INTEGER)")
+	dbCfg := databaseConfig{Name: "local", Type: "sqlite", Database: path}
+
+	for _, tt := range []struct {
+		database string
+		want     bool
+	}{
+		{database: "main", want: true},
+		{database: "dropped", want: false},
+	} {
+		got, err := liveDatabaseExists(dbCfg, tt.database)
+		if err != nil {
+			t.Fatalf("liveDatabaseExists(%q) error = %v", tt.database, err)
+		}
+		if got != tt.want {
+			t.Fatalf("liveDatabaseExists(%q) = %v, want %v", tt.database, got, tt.want)
+		}
+	}
+}
+
 func TestReadDatabasesCatalog(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "_databases.yml")
@@ -888,6 +976,7 @@ databases:
 	if err := writeDefaultDatabaseToDatabasesFile(
 		baseDir,
 		"primary",
+		"",
 		"postgres",
 		"analytics",
 		[]string{"myapp", "analytics"},
@@ -942,6 +1031,96 @@ func TestBuildConnectionSelectionArgs(t *testing.T) {
 	}
 }
 
+func TestRunSyncConnectionsContinuesAfterFailedConnection(t *testing.T) {
+	stages := []syncStage{
+		{Name: "databases", Subcommand: "databases", Description: "stage one"},
+		{Name: "schemas", Subcommand: "schemas", Description: "stage two"},
+	}
+
+	var calls []string
+	runner := func(command string, args []string) error {
+		calls = append(calls, command+" "+strings.Join(args, " "))
+		if command == "schemas" && args[1] == "finance" {
+			return errors.New("schema discovery failed")
+		}
+		return nil
+	}
+
+	var out bytes.Buffer
+	failed := runSyncConnections([]string{"finance", "marketing"}, stages, 0, runner, &out)
+
+	if !reflect.DeepEqual(failed, []string{"finance"}) {
+		t.Fatalf("failed = %v, want [finance]", failed)
+	}
+	wantCalls := []string{
+		"databases -s finance",
+		"schemas -s finance",
+		"databases -s marketing",
+		"schemas -s marketing",
+	}
+	if !reflect.DeepEqual(calls, wantCalls) {
+		t.Fatalf("calls = %v, want %v", calls, wantCalls)
+	}
+	if !strings.Contains(out.String(), "Synced 2 connection(s); 1 failed: finance") {
+		t.Fatalf("output missing overall summary:\n%s", out.String())
+	}
+}
+
+func TestFilterConnectionsByTag(t *testing.T) {
+	connections := []databaseConfig{
+		{Name: "finance", Tags: []string{"analytics", "team-finance"}},
+		{Name: "app", Tags: []string{"oltp"}},
+		{Name: "marketing", Tags: []string{"Analytics"}},
+		{Name: "scratch"},
+	}
+
+	var names []string
+	for _, entry := range filterConnectionsByTag(connections, []string{"analytics"}) {
+		names = append(names, entry.Name)
+	}
+	if want := []string{"finance", "marketing"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("filterConnectionsByTag(analytics) = %v, want %v", names, want)
+	}
+	if got := filterConnectionsByTag(connections, []string{"oltp", "team-finance"}); len(got) != 2 {
+		t.Fatalf("filterConnectionsByTag(oltp, team-finance) kept %d connection(s), want 2", len(got))
+	}
+	if got := filterConnectionsByTag(connections, nil); len(got) != len(connections) {
+		t.Fatalf("filterConnectionsByTag(nil) kept %d connection(s), want all %d", len(got), len(connections))
+	}
+}
+
+func TestFindPrimaryConnectionByTag(t *testing.T) {
+	cfg := config{Connections: []databaseConfig{
+		{Name: "app", Primary: true, Tags: []string{"ops"}},
+		{Name: "finance", Tags: []string{"analytics"}},
+		{Name: "marketing", Tags: []string{"Analytics"}, PrimaryTags: []string{"analytics"}},
+		{Name: "metrics", Tags: []string{"ops", "monitoring"}},
+		{Name: "scratch", Tags: []string{"monitoring"}},
+	}}
+
+	for _, tc := range []struct {
+		tag  string
+		want string
+	}{
+		{tag: "", want: "app"},
+		{tag: "ANALYTICS", want: "marketing"},
+		{tag: "ops", want: "app"},
+		{tag: "monitoring", want: "metrics"},
+	} {
+		got, err := findPrimaryConnection(cfg, tc.tag)
+		if err != nil {
+			t.Fatalf("findPrimaryConnection(%q) error = %v", tc.tag, err)
+		}
+		if got.Name != tc.want {
+			t.Errorf("findPrimaryConnection(%q) = %q, want %q", tc.tag, got.Name, tc.want)
+		}
+	}
+
+	if _, err := findPrimaryConnection(cfg, "billing"); err == nil || !strings.Contains(err.Error(), "no connections are tagged billing") {
+		t.Fatalf("findPrimaryConnection(billing) error = %v, want no connections are tagged billing", err)
+	}
+}
+
 func TestRunSyncStagesContinuesAfterFailure(t *testing.T) {
 	stages := []syncStage{
 		{Name: "databases", Subcommand: "databases", Description: "stage one"},
@@ -1149,3 +1328,1105 @@ func TestPrintSyncSummary(t *testing.T) {
 		}
 	}
 }
+
+func TestRetryFailedSyncStages(t *testing.T) {
+	var sleeps []time.Duration
+	previousSleep := syncRetrySleep
+	syncRetrySleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	t.Cleanup(func() { syncRetrySleep = previousSleep })
+
+	stages := []syncStage{
+		{Name: "databases", Subcommand: "databases"},
+		{Name: "schemas", Subcommand: "schemas"},
+		{Name: "tables", Subcommand: "tables"},
+	}
+	results := []syncStageResult{
+		{StageName: "databases"},
+		{StageName: "schemas", Err: errors.New("connection reset")},
+		{StageName: "tables", Err: errors.New("connection reset")},
+	}
+
+	// schemas recovers on the first retry; tables keeps failing.
+	var calls []string
+	runner := func(command string, args []string) error {
+		calls = append(calls, command)
+		if command == "tables" {
+			return errors.New("still down")
+		}
+		return nil
+	}
+
+	var out bytes.Buffer
+	got := retryFailedSyncStages(stages, []string{"-s", "warehouse"}, results, 3, runner, &out)
+
+	if want := []string{"schemas", "tables", "tables", "tables"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("retried stages = %v, want %v", calls, want)
+	}
+	if want := []time.Duration{syncRetryBackoff, 2 * syncRetryBackoff, 4 * syncRetryBackoff}; !reflect.DeepEqual(sleeps, want) {
+		t.Fatalf("backoff = %v, want %v", sleeps, want)
+	}
+	if got[0].Retries != 0 || got[0].Err != nil {
+		t.Fatalf("databases result = %+v, want untouched", got[0])
+	}
+	if got[1].Err != nil || got[1].Retries != 1 {
+		t.Fatalf("schemas result = %+v, want success after 1 retry", got[1])
+	}
+	if got[2].Err == nil || got[2].Err.Error() != "still down" || got[2].Retries != 3 {
+		t.Fatalf("tables result = %+v, want the last failure after 3 retries", got[2])
+	}
+	if results[1].Err == nil {
+		t.Fatal("retryFailedSyncStages modified the results passed in")
+	}
+
+	var summary bytes.Buffer
+	printSyncSummary(got, &summary)
+	for _, expected := range []string{", after 1 retry)", ", after 3 retries)"} {
+		if !strings.Contains(summary.String(), expected) {
+			t.Fatalf("expected summary to contain %q, got:\n%s", expected, summary.String())
+		}
+	}
+
+	calls, sleeps = nil, nil
+	retryFailedSyncStages(stages, nil, got[:2], 2, runner, &out)
+	if len(calls) != 0 || len(sleeps) != 0 {
+		t.Fatalf("retried %v after %v with no failures, want nothing", calls, sleeps)
+	}
+}
+
+func TestIsProductionEnvironment(t *testing.T) {
+	tests := []struct {
+		environment string
+		want        bool
+	}{
+		{environment: "production", want: true},
+		{environment: " Production ", want: true},
+		{environment: "prod", want: true},
+		{environment: "staging", want: false},
+		{environment: "", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isProductionEnvironment(tt.environment); got != tt.want {
+			t.Fatalf("isProductionEnvironment(%q) = %v, want %v", tt.environment, got, tt.want)
+		}
+	}
+}
+
+func TestConfirmProductionDataAccess(t *testing.T) {
+	originalReader := stdinReader
+	defer func() {
+		stdinReader = originalReader
+	}()
+
+	prod := databaseConfig{Name: "warehouse", Environment: "production"}
+
+	var out bytes.Buffer
+	stdinReader = bufio.NewReader(strings.NewReader("\n"))
+	if confirmProductionDataAccess(&out, prod, "sample rows from", false) {
+		t.Fatalf("confirmProductionDataAccess(...) = true for empty response, want false")
+	}
+	for _, expected := range []string{`"warehouse"`, `"production"`, "sample rows from"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("expected warning to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+
+	out.Reset()
+	stdinReader = bufio.NewReader(strings.NewReader(""))
+	if !confirmProductionDataAccess(&out, prod, "sample rows from", true) {
+		t.Fatalf("confirmProductionDataAccess(..., true) = false, want true")
+	}
+	if !strings.Contains(out.String(), "WARNING") {
+		t.Fatalf("expected warning to be printed even with --yes, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	staging := databaseConfig{Name: "staging-db", Environment: "staging"}
+	if !confirmProductionDataAccess(&out, staging, "sample rows from", false) {
+		t.Fatalf("confirmProductionDataAccess(staging) = false, want true")
+	}
+	if out.Len() != 0 {
+		t.Fatalf("expected no output for non-production connection, got:\n%s", out.String())
+	}
+}
+
+func TestFilterTablesMissingEnrichmentSkipsProfiledTables(t *testing.T) {
+	baseDir := t.TempDir()
+	opts := contextgen.Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+	}
+
+	if _, err := contextgen.WriteEnrichedColumnsFile(contextgen.EnrichedColumnsInput{
+		Schema: "public",
+		Table:  "users",
+		Columns: []discovery.EnrichedColumnInfo{
+			{Name: "id", DataType: "integer", TotalRows: 1, NonNullCount: 1, DistinctNonNullCount: 1},
+		},
+	}, opts); err != nil {
+		t.Fatalf("WriteEnrichedColumnsFile() error = %v", err)
+	}
+
+	schemas := []discovery.SchemaInfo{
+		{Name: "public", Tables: []discovery.TableInfo{{Name: "users"}, {Name: "orders"}, {Name: "accounts"}}},
+		{Name: "staging", Tables: []discovery.TableInfo{{Name: "events"}}},
+		{Name: "ignored", Tables: []discovery.TableInfo{{Name: "other"}}},
+	}
+
+	candidates, candidateCount := allTablesInSchemas(schemas, []string{"staging", "public"})
+	if candidateCount != 4 {
+		t.Fatalf("allTablesInSchemas() count = %d, want 4", candidateCount)
+	}
+
+	selected, total, profiled, err := filterTablesMissingEnrichment(candidates, opts)
+	if err != nil {
+		t.Fatalf("filterTablesMissingEnrichment() error = %v", err)
+	}
+
+	want := map[string][]string{
+		"public":  {"accounts", "orders"},
+		"staging": {"events"},
+	}
+	if !reflect.DeepEqual(selected, want) {
+		t.Fatalf("filterTablesMissingEnrichment() selected = %v, want %v", selected, want)
+	}
+	if total != 3 {
+		t.Fatalf("filterTablesMissingEnrichment() total = %d, want 3", total)
+	}
+	if profiled != 1 {
+		t.Fatalf("filterTablesMissingEnrichment() profiled = %d, want 1", profiled)
+	}
+}
+
+func TestFilterUnchangedTablesSkipsTablesNotModifiedSinceProfiling(t *testing.T) {
+	baseDir := t.TempDir()
+	opts := contextgen.Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "snowflake",
+		BaseDir:        baseDir,
+	}
+	profiledAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	columns := []discovery.EnrichedColumnInfo{
+		{Name: "id", DataType: "NUMBER", TotalRows: 1, NonNullCount: 1, DistinctNonNullCount: 1},
+	}
+	for _, table := range []string{"users", "orders"} {
+		if _, err := contextgen.WriteEnrichedColumnsFile(contextgen.EnrichedColumnsInput{
+			Schema: "PUBLIC", Table: table, Columns: columns, LastModified: profiledAt,
+		}, opts); err != nil {
+			t.Fatalf("WriteEnrichedColumnsFile(%s) error = %v", table, err)
+		}
+	}
+	// Written without a modification time, so it is always re-profiled.
+	if _, err := contextgen.WriteEnrichedColumnsFile(contextgen.EnrichedColumnsInput{
+		Schema: "PUBLIC", Table: "accounts", Columns: columns,
+	}, opts); err != nil {
+		t.Fatalf("WriteEnrichedColumnsFile(accounts) error = %v", err)
+	}
+
+	selected := map[string][]string{"PUBLIC": {"accounts", "events", "orders", "sessions", "users"}}
+	lastModified := map[string]time.Time{
+		"PUBLIC.users":    profiledAt,
+		"PUBLIC.orders":   profiledAt.Add(time.Minute),
+		"PUBLIC.accounts": profiledAt,
+		"PUBLIC.events":   profiledAt,
+		// PUBLIC.sessions has no modification time.
+	}
+
+	remaining, total, unchanged, err := filterUnchangedTables(selected, lastModified, opts)
+	if err != nil {
+		t.Fatalf("filterUnchangedTables() error = %v", err)
+	}
+	want := map[string][]string{"PUBLIC": {"accounts", "events", "orders", "sessions"}}
+	if !reflect.DeepEqual(remaining, want) || total != 4 || unchanged != 1 {
+		t.Fatalf("filterUnchangedTables() = %v, %d, %d; want %v, 4, 1", remaining, total, unchanged, want)
+	}
+}
+
+func TestSelectRequestedTables(t *testing.T) {
+	schemas := []discovery.SchemaInfo{
+		{Name: "public", Tables: []discovery.TableInfo{{Name: "users"}, {Name: "orders"}}},
+		{Name: "staging", Tables: []discovery.TableInfo{{Name: "events"}}},
+	}
+
+	selected, total, err := selectRequestedTables(schemas, []string{"public", "staging"}, []string{"public.orders", "staging.events", "public.users"})
+	if err != nil {
+		t.Fatalf("selectRequestedTables() error = %v", err)
+	}
+	want := map[string][]string{
+		"public":  {"orders", "users"},
+		"staging": {"events"},
+	}
+	if !reflect.DeepEqual(selected, want) || total != 3 {
+		t.Fatalf("selectRequestedTables() = %v, %d, want %v, 3", selected, total, want)
+	}
+
+	if _, _, err := selectRequestedTables(schemas, []string{"public"}, []string{"public.missing"}); err == nil {
+		t.Fatalf("selectRequestedTables(missing table) error = nil, want non-nil")
+	}
+	if _, _, err := selectRequestedTables(schemas, []string{"public"}, []string{"users"}); err == nil {
+		t.Fatalf("selectRequestedTables(unqualified table) error = nil, want non-nil")
+	}
+}
+
+func TestSelectFailedTables(t *testing.T) {
+	schemas := []discovery.SchemaInfo{
+		{Name: "public", Tables: []discovery.TableInfo{{Name: "users"}, {Name: "orders"}}},
+		{Name: "staging", Tables: []discovery.TableInfo{{Name: "events"}}},
+	}
+	failures := []contextgen.EnrichFailure{
+		{Schema: "public", Table: "orders"},
+		{Schema: "public", Table: "dropped"},
+		{Schema: "staging", Table: "events"},
+		{Schema: "gone", Table: "old"},
+	}
+
+	if got := schemasOfFailures(failures, []string{"public", "staging"}); !reflect.DeepEqual(got, []string{"public", "staging"}) {
+		t.Fatalf("schemasOfFailures() = %v, want [public staging]", got)
+	}
+
+	selected, total := selectFailedTables(schemas, []string{"public", "staging"}, failures, nil)
+	want := map[string][]string{"public": {"orders"}, "staging": {"events"}}
+	if total != 2 || !reflect.DeepEqual(selected, want) {
+		t.Fatalf("selectFailedTables() = %v (%d), want %v (2)", selected, total, want)
+	}
+
+	selected, total = selectFailedTables(schemas, []string{"public", "staging"}, failures, []string{"staging.events", "public.users"})
+	want = map[string][]string{"staging": {"events"}}
+	if total != 1 || !reflect.DeepEqual(selected, want) {
+		t.Fatalf("selectFailedTables() with --tables = %v (%d), want %v (1)", selected, total, want)
+	}
+}
+
+func TestSchemasFromTableRefs(t *testing.T) {
+	got, err := schemasFromTableRefs([]string{"staging.events", "public.users", "public.orders"})
+	if err != nil {
+		t.Fatalf("schemasFromTableRefs() error = %v", err)
+	}
+	want := []string{"public", "staging"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("schemasFromTableRefs() = %v, want %v", got, want)
+	}
+}
+
+func TestPingDatabaseRejectsUnsupportedType(t *testing.T) {
+	err := pingDatabase(databaseConfig{Type: "oracle"})
+	if !errors.Is(err, discovery.ErrUnsupportedType) {
+		t.Fatalf("pingDatabase(oracle) error = %v, want %v", err, discovery.ErrUnsupportedType)
+	}
+}
+
+func TestSetConnectionDatabaseSet(t *testing.T) {
+	cfg := config{
+		Connections: []databaseConfig{
+			{Name: "warehouse", Type: "snowflake"},
+		},
+	}
+
+	updated, err := setConnectionDatabaseSet(&cfg, "warehouse", []string{"SALES", " ANALYTICS ", "SALES"})
+	if err != nil {
+		t.Fatalf("setConnectionDatabaseSet() error = %v", err)
+	}
+	if !updated {
+		t.Fatalf("setConnectionDatabaseSet() updated = false, want true")
+	}
+	want := []string{"ANALYTICS", "SALES"}
+	if !reflect.DeepEqual(cfg.Connections[0].Databases, want) {
+		t.Fatalf("databases = %v, want %v", cfg.Connections[0].Databases, want)
+	}
+
+	updated, err = setConnectionDatabaseSet(&cfg, "warehouse", []string{"SALES", "ANALYTICS"})
+	if err != nil {
+		t.Fatalf("setConnectionDatabaseSet() error = %v", err)
+	}
+	if updated {
+		t.Fatalf("setConnectionDatabaseSet() updated = true for unchanged set, want false")
+	}
+
+	updated, err = setConnectionDatabaseSet(&cfg, "warehouse", nil)
+	if err != nil || !updated || len(cfg.Connections[0].Databases) != 0 {
+		t.Fatalf("clearing set: updated = %v, err = %v, databases = %v", updated, err, cfg.Connections[0].Databases)
+	}
+
+	if _, err := setConnectionDatabaseSet(&cfg, "missing", []string{"SALES"}); err == nil {
+		t.Fatalf("setConnectionDatabaseSet(missing) error = nil, want non-nil")
+	}
+}
+
+func TestSelectDatabasesForTablesUsesSavedSetWithoutTTY(t *testing.T) {
+	original := stdinIsTerminal
+	defer func() { stdinIsTerminal = original }()
+	stdinIsTerminal = func() bool { return false }
+
+	cfg := config{}
+	dbCfg := databaseConfig{
+		Name:      "warehouse",
+		Type:      "snowflake",
+		Database:  "SALES",
+		Databases: []string{"SALES", "ANALYTICS"},
+	}
+
+	got, err := selectDatabasesForTables(io.Discard, &cfg, &dbCfg, filepath.Join(t.TempDir(), "config.json"), nil)
+	if err != nil {
+		t.Fatalf("selectDatabasesForTables() error = %v", err)
+	}
+	want := []string{"ANALYTICS", "SALES"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("selectDatabasesForTables() = %v, want %v", got, want)
+	}
+
+	dbCfg.Databases = nil
+	got, err = selectDatabasesForTables(io.Discard, &cfg, &dbCfg, filepath.Join(t.TempDir(), "config.json"), nil)
+	if err != nil {
+		t.Fatalf("selectDatabasesForTables() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"SALES"}) {
+		t.Fatalf("selectDatabasesForTables() = %v, want [SALES]", got)
+	}
+}
+
+func TestParseOutputEncoding(t *testing.T) {
+	tests := map[string]contextgen.XMLEncoding{
+		"":           contextgen.XMLEncodingUTF8,
+		"utf-8":      contextgen.XMLEncodingUTF8,
+		" UTF-8-BOM": contextgen.XMLEncodingUTF8BOM,
+		"utf-16le":   contextgen.XMLEncodingUTF16LE,
+	}
+	for input, want := range tests {
+		got, err := parseOutputEncoding(input)
+		if err != nil || got != want {
+			t.Fatalf("parseOutputEncoding(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := parseOutputEncoding("latin1"); err == nil {
+		t.Fatal("parseOutputEncoding(latin1) error = nil, want unsupported encoding")
+	}
+}
+
+func TestParseColumnsFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    contextgen.ColumnsFormat
+		wantErr bool
+	}{
+		{input: "", want: contextgen.ColumnsFormatYAML},
+		{input: "yaml", want: contextgen.ColumnsFormatYAML},
+		{input: " JSON ", want: contextgen.ColumnsFormatJSON},
+		{input: "both", want: contextgen.ColumnsFormatBoth},
+		{input: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseColumnsFormat(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Fatalf("parseColumnsFormat(%q) error = nil, want non-nil", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("parseColumnsFormat(%q) error = %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Fatalf("parseColumnsFormat(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestToDiscoveryConfigCarriesRedshiftServerlessFields(t *testing.T) {
+	// dbh databases and the database prompts open their listers with this
+	// config, so it must resolve the workgroup endpoint and IAM credentials.
+	got := toDiscoveryConfig(databaseConfig{Name: "rs", Type: "redshift", Serverless: true, Workgroup: "analytics", Region: "us-west-2", IAMAuth: true})
+	if !got.Serverless || got.Workgroup != "analytics" || got.Region != "us-west-2" || !got.IAMAuth {
+		t.Fatalf("toDiscoveryConfig() = %+v, want the serverless workgroup, region, and IAM auth", got)
+	}
+}
+
+func TestToDiscoveryConfigCarriesSnowflakeEndpoint(t *testing.T) {
+	// Database listing and discovery must connect to the same endpoint.
+	got := toDiscoveryConfig(databaseConfig{Name: "sf", Type: "snowflake", Account: "acct", Host: "acct.privatelink.snowflakecomputing.com", Region: "us-gov-west-1"})
+	if got.Region != "us-gov-west-1" || got.Host != "acct.privatelink.snowflakecomputing.com" {
+		t.Fatalf("toDiscoveryConfig() = %+v, want the configured host and region", got)
+	}
+}
+
+func TestToDiscoveryConfigCachesSSOTokenInSyncStages(t *testing.T) {
+	dbCfg := databaseConfig{Name: "sf", Type: "snowflake", Authenticator: "externalbrowser"}
+
+	t.Setenv(syncSessionEnv, "")
+	if toDiscoveryConfig(dbCfg).CacheSSOToken {
+		t.Fatalf("CacheSSOToken = true outside dbh sync, want false")
+	}
+
+	t.Setenv(syncSessionEnv, "1")
+	if !toDiscoveryConfig(dbCfg).CacheSSOToken {
+		t.Fatalf("CacheSSOToken = false in a dbh sync stage, want true")
+	}
+}
+
+func TestTableIsEmpty(t *testing.T) {
+	path := createCompareTestDatabase(t, filepath.Join(t.TempDir(), "app.db"), `
+		CREATE TABLE users (id INTEGER);
+		INSERT INTO users (id) VALUES (1);
+		CREATE TABLE staging (id INTEGER);
+	`)
+	disc, err := discovery.NewTableDetailDiscoverer(discovery.DatabaseConfig{Type: "sqlite", Database: path})
+	if err != nil {
+		t.Fatalf("NewTableDetailDiscoverer() error = %v", err)
+	}
+	defer disc.Close()
+
+	if tableIsEmpty(disc, "main", "users", nil) {
+		t.Fatal("tableIsEmpty(users) = true, want false")
+	}
+	if !tableIsEmpty(disc, "main", "staging", map[string]int64{"staging": 0}) {
+		t.Fatal("tableIsEmpty(staging) = false, want true")
+	}
+	// A positive estimate skips the check, even when it is stale.
+	if tableIsEmpty(disc, "main", "staging", map[string]int64{"staging": 12}) {
+		t.Fatal("tableIsEmpty(staging) with a positive estimate = true, want false")
+	}
+}
+
+func TestOrderColumnTargetsBySize(t *testing.T) {
+	targets := []tableColumnTarget{
+		{Schema: "public", Table: "events"},
+		{Schema: "public", Table: "orders"},
+		{Schema: "public", Table: "users"},
+		{Schema: "sales", Table: "regions"},
+	}
+	estimates := map[string]int64{
+		"public.events": 5000000,
+		"public.users":  1200,
+		"sales.regions": 12,
+	}
+
+	if !orderColumnTargetsBySize(targets, estimates) {
+		t.Fatalf("orderColumnTargetsBySize() = false, want true")
+	}
+
+	var got []string
+	for _, target := range targets {
+		got = append(got, target.Schema+"."+target.Table)
+	}
+	want := []string{"sales.regions", "public.users", "public.events", "public.orders"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("order = %v, want %v", got, want)
+	}
+}
+
+func TestOrderColumnTargetsBySizeKeepsOrderWithoutEstimates(t *testing.T) {
+	targets := []tableColumnTarget{
+		{Schema: "public", Table: "b"},
+		{Schema: "public", Table: "a"},
+	}
+
+	if orderColumnTargetsBySize(targets, nil) {
+		t.Fatalf("orderColumnTargetsBySize() = true without estimates, want false")
+	}
+	if targets[0].Table != "b" || targets[1].Table != "a" {
+		t.Fatalf("targets reordered without estimates: %+v", targets)
+	}
+}
+
+func TestSinceColumnResolutionPerTarget(t *testing.T) {
+	targets := []tableColumnTarget{
+		{Schema: "PUBLIC", Table: "EVENTS", Columns: []discovery.ColumnInfo{{Name: "ID"}, {Name: "CREATED_AT"}}},
+		{Schema: "PUBLIC", Table: "REGIONS", Columns: []discovery.ColumnInfo{{Name: "ID"}, {Name: "NAME"}}},
+	}
+
+	kept, skipped := filterTargetsWithColumn(io.Discard, targets, "created_at")
+	if skipped != 1 || len(kept) != 1 || kept[0].Table != "EVENTS" {
+		t.Fatalf("filterTargetsWithColumn() = %+v, skipped %d; want only EVENTS kept", kept, skipped)
+	}
+
+	opts := discovery.EnrichmentOptions{
+		Since: discovery.SinceFilter{Column: "created_at", Op: ">", Value: "2024-01-01"},
+	}
+	got := enrichmentForTarget(opts, kept[0])
+	if got.Since.Column != "CREATED_AT" {
+		t.Fatalf("enrichmentForTarget() since column = %q, want CREATED_AT", got.Since.Column)
+	}
+	if opts.Since.Column != "created_at" {
+		t.Fatalf("enrichmentForTarget() modified the shared options")
+	}
+}
+
+func TestPrintColumnEstimate(t *testing.T) {
+	targets := []tableColumnTarget{
+		{Schema: "public", Table: "users", Columns: make([]discovery.ColumnInfo, 3)},
+		{Schema: "sales", Table: "orders", Columns: make([]discovery.ColumnInfo, 9)},
+	}
+
+	var buf bytes.Buffer
+	printColumnEstimate(&buf, targets, 2)
+	out := buf.String()
+	for _, want := range []string{
+		"  public.users: 3 column(s)\n",
+		"  sales.orders: 9 column(s)\n",
+		"Selected 2 table(s) across 2 schema(s) with 12 total column(s).\n",
+		"Estimated runtime: 1m0s to 2m0s\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("printColumnEstimate() output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+type fakeEnrichmentExplainer struct{}
+
+func (fakeEnrichmentExplainer) ExplainColumnEnrichment(_ context.Context, schema, table string, column discovery.ColumnInfo, opts discovery.EnrichmentOptions) ([]discovery.ExplainedQuery, error) {
+	return []discovery.ExplainedQuery{{
+		Purpose: "stats",
+		SQL:     fmt.Sprintf("SELECT %d FROM %s.%s", len(opts.TableColumns), schema, table),
+		Args:    []string{opts.Since.Value},
+	}}, nil
+}
+
+func TestPrintColumnQueries(t *testing.T) {
+	targets := []tableColumnTarget{{
+		Schema:  "public",
+		Table:   "users",
+		Columns: []discovery.ColumnInfo{{Name: "id"}, {Name: "email"}},
+	}}
+	opts := discovery.EnrichmentOptions{Since: discovery.SinceFilter{Column: "created_at", Op: ">", Value: "2024-01-01"}}
+
+	var buf bytes.Buffer
+	if err := printColumnQueries(context.Background(), &buf, fakeEnrichmentExplainer{}, targets, opts, true); err != nil {
+		t.Fatalf("printColumnQueries() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"-- public.users.id: stats\n-- args: 2024-01-01\nSELECT 2 FROM public.users;\n\n",
+		"-- public.users.email: stats\n",
+		"Nothing was profiled; rerun without --explain to run these queries.\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("printColumnQueries() output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestMatchesDataTypeFilter(t *testing.T) {
+	patterns := []string{"timestamp", " DATE "}
+	tests := []struct {
+		dataType string
+		want     bool
+	}{
+		{dataType: "TIMESTAMP_NTZ", want: true},
+		{dataType: "timestamp with time zone", want: true},
+		{dataType: "date", want: true},
+		{dataType: "DATETIME", want: true},
+		{dataType: "integer", want: false},
+		{dataType: "", want: false},
+	}
+	for _, tt := range tests {
+		if got := matchesDataTypeFilter(tt.dataType, patterns); got != tt.want {
+			t.Fatalf("matchesDataTypeFilter(%q) = %v, want %v", tt.dataType, got, tt.want)
+		}
+	}
+	if matchesDataTypeFilter("integer", []string{""}) {
+		t.Fatalf("matchesDataTypeFilter() matched an empty pattern")
+	}
+}
+
+func TestFilterTargetColumnsKeepsSinceColumn(t *testing.T) {
+	targets := []tableColumnTarget{
+		{Schema: "PUBLIC", Table: "EVENTS", Columns: []discovery.ColumnInfo{
+			{Name: "ID", DataType: "NUMBER"},
+			{Name: "CREATED_AT", DataType: "TIMESTAMP_NTZ"},
+			{Name: "SEEN_ON", DataType: "DATE"},
+		}},
+		{Schema: "PUBLIC", Table: "REGIONS", Columns: []discovery.ColumnInfo{
+			{Name: "ID", DataType: "NUMBER"},
+			{Name: "CREATED_AT", DataType: "VARCHAR"},
+		}},
+	}
+
+	targets, _ = filterTargetsWithColumn(io.Discard, targets, "created_at")
+	kept, skipped := filterTargetColumns(targets, columnSelector{DataTypes: []string{"timestamp", "date"}})
+	if skipped != 1 || len(kept) != 1 || kept[0].Table != "EVENTS" {
+		t.Fatalf("filterTargetColumns() = %+v, skipped %d; want only EVENTS kept", kept, skipped)
+	}
+	if len(kept[0].Columns) != 2 || kept[0].Columns[0].Name != "CREATED_AT" || kept[0].Columns[1].Name != "SEEN_ON" {
+		t.Fatalf("EVENTS columns = %+v, want CREATED_AT and SEEN_ON", kept[0].Columns)
+	}
+	if len(kept[0].TableColumns) != 3 {
+		t.Fatalf("EVENTS table columns = %+v, want all three", kept[0].TableColumns)
+	}
+
+	opts := discovery.EnrichmentOptions{
+		Since: discovery.SinceFilter{Column: "created_at", Op: ">", Value: "2024-01-01"},
+	}
+	if got := enrichmentForTarget(opts, kept[0]); got.Since.Column != "CREATED_AT" {
+		t.Fatalf("enrichmentForTarget() since column = %q, want CREATED_AT", got.Since.Column)
+	}
+}
+
+func TestFilterTargetColumnsExcludeKeepsTableColumns(t *testing.T) {
+	targets := []tableColumnTarget{
+		{Schema: "public", Table: "events", Columns: []discovery.ColumnInfo{
+			{Name: "id", DataType: "integer"},
+			{Name: "payload_raw", DataType: "text"},
+		}},
+		{Schema: "public", Table: "regions", Columns: []discovery.ColumnInfo{
+			{Name: "id", DataType: "integer"},
+		}},
+	}
+
+	kept, _ := filterTargetColumns(targets, columnSelector{Exclude: []string{"*_raw"}})
+	if len(kept) != 2 {
+		t.Fatalf("filterTargetColumns() kept %d target(s), want 2", len(kept))
+	}
+	if len(kept[0].Columns) != 1 || len(kept[0].TableColumns) != 2 {
+		t.Fatalf("events = %+v, want id profiled and both columns kept in TableColumns", kept[0])
+	}
+	if kept[1].TableColumns != nil {
+		t.Fatalf("regions TableColumns = %+v, want nil when nothing was excluded", kept[1].TableColumns)
+	}
+}
+
+func TestColumnSelectorMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector columnSelector
+		column   discovery.ColumnInfo
+		want     bool
+	}{
+		{name: "no selectors", column: discovery.ColumnInfo{Name: "payload_raw", DataType: "text"}, want: true},
+		{name: "excluded by glob", selector: columnSelector{Exclude: []string{"*_raw"}}, column: discovery.ColumnInfo{Name: "payload_raw", DataType: "text"}, want: false},
+		{name: "exclude ignores case", selector: columnSelector{Exclude: []string{"updated_at"}}, column: discovery.ColumnInfo{Name: "UPDATED_AT", DataType: "TIMESTAMP_NTZ"}, want: false},
+		{name: "not excluded", selector: columnSelector{Exclude: []string{"*_raw", "updated_at"}}, column: discovery.ColumnInfo{Name: "created_at", DataType: "timestamp"}, want: true},
+		{name: "type filter misses", selector: columnSelector{DataTypes: []string{"timestamp"}}, column: discovery.ColumnInfo{Name: "id", DataType: "integer"}, want: false},
+		{name: "type filter and exclude compose", selector: columnSelector{DataTypes: []string{"timestamp"}, Exclude: []string{"updated_*"}}, column: discovery.ColumnInfo{Name: "updated_at", DataType: "timestamp"}, want: false},
+		{name: "type filter and exclude both pass", selector: columnSelector{DataTypes: []string{"timestamp"}, Exclude: []string{"updated_*"}}, column: discovery.ColumnInfo{Name: "created_at", DataType: "timestamp"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.selector.matches(tt.column); got != tt.want {
+				t.Fatalf("matches(%+v) = %v, want %v", tt.column, got, tt.want)
+			}
+		})
+	}
+
+	if err := (columnSelector{Exclude: []string{"[_raw"}}).validate(); err == nil {
+		t.Fatal("validate() accepted a malformed glob")
+	}
+}
+
+func TestPrintQualitySummaryGroupsByFlag(t *testing.T) {
+	columns := []discovery.EnrichedColumnInfo{
+		{Name: "id", TotalRows: 3, NonNullCount: 3, DistinctNonNullCount: 3},
+		{Name: "legacy", TotalRows: 3, NullCount: 3, NullOfTotalRowsPct: 100},
+		{Name: "status", TotalRows: 3, NonNullCount: 3, DistinctNonNullCount: 2},
+	}
+	items := qualityItemsForTable("public", "users", columns, contextgen.DefaultHighNullPct)
+	if len(items) != 2 {
+		t.Fatalf("qualityItemsForTable() = %+v, want 2 flagged columns", items)
+	}
+
+	var buf bytes.Buffer
+	printQualitySummary(&buf, items, contextgen.DefaultHighNullPct)
+	out := buf.String()
+	for _, want := range []string{
+		"2 column(s) flagged",
+		"Entirely NULL (1):\n    - public.users.legacy",
+		"All values distinct (possible key) (1):\n    - public.users.id",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("summary missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "status") {
+		t.Fatalf("summary lists unflagged column:\n%s", out)
+	}
+}
+
+func TestWithEnrichmentWarehouse(t *testing.T) {
+	dbCfg := databaseConfig{Type: "snowflake", Warehouse: "COMPUTE_WH"}
+	if got := withEnrichmentWarehouse(dbCfg); got.Warehouse != "COMPUTE_WH" {
+		t.Fatalf("warehouse = %q without enrichment_warehouse, want COMPUTE_WH", got.Warehouse)
+	}
+
+	dbCfg.EnrichmentWarehouse = " PROFILING_WH "
+	if got := withEnrichmentWarehouse(dbCfg); got.Warehouse != "PROFILING_WH" {
+		t.Fatalf("warehouse = %q, want PROFILING_WH", got.Warehouse)
+	}
+	if dbCfg.Warehouse != "COMPUTE_WH" {
+		t.Fatalf("withEnrichmentWarehouse() modified the caller's config")
+	}
+}
+
+func TestWarnWarehouseResizeWarnsOnce(t *testing.T) {
+	t.Cleanup(func() { warnedWarehouseResize = false })
+	warnedWarehouseResize = false
+
+	var out bytes.Buffer
+	warnWarehouseResize(&out, databaseConfig{Type: "snowflake", Warehouse: "COMPUTE_WH"})
+	if out.Len() != 0 {
+		t.Fatalf("warned without warehouse_size: %q", out.String())
+	}
+
+	dbCfg := databaseConfig{Type: "snowflake", Warehouse: "COMPUTE_WH", WarehouseSize: "medium"}
+	warnWarehouseResize(&out, dbCfg)
+	if got := out.String(); !strings.Contains(got, `"COMPUTE_WH" to MEDIUM`) || !strings.Contains(got, "does not restore") {
+		t.Fatalf("warning = %q, want the warehouse, the size, and that it is not restored", got)
+	}
+
+	out.Reset()
+	warnWarehouseResize(&out, dbCfg)
+	if out.Len() != 0 {
+		t.Fatalf("warned twice: %q", out.String())
+	}
+}
+
+func TestWarnNoAllowlistedSchemas(t *testing.T) {
+	dbCfg := databaseConfig{Name: "sf", Type: "snowflake", Schemas: []string{"staging"}}
+
+	var out bytes.Buffer
+	warnNoAllowlistedSchemas(&out, dbCfg, []discovery.SchemaInfo{{Name: "STAGING"}})
+	warnNoAllowlistedSchemas(&out, databaseConfig{Type: "snowflake"}, nil)
+	if out.Len() != 0 {
+		t.Fatalf("warned with a matching or empty allowlist: %q", out.String())
+	}
+
+	warnNoAllowlistedSchemas(&out, dbCfg, nil)
+	if got := out.String(); !strings.Contains(got, `"sf"`) || !strings.Contains(got, "(staging)") {
+		t.Fatalf("warning = %q, want the connection and the allowlist", got)
+	}
+}
+
+func TestSnowflakeQueryTag(t *testing.T) {
+	previous := currentCommand
+	t.Cleanup(func() { currentCommand = previous })
+
+	currentCommand = "columns"
+	if got := snowflakeQueryTag(""); got != "dbh columns" {
+		t.Fatalf("snowflakeQueryTag(\"\") = %q, want %q", got, "dbh columns")
+	}
+	if got := snowflakeQueryTag(" finance:dbharness "); got != "finance:dbharness" {
+		t.Fatalf("snowflakeQueryTag(configured) = %q, want %q", got, "finance:dbharness")
+	}
+}
+
+func TestPendingColumnTargetsSkipsCheckpointedWork(t *testing.T) {
+	state := &contextgen.EnrichState{}
+	state.CompleteTable("public", "users")
+	state.AddColumn("public", "events", discovery.EnrichedColumnInfo{Name: "id"})
+
+	targets := []tableColumnTarget{
+		{Schema: "public", Table: "events", Columns: []discovery.ColumnInfo{{Name: "id"}, {Name: "kind"}}},
+		{Schema: "public", Table: "orders", Columns: []discovery.ColumnInfo{{Name: "id"}}},
+		{Schema: "public", Table: "users", Columns: []discovery.ColumnInfo{{Name: "id"}}},
+	}
+
+	pending, finishedTables, profiledColumns := pendingColumnTargets(targets, state)
+	if finishedTables != 1 || profiledColumns != 1 {
+		t.Fatalf("pendingColumnTargets() skipped %d table(s), %d column(s); want 1, 1", finishedTables, profiledColumns)
+	}
+	var got []string
+	for _, target := range pending {
+		got = append(got, target.Table)
+	}
+	if want := []string{"events", "orders"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("pending tables = %v, want %v", got, want)
+	}
+}
+
+func TestRefreshConnectionsIndexOmitsSecrets(t *testing.T) {
+	baseDir := t.TempDir()
+	cfg := `{"connections":[
+		{"name":"app","type":"postgres","primary":true,"database":"app","host":"db.internal","user":"svc","password":"hunter2"},
+		{"name":"local","type":"sqlite","primary":false,"database":"/tmp/local.db"}
+	]}`
+	if err := os.WriteFile(filepath.Join(baseDir, "config.json"), []byte(cfg), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	refreshConnectionsIndex(baseDir, filepath.Join(baseDir, "config.json"))
+
+	data, err := os.ReadFile(filepath.Join(baseDir, "context", contextgen.ConnectionsFileName))
+	if err != nil {
+		t.Fatalf("read connections index: %v", err)
+	}
+	out := string(data)
+	for _, secret := range []string{"hunter2", "db.internal", "svc", "/tmp/local.db"} {
+		if strings.Contains(out, secret) {
+			t.Fatalf("connections index leaks %q:\n%s", secret, out)
+		}
+	}
+	for _, want := range []string{"name: app", "default_database: app", "name: local", "default_database: main"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("connections index missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestExcludeViewsKeepsTablesAndMaterializedViews(t *testing.T) {
+	schemas := []discovery.SchemaInfo{
+		{Name: "public", Tables: []discovery.TableInfo{
+			{Name: "orders", TableType: "BASE TABLE"},
+			{Name: "order_totals", TableType: "VIEW"},
+			{Name: "daily_rollup", TableType: "MATERIALIZED VIEW"},
+		}},
+		{Name: "reporting", Tables: []discovery.TableInfo{
+			{Name: "summary", TableType: "view"},
+		}},
+	}
+	selected := map[string][]string{
+		"public":    {"orders", "order_totals", "daily_rollup"},
+		"reporting": {"summary"},
+	}
+
+	kept, count, views := excludeViews(schemas, selected)
+	if count != 2 {
+		t.Fatalf("kept count = %d, want 2", count)
+	}
+	if want := map[string][]string{"public": {"orders", "daily_rollup"}}; !reflect.DeepEqual(kept, want) {
+		t.Fatalf("kept = %v, want %v", kept, want)
+	}
+	if want := []string{"public.order_totals", "reporting.summary"}; !reflect.DeepEqual(views, want) {
+		t.Fatalf("views = %v, want %v", views, want)
+	}
+}
+
+func TestParseTimeZoneFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeZone string
+		utc      bool
+		want     string
+		wantErr  bool
+	}{
+		{name: "unset", want: ""},
+		{name: "utc flag", utc: true, want: "UTC"},
+		{name: "utc with matching timezone", timeZone: "UTC", utc: true, want: "UTC"},
+		{name: "named zone", timeZone: " Europe/Berlin ", want: "Europe/Berlin"},
+		{name: "conflict", timeZone: "Europe/Berlin", utc: true, wantErr: true},
+		{name: "unknown zone", timeZone: "Mars/Olympus", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTimeZoneFlags(tt.timeZone, tt.utc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimeZoneFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("parseTimeZoneFlags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplainLoginTimeout(t *testing.T) {
+	browser := databaseConfig{Name: "sf", Type: "snowflake", Authenticator: "externalbrowser"}
+	deadline := fmt.Errorf("ping: %w", context.DeadlineExceeded)
+
+	err := explainLoginTimeout(browser, deadline)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("explainLoginTimeout() = %v, want it to wrap context.DeadlineExceeded", err)
+	}
+	for _, want := range []string{"browser authentication timed out after 2m0s", "login_timeout_seconds", `"sf"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("explainLoginTimeout() = %q, want it to mention %q", err, want)
+		}
+	}
+
+	password := databaseConfig{Name: "sf", Type: "snowflake"}
+	if got := explainLoginTimeout(password, deadline); got != deadline {
+		t.Fatalf("explainLoginTimeout(password auth) = %v, want the error unchanged", got)
+	}
+	other := errors.New("incorrect username or password")
+	if got := explainLoginTimeout(browser, other); got != other {
+		t.Fatalf("explainLoginTimeout(other error) = %v, want the error unchanged", got)
+	}
+}
+
+func TestConnectTimeout(t *testing.T) {
+	browser := databaseConfig{Type: "snowflake", Authenticator: "externalbrowser"}
+	if got := connectTimeout(browser, 10*time.Second); got != defaultBrowserLoginTimeout {
+		t.Fatalf("connectTimeout(browser) = %s, want %s", got, defaultBrowserLoginTimeout)
+	}
+	browser.LoginTimeoutSeconds = 300
+	if got := connectTimeout(browser, 10*time.Second); got != 5*time.Minute {
+		t.Fatalf("connectTimeout(browser, 300s) = %s, want 5m0s", got)
+	}
+	if got := connectTimeout(databaseConfig{Type: "postgres"}, 10*time.Second); got != 10*time.Second {
+		t.Fatalf("connectTimeout(postgres) = %s, want 10s", got)
+	}
+}
+
+func TestInitTemplateRootOverlayAndReplace(t *testing.T) {
+	templateDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(templateDir, "AGENTS.md"), []byte("# Team agents\n"), 0o644); err != nil {
+		t.Fatalf("write AGENTS.md: %v", err)
+	}
+
+	root, cleanup, err := initTemplateRoot(templateDir, templateModeOverlay)
+	if err != nil {
+		t.Fatalf("initTemplateRoot(overlay) error = %v", err)
+	}
+	defer cleanup()
+
+	targetDir := filepath.Join(t.TempDir(), ".dbharness")
+	if _, err := installTemplateFS(targetDir, false, root, nil); err != nil {
+		t.Fatalf("installTemplateFS() error = %v", err)
+	}
+	assertFileContent(t, filepath.Join(targetDir, "AGENTS.md"), "# Team agents\n")
+	if _, err := os.Stat(filepath.Join(targetDir, "context", "README.md")); err != nil {
+		t.Fatalf("overlay should keep built-in context/README.md: %v", err)
+	}
+
+	if _, _, err := initTemplateRoot(templateDir, templateModeReplace); err == nil {
+		t.Fatal("initTemplateRoot(replace) error = nil, want an invalid template error")
+	}
+}
+
+func TestCopyFSSkipsSymlinks(t *testing.T) {
+	sourceDir := t.TempDir()
+	secret := filepath.Join(t.TempDir(), "id_rsa")
+	if err := os.WriteFile(secret, []byte("private key\n"), 0o600); err != nil {
+		t.Fatalf("write secret: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "README.md"), []byte("# Template\n"), 0o644); err != nil {
+		t.Fatalf("write README.md: %v", err)
+	}
+	if err := os.Symlink(secret, filepath.Join(sourceDir, "AGENTS.md")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	targetDir := t.TempDir()
+	if err := copyFS(os.DirFS(sourceDir), targetDir, nil); err != nil {
+		t.Fatalf("copyFS() error = %v", err)
+	}
+	assertFileContent(t, filepath.Join(targetDir, "README.md"), "# Template\n")
+	if _, err := os.Lstat(filepath.Join(targetDir, "AGENTS.md")); !os.IsNotExist(err) {
+		t.Fatalf("symlinked AGENTS.md was copied: %v", err)
+	}
+}
+
+func TestValidateSampleExportFlags(t *testing.T) {
+	if err := validateSampleExportFlags("", ""); err != nil {
+		t.Fatalf("no flags error = %v", err)
+	}
+	if err := validateSampleExportFlags("parquet", "out"); err != nil {
+		t.Fatalf("parquet error = %v", err)
+	}
+	if err := validateSampleExportFlags("csv", ""); err == nil {
+		t.Fatal("--sample-export without --sample-export-dir was accepted")
+	}
+	if err := validateSampleExportFlags("", "out"); err == nil {
+		t.Fatal("--sample-export-dir without --sample-export was accepted")
+	}
+	if err := validateSampleExportFlags("json", "out"); err == nil {
+		t.Fatal("unsupported format json was accepted")
+	}
+}
+
+func TestWriteSampleExport(t *testing.T) {
+	dir := t.TempDir()
+	sample := &discovery.SampleResult{Columns: []string{"id"}, Rows: [][]string{{"1"}, {"2"}}}
+
+	path, err := writeSampleExport(dir, "csv", "analytics", "public", "users", sample)
+	if err != nil {
+		t.Fatalf("writeSampleExport() error = %v", err)
+	}
+	if want := filepath.Join(dir, "analytics", "public", "users.csv"); path != want {
+		t.Fatalf("path = %q, want %q", path, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "id\n1\n2\n" {
+		t.Fatalf("csv = %q", data)
+	}
+}
+
+func TestPrintGeneratedSchemaFiles(t *testing.T) {
+	schemaDirs := []string{"schemas/public", "schemas/analytics"}
+
+	var full bytes.Buffer
+	printGeneratedSchemaFiles(&full, "databases", "schemas", schemaDirs, false, false)
+	want := "Files generated:\n  databases/_databases.yml\n  schemas/_schemas.yml\n  schemas/public/_tables.yml\n  schemas/analytics/_tables.yml\n"
+	if full.String() != want {
+		t.Fatalf("full listing = %q, want %q", full.String(), want)
+	}
+
+	var auto bytes.Buffer
+	printGeneratedSchemaFiles(&auto, "databases", "schemas", schemaDirs, true, false)
+	if !strings.Contains(auto.String(), "2 _tables.yml file(s) under schemas") || !strings.Contains(auto.String(), "--summary-only=false") {
+		t.Fatalf("automatic summary = %q", auto.String())
+	}
+
+	var explicit bytes.Buffer
+	printGeneratedSchemaFiles(&explicit, "databases", "schemas", schemaDirs, true, true)
+	if strings.Contains(explicit.String(), "--summary-only=false") || strings.Contains(explicit.String(), "public") {
+		t.Fatalf("explicit summary = %q", explicit.String())
+	}
+}
+
+func TestSampleSelectedTables(t *testing.T) {
+	selected := map[string][]string{
+		"public":    {"orders", "users", "events", "sessions"},
+		"analytics": {"daily", "weekly"},
+	}
+
+	kept, count, names := sampleSelectedTables(selected, 3, 42)
+	if count != 3 || len(names) != 3 {
+		t.Fatalf("count = %d, names = %q, want 3 tables", count, names)
+	}
+	if !sort.StringsAreSorted(names) {
+		t.Fatalf("names = %q, want sorted", names)
+	}
+	keptCount := 0
+	for schema, tables := range kept {
+		for _, table := range tables {
+			if !slices.Contains(selected[schema], table) || !slices.Contains(names, schema+"."+table) {
+				t.Fatalf("kept %s.%s, which was not selected or reported", schema, table)
+			}
+			keptCount++
+		}
+	}
+	if keptCount != 3 {
+		t.Fatalf("kept %d table(s), want 3", keptCount)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, _, again := sampleSelectedTables(selected, 3, 42); !reflect.DeepEqual(again, names) {
+			t.Fatalf("seed 42 picked %q, then %q", names, again)
+		}
+	}
+
+	if _, count, _ := sampleSelectedTables(selected, 10, 1); count != 6 {
+		t.Fatalf("count = %d, want every table when n exceeds the selection", count)
+	}
+}
+
+func TestEnrichStateScopeSeparatesCatalogStats(t *testing.T) {
+	scanned := discovery.EnrichmentOptions{}
+	catalog := discovery.EnrichmentOptions{CatalogStats: true}
+	if enrichStateScope(columnsRunOptions{Enrichment: scanned}) == enrichStateScope(columnsRunOptions{Enrichment: catalog}) {
+		t.Fatalf("enrichStateScope() = %q for both scanned and catalog runs", enrichStateScope(columnsRunOptions{Enrichment: scanned}))
+	}
+	if got := enrichStateScope(columnsRunOptions{ExcludeColumns: []string{"*_raw"}}); got != "--exclude-columns *_raw" {
+		t.Errorf("enrichStateScope(--exclude-columns) = %q, want the selector flags", got)
+	}
+	if got := statsSource(catalog); got != contextgen.StatsSourceCatalog {
+		t.Errorf("statsSource(catalog) = %q, want %q", got, contextgen.StatsSourceCatalog)
+	}
+	if got := statsSource(scanned); got != "" {
+		t.Errorf("statsSource(scanned) = %q, want empty", got)
+	}
+}
+
+func TestSamplePct(t *testing.T) {
+	for pct, want := range map[float64]float64{0: 0, 10: 10, 0.5: 0.5, 100: 0} {
+		if got := samplePct(discovery.EnrichmentOptions{TablesamplePct: pct}); got != want {
+			t.Errorf("samplePct(%v) = %v, want %v", pct, got, want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/genesisdayrit/dbharness/internal/contextgen"
+)
+
+func runMerge(args []string) {
+	flags := flag.NewFlagSet("merge", flag.ExitOnError)
+	into := flags.String("into", "", "Name of the merged view written to context/merged/<name>.")
+	copyFiles := flags.Bool("copy", false, "Copy the schema directories instead of symlinking them.")
+	_ = flags.Parse(args)
+
+	if strings.TrimSpace(*into) == "" || flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: dbh merge --into <name> [--copy] <connection>[/<database>[/<schema>]] ...")
+		os.Exit(2)
+	}
+
+	baseDir := filepath.Join(".", ".dbharness")
+	cfg, err := readConfig(resolveConfigPath())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	sources, err := parseMergeSources(cfg, flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	viewDir, file, skipped, err := contextgen.WriteMergedView(baseDir, strings.TrimSpace(*into), sources, *copyFiles)
+	for _, schema := range skipped {
+		fmt.Printf("Skipped %s: no generated tables yet (run dbh tables)\n", schema)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	absPath, _ := filepath.Abs(viewDir)
+	fmt.Printf("Merged %d schema(s) from %d source(s) into %s (%s)\n", len(file.Schemas), len(sources), absPath, file.Mode)
+}
+
+// parseMergeSources turns dbh merge arguments of the form
+// <connection>[/<database>[/<schema>]] into merge sources. Without a
+// database the connection's default database is used, and without a schema
+// every generated schema of the database is included.
+func parseMergeSources(cfg config, args []string) ([]contextgen.MergeSource, error) {
+	sources := make([]contextgen.MergeSource, 0, len(args))
+	for _, arg := range args {
+		parts := strings.SplitN(strings.Trim(strings.TrimSpace(arg), "/"), "/", 3)
+		if parts[0] == "" {
+			return nil, errors.New("empty merge source; want <connection>[/<database>[/<schema>]]")
+		}
+		dbCfg, err := lookupDatabaseConfig(cfg, parts[0])
+		if err != nil {
+			return nil, err
+		}
+
+		source := contextgen.MergeSource{
+			Connection:   dbCfg.Name,
+			Database:     contextDatabaseNameForConnection(dbCfg),
+			DatabaseType: dbCfg.Type,
+		}
+		if len(parts) > 1 {
+			source.Database = parts[1]
+		}
+		if len(parts) > 2 {
+			source.Schema = parts[2]
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/genesisdayrit/dbharness/internal/contextgen"
+)
+
+func TestParseMergeSources(t *testing.T) {
+	cfg := config{Connections: []databaseConfig{
+		{Name: "billing", Type: "postgres", Database: "invoices"},
+		{Name: "local", Type: "sqlite", Database: "./app.db"},
+	}}
+
+	got, err := parseMergeSources(cfg, []string{"billing", "billing/archive/public", "local/"})
+	if err != nil {
+		t.Fatalf("parseMergeSources() error = %v", err)
+	}
+	want := []contextgen.MergeSource{
+		{Connection: "billing", Database: "invoices", DatabaseType: "postgres"},
+		{Connection: "billing", Database: "archive", DatabaseType: "postgres", Schema: "public"},
+		{Connection: "local", Database: "main", DatabaseType: "sqlite"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseMergeSources() = %+v, want %+v", got, want)
+	}
+
+	if _, err := parseMergeSources(cfg, []string{"missing/db"}); err == nil {
+		t.Fatal("parseMergeSources(missing connection) error = nil, want error")
+	}
+}
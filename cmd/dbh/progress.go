@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	progressModeLines = "lines"
+	progressModeBar   = "bar"
+	progressBarWidth  = 30
+)
+
+// parseProgressMode validates the --progress flag value. An empty value
+// selects the default line-by-line output.
+func parseProgressMode(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", progressModeLines:
+		return progressModeLines, nil
+	case progressModeBar:
+		return progressModeBar, nil
+	default:
+		return "", fmt.Errorf("invalid --progress value %q (want %q or %q)", value, progressModeLines, progressModeBar)
+	}
+}
+
+// columnProgress reports dbh columns profiling progress either as one line
+// per profiled column or as a single live progress bar redrawn in place.
+type columnProgress struct {
+	out       io.Writer
+	bar       bool
+	total     int
+	startedAt time.Time
+	done      int
+	label     string
+}
+
+func newColumnProgress(out io.Writer, bar bool, total int, startedAt time.Time) *columnProgress {
+	return &columnProgress{
+		out:       out,
+		bar:       bar,
+		total:     total,
+		startedAt: startedAt,
+	}
+}
+
+func (p *columnProgress) startTable(schema, table string, columnCount int) {
+	if p.bar {
+		p.label = schema + "." + table
+		p.redraw()
+		return
+	}
+	fmt.Fprintf(p.out, "\nProcessing table %s.%s (%d column(s))...\n", schema, table, columnCount)
+}
+
+func (p *columnProgress) columnDone(schema, table, column string, processed int, took time.Duration) {
+	p.done = processed
+	if p.bar {
+		p.redraw()
+		return
+	}
+	remainingETA := estimateRemainingDuration(time.Since(p.startedAt), processed, p.total-processed)
+	fmt.Fprintf(
+		p.out,
+		"  [%d/%d] %s.%s.%s profiled (%s, est. remaining %s)\n",
+		processed,
+		p.total,
+		schema,
+		table,
+		column,
+		took.Round(time.Millisecond),
+		remainingETA,
+	)
+}
+
+// printf writes a status line. In bar mode the bar is cleared first and
+// redrawn afterwards so messages scroll above it.
+func (p *columnProgress) printf(format string, args ...interface{}) {
+	if p.bar {
+		fmt.Fprint(p.out, "\r\033[K")
+	}
+	fmt.Fprintf(p.out, format, args...)
+	if p.bar {
+		p.redraw()
+	}
+}
+
+func (p *columnProgress) finish() {
+	if p.bar {
+		fmt.Fprintln(p.out)
+	}
+}
+
+func (p *columnProgress) redraw() {
+	remainingETA := estimateRemainingDuration(time.Since(p.startedAt), p.done, p.total-p.done)
+	line := renderProgressBar(p.done, p.total, progressBarWidth, remainingETA)
+	if p.label != "" {
+		line += "  " + p.label
+	}
+	fmt.Fprint(p.out, "\r\033[K"+line)
+}
+
+// renderProgressBar formats a fixed-width text progress bar such as
+// "[#####-----]  5/10 columns  50%  ETA 12s".
+func renderProgressBar(done, total, width int, eta time.Duration) string {
+	if total < 0 {
+		total = 0
+	}
+	if done < 0 {
+		done = 0
+	}
+	if done > total {
+		done = total
+	}
+
+	filled := width
+	percent := 100
+	if total > 0 {
+		filled = done * width / total
+		percent = done * 100 / total
+	}
+
+	return fmt.Sprintf(
+		"[%s%s] %d/%d columns %3d%%  ETA %s",
+		strings.Repeat("#", filled),
+		strings.Repeat("-", width-filled),
+		done,
+		total,
+		percent,
+		eta,
+	)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseProgressMode(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "", want: progressModeLines},
+		{input: "lines", want: progressModeLines},
+		{input: " BAR ", want: progressModeBar},
+		{input: "spinner", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseProgressMode(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Fatalf("parseProgressMode(%q) error = nil, want non-nil", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("parseProgressMode(%q) error = %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Fatalf("parseProgressMode(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRenderProgressBar(t *testing.T) {
+	tests := []struct {
+		name  string
+		done  int
+		total int
+		want  string
+	}{
+		{name: "empty", done: 0, total: 10, want: "[----------] 0/10 columns   0%  ETA 0s"},
+		{name: "half", done: 5, total: 10, want: "[#####-----] 5/10 columns  50%  ETA 0s"},
+		{name: "complete", done: 10, total: 10, want: "[##########] 10/10 columns 100%  ETA 0s"},
+		{name: "clamps overflow", done: 12, total: 10, want: "[##########] 10/10 columns 100%  ETA 0s"},
+		{name: "zero total", done: 0, total: 0, want: "[##########] 0/0 columns 100%  ETA 0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderProgressBar(tt.done, tt.total, 10, 0)
+			if got != tt.want {
+				t.Fatalf("renderProgressBar(%d, %d) = %q, want %q", tt.done, tt.total, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumnProgressLineModePrintsEachColumn(t *testing.T) {
+	var out bytes.Buffer
+	progress := newColumnProgress(&out, false, 2, time.Now())
+
+	progress.startTable("public", "users", 2)
+	progress.columnDone("public", "users", "id", 1, time.Millisecond)
+	progress.columnDone("public", "users", "email", 2, time.Millisecond)
+	progress.finish()
+
+	got := out.String()
+	for _, want := range []string{
+		"Processing table public.users (2 column(s))...",
+		"[1/2] public.users.id profiled",
+		"[2/2] public.users.email profiled",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("line progress output = %q, want substring %q", got, want)
+		}
+	}
+	if strings.Contains(got, "\r") {
+		t.Fatalf("line progress output contains carriage return: %q", got)
+	}
+}
+
+func TestColumnProgressBarModeRedrawsInPlace(t *testing.T) {
+	var out bytes.Buffer
+	progress := newColumnProgress(&out, true, 2, time.Now())
+
+	progress.startTable("public", "users", 2)
+	progress.columnDone("public", "users", "id", 1, time.Millisecond)
+	progress.printf("  Wrote %s\n", "users__columns.yml")
+	progress.finish()
+
+	got := out.String()
+	if strings.Contains(got, "profiled") {
+		t.Fatalf("bar progress output should not print per-column lines: %q", got)
+	}
+	if !strings.Contains(got, "1/2 columns") {
+		t.Fatalf("bar progress output = %q, want bar with 1/2 columns", got)
+	}
+	if !strings.Contains(got, "\r\033[K  Wrote users__columns.yml\n") {
+		t.Fatalf("bar progress output = %q, want cleared line before message", got)
+	}
+}
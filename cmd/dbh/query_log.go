@@ -0,0 +1,20 @@
+package main
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+)
+
+// logQueriesEnvVar turns on query logging, like --log-queries. main sets it
+// from the flag so dbh sync and dbh watch stages log their queries too.
+const logQueriesEnvVar = "DBH_LOG_QUERIES"
+
+// enableQueryLog writes every statement the discoverers send to stderr
+// when --log-queries or DBH_LOG_QUERIES is set.
+func enableQueryLog() {
+	if on, err := strconv.ParseBool(os.Getenv(logQueriesEnvVar)); err == nil && on {
+		discovery.SetQueryLog(os.Stderr)
+	}
+}
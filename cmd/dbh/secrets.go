@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/genesisdayrit/dbharness/internal/secrets"
+)
+
+// secretResolveTimeout bounds resolving the references of one connection,
+// including vault or op CLI calls that may wait for a login.
+const secretResolveTimeout = 2 * time.Minute
+
+// configSecrets resolves ${scheme:ref} references in config.json.
+var configSecrets = secrets.Default()
+
+// resolveConnectionSecrets replaces secret references in every string field
+// of entry with the secret they name. Only the connection a command uses is
+// resolved, so a missing secret of another connection does not break it.
+func resolveConnectionSecrets(entry *databaseConfig, resolvers secrets.Set) error {
+	ctx, cancel := context.WithTimeout(context.Background(), secretResolveTimeout)
+	defer cancel()
+
+	for field, value := range connectionStringFields(entry) {
+		if !secrets.HasReference(value.String()) {
+			continue
+		}
+		resolved, err := resolvers.Expand(ctx, value.String())
+		if err != nil {
+			if entry.Environment != "" {
+				return fmt.Errorf("connection %q environment %q field %s: %w", entry.Name, entry.Environment, field, err)
+			}
+			return fmt.Errorf("connection %q field %s: %w", entry.Name, field, err)
+		}
+		value.SetString(resolved)
+	}
+	return nil
+}
+
+// connectionStringFields returns the settable string fields of entry keyed
+// by their config.json name.
+func connectionStringFields(entry *databaseConfig) map[string]reflect.Value {
+	value := reflect.ValueOf(entry).Elem()
+	fields := make(map[string]reflect.Value)
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() || field.Type.Kind() != reflect.String {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			name = field.Name
+		}
+		fields[name] = value.Field(i)
+	}
+	return fields
+}
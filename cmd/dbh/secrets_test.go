@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/genesisdayrit/dbharness/internal/secrets"
+)
+
+func TestFindDatabaseConfigResolvesSecretReferences(t *testing.T) {
+	t.Setenv("DBH_TEST_PG_PASSWORD", "s3cret")
+	t.Setenv("DBH_TEST_PG_HOST", "db.internal")
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	raw := `{"connections":[{"name":"warehouse","type":"postgres","host":"${env:DBH_TEST_PG_HOST}","port":5432,"user":"analyst","password":"${env:DBH_TEST_PG_PASSWORD}"}]}` + "\n"
+	if err := os.WriteFile(configPath, []byte(raw), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := readConfig(configPath)
+	if err != nil {
+		t.Fatalf("readConfig() error = %v", err)
+	}
+	if cfg.Connections[0].Password != "${env:DBH_TEST_PG_PASSWORD}" {
+		t.Fatalf("readConfig() password = %q, want the reference left in place", cfg.Connections[0].Password)
+	}
+	entry, err := findDatabaseConfig(cfg, "warehouse")
+	if err != nil {
+		t.Fatalf("findDatabaseConfig() error = %v", err)
+	}
+	if entry.Password != "s3cret" || entry.Host != "db.internal" || entry.User != "analyst" {
+		t.Fatalf("resolved connection = host %q user %q password %q", entry.Host, entry.User, entry.Password)
+	}
+
+	// Writing the config back must keep the references, not the secrets.
+	cfg.Connections[0].Database = "analytics"
+	if err := writeConfig(configPath, cfg); err != nil {
+		t.Fatalf("writeConfig() error = %v", err)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	written := string(data)
+	for _, want := range []string{`"password": "${env:DBH_TEST_PG_PASSWORD}"`, `"host": "${env:DBH_TEST_PG_HOST}"`, `"database": "analytics"`} {
+		if !strings.Contains(written, want) {
+			t.Fatalf("written config = %s, want it to contain %s", written, want)
+		}
+	}
+	if strings.Contains(written, "s3cret") {
+		t.Fatalf("written config leaked the resolved secret: %s", written)
+	}
+}
+
+func TestFindDatabaseConfigReportsUnresolvedSecret(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	raw := `{"connections":[{"name":"warehouse","type":"postgres","password":"${env:DBH_TEST_SURELY_UNSET}"},{"name":"local","type":"sqlite","database":"app.db"}]}` + "\n"
+	if err := os.WriteFile(configPath, []byte(raw), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := readConfig(configPath)
+	if err != nil {
+		t.Fatalf("readConfig() error = %v, want secrets left unresolved", err)
+	}
+	if _, err := findDatabaseConfig(cfg, "local"); err != nil {
+		t.Fatalf("findDatabaseConfig(local) error = %v, want another connection's secret ignored", err)
+	}
+	if _, err := lookupDatabaseConfig(cfg, "warehouse"); err != nil {
+		t.Fatalf("lookupDatabaseConfig(warehouse) error = %v, want nil without resolving", err)
+	}
+
+	_, err = findDatabaseConfig(cfg, "warehouse")
+	if !errors.Is(err, secrets.ErrNotFound) {
+		t.Fatalf("findDatabaseConfig() error = %v, want secrets.ErrNotFound", err)
+	}
+	if !strings.Contains(err.Error(), `connection "warehouse" field password`) {
+		t.Fatalf("findDatabaseConfig() error = %q, want it to name the connection and field", err)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// errNoTTY is returned by interactive prompts when stdin is not a terminal,
+// where huh prompts would otherwise block forever (e.g. in CI).
+var errNoTTY = errors.New("no TTY; pass --schemas/--tables/--yes to run non-interactively")
+
+// stdinIsTerminal reports whether stdin is attached to a terminal. Tests
+// replace it to exercise the non-interactive code paths.
+var stdinIsTerminal = func() bool {
+	return isTerminal(os.Stdin)
+}
+
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// ensureInteractive returns errNoTTY when the prompt with the given label
+// cannot be shown because stdin is not a terminal.
+func ensureInteractive(label string) error {
+	if stdinIsTerminal() {
+		return nil
+	}
+	return fmt.Errorf("%w (prompt: %s)", errNoTTY, label)
+}
+
+// parseListFlag splits a comma-separated flag value into trimmed, non-empty
+// entries.
+func parseListFlag(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// matchRequestedNames validates that every requested name is in available and
+// returns the requested names sorted. kind names the object in errors (e.g.
+// "schema").
+func matchRequestedNames(available, requested []string, kind string) ([]string, error) {
+	availableSet := make(map[string]bool, len(available))
+	for _, name := range available {
+		availableSet[name] = true
+	}
+
+	var missing []string
+	matched := make([]string, 0, len(requested))
+	seen := make(map[string]bool, len(requested))
+	for _, name := range requested {
+		if !availableSet[name] {
+			missing = append(missing, name)
+			continue
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		matched = append(matched, name)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s not found: %s", kind, strings.Join(missing, ", "))
+	}
+
+	sort.Strings(matched)
+	return matched, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEnsureInteractive(t *testing.T) {
+	original := stdinIsTerminal
+	defer func() { stdinIsTerminal = original }()
+
+	stdinIsTerminal = func() bool { return true }
+	if err := ensureInteractive("Select schemas"); err != nil {
+		t.Fatalf("ensureInteractive() with TTY error = %v, want nil", err)
+	}
+
+	stdinIsTerminal = func() bool { return false }
+	err := ensureInteractive("Select schemas")
+	if !errors.Is(err, errNoTTY) {
+		t.Fatalf("ensureInteractive() without TTY error = %v, want %v", err, errNoTTY)
+	}
+}
+
+func TestPromptsFailWithoutTTY(t *testing.T) {
+	original := stdinIsTerminal
+	defer func() { stdinIsTerminal = original }()
+	stdinIsTerminal = func() bool { return false }
+
+	if _, err := promptMultiSelectWithAll("Select schemas", []string{"public"}); !errors.Is(err, errNoTTY) {
+		t.Fatalf("promptMultiSelectWithAll() error = %v, want %v", err, errNoTTY)
+	}
+	if _, err := promptSelectRequired("Select a database", []string{"analytics"}); !errors.Is(err, errNoTTY) {
+		t.Fatalf("promptSelectRequired() error = %v, want %v", err, errNoTTY)
+	}
+	if _, err := selectSchemas([]string{"public"}, nil); !errors.Is(err, errNoTTY) {
+		t.Fatalf("selectSchemas() error = %v, want %v", err, errNoTTY)
+	}
+}
+
+func TestParseListFlag(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{input: "", want: nil},
+		{input: "public", want: []string{"public"}},
+		{input: " public , staging,,", want: []string{"public", "staging"}},
+	}
+
+	for _, tt := range tests {
+		got := parseListFlag(tt.input)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("parseListFlag(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMatchRequestedNames(t *testing.T) {
+	available := []string{"analytics", "public", "staging"}
+
+	got, err := matchRequestedNames(available, []string{"staging", "public", "staging"}, "schemas")
+	if err != nil {
+		t.Fatalf("matchRequestedNames() error = %v", err)
+	}
+	want := []string{"public", "staging"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("matchRequestedNames() = %v, want %v", got, want)
+	}
+
+	if _, err := matchRequestedNames(available, []string{"public", "missing"}, "schemas"); err == nil {
+		t.Fatalf("matchRequestedNames(missing) error = nil, want non-nil")
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// defaultWatchInterval is how often dbh watch re-runs the sync stages.
+const defaultWatchInterval = time.Hour
+
+// runWatch re-runs the dbh sync stages on an interval until interrupted.
+// Stages run without stdin, so a stage that needs to prompt fails and the
+// next run tries again.
+func runWatch(args []string) {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	shortName := flags.String("s", "", "Connection name from config.json.")
+	longName := flags.String("name", "", "Connection name from config.json.")
+	interval := flags.Duration("interval", defaultWatchInterval, "Time between the starts of two runs (e.g. 30m, 1h).")
+	schemasFlag := flags.String("schemas", "", "Comma-separated schemas for the tables stage (all schemas when empty).")
+	yes := flags.Bool("yes", false, "Pass --yes to the tables stage (skips the production connection warning).")
+	_ = flags.Parse(args)
+
+	if flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "watch does not accept positional arguments")
+		os.Exit(2)
+	}
+	if *interval <= 0 {
+		fmt.Fprintf(os.Stderr, "--interval must be positive, got %s\n", *interval)
+		os.Exit(1)
+	}
+
+	name := strings.TrimSpace(*shortName)
+	if name == "" {
+		name = strings.TrimSpace(*longName)
+	}
+	stageArgs := buildConnectionSelectionArgs(name)
+	stages := watchStages(parseListFlag(*schemasFlag), *yes)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if name == "" {
+		fmt.Printf("Watching the primary connection; syncing every %s. Press Ctrl+C to stop.\n\n", *interval)
+	} else {
+		fmt.Printf("Watching connection %q; syncing every %s. Press Ctrl+C to stop.\n\n", name, *interval)
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	watchLoop(ctx, ticker.C, os.Stdout, func(run int) {
+		fmt.Printf("Run %d started at %s\n", run, time.Now().Format(time.RFC3339))
+		results := runSyncStages(stages, stageArgs, runSelfSubcommandUnattended, os.Stdout)
+		printSyncSummary(results, os.Stdout)
+		fmt.Printf("Next run in %s.\n\n", *interval)
+	})
+}
+
+// watchStages returns the sync stages with the tables stage set up to run
+// unattended: without a TTY it needs the schemas to process, so every
+// schema is requested unless schemas narrows them.
+func watchStages(schemas []string, yes bool) []syncStage {
+	stages := syncStages()
+	for i := range stages {
+		if stages[i].Subcommand != "tables" {
+			continue
+		}
+		if len(schemas) > 0 {
+			stages[i].Args = append(stages[i].Args, "--schemas", strings.Join(schemas, ","))
+		} else {
+			stages[i].Args = append(stages[i].Args, "--all-schemas")
+		}
+		if yes {
+			stages[i].Args = append(stages[i].Args, "--yes")
+		}
+	}
+	return stages
+}
+
+// watchLoop calls run once right away and again on every tick until ctx is
+// done. A tick that arrives while the previous run is still going is
+// skipped. When ctx is done mid-run, watchLoop waits for the run to end.
+func watchLoop(ctx context.Context, ticks <-chan time.Time, out io.Writer, run func(n int)) {
+	done := make(chan struct{})
+	running := false
+	runs := 0
+	start := func() {
+		runs++
+		running = true
+		go func(n int) {
+			run(n)
+			done <- struct{}{}
+		}(runs)
+	}
+
+	start()
+	for {
+		select {
+		case <-ctx.Done():
+			if running {
+				fmt.Fprintln(out, "Stopping after the current run finishes...")
+				<-done
+			}
+			fmt.Fprintln(out, "Watch stopped.")
+			return
+		case <-done:
+			running = false
+		case <-ticks:
+			if running {
+				fmt.Fprintf(out, "Run %d is still in progress; skipping this interval.\n", runs)
+				continue
+			}
+			start()
+		}
+	}
+}
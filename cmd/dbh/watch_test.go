@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatchLoopSkipsTicksWhileRunning(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ticks := make(chan time.Time)
+	started := make(chan int)
+	release := make(chan struct{})
+
+	var out bytes.Buffer
+	var mu sync.Mutex
+	var runs []int
+	finished := make(chan struct{})
+	go func() {
+		watchLoop(ctx, ticks, &out, func(n int) {
+			mu.Lock()
+			runs = append(runs, n)
+			mu.Unlock()
+			started <- n
+			<-release
+		})
+		close(finished)
+	}()
+
+	<-started
+	ticks <- time.Now() // run 1 still going: skipped
+	release <- struct{}{}
+	ticks <- time.Now() // may land before or after run 1's completion is seen
+	select {
+	case <-started:
+	case <-time.After(100 * time.Millisecond):
+		ticks <- time.Now()
+		<-started
+	}
+	cancel()
+	release <- struct{}{}
+	<-finished
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(runs, []int{1, 2}) {
+		t.Fatalf("runs = %v, want [1 2]", runs)
+	}
+	for _, want := range []string{"Run 1 is still in progress; skipping this interval.", "Watch stopped."} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestWatchStages(t *testing.T) {
+	tablesArgs := func(stages []syncStage) []string {
+		for _, stage := range stages {
+			if stage.Subcommand == "tables" {
+				return stage.Args
+			}
+		}
+		t.Fatal("no tables stage")
+		return nil
+	}
+
+	if got := tablesArgs(watchStages(nil, false)); !reflect.DeepEqual(got, []string{"--all-schemas"}) {
+		t.Fatalf("tables args = %v, want [--all-schemas]", got)
+	}
+	got := tablesArgs(watchStages([]string{"public", "sales"}, true))
+	if want := []string{"--schemas", "public,sales", "--yes"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("tables args = %v, want %v", got, want)
+	}
+	for _, stage := range watchStages(nil, true) {
+		if stage.Subcommand != "tables" && len(stage.Args) > 0 {
+			t.Fatalf("%s stage args = %v, want none", stage.Subcommand, stage.Args)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package contextgen
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ChecksumsFileName is the file in the context directory listing the
+// SHA-256 of each generated file, one "<sha256>  <path>" line per file with
+// paths relative to the context directory, as sha256sum writes them.
+const ChecksumsFileName = ".checksums"
+
+// writtenChecksums holds the SHA-256 of every file written since the last
+// WriteChecksums, keyed by absolute path.
+var writtenChecksums struct {
+	mu   sync.Mutex
+	sums map[string]string
+}
+
+// recordChecksum notes the SHA-256 of data, just written to path, for the
+// next WriteChecksums.
+func recordChecksum(path string, data []byte) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256(data)
+
+	writtenChecksums.mu.Lock()
+	defer writtenChecksums.mu.Unlock()
+	if writtenChecksums.sums == nil {
+		writtenChecksums.sums = make(map[string]string)
+	}
+	writtenChecksums.sums[abs] = hex.EncodeToString(sum[:])
+}
+
+// WriteChecksums updates <baseDir>/context/.checksums with the files written
+// under the context directory since the last call. Entries for files that
+// no longer exist are dropped, and the others are kept as they were, so the
+// file covers the whole tree across runs that each write part of it.
+func WriteChecksums(baseDir string) (string, error) {
+	contextDir, err := filepath.Abs(filepath.Join(baseDir, "context"))
+	if err != nil {
+		return "", fmt.Errorf("resolve context dir: %w", err)
+	}
+	path := filepath.Join(contextDir, ChecksumsFileName)
+
+	sums, err := readChecksums(path)
+	if err != nil {
+		return "", err
+	}
+
+	writtenChecksums.mu.Lock()
+	for abs, sum := range writtenChecksums.sums {
+		rel, err := filepath.Rel(contextDir, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		sums[filepath.ToSlash(rel)] = sum
+		delete(writtenChecksums.sums, abs)
+	}
+	writtenChecksums.mu.Unlock()
+
+	for rel := range sums {
+		if _, err := os.Stat(filepath.Join(contextDir, filepath.FromSlash(rel))); errors.Is(err, os.ErrNotExist) {
+			delete(sums, rel)
+		}
+	}
+	if len(sums) == 0 {
+		return "", nil
+	}
+
+	paths := make([]string, 0, len(sums))
+	for rel := range sums {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+	var b strings.Builder
+	for _, rel := range paths {
+		fmt.Fprintf(&b, "%s  %s\n", sums[rel], rel)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(b.String()), 0o644); err != nil {
+		return "", fmt.Errorf("write %s: %w", ChecksumsFileName, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return "", fmt.Errorf("rename %s: %w", ChecksumsFileName, err)
+	}
+	return path, nil
+}
+
+// readChecksums reads a .checksums file into a map of relative path to
+// SHA-256. A missing file is empty.
+func readChecksums(path string) (map[string]string, error) {
+	sums := make(map[string]string)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return sums, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", ChecksumsFileName, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		sum, rel, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok || rel == "" {
+			continue
+		}
+		sums[rel] = sum
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", ChecksumsFileName, err)
+	}
+	return sums, nil
+}
@@ -0,0 +1,81 @@
+package contextgen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+)
+
+func TestWriteChecksums_RecordsWrittenFiles(t *testing.T) {
+	baseDir := t.TempDir()
+	opts := Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+	}
+	schemas := []discovery.SchemaInfo{
+		{Name: "public", Tables: []discovery.TableInfo{{Name: "users", TableType: "BASE TABLE"}}},
+		{Name: "staging", Tables: []discovery.TableInfo{{Name: "events", TableType: "BASE TABLE"}}},
+	}
+	if err := Generate(schemas, opts); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	path, err := WriteChecksums(baseDir)
+	if err != nil {
+		t.Fatalf("WriteChecksums() error = %v", err)
+	}
+	if want := filepath.Join(baseDir, "context", ChecksumsFileName); path != want {
+		t.Fatalf("WriteChecksums() path = %q, want %q", path, want)
+	}
+
+	sums, err := readChecksums(path)
+	if err != nil {
+		t.Fatalf("readChecksums() error = %v", err)
+	}
+	tablesPath := "connections/my-db/databases/analytics/schemas/staging/_tables.yml"
+	for _, rel := range []string{
+		"connections/my-db/databases/_databases.yml",
+		"connections/my-db/databases/analytics/schemas/_schemas.yml",
+		tablesPath,
+	} {
+		data, err := os.ReadFile(filepath.Join(baseDir, "context", filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatalf("read %s: %v", rel, err)
+		}
+		sum := sha256.Sum256(data)
+		if got, want := sums[rel], hex.EncodeToString(sum[:]); got != want {
+			t.Errorf("checksum of %s = %q, want %q", rel, got, want)
+		}
+	}
+
+	// A later run keeps the entries it did not rewrite and drops files that
+	// are gone.
+	if err := os.Remove(filepath.Join(baseDir, "context", filepath.FromSlash(tablesPath))); err != nil {
+		t.Fatalf("remove %s: %v", tablesPath, err)
+	}
+	if _, err := WriteConnectionsFile(baseDir, []ConnectionsFileItem{{Name: "my-db", Type: "postgres"}}); err != nil {
+		t.Fatalf("WriteConnectionsFile() error = %v", err)
+	}
+	if _, err := WriteChecksums(baseDir); err != nil {
+		t.Fatalf("WriteChecksums() second run error = %v", err)
+	}
+	updated, err := readChecksums(path)
+	if err != nil {
+		t.Fatalf("readChecksums() error = %v", err)
+	}
+	if _, ok := updated[tablesPath]; ok {
+		t.Errorf("checksums still list removed %s", tablesPath)
+	}
+	if updated[ConnectionsFileName] == "" {
+		t.Errorf("checksums do not list %s written by the second run", ConnectionsFileName)
+	}
+	if updated["connections/my-db/databases/_databases.yml"] != sums["connections/my-db/databases/_databases.yml"] {
+		t.Errorf("checksum of _databases.yml changed without a rewrite")
+	}
+}
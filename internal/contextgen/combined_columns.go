@@ -0,0 +1,138 @@
+package contextgen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CombinedColumnsFile is written as <schema>__columns.yml in a schema
+// directory by dbh columns --combine-schema, in place of one enriched
+// columns file per table. Each entry of Tables is what the table's own
+// <table_name>__columns.yml would hold.
+type CombinedColumnsFile struct {
+	Schema       string                `yaml:"schema" json:"schema"`
+	Connection   string                `yaml:"connection" json:"connection"`
+	Database     string                `yaml:"database" json:"database"`
+	DatabaseType string                `yaml:"database_type" json:"database_type"`
+	GeneratedAt  string                `yaml:"generated_at" json:"generated_at"`
+	Tables       []EnrichedColumnsFile `yaml:"tables" json:"tables"`
+}
+
+// WriteCombinedColumnsFile merges input into the combined columns file of
+// its schema: <schema>__columns.yml, <schema>__columns.json, or both,
+// depending on opts.ColumnsFormat. Tables already in the file that are not
+// input keep their previous entry, so the file covers every table profiled
+// so far. Files are written atomically. The returned path is the YAML file
+// unless only JSON was written.
+func WriteCombinedColumnsFile(input EnrichedColumnsInput, opts Options) (string, error) {
+	if err := input.validate(); err != nil {
+		return "", err
+	}
+
+	defaultDatabase, err := resolveGenerationDatabase(opts)
+	if err != nil {
+		return "", err
+	}
+
+	dir := schemaDirPath(opts, defaultDatabase, input.Schema)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create schema dir %q: %w", input.Schema, err)
+	}
+	colPath := filepath.Join(dir, sanitizeName(input.Schema)+"__columns.yml")
+	jsonPath := enrichedColumnsJSONFilePath(colPath)
+
+	existingPath := colPath
+	if !opts.ColumnsFormat.writesYAML() {
+		existingPath = jsonPath
+	}
+	tables, err := readCombinedColumnsTables(existingPath)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	var previous *EnrichedColumnsFile
+	if table, ok := tables[input.Table]; ok {
+		previous = &table
+	}
+	tables[input.Table] = newEnrichedColumnsFile(input, opts, defaultDatabase, now, previous)
+
+	file := CombinedColumnsFile{
+		Schema:       input.Schema,
+		Connection:   opts.ConnectionName,
+		Database:     defaultDatabase,
+		DatabaseType: opts.DatabaseType,
+		GeneratedAt:  now,
+		Tables:       make([]EnrichedColumnsFile, 0, len(tables)),
+	}
+	for _, table := range tables {
+		file.Tables = append(file.Tables, table)
+	}
+	sort.Slice(file.Tables, func(i, j int) bool {
+		return file.Tables[i].Table < file.Tables[j].Table
+	})
+
+	if opts.ColumnsFormat.writesJSON() {
+		if err := writeJSONAtomic(jsonPath, file); err != nil {
+			return "", fmt.Errorf("write combined columns json for %q: %w", input.Schema, err)
+		}
+		if !opts.ColumnsFormat.writesYAML() {
+			return jsonPath, nil
+		}
+	}
+
+	header := combinedColumnsHeader(opts, defaultDatabase, input.Schema)
+	if err := writeYAMLWithHeaderAtomic(colPath, file, header); err != nil {
+		return "", fmt.Errorf("write combined columns for %q: %w", input.Schema, err)
+	}
+
+	return colPath, nil
+}
+
+// readCombinedColumnsTables returns the tables of the combined columns file
+// at path by name, or none when it does not exist yet.
+func readCombinedColumnsTables(path string) (map[string]EnrichedColumnsFile, error) {
+	tables := make(map[string]EnrichedColumnsFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return tables, nil
+		}
+		return nil, fmt.Errorf("read existing %s: %w", filepath.Base(path), err)
+	}
+
+	var existing CombinedColumnsFile
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(data, &existing)
+	} else {
+		err = yaml.Unmarshal(data, &existing)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse existing %s: %w", filepath.Base(path), err)
+	}
+	for _, table := range existing.Tables {
+		tables[table.Table] = table
+	}
+	return tables, nil
+}
+
+func combinedColumnsHeader(opts Options, database, schema string) string {
+	if opts.Dense {
+		return ""
+	}
+	return fmt.Sprintf(`# =============================================================================
+# Enriched columns for schema: %s
+# Connection: %s | Database: %s | Type: %s
+# =============================================================================
+#
+# This file was generated by dbh columns --combine-schema. Each entry under
+# "tables" holds the enriched columns of one table of the schema.
+`, schema, opts.ConnectionName, database, opts.DatabaseType) + enrichedColumnFieldsHelp
+}
@@ -0,0 +1,80 @@
+package contextgen
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteCombinedColumnsFile_ContainsAllTables(t *testing.T) {
+	opts := Options{ConnectionName: "my-db", DatabaseName: "analytics", DatabaseType: "postgres", BaseDir: t.TempDir()}
+
+	var path string
+	for _, input := range []EnrichedColumnsInput{
+		{Schema: "public", Table: "users", Columns: []discovery.EnrichedColumnInfo{{Name: "id", OrdinalPosition: 1, TotalRows: 3}}},
+		{Schema: "public", Table: "orders", Columns: []discovery.EnrichedColumnInfo{{Name: "id", OrdinalPosition: 1, TotalRows: 9}}},
+		{Schema: "public", Table: "users", Columns: []discovery.EnrichedColumnInfo{{Name: "id", OrdinalPosition: 1, TotalRows: 4}}},
+	} {
+		var err error
+		path, err = WriteCombinedColumnsFile(input, opts)
+		if err != nil {
+			t.Fatalf("WriteCombinedColumnsFile(%s) error = %v", input.Table, err)
+		}
+	}
+
+	want := filepath.Join(opts.BaseDir, "context", "connections", "my-db", "databases", "analytics", "schemas", "public", "public__columns.yml")
+	if path != want {
+		t.Fatalf("WriteCombinedColumnsFile() path = %q, want %q", path, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read combined columns file: %v", err)
+	}
+	var file CombinedColumnsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		t.Fatalf("parse combined columns file: %v", err)
+	}
+	if file.Schema != "public" || len(file.Tables) != 2 {
+		t.Fatalf("combined columns file = %+v, want both public tables", file)
+	}
+	if file.Tables[0].Table != "orders" || file.Tables[1].Table != "users" {
+		t.Fatalf("combined tables = %s, %s; want orders, users", file.Tables[0].Table, file.Tables[1].Table)
+	}
+	if got := file.Tables[1].Columns[0].TotalRows; got != 4 {
+		t.Fatalf("users total_rows = %d, want the latest write (4)", got)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), "users")); !os.IsNotExist(err) {
+		t.Fatalf("per-table directory was created: %v", err)
+	}
+}
+
+func TestWriteCombinedColumnsFile_JSONOnly(t *testing.T) {
+	opts := Options{ConnectionName: "my-db", DatabaseName: "analytics", DatabaseType: "postgres", BaseDir: t.TempDir(), ColumnsFormat: ColumnsFormatJSON}
+
+	for _, table := range []string{"users", "orders"} {
+		input := EnrichedColumnsInput{Schema: "public", Table: table, Columns: []discovery.EnrichedColumnInfo{{Name: "id", OrdinalPosition: 1}}}
+		if _, err := WriteCombinedColumnsFile(input, opts); err != nil {
+			t.Fatalf("WriteCombinedColumnsFile(%s) error = %v", table, err)
+		}
+	}
+
+	dir := filepath.Join(opts.BaseDir, "context", "connections", "my-db", "databases", "analytics", "schemas", "public")
+	data, err := os.ReadFile(filepath.Join(dir, "public__columns.json"))
+	if err != nil {
+		t.Fatalf("read combined columns json: %v", err)
+	}
+	var file CombinedColumnsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("parse combined columns json: %v", err)
+	}
+	if len(file.Tables) != 2 {
+		t.Fatalf("combined columns json has %d table(s), want 2", len(file.Tables))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "public__columns.yml")); !os.IsNotExist(err) {
+		t.Fatalf("YAML file written for JSON-only format: %v", err)
+	}
+}
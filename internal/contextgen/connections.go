@@ -0,0 +1,91 @@
+package contextgen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConnectionsFileName is the crawl root written at the top of the context
+// tree.
+const ConnectionsFileName = "_connections.yml"
+
+// ConnectionsFile is the top-level context/_connections.yml listing every
+// configured connection.
+type ConnectionsFile struct {
+	GeneratedAt string                `yaml:"generated_at"`
+	Connections []ConnectionsFileItem `yaml:"connections"`
+}
+
+// ConnectionsFileItem is one connection in _connections.yml. It mirrors the
+// non-secret fields of config.json.
+type ConnectionsFileItem struct {
+	Name            string `yaml:"name"`
+	Type            string `yaml:"type"`
+	Environment     string `yaml:"environment,omitempty"`
+	Description     string `yaml:"description,omitempty"`
+	Primary         bool   `yaml:"primary"`
+	DefaultDatabase string `yaml:"default_database,omitempty"`
+	ContextPath     string `yaml:"context_path"` // relative to the context directory
+	HasContext      bool   `yaml:"has_context"`  // whether databases/_databases.yml exists
+}
+
+// WriteConnectionsFile writes <baseDir>/context/_connections.yml for the
+// given connections, in the order given. ContextPath and HasContext are
+// filled in from the context tree, and DefaultDatabase falls back to the
+// connection's _databases.yml when empty.
+func WriteConnectionsFile(baseDir string, connections []ConnectionsFileItem) (string, error) {
+	contextDir := filepath.Join(baseDir, "context")
+	if err := os.MkdirAll(contextDir, 0o755); err != nil {
+		return "", fmt.Errorf("create context dir: %w", err)
+	}
+
+	file := ConnectionsFile{
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Connections: make([]ConnectionsFileItem, 0, len(connections)),
+	}
+	for _, connection := range connections {
+		connection.ContextPath = filepath.ToSlash(filepath.Join("connections", connection.Name))
+		databasesPath := filepath.Join(contextDir, "connections", connection.Name, "databases", "_databases.yml")
+		if data, err := os.ReadFile(databasesPath); err == nil {
+			connection.HasContext = true
+			var databases DatabasesFile
+			if connection.DefaultDatabase == "" && yaml.Unmarshal(data, &databases) == nil {
+				connection.DefaultDatabase = databases.DefaultDatabase
+			}
+		}
+		file.Connections = append(file.Connections, connection)
+	}
+
+	path := filepath.Join(contextDir, ConnectionsFileName)
+	if err := writeYAMLWithHeaderAtomic(path, file, connectionsHeader()); err != nil {
+		return "", fmt.Errorf("write %s: %w", ConnectionsFileName, err)
+	}
+	return path, nil
+}
+
+func connectionsHeader() string {
+	return `# =============================================================================
+# Connections
+# =============================================================================
+#
+# This file was generated by dbh and lists every connection in config.json.
+# Start here, then open <context_path>/databases/_databases.yml for the
+# connection you need (prefer the primary one).
+#
+# Connection fields:
+#   name             - Connection name from config.json
+#   type             - Database type
+#   environment      - Environment label, if configured
+#   description      - What the connection is for, if configured
+#   primary          - Whether this is the primary (default) connection
+#   default_database - Default database, if known
+#   context_path     - Connection context directory, relative to this file
+#   has_context      - Whether dbh has generated context for the connection
+# =============================================================================
+
+`
+}
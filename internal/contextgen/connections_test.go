@@ -0,0 +1,65 @@
+package contextgen
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteConnectionsFile(t *testing.T) {
+	baseDir := t.TempDir()
+
+	if _, err := UpdateDatabasesFile([]string{"analytics", "raw"}, Options{
+		ConnectionName: "warehouse",
+		DatabaseName:   "analytics",
+		DatabaseType:   "snowflake",
+		BaseDir:        baseDir,
+	}); err != nil {
+		t.Fatalf("UpdateDatabasesFile() error = %v", err)
+	}
+
+	path, err := WriteConnectionsFile(baseDir, []ConnectionsFileItem{
+		{Name: "warehouse", Type: "snowflake", Environment: "production", Description: "Analytics warehouse", Primary: true},
+		{Name: "local", Type: "postgres", DefaultDatabase: "app"},
+	})
+	if err != nil {
+		t.Fatalf("WriteConnectionsFile() error = %v", err)
+	}
+	if want := filepath.Join(baseDir, "context", "_connections.yml"); path != want {
+		t.Fatalf("path = %q, want %q", path, want)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read connections file: %v", err)
+	}
+	var file ConnectionsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		t.Fatalf("parse connections file: %v", err)
+	}
+
+	want := []ConnectionsFileItem{
+		{
+			Name:            "warehouse",
+			Type:            "snowflake",
+			Environment:     "production",
+			Description:     "Analytics warehouse",
+			Primary:         true,
+			DefaultDatabase: "analytics",
+			ContextPath:     "connections/warehouse",
+			HasContext:      true,
+		},
+		{
+			Name:            "local",
+			Type:            "postgres",
+			DefaultDatabase: "app",
+			ContextPath:     "connections/local",
+		},
+	}
+	if !reflect.DeepEqual(file.Connections, want) {
+		t.Fatalf("connections = %+v, want %+v", file.Connections, want)
+	}
+}
@@ -4,13 +4,19 @@
 package contextgen
 
 import (
+	"encoding/binary"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/genesisdayrit/dbharness/internal/discovery"
 	"gopkg.in/yaml.v3"
@@ -24,15 +30,20 @@ import (
 // available under a connection.
 type DatabasesFile struct {
 	Connection      string         `yaml:"connection"`
+	Description     string         `yaml:"description,omitempty"`
 	DatabaseType    string         `yaml:"database_type"`
 	DefaultDatabase string         `yaml:"default_database"`
 	GeneratedAt     string         `yaml:"generated_at"`
 	Databases       []DatabaseItem `yaml:"databases"`
 }
 
-// DatabaseItem is one entry in the _databases.yml file.
+// DatabaseItem is one entry in the _databases.yml file. ContextDir is set
+// when the database's context is not in the <name>/ directory: attached
+// SQLite databases are discovered as schemas of main, so theirs is
+// main/schemas/<name>. It is relative to the databases directory.
 type DatabaseItem struct {
-	Name string `yaml:"name"`
+	Name       string `yaml:"name"`
+	ContextDir string `yaml:"context_dir,omitempty"`
 }
 
 // SchemasFile is the _schemas.yml that gives an LLM a quick
@@ -72,6 +83,9 @@ type TablesFile struct {
 	DatabaseType string        `yaml:"database_type"`
 	GeneratedAt  string        `yaml:"generated_at"`
 	Tables       []TablesEntry `yaml:"tables"`
+
+	// InferredRelationships is written by dbh tables; see InferRelationships.
+	InferredRelationships []InferredRelationship `yaml:"inferred_relationships,omitempty"`
 }
 
 // TablesEntry is one row in a tables.yml file.
@@ -80,6 +94,11 @@ type TablesEntry struct {
 	Type          string `yaml:"type"` // BASE TABLE, VIEW, etc.
 	AIDescription string `yaml:"ai_description"`
 	DBDescription string `yaml:"db_description"`
+
+	// Dir is the table's directory when it is not the lowercased table
+	// name, as with --preserve-case or tables whose names differ only in
+	// case.
+	Dir string `yaml:"dir,omitempty"`
 }
 
 // --------------------------------------------------------------------------
@@ -92,6 +111,112 @@ type Options struct {
 	DatabaseName   string
 	DatabaseType   string
 	BaseDir        string // e.g. ".dbharness"
+
+	// ConnectionDescription is the connection's description from
+	// config.json, written to _databases.yml when set.
+	ConnectionDescription string
+
+	// ColumnsFormat selects which file(s) WriteEnrichedColumnsFile writes.
+	// The zero value writes YAML only.
+	ColumnsFormat ColumnsFormat
+
+	// MaxCellLength truncates __sample.xml cell values longer than this many
+	// characters. Zero disables truncation.
+	MaxCellLength int
+
+	// XMLEncoding is the character encoding of __sample.xml files. The zero
+	// value writes UTF-8 without a byte order mark.
+	XMLEncoding XMLEncoding
+
+	// RenumberColumns adds a contiguous 1..N position to each column in
+	// columns files, next to the database's ordinal_position.
+	RenumberColumns bool
+
+	// Dense omits the comment header at the top of each YAML file, which
+	// saves tokens when the files are fed to an LLM.
+	Dense bool
+
+	// FileLimit, when set, stops Generate and GenerateTableDetails with
+	// ErrMaxFilesExceeded before they write more files than it allows.
+	FileLimit *FileLimit
+
+	// PreserveCase keeps the case of table names in table directory and
+	// file names instead of lowercasing them. Tables whose names differ
+	// only in case get distinct paths either way; see tablePathName.
+	PreserveCase bool
+
+	// ExcludeEmptySchemas leaves schemas without tables or views out of
+	// Generate and WriteSchemaCounts: they are not listed in _schemas.yml
+	// and get no schema directory. See ExcludeEmptySchemas.
+	ExcludeEmptySchemas bool
+}
+
+// ColumnsFormat is the on-disk format of enriched columns files.
+type ColumnsFormat string
+
+const (
+	ColumnsFormatYAML ColumnsFormat = "yaml"
+	ColumnsFormatJSON ColumnsFormat = "json"
+	ColumnsFormatBoth ColumnsFormat = "both"
+)
+
+func (f ColumnsFormat) writesYAML() bool {
+	return f == "" || f == ColumnsFormatYAML || f == ColumnsFormatBoth
+}
+
+func (f ColumnsFormat) writesJSON() bool {
+	return f == ColumnsFormatJSON || f == ColumnsFormatBoth
+}
+
+// XMLEncoding is the character encoding __sample.xml files are written in.
+// Some legacy tools need a byte order mark and others break on one.
+type XMLEncoding string
+
+const (
+	// XMLEncodingUTF8 is UTF-8 without a byte order mark.
+	XMLEncodingUTF8 XMLEncoding = "utf-8"
+	// XMLEncodingUTF8BOM is UTF-8 with a byte order mark.
+	XMLEncodingUTF8BOM XMLEncoding = "utf-8-bom"
+	// XMLEncodingUTF16LE is little-endian UTF-16 with a byte order mark,
+	// what Windows tools call "Unicode".
+	XMLEncodingUTF16LE XMLEncoding = "utf-16le"
+	// XMLEncodingUTF16BE is big-endian UTF-16 with a byte order mark.
+	XMLEncodingUTF16BE XMLEncoding = "utf-16be"
+)
+
+// XMLEncodings lists the supported values of Options.XMLEncoding.
+var XMLEncodings = []XMLEncoding{XMLEncodingUTF8, XMLEncodingUTF8BOM, XMLEncodingUTF16LE, XMLEncodingUTF16BE}
+
+// header returns the XML declaration naming the encoding.
+func (e XMLEncoding) header() string {
+	switch e {
+	case XMLEncodingUTF16LE, XMLEncodingUTF16BE:
+		return `<?xml version="1.0" encoding="UTF-16"?>` + "\n"
+	default:
+		return xml.Header
+	}
+}
+
+// encode converts text, which is UTF-8, to the encoding and prefixes the
+// byte order mark if the encoding has one.
+func (e XMLEncoding) encode(text string) []byte {
+	switch e {
+	case XMLEncodingUTF8BOM:
+		return append([]byte("\uFEFF"), text...)
+	case XMLEncodingUTF16LE, XMLEncodingUTF16BE:
+		units := utf16.Encode([]rune("\uFEFF" + text))
+		data := make([]byte, 0, 2*len(units))
+		for _, unit := range units {
+			if e == XMLEncodingUTF16LE {
+				data = binary.LittleEndian.AppendUint16(data, unit)
+			} else {
+				data = binary.BigEndian.AppendUint16(data, unit)
+			}
+		}
+		return data
+	default:
+		return []byte(text)
+	}
 }
 
 // Generate writes the full context directory tree for the given schemas.
@@ -103,9 +228,17 @@ func Generate(schemas []discovery.SchemaInfo, opts Options) error {
 		return err
 	}
 
+	if opts.ExcludeEmptySchemas {
+		schemas, _ = ExcludeEmptySchemas(schemas)
+	}
 	sortedSchemas := sortedSchemaInfos(schemas)
 
-	dbName := sanitizeName(defaultDatabase)
+	// _databases.yml, _schemas.yml, and one _tables.yml per schema. Nothing
+	// is written when they do not all fit in the limit.
+	if err := opts.FileLimit.reserve(2 + len(sortedSchemas)); err != nil {
+		return err
+	}
+
 	headerOpts := opts
 	headerOpts.DatabaseName = defaultDatabase
 
@@ -117,10 +250,11 @@ func Generate(schemas []discovery.SchemaInfo, opts Options) error {
 	// ---- _databases.yml ----
 	df := DatabasesFile{
 		Connection:      opts.ConnectionName,
+		Description:     strings.TrimSpace(opts.ConnectionDescription),
 		DatabaseType:    opts.DatabaseType,
 		DefaultDatabase: defaultDatabase,
 		GeneratedAt:     now,
-		Databases:       []DatabaseItem{{Name: defaultDatabase}},
+		Databases:       generatedDatabaseItems(opts.DatabaseType, defaultDatabase, sortedSchemas),
 	}
 
 	databasesPath := filepath.Join(databasesDir, "_databases.yml")
@@ -128,7 +262,7 @@ func Generate(schemas []discovery.SchemaInfo, opts Options) error {
 		return fmt.Errorf("write _databases.yml: %w", err)
 	}
 
-	schemasDir := filepath.Join(databasesDir, dbName, "schemas")
+	schemasDir := schemasDirPath(opts, defaultDatabase)
 	if err := os.MkdirAll(schemasDir, 0o755); err != nil {
 		return fmt.Errorf("create schemas dir: %w", err)
 	}
@@ -171,7 +305,7 @@ func Generate(schemas []discovery.SchemaInfo, opts Options) error {
 
 	// ---- per-schema _tables.yml files ----
 	for _, s := range sortedSchemas {
-		schemaDir := filepath.Join(schemasDir, sanitizeName(s.Name))
+		schemaDir := schemaDirPath(opts, defaultDatabase, s.Name)
 		if err := os.MkdirAll(schemaDir, 0o755); err != nil {
 			return fmt.Errorf("create schema dir %q: %w", s.Name, err)
 		}
@@ -183,13 +317,21 @@ func Generate(schemas []discovery.SchemaInfo, opts Options) error {
 			DatabaseType: opts.DatabaseType,
 			GeneratedAt:  now,
 		}
+		siblings := make([]string, 0, len(s.Tables))
 		for _, t := range s.Tables {
-			tf.Tables = append(tf.Tables, TablesEntry{
+			siblings = append(siblings, t.Name)
+		}
+		for _, t := range s.Tables {
+			entry := TablesEntry{
 				Name:          t.Name,
 				Type:          t.TableType,
 				AIDescription: "",
 				DBDescription: "",
-			})
+			}
+			if dir := tablePathName(opts, t.Name, siblings); dir != sanitizeName(t.Name) {
+				entry.Dir = dir
+			}
+			tf.Tables = append(tf.Tables, entry)
 		}
 
 		tablesPath := filepath.Join(schemaDir, "_tables.yml")
@@ -254,6 +396,7 @@ func UpdateDatabasesFile(discoveredDBs []string, opts Options) (added []string,
 
 	df := DatabasesFile{
 		Connection:      opts.ConnectionName,
+		Description:     strings.TrimSpace(opts.ConnectionDescription),
 		DatabaseType:    opts.DatabaseType,
 		DefaultDatabase: defaultDatabase,
 		GeneratedAt:     now,
@@ -267,6 +410,102 @@ func UpdateDatabasesFile(discoveredDBs []string, opts Options) (added []string,
 	return newDBs, nil
 }
 
+// UpdateSchemasFile refreshes the _schemas.yml file of the database in opts
+// from discovered schemas without touching the per-schema _tables.yml files.
+// Existing schemas keep their position and descriptions (and their tables'
+// descriptions) while their table lists and counts are refreshed; newly
+// discovered schemas are appended alphabetically. Schemas that are no
+// longer discovered are kept unchanged and returned as missing.
+func UpdateSchemasFile(schemas []discovery.SchemaInfo, opts Options) (added, missing []string, err error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	defaultDatabase, err := resolveGenerationDatabase(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	headerOpts := opts
+	headerOpts.DatabaseName = defaultDatabase
+
+	schemasDir := schemasDirPath(opts, defaultDatabase)
+	if err := os.MkdirAll(schemasDir, 0o755); err != nil {
+		return nil, nil, fmt.Errorf("create schemas dir: %w", err)
+	}
+	schemasPath := filepath.Join(schemasDir, "_schemas.yml")
+
+	var existing SchemasFile
+	if data, readErr := os.ReadFile(schemasPath); readErr == nil {
+		if err := yaml.Unmarshal(data, &existing); err != nil {
+			return nil, nil, fmt.Errorf("parse existing _schemas.yml: %w", err)
+		}
+	}
+
+	sortedSchemas := sortedSchemaInfos(schemas)
+	discovered := make(map[string]discovery.SchemaInfo, len(sortedSchemas))
+	for _, schema := range sortedSchemas {
+		discovered[schema.Name] = schema
+	}
+
+	merged := make([]SchemaItem, 0, len(existing.Schemas)+len(schemas))
+	existingSet := make(map[string]bool, len(existing.Schemas))
+	for _, item := range existing.Schemas {
+		existingSet[item.Name] = true
+		schema, ok := discovered[item.Name]
+		if !ok {
+			missing = append(missing, item.Name)
+			merged = append(merged, item)
+			continue
+		}
+		merged = append(merged, refreshSchemaItem(item, schema))
+	}
+
+	for _, schema := range sortedSchemas {
+		if existingSet[schema.Name] {
+			continue
+		}
+		added = append(added, schema.Name)
+		merged = append(merged, refreshSchemaItem(SchemaItem{Name: schema.Name}, schema))
+	}
+
+	sf := SchemasFile{
+		Connection:   opts.ConnectionName,
+		Database:     defaultDatabase,
+		DatabaseType: opts.DatabaseType,
+		GeneratedAt:  now,
+		Schemas:      merged,
+	}
+	if err := writeYAMLWithHeaderAtomic(schemasPath, sf, schemasHeader(headerOpts)); err != nil {
+		return nil, nil, fmt.Errorf("write _schemas.yml: %w", err)
+	}
+	return added, missing, nil
+}
+
+// refreshSchemaItem replaces the table list and counts of item with those of
+// schema, keeping the descriptions already in item.
+func refreshSchemaItem(item SchemaItem, schema discovery.SchemaInfo) SchemaItem {
+	previous := make(map[string]SchemaTableItem, len(item.Tables))
+	for _, table := range item.Tables {
+		previous[table.Name] = table
+	}
+
+	item.Tables = nil
+	item.TableCount = 0
+	item.ViewCount = 0
+	for _, t := range schema.Tables {
+		table := SchemaTableItem{Name: t.Name, Type: t.TableType}
+		if old, ok := previous[t.Name]; ok {
+			table.AIDescription = old.AIDescription
+			table.DBDescription = old.DBDescription
+		}
+		item.Tables = append(item.Tables, table)
+		if isView(t.TableType) {
+			item.ViewCount++
+		} else {
+			item.TableCount++
+		}
+	}
+	return item
+}
+
 // --------------------------------------------------------------------------
 // Table detail YAML/XML types
 // --------------------------------------------------------------------------
@@ -279,6 +518,8 @@ type ColumnsFile struct {
 	Database     string            `yaml:"database"`
 	DatabaseType string            `yaml:"database_type"`
 	GeneratedAt  string            `yaml:"generated_at"`
+	OrdinalGaps  bool              `yaml:"ordinal_gaps,omitempty"` // ordinal positions are not 1..N, usually after dropped columns
+	Empty        bool              `yaml:"empty,omitempty"`        // the table had no rows, so no sample was taken
 	Columns      []ColumnsFileItem `yaml:"columns"`
 }
 
@@ -288,47 +529,109 @@ type ColumnsFileItem struct {
 	DataType        string `yaml:"data_type"`
 	IsNullable      string `yaml:"is_nullable"`
 	OrdinalPosition int    `yaml:"ordinal_position"`
+	Position        int    `yaml:"position,omitempty"` // contiguous 1..N position, with Options.RenumberColumns
 	ColumnDefault   string `yaml:"column_default,omitempty"`
+	IsGenerated     bool   `yaml:"is_generated,omitempty"`
 }
 
-// EnrichedColumnsFile is written as <table_name>__columns.yml when using
-// the dbh columns command.
+// EnrichedColumnsFile is written as <table_name>__columns.yml (and/or
+// <table_name>__columns.json, see Options.ColumnsFormat) when using the dbh
+// columns command.
 type EnrichedColumnsFile struct {
-	Schema       string                    `yaml:"schema"`
-	Table        string                    `yaml:"table"`
-	Connection   string                    `yaml:"connection"`
-	Database     string                    `yaml:"database"`
-	DatabaseType string                    `yaml:"database_type"`
-	GeneratedAt  string                    `yaml:"generated_at"`
-	Columns      []EnrichedColumnsFileItem `yaml:"columns"`
+	Schema       string                    `yaml:"schema" json:"schema"`
+	Table        string                    `yaml:"table" json:"table"`
+	Connection   string                    `yaml:"connection" json:"connection"`
+	Database     string                    `yaml:"database" json:"database"`
+	DatabaseType string                    `yaml:"database_type" json:"database_type"`
+	GeneratedAt  string                    `yaml:"generated_at" json:"generated_at"`
+	Scope        string                    `yaml:"scope,omitempty" json:"scope,omitempty"`               // row filter the stats were computed over, if any
+	StatsSource  string                    `yaml:"stats_source,omitempty" json:"stats_source,omitempty"` // StatsSourceCatalog or empty
+	SamplePct    float64                   `yaml:"sample_pct,omitempty" json:"sample_pct,omitempty"`     // block sample percent; total_rows counts only sampled rows
+	OrdinalGaps  bool                      `yaml:"ordinal_gaps,omitempty" json:"ordinal_gaps,omitempty"`
+	LastModified string                    `yaml:"last_modified,omitempty" json:"last_modified,omitempty"` // table's modification time when profiled, if the backend reports one
+	Columns      []EnrichedColumnsFileItem `yaml:"columns" json:"columns"`
+
+	// UnprofiledColumns names the columns of the table that have no entry
+	// in Columns yet, because a dbh columns run with --datatype-filter or
+	// --exclude-columns left them out and no earlier run profiled them.
+	UnprofiledColumns []string `yaml:"unprofiled_columns,omitempty" json:"unprofiled_columns,omitempty"`
 }
 
 // EnrichedColumnsFileItem is one enriched column profile entry.
 type EnrichedColumnsFileItem struct {
-	Name                  string   `yaml:"name"`
-	DataType              string   `yaml:"data_type"`
-	IsNullable            string   `yaml:"is_nullable"`
-	OrdinalPosition       int      `yaml:"ordinal_position"`
-	ColumnDefault         string   `yaml:"column_default,omitempty"`
-	AIDescription         string   `yaml:"ai_description"`
-	DBDescription         string   `yaml:"db_description"`
-	TotalRows             int64    `yaml:"total_rows"`
-	NullCount             int64    `yaml:"null_count"`
-	NonNullCount          int64    `yaml:"non_null_count"`
-	DistinctNonNullCount  int64    `yaml:"distinct_non_null_count"`
-	DistinctOfNonNullPct  float64  `yaml:"distinct_of_non_null_pct"`
-	NullOfTotalRowsPct    float64  `yaml:"null_of_total_rows_pct"`
-	NonNullOfTotalRowsPct float64  `yaml:"non_null_of_total_rows_pct"`
-	SampleValues          []string `yaml:"sample_values,omitempty"`
-}
-
-// EnrichedColumnsInput holds all enriched columns for one table.
+	Name                  string   `yaml:"name" json:"name"`
+	DataType              string   `yaml:"data_type" json:"data_type"`
+	IsNullable            string   `yaml:"is_nullable" json:"is_nullable"`
+	OrdinalPosition       int      `yaml:"ordinal_position" json:"ordinal_position"`
+	Position              int      `yaml:"position,omitempty" json:"position,omitempty"`
+	ColumnDefault         string   `yaml:"column_default,omitempty" json:"column_default,omitempty"`
+	IsGenerated           bool     `yaml:"is_generated,omitempty" json:"is_generated,omitempty"`
+	AIDescription         string   `yaml:"ai_description" json:"ai_description"`
+	DBDescription         string   `yaml:"db_description" json:"db_description"`
+	TotalRows             int64    `yaml:"total_rows" json:"total_rows"`
+	NullCount             int64    `yaml:"null_count" json:"null_count"`
+	NonNullCount          int64    `yaml:"non_null_count" json:"non_null_count"`
+	DistinctNonNullCount  int64    `yaml:"distinct_non_null_count" json:"distinct_non_null_count"`
+	DistinctOfNonNullPct  float64  `yaml:"distinct_of_non_null_pct" json:"distinct_of_non_null_pct"`
+	NullOfTotalRowsPct    float64  `yaml:"null_of_total_rows_pct" json:"null_of_total_rows_pct"`
+	NonNullOfTotalRowsPct float64  `yaml:"non_null_of_total_rows_pct" json:"non_null_of_total_rows_pct"`
+	SampleValues          []string `yaml:"sample_values,omitempty" json:"sample_values,omitempty"`
+
+	Elements    *EnrichedColumnElements    `yaml:"elements,omitempty" json:"elements,omitempty"`
+	Percentiles *EnrichedColumnPercentiles `yaml:"percentiles,omitempty" json:"percentiles,omitempty"`
+}
+
+// EnrichedColumnElements is the element-level profile of an array column,
+// written by dbh columns --unnest-arrays.
+type EnrichedColumnElements struct {
+	ElementCount         int64    `yaml:"element_count" json:"element_count"`
+	DistinctElementCount int64    `yaml:"distinct_element_count" json:"distinct_element_count"`
+	SampleElements       []string `yaml:"sample_elements,omitempty" json:"sample_elements,omitempty"`
+}
+
+// EnrichedColumnPercentiles are the percentiles of a numeric column's
+// non-null values, written by dbh columns --percentiles.
+type EnrichedColumnPercentiles struct {
+	P50 float64 `yaml:"p50" json:"p50"`
+	P95 float64 `yaml:"p95" json:"p95"`
+	P99 float64 `yaml:"p99" json:"p99"`
+}
+
+// EnrichedColumnsInput holds all enriched columns for one table. Scope
+// describes the row filter used for profiling (e.g. from dbh columns
+// --since); it is empty when every row was profiled.
 type EnrichedColumnsInput struct {
 	Schema  string
 	Table   string
+	Scope   string
 	Columns []discovery.EnrichedColumnInfo
+
+	// StatsSource is StatsSourceCatalog when the columns were profiled from
+	// the database's own statistics (dbh columns --use-catalog-stats), and
+	// empty when they were computed by scanning the table.
+	StatsSource string
+
+	// SamplePct is the percent of the table the stats were computed over
+	// with dbh columns --tablesample-pct, so the counts describe a sample.
+	// Zero means the whole table was read.
+	SamplePct float64
+
+	// LastModified is the table's modification time read before profiling,
+	// recorded so dbh columns --only-changed can skip unchanged tables.
+	// Zero omits it.
+	LastModified time.Time
+
+	// TableColumns lists every column of the table when Columns holds only
+	// some of them, such as after dbh columns --datatype-filter. The other
+	// columns keep their entry from the existing file instead of being
+	// dropped from it. Nil means Columns is the whole table.
+	TableColumns []discovery.ColumnInfo
 }
 
+// StatsSourceCatalog marks enriched columns read from the database's own
+// statistics, such as Postgres pg_stats, rather than computed by a scan.
+const StatsSourceCatalog = "catalog"
+
 // SampleXML is the root element for <table_name>__sample.xml files.
 type SampleXML struct {
 	XMLName     xml.Name       `xml:"table_sample"`
@@ -347,23 +650,30 @@ type SampleRowXML struct {
 }
 
 // SampleFieldXML is a single field (column value) in a sample row.
+// Truncated is set when the value was cut to Options.MaxCellLength.
 type SampleFieldXML struct {
-	Name  string `xml:"name,attr"`
-	Value string `xml:",chardata"`
+	Name      string `xml:"name,attr"`
+	Truncated bool   `xml:"truncated,attr,omitempty"`
+	Value     string `xml:",chardata"`
 }
 
 // TableDetailInput holds the data needed to generate per-table detail files.
+// Empty marks a table found to have no rows; it is recorded in the columns
+// file and any sample file left from an earlier run is removed.
 type TableDetailInput struct {
 	Schema  string
 	Table   string
 	Columns []discovery.ColumnInfo
 	Sample  *discovery.SampleResult
+	Empty   bool
 }
 
 // GenerateTableDetails writes per-table __columns.yml and __sample.xml files
 // for the given tables. Files are placed in the directory structure:
 //
 //	<baseDir>/context/connections/<conn>/databases/<db>/schemas/<schema>/<table>/
+//
+// or <db>/<table>/ for backends without a schema level.
 func GenerateTableDetails(tables []TableDetailInput, opts Options) error {
 	now := time.Now().UTC().Format(time.RFC3339)
 
@@ -372,13 +682,28 @@ func GenerateTableDetails(tables []TableDetailInput, opts Options) error {
 		return err
 	}
 
-	dbName := sanitizeName(defaultDatabase)
-	schemasDir := filepath.Join(opts.BaseDir, "context", "connections", opts.ConnectionName, "databases", dbName, "schemas")
+	batch := make(map[string][]string)
+	for _, td := range tables {
+		batch[td.Schema] = append(batch[td.Schema], td.Table)
+	}
 
 	for _, td := range tables {
-		schemaDir := sanitizeName(td.Schema)
-		tableDir := sanitizeName(td.Table)
-		dir := filepath.Join(schemasDir, schemaDir, tableDir)
+		// Each table's files are written only when they all fit, so a limit
+		// never leaves a table half written.
+		files := 0
+		if td.Columns != nil {
+			files++
+		}
+		if td.Sample != nil && len(td.Sample.Rows) > 0 {
+			files++
+		}
+		if err := opts.FileLimit.reserve(files); err != nil {
+			return err
+		}
+
+		siblings := slices.Concat(schemaTableNames(opts, defaultDatabase, td.Schema), batch[td.Schema])
+		pathName := tablePathName(opts, td.Table, siblings)
+		dir := filepath.Join(schemaDirPath(opts, defaultDatabase, td.Schema), pathName)
 		if err := os.MkdirAll(dir, 0o755); err != nil {
 			return fmt.Errorf("create table dir %q/%q: %w", td.Schema, td.Table, err)
 		}
@@ -392,7 +717,9 @@ func GenerateTableDetails(tables []TableDetailInput, opts Options) error {
 				Database:     defaultDatabase,
 				DatabaseType: opts.DatabaseType,
 				GeneratedAt:  now,
+				Empty:        td.Empty,
 			}
+			ordinals := make([]int, 0, len(td.Columns))
 			for _, c := range td.Columns {
 				cf.Columns = append(cf.Columns, ColumnsFileItem{
 					Name:            c.Name,
@@ -400,10 +727,18 @@ func GenerateTableDetails(tables []TableDetailInput, opts Options) error {
 					IsNullable:      c.IsNullable,
 					OrdinalPosition: c.OrdinalPosition,
 					ColumnDefault:   c.ColumnDefault,
+					IsGenerated:     c.IsGenerated,
 				})
+				ordinals = append(ordinals, c.OrdinalPosition)
+			}
+			cf.OrdinalGaps = hasOrdinalGaps(ordinals)
+			if opts.RenumberColumns {
+				for i, position := range contiguousPositions(ordinals) {
+					cf.Columns[i].Position = position
+				}
 			}
 
-			colFileName := sanitizeName(td.Table) + "__columns.yml"
+			colFileName := pathName + "__columns.yml"
 			colPath := filepath.Join(dir, colFileName)
 			header := columnsHeader(opts, defaultDatabase, td.Schema, td.Table)
 			if err := writeYAMLWithHeader(colPath, cf, header); err != nil {
@@ -412,6 +747,12 @@ func GenerateTableDetails(tables []TableDetailInput, opts Options) error {
 		}
 
 		// Write __sample.xml
+		if td.Empty {
+			samplePath := filepath.Join(dir, pathName+"__sample.xml")
+			if err := os.Remove(samplePath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("remove stale sample for %q.%q: %w", td.Schema, td.Table, err)
+			}
+		}
 		if td.Sample != nil && len(td.Sample.Rows) > 0 {
 			sx := SampleXML{
 				Schema:      td.Schema,
@@ -428,17 +769,19 @@ func GenerateTableDetails(tables []TableDetailInput, opts Options) error {
 					if ci < len(row) {
 						val = row[ci]
 					}
+					val, truncated := truncateCellValue(val, opts.MaxCellLength)
 					srow.Fields = append(srow.Fields, SampleFieldXML{
-						Name:  col,
-						Value: val,
+						Name:      sanitizeXMLText(col),
+						Truncated: truncated,
+						Value:     sanitizeXMLText(val),
 					})
 				}
 				sx.Rows = append(sx.Rows, srow)
 			}
 
-			sampleFileName := sanitizeName(td.Table) + "__sample.xml"
+			sampleFileName := pathName + "__sample.xml"
 			samplePath := filepath.Join(dir, sampleFileName)
-			if err := writeXML(samplePath, sx); err != nil {
+			if err := writeXML(samplePath, sx, opts.XMLEncoding); err != nil {
 				return fmt.Errorf("write sample for %q.%q: %w", td.Schema, td.Table, err)
 			}
 		}
@@ -447,88 +790,325 @@ func GenerateTableDetails(tables []TableDetailInput, opts Options) error {
 	return nil
 }
 
-// WriteEnrichedColumnsFile writes one enriched <table_name>__columns.yml file.
-// The file is written atomically so existing files are only replaced after the
-// full payload has been successfully serialized.
+// WriteEnrichedColumnsFile writes one enriched <table_name>__columns.yml file,
+// a <table_name>__columns.json file, or both, depending on opts.ColumnsFormat.
+// Files are written atomically so existing files are only replaced after the
+// full payload has been successfully serialized. The returned path is the
+// YAML file unless only JSON was written.
 func WriteEnrichedColumnsFile(input EnrichedColumnsInput, opts Options) (string, error) {
-	if strings.TrimSpace(input.Schema) == "" {
-		return "", fmt.Errorf("schema is required for enriched columns")
-	}
-	if strings.TrimSpace(input.Table) == "" {
-		return "", fmt.Errorf("table is required for enriched columns")
-	}
-	if len(input.Columns) == 0 {
-		return "", fmt.Errorf("no columns provided for %s.%s", input.Schema, input.Table)
+	if err := input.validate(); err != nil {
+		return "", err
 	}
 
-	now := time.Now().UTC().Format(time.RFC3339)
-
 	defaultDatabase, err := resolveGenerationDatabase(opts)
 	if err != nil {
 		return "", err
 	}
 
-	dbName := sanitizeName(defaultDatabase)
-	schemaDir := sanitizeName(input.Schema)
-	tableDir := sanitizeName(input.Table)
-	dir := filepath.Join(
-		opts.BaseDir,
-		"context",
-		"connections",
-		opts.ConnectionName,
-		"databases",
-		dbName,
-		"schemas",
-		schemaDir,
-		tableDir,
-	)
+	colPath := enrichedColumnsFilePath(opts, defaultDatabase, input.Schema, input.Table)
+	dir := filepath.Dir(colPath)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return "", fmt.Errorf("create table dir %q/%q: %w", input.Schema, input.Table, err)
 	}
 
+	var previous *EnrichedColumnsFile
+	if input.TableColumns != nil {
+		existingPath := colPath
+		if !opts.ColumnsFormat.writesYAML() {
+			existingPath = enrichedColumnsJSONFilePath(colPath)
+		}
+		previous, err = readEnrichedColumnsFile(existingPath)
+		if err != nil {
+			return "", err
+		}
+	}
+	file := newEnrichedColumnsFile(input, opts, defaultDatabase, time.Now().UTC().Format(time.RFC3339), previous)
+
+	if opts.ColumnsFormat.writesJSON() {
+		jsonPath := enrichedColumnsJSONFilePath(colPath)
+		if err := writeJSONAtomic(jsonPath, file); err != nil {
+			return "", fmt.Errorf("write enriched columns json for %q.%q: %w", input.Schema, input.Table, err)
+		}
+		if !opts.ColumnsFormat.writesYAML() {
+			return jsonPath, nil
+		}
+	}
+
+	header := enrichedColumnsHeader(opts, defaultDatabase, input.Schema, input.Table)
+	if err := writeYAMLWithHeaderAtomic(colPath, file, header); err != nil {
+		return "", fmt.Errorf("write enriched columns for %q.%q: %w", input.Schema, input.Table, err)
+	}
+
+	return colPath, nil
+}
+
+// validate reports an input that cannot be written as a columns file.
+func (input EnrichedColumnsInput) validate() error {
+	if strings.TrimSpace(input.Schema) == "" {
+		return fmt.Errorf("schema is required for enriched columns")
+	}
+	if strings.TrimSpace(input.Table) == "" {
+		return fmt.Errorf("table is required for enriched columns")
+	}
+	if len(input.Columns) == 0 {
+		return fmt.Errorf("no columns provided for %s.%s", input.Schema, input.Table)
+	}
+	return nil
+}
+
+// readEnrichedColumnsFile parses the enriched columns file at path, or
+// returns nil when it does not exist yet. JSON columns files parse as YAML
+// too.
+func readEnrichedColumnsFile(path string) (*EnrichedColumnsFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read existing %s: %w", filepath.Base(path), err)
+	}
+	var file EnrichedColumnsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse existing %s: %w", filepath.Base(path), err)
+	}
+	return &file, nil
+}
+
+// newEnrichedColumnsFile assembles the enriched columns file of one table.
+// When input.TableColumns is set, the columns not in input.Columns keep
+// their entry from previous, which may be nil.
+func newEnrichedColumnsFile(input EnrichedColumnsInput, opts Options, database, generatedAt string, previous *EnrichedColumnsFile) EnrichedColumnsFile {
 	file := EnrichedColumnsFile{
 		Schema:       input.Schema,
 		Table:        input.Table,
 		Connection:   opts.ConnectionName,
-		Database:     defaultDatabase,
+		Database:     database,
 		DatabaseType: opts.DatabaseType,
-		GeneratedAt:  now,
+		GeneratedAt:  generatedAt,
+		Scope:        input.Scope,
+		StatsSource:  input.StatsSource,
+		SamplePct:    input.SamplePct,
+	}
+	if !input.LastModified.IsZero() {
+		file.LastModified = input.LastModified.UTC().Format(time.RFC3339Nano)
+	}
+
+	if input.TableColumns != nil {
+		mergeEnrichedColumns(&file, input, opts, previous)
+		return file
 	}
 
+	ordinals := make([]int, 0, len(input.Columns))
 	for _, column := range input.Columns {
-		file.Columns = append(file.Columns, EnrichedColumnsFileItem{
-			Name:                  column.Name,
-			DataType:              column.DataType,
-			IsNullable:            column.IsNullable,
-			OrdinalPosition:       column.OrdinalPosition,
-			ColumnDefault:         column.ColumnDefault,
-			AIDescription:         column.AIDescription,
-			DBDescription:         column.DBDescription,
-			TotalRows:             column.TotalRows,
-			NullCount:             column.NullCount,
-			NonNullCount:          column.NonNullCount,
-			DistinctNonNullCount:  column.DistinctNonNullCount,
-			DistinctOfNonNullPct:  column.DistinctOfNonNullPct,
-			NullOfTotalRowsPct:    column.NullOfTotalRowsPct,
-			NonNullOfTotalRowsPct: column.NonNullOfTotalRowsPct,
-			SampleValues:          column.SampleValues,
-		})
+		file.Columns = append(file.Columns, enrichedColumnsFileItem(column))
+		ordinals = append(ordinals, column.OrdinalPosition)
 	}
+	file.OrdinalGaps = hasOrdinalGaps(ordinals)
+	if opts.RenumberColumns {
+		for i, position := range contiguousPositions(ordinals) {
+			file.Columns[i].Position = position
+		}
+	}
+	return file
+}
 
-	colFileName := sanitizeName(input.Table) + "__columns.yml"
-	colPath := filepath.Join(dir, colFileName)
-	header := enrichedColumnsHeader(opts, defaultDatabase, input.Schema, input.Table)
-	if err := writeYAMLWithHeaderAtomic(colPath, file, header); err != nil {
-		return "", fmt.Errorf("write enriched columns for %q.%q: %w", input.Schema, input.Table, err)
+// mergeEnrichedColumns fills file with an entry for every column of
+// input.TableColumns: the new profile when the column is in input.Columns,
+// otherwise its entry in previous with refreshed metadata. Columns with
+// neither are listed in UnprofiledColumns. Ordinal gaps and positions are
+// worked out over the whole table.
+func mergeEnrichedColumns(file *EnrichedColumnsFile, input EnrichedColumnsInput, opts Options, previous *EnrichedColumnsFile) {
+	profiled := make(map[string]EnrichedColumnsFileItem, len(input.Columns))
+	for _, column := range input.Columns {
+		profiled[column.Name] = enrichedColumnsFileItem(column)
+	}
+	kept := make(map[string]EnrichedColumnsFileItem)
+	if previous != nil {
+		for _, item := range previous.Columns {
+			kept[item.Name] = item
+		}
 	}
 
-	return colPath, nil
+	ordinals := make([]int, 0, len(input.TableColumns))
+	for _, column := range input.TableColumns {
+		ordinals = append(ordinals, column.OrdinalPosition)
+	}
+	file.OrdinalGaps = hasOrdinalGaps(ordinals)
+	positions := contiguousPositions(ordinals)
+
+	keptAny := false
+	for i, column := range input.TableColumns {
+		item, ok := profiled[column.Name]
+		if !ok {
+			item, ok = kept[column.Name]
+			if !ok {
+				file.UnprofiledColumns = append(file.UnprofiledColumns, column.Name)
+				continue
+			}
+			keptAny = true
+			item.DataType = column.DataType
+			item.IsNullable = column.IsNullable
+			item.OrdinalPosition = column.OrdinalPosition
+			item.ColumnDefault = column.ColumnDefault
+			item.IsGenerated = column.IsGenerated
+		}
+		item.Position = 0
+		if opts.RenumberColumns {
+			item.Position = positions[i]
+		}
+		file.Columns = append(file.Columns, item)
+	}
+
+	// The kept entries describe the table as of the earlier run, so
+	// --only-changed must compare against that run's modification time.
+	if keptAny {
+		file.LastModified = previous.LastModified
+	}
+}
+
+// EnrichedColumnsFilePath returns the path of the enriched columns file that
+// WriteEnrichedColumnsFile returns for the given schema and table: the
+// <table_name>__columns.yml file, or <table_name>__columns.json when
+// opts.ColumnsFormat is JSON only.
+func EnrichedColumnsFilePath(opts Options, schema, table string) (string, error) {
+	defaultDatabase, err := resolveGenerationDatabase(opts)
+	if err != nil {
+		return "", err
+	}
+	path := enrichedColumnsFilePath(opts, defaultDatabase, schema, table)
+	if !opts.ColumnsFormat.writesYAML() {
+		path = enrichedColumnsJSONFilePath(path)
+	}
+	return path, nil
+}
+
+// HasCompleteEnrichedColumnsFile reports whether the enriched columns file for
+// the given table exists and every column entry carries the profiling stats
+// fields. Files written by dbh tables (basic column metadata only) or files
+// that fail to parse are reported as incomplete.
+func HasCompleteEnrichedColumnsFile(opts Options, schema, table string) (bool, error) {
+	path, err := EnrichedColumnsFilePath(opts, schema, table)
+	if err != nil {
+		return false, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("read columns file %s: %w", path, err)
+	}
+
+	return columnsHaveStats(data), nil
+}
+
+// EnrichedColumnsLastModified returns the table modification time recorded
+// in the table's enriched columns file. It returns the zero time when the
+// file is missing or incomplete, or was written without one.
+func EnrichedColumnsLastModified(opts Options, schema, table string) (time.Time, error) {
+	path, err := EnrichedColumnsFilePath(opts, schema, table)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("read columns file %s: %w", path, err)
+	}
+	if !columnsHaveStats(data) {
+		return time.Time{}, nil
+	}
+
+	// JSON columns files parse as YAML too.
+	var file struct {
+		LastModified string `yaml:"last_modified"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil || file.LastModified == "" {
+		return time.Time{}, nil
+	}
+	lastModified, err := time.Parse(time.RFC3339Nano, file.LastModified)
+	if err != nil {
+		return time.Time{}, nil
+	}
+	return lastModified, nil
+}
+
+// enrichedStatsFields lists the YAML keys that only enriched columns files
+// contain; their presence distinguishes a dbh columns file from a dbh tables one.
+var enrichedStatsFields = []string{
+	"total_rows",
+	"null_count",
+	"non_null_count",
+	"distinct_non_null_count",
 }
 
 // --------------------------------------------------------------------------
 // helpers
 // --------------------------------------------------------------------------
 
+func enrichedColumnsFilePath(opts Options, database, schema, table string) string {
+	pathName := tablePathName(opts, table, schemaTableNames(opts, database, schema))
+	return filepath.Join(
+		schemaDirPath(opts, database, schema),
+		pathName,
+		pathName+"__columns.yml",
+	)
+}
+
+// SchemasDir returns the directory holding _schemas.yml for the database in
+// opts.
+func SchemasDir(opts Options) (string, error) {
+	database, err := resolveGenerationDatabase(opts)
+	if err != nil {
+		return "", err
+	}
+	return schemasDirPath(opts, database), nil
+}
+
+// SchemaDir returns the directory holding the _tables.yml and table
+// directories of schema in the database in opts.
+func SchemaDir(opts Options, schema string) (string, error) {
+	database, err := resolveGenerationDatabase(opts)
+	if err != nil {
+		return "", err
+	}
+	return schemaDirPath(opts, database, schema), nil
+}
+
+func databaseDirPath(opts Options, database string) string {
+	return filepath.Join(opts.BaseDir, "context", "connections", opts.ConnectionName, "databases", sanitizeName(database))
+}
+
+// schemasDirPath returns <database>/schemas, or the database directory
+// itself for backends without a schema level.
+func schemasDirPath(opts Options, database string) string {
+	if discovery.HasSingleLevelNamespace(opts.DatabaseType) {
+		return databaseDirPath(opts, database)
+	}
+	return filepath.Join(databaseDirPath(opts, database), "schemas")
+}
+
+// schemaDirPath returns <database>/schemas/<schema>. For backends without a
+// schema level the schema named after the database is the database
+// directory; any other schema keeps the nested path so it cannot collide
+// with a table directory.
+func schemaDirPath(opts Options, database, schema string) string {
+	if discovery.HasSingleLevelNamespace(opts.DatabaseType) && sanitizeName(schema) == sanitizeName(database) {
+		return databaseDirPath(opts, database)
+	}
+	return filepath.Join(databaseDirPath(opts, database), "schemas", sanitizeName(schema))
+}
+
+// enrichedColumnsJSONFilePath returns the JSON sibling of a
+// <table_name>__columns.yml path.
+func enrichedColumnsJSONFilePath(yamlPath string) string {
+	return strings.TrimSuffix(yamlPath, ".yml") + ".json"
+}
+
 func writeYAMLWithHeader(path string, v interface{}, header string) error {
 	data, err := yaml.Marshal(v)
 	if err != nil {
@@ -539,7 +1119,11 @@ func writeYAMLWithHeader(path string, v interface{}, header string) error {
 	buf.WriteString(header)
 	buf.Write(data)
 
-	return os.WriteFile(path, []byte(buf.String()), 0o644)
+	if err := os.WriteFile(path, []byte(buf.String()), 0o644); err != nil {
+		return err
+	}
+	recordChecksum(path, []byte(buf.String()))
+	return nil
 }
 
 func writeYAMLWithHeaderAtomic(path string, v interface{}, header string) error {
@@ -560,30 +1144,78 @@ func writeYAMLWithHeaderAtomic(path string, v interface{}, header string) error
 	if err := os.Rename(tmpPath, path); err != nil {
 		return fmt.Errorf("rename temp yaml: %w", err)
 	}
+	recordChecksum(path, []byte(buf.String()))
+
+	return nil
+}
+
+func writeJSONAtomic(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal json: %w", err)
+	}
+	data = append(data, '\n')
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("write temp json: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("rename temp json: %w", err)
+	}
+	recordChecksum(path, data)
 
 	return nil
 }
 
 func databasesHeader(opts Options) string {
+	if opts.Dense {
+		return ""
+	}
 	return fmt.Sprintf(`# =============================================================================
 # Databases for connection: %s
 # Connection: %s | Type: %s
-# =============================================================================
+%s# =============================================================================
 #
 # This file was generated by dbh to provide LLM-friendly database context.
 #
 # Structure:
 #   _databases.yml (this file)               - List of databases in this connection
-#   <database>/schemas/_schemas.yml           - Schemas within each database
-#   <database>/schemas/<schema>/_tables.yml   - Tables within each schema
-#
+%s#
 # To explore a database, navigate into its directory.
 # =============================================================================
 
-`, opts.ConnectionName, opts.ConnectionName, opts.DatabaseType)
+`, opts.ConnectionName, opts.ConnectionName, opts.DatabaseType, connectionDescriptionLine(opts.ConnectionDescription), databasesStructure(opts))
+}
+
+// connectionDescriptionLine renders a connection's description as a header
+// comment line, or nothing when it has none.
+func connectionDescriptionLine(description string) string {
+	description = strings.Join(strings.Fields(description), " ")
+	if description == "" {
+		return ""
+	}
+	return "# Description: " + description + "\n"
+}
+
+// databasesStructure lists the files below each database directory for the
+// _databases.yml header.
+func databasesStructure(opts Options) string {
+	if discovery.HasSingleLevelNamespace(opts.DatabaseType) {
+		return `#   <database>/_schemas.yml                   - The database, which is also its only schema
+#   <database>/_tables.yml                    - Tables within each database
+`
+	}
+	return `#   <database>/schemas/_schemas.yml           - Schemas within each database
+#   <database>/schemas/<schema>/_tables.yml   - Tables within each schema
+`
 }
 
 func schemasHeader(opts Options) string {
+	if opts.Dense {
+		return ""
+	}
 	return fmt.Sprintf(`# =============================================================================
 # Database Schema Context
 # Connection: %s | Database: %s | Type: %s
@@ -592,22 +1224,38 @@ func schemasHeader(opts Options) string {
 # This file was generated by dbh to provide LLM-friendly database context.
 #
 # Structure:
-#   _schemas.yml (this file)                 - Overview of all schemas
-#   <schema_name>/_tables.yml                - Tables within each schema
-#
-# To explore a specific schema, navigate into the schema subdirectory.
-# Each schema directory contains a _tables.yml with its table listing.
-#
+%s#
 # Description fields:
 #   ai_description - Intended for AI-authored descriptions.
 #   db_description - Intended for database-native descriptions/comments.
 # Both are empty when no description data is available.
 # =============================================================================
 
-`, opts.ConnectionName, opts.DatabaseName, opts.DatabaseType)
+`, opts.ConnectionName, opts.DatabaseName, opts.DatabaseType, schemasStructure(opts))
+}
+
+// schemasStructure lists the files next to _schemas.yml for its header.
+func schemasStructure(opts Options) string {
+	if discovery.HasSingleLevelNamespace(opts.DatabaseType) {
+		return `#   _schemas.yml (this file)                 - Overview of the database's schema
+#   _tables.yml                              - Tables in the database
+#
+# The database is also its only schema, so its tables are listed in the
+# _tables.yml next to this file.
+`
+	}
+	return `#   _schemas.yml (this file)                 - Overview of all schemas
+#   <schema_name>/_tables.yml                - Tables within each schema
+#
+# To explore a specific schema, navigate into the schema subdirectory.
+# Each schema directory contains a _tables.yml with its table listing.
+`
 }
 
 func tablesHeader(opts Options, schemaName string) string {
+	if opts.Dense {
+		return ""
+	}
 	return fmt.Sprintf(`# =============================================================================
 # Tables in schema: %s
 # Connection: %s | Database: %s | Type: %s
@@ -619,26 +1267,99 @@ func tablesHeader(opts Options, schemaName string) string {
 #   ai_description - Intended for AI-authored descriptions.
 #   db_description - Intended for database-native descriptions/comments.
 # Both are empty when no description data is available.
+#
+# inferred_relationships (written by dbh tables) are HEURISTIC guesses from
+# column names and types, such as orders.user_id -> users.id. They are not
+# declared foreign keys and may be wrong.
 # =============================================================================
 
 `, schemaName, opts.ConnectionName, opts.DatabaseName, opts.DatabaseType, schemaName)
 }
 
-func writeXML(path string, v interface{}) error {
+// truncateCellValue shortens value to at most maxLength characters, ending
+// in "..." like the enrichment sample values. It cuts on a rune boundary and
+// reports whether the value was shortened. maxLength <= 0 disables it.
+func truncateCellValue(value string, maxLength int) (string, bool) {
+	if maxLength <= 0 || utf8.RuneCountInString(value) <= maxLength {
+		return value, false
+	}
+	if maxLength <= 3 {
+		return string([]rune(value)[:maxLength]), true
+	}
+	return string([]rune(value)[:maxLength-3]) + "...", true
+}
+
+// sanitizeXMLText makes a sample value safe for XML 1.0. Characters outside
+// the XML 1.0 Char production (NUL, most C0 controls, surrogates, U+FFFE/FFFF)
+// cannot appear in a document even as character references, so they are
+// replaced with a visible \uXXXX escape; invalid UTF-8 bytes become \xXX.
+// Everything else is left for the XML encoder to escape.
+func sanitizeXMLText(value string) string {
+	clean := true
+	for i, r := range value {
+		if !isXMLChar(r) || (r == utf8.RuneError && !validRuneAt(value, i)) {
+			clean = false
+			break
+		}
+	}
+	if clean {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); {
+		r, size := utf8.DecodeRuneInString(value[i:])
+		switch {
+		case r == utf8.RuneError && size <= 1:
+			fmt.Fprintf(&b, `\x%02X`, value[i])
+		case !isXMLChar(r):
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// isXMLChar reports whether r matches the XML 1.0 Char production.
+func isXMLChar(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' ||
+		(r >= 0x20 && r <= 0xD7FF) ||
+		(r >= 0xE000 && r <= 0xFFFD) ||
+		(r >= 0x10000 && r <= 0x10FFFF)
+}
+
+// validRuneAt reports whether the rune starting at byte i decodes cleanly,
+// distinguishing a literal U+FFFD from an invalid UTF-8 byte.
+func validRuneAt(value string, i int) bool {
+	_, size := utf8.DecodeRuneInString(value[i:])
+	return size > 1
+}
+
+func writeXML(path string, v interface{}, encoding XMLEncoding) error {
 	data, err := xml.MarshalIndent(v, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshal xml: %w", err)
 	}
 
 	var buf strings.Builder
-	buf.WriteString(xml.Header)
+	buf.WriteString(encoding.header())
 	buf.Write(data)
 	buf.WriteString("\n")
 
-	return os.WriteFile(path, []byte(buf.String()), 0o644)
+	encoded := encoding.encode(buf.String())
+	if err := os.WriteFile(path, encoded, 0o644); err != nil {
+		return err
+	}
+	recordChecksum(path, encoded)
+	return nil
 }
 
 func columnsHeader(opts Options, database, schema, table string) string {
+	if opts.Dense {
+		return ""
+	}
 	return fmt.Sprintf(`# =============================================================================
 # Columns for table: %s.%s
 # Connection: %s | Database: %s | Type: %s
@@ -651,26 +1372,50 @@ func columnsHeader(opts Options, database, schema, table string) string {
 #   data_type        - Database data type
 #   is_nullable      - Whether the column allows NULL values (YES/NO)
 #   ordinal_position - Column position in the table
+#   position         - Contiguous 1..N position (dbh tables --renumber only)
 #   column_default   - Default value expression (if any)
+#   is_generated     - true for generated/computed columns, which the
+#                      database derives from other columns; not writable
+#
+# ordinal_gaps: true means the ordinal positions skip numbers, usually because
+# columns were dropped. The column order is still correct.
+#
+# empty: true means the table had no rows, so no sample file was written.
 # =============================================================================
 
 `, schema, table, opts.ConnectionName, database, opts.DatabaseType)
 }
 
 func enrichedColumnsHeader(opts Options, database, schema, table string) string {
+	if opts.Dense {
+		return ""
+	}
 	return fmt.Sprintf(`# =============================================================================
 # Enriched columns for table: %s.%s
 # Connection: %s | Database: %s | Type: %s
 # =============================================================================
 #
 # This file was generated by dbh columns to provide enriched per-column context.
+`, schema, table, opts.ConnectionName, database, opts.DatabaseType) + enrichedColumnFieldsHelp
+}
+
+// enrichedColumnFieldsHelp ends the comment header of enriched columns files,
+// describing their fields.
+const enrichedColumnFieldsHelp = `# When "scope" is present, the counts and samples cover only the rows matching
+# that filter (dbh columns --since), not the whole table. stats_source: catalog
+# means the counts are estimates read from the database's own statistics
+# (dbh columns --use-catalog-stats) as of its last ANALYZE, and sample_values
+# are the most common values.
 #
 # Column fields:
 #   name                       - Column name
 #   data_type                  - Database data type
 #   is_nullable                - Whether NULL is allowed (YES/NO)
 #   ordinal_position           - Column position in the table
+#   position                   - Contiguous 1..N position (dbh columns --renumber only)
 #   column_default             - Default expression (if any)
+#   is_generated               - true for generated/computed columns (not
+#                                writable; sample_values are not collected)
 #   ai_description             - Blank placeholder for future AI descriptions
 #   db_description             - Database-native description/comment (if available)
 #   total_rows                 - Total rows in table at profiling time
@@ -681,9 +1426,55 @@ func enrichedColumnsHeader(opts Options, database, schema, table string) string
 #   null_of_total_rows_pct     - null_count / total_rows * 100
 #   non_null_of_total_rows_pct - non_null_count / total_rows * 100
 #   sample_values              - Up to 5 truncated example values
+#
+# ordinal_gaps: true means the ordinal positions skip numbers, usually because
+# columns were dropped. The column order is still correct.
+#
+# unprofiled_columns lists columns that no dbh columns run has profiled yet,
+# because --datatype-filter or --exclude-columns left them out.
 # =============================================================================
 
-`, schema, table, opts.ConnectionName, database, opts.DatabaseType)
+`
+
+// hasOrdinalGaps reports whether the known ordinal positions are not
+// exactly 1..N. Zero positions (not reported by the backend) are ignored.
+func hasOrdinalGaps(ordinals []int) bool {
+	known := make([]int, 0, len(ordinals))
+	for _, ordinal := range ordinals {
+		if ordinal > 0 {
+			known = append(known, ordinal)
+		}
+	}
+	sort.Ints(known)
+	for i, ordinal := range known {
+		if ordinal != i+1 {
+			return true
+		}
+	}
+	return false
+}
+
+// contiguousPositions numbers columns 1..N in ordinal order. When any
+// ordinal is unknown (zero) the given order is used instead. The result is
+// indexed like ordinals.
+func contiguousPositions(ordinals []int) []int {
+	order := make([]int, len(ordinals))
+	known := true
+	for i, ordinal := range ordinals {
+		order[i] = i
+		known = known && ordinal > 0
+	}
+	if known {
+		sort.SliceStable(order, func(a, b int) bool {
+			return ordinals[order[a]] < ordinals[order[b]]
+		})
+	}
+
+	positions := make([]int, len(ordinals))
+	for position, index := range order {
+		positions[index] = position + 1
+	}
+	return positions
 }
 
 func isView(tableType string) bool {
@@ -712,15 +1503,51 @@ func resolveGenerationDatabase(opts Options) (string, error) {
 	return resolveDefaultDatabase(configured, nil), nil
 }
 
+// generatedDatabaseItems lists the databases Generate records in
+// _databases.yml: the default database, plus, for SQLite, every attached
+// database. SQLite discovery reports attached databases as schemas, so they
+// are the schema names, and their context is written as schemas of the
+// default database, which ContextDir records.
+func generatedDatabaseItems(databaseType, defaultDatabase string, schemas []discovery.SchemaInfo) []DatabaseItem {
+	items := []DatabaseItem{{Name: defaultDatabase}}
+	if !strings.EqualFold(strings.TrimSpace(databaseType), "sqlite") {
+		return items
+	}
+	for _, schema := range schemas {
+		if schema.Name != defaultDatabase {
+			items = append(items, DatabaseItem{
+				Name:       schema.Name,
+				ContextDir: path.Join(sanitizeName(defaultDatabase), "schemas", sanitizeName(schema.Name)),
+			})
+		}
+	}
+	return items
+}
+
 func requiresExplicitDefaultDatabase(databaseType string) bool {
 	switch strings.ToLower(strings.TrimSpace(databaseType)) {
-	case "postgres", "redshift", "snowflake", "mysql", "bigquery":
+	case "postgres", "redshift", "snowflake", "mysql", "bigquery", "databricks":
 		return true
 	default:
 		return false
 	}
 }
 
+// ExcludeEmptySchemas returns the schemas that have at least one table or
+// view, in their original order, and the names of the others.
+func ExcludeEmptySchemas(schemas []discovery.SchemaInfo) ([]discovery.SchemaInfo, []string) {
+	kept := make([]discovery.SchemaInfo, 0, len(schemas))
+	var excluded []string
+	for _, schema := range schemas {
+		if len(schema.Tables) == 0 {
+			excluded = append(excluded, schema.Name)
+			continue
+		}
+		kept = append(kept, schema)
+	}
+	return kept, excluded
+}
+
 func sortedSchemaInfos(schemas []discovery.SchemaInfo) []discovery.SchemaInfo {
 	sorted := make([]discovery.SchemaInfo, len(schemas))
 	for i := range schemas {
@@ -755,13 +1582,20 @@ func resolveDefaultDatabase(configured string, databases []DatabaseItem) string
 	return "_default"
 }
 
-// sanitizeName replaces characters that are not safe for directory names.
+// sanitizeName replaces characters that are not safe for directory names
+// and lowercases the result.
 func sanitizeName(name string) string {
+	return strings.ToLower(sanitizeNameKeepCase(name))
+}
+
+// sanitizeNameKeepCase replaces characters that are not safe for directory
+// names, keeping the case of the rest.
+func sanitizeNameKeepCase(name string) string {
 	replacer := strings.NewReplacer(
 		"/", "_",
 		"\\", "_",
 		" ", "_",
 		".", "_",
 	)
-	return strings.ToLower(replacer.Replace(name))
+	return replacer.Replace(name)
 }
@@ -1,10 +1,16 @@
 package contextgen
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"unicode/utf16"
 
 	"github.com/genesisdayrit/dbharness/internal/discovery"
 	"gopkg.in/yaml.v3"
@@ -42,6 +48,50 @@ func TestGenerate_WritesDefaultDatabaseFieldWhenProvided(t *testing.T) {
 	}
 }
 
+func TestGenerate_DenseOmitsHeaders(t *testing.T) {
+	baseDir := t.TempDir()
+
+	schemas := []discovery.SchemaInfo{
+		{
+			Name: "public",
+			Tables: []discovery.TableInfo{
+				{Name: "users", TableType: "BASE TABLE"},
+			},
+		},
+	}
+
+	opts := Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+		Dense:          true,
+	}
+
+	if err := Generate(schemas, opts); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	databasesDir := filepath.Join(baseDir, "context", "connections", "my-db", "databases")
+	for _, path := range []string{
+		filepath.Join(databasesDir, "_databases.yml"),
+		filepath.Join(databasesDir, "analytics", "schemas", "_schemas.yml"),
+		filepath.Join(databasesDir, "analytics", "schemas", "public", "_tables.yml"),
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		if strings.Contains(string(data), "#") {
+			t.Fatalf("%s has comments in dense mode:\n%s", path, data)
+		}
+	}
+
+	if sf := readSchemasFile(t, baseDir, "my-db", "analytics"); len(sf.Schemas) != 1 {
+		t.Fatalf("schemas = %+v, want 1 schema", sf.Schemas)
+	}
+}
+
 func TestGenerate_RequiresDefaultDatabaseSelectionWhenMissing(t *testing.T) {
 	t.Parallel()
 
@@ -122,6 +172,89 @@ func TestGenerate_AllowsSQLiteWithoutConfiguredDefaultDatabase(t *testing.T) {
 	}
 }
 
+func TestGenerate_ListsAttachedSQLiteDatabases(t *testing.T) {
+	baseDir := t.TempDir()
+
+	schemas := []discovery.SchemaInfo{
+		{Name: "main", Tables: []discovery.TableInfo{{Name: "users", TableType: "BASE TABLE"}}},
+		{Name: "analytics", Tables: []discovery.TableInfo{{Name: "events", TableType: "BASE TABLE"}}},
+		{Name: "archive"},
+	}
+
+	opts := Options{
+		ConnectionName: "local-sqlite",
+		DatabaseName:   "main",
+		DatabaseType:   "sqlite",
+		BaseDir:        baseDir,
+	}
+	if err := Generate(schemas, opts); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	df, _ := readDatabasesFile(t, baseDir, "local-sqlite")
+	var names []string
+	for _, db := range df.Databases {
+		names = append(names, db.Name)
+	}
+	if want := []string{"main", "analytics", "archive"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("databases = %v, want %v", names, want)
+	}
+	if got := df.Databases[1].ContextDir; got != "main/schemas/analytics" {
+		t.Fatalf("analytics context_dir = %q, want main/schemas/analytics", got)
+	}
+	if got := df.Databases[0].ContextDir; got != "" {
+		t.Fatalf("main context_dir = %q, want none", got)
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, "context", "connections", "local-sqlite", "databases", df.Databases[1].ContextDir, "_tables.yml")); err != nil {
+		t.Fatalf("analytics context not at its context_dir: %v", err)
+	}
+	if df.DefaultDatabase != "main" {
+		t.Fatalf("default database = %q, want main", df.DefaultDatabase)
+	}
+}
+
+func TestGenerate_ExcludeEmptySchemas(t *testing.T) {
+	baseDir := t.TempDir()
+
+	schemas := []discovery.SchemaInfo{
+		{Name: "staging"},
+		{Name: "analytics", Tables: []discovery.TableInfo{{Name: "users", TableType: "BASE TABLE"}}},
+		{Name: "reporting", Tables: []discovery.TableInfo{{Name: "daily", TableType: "VIEW"}}},
+		{Name: "scratch"},
+	}
+	opts := Options{
+		ConnectionName:      "my-db",
+		DatabaseName:        "warehouse",
+		DatabaseType:        "postgres",
+		BaseDir:             baseDir,
+		ExcludeEmptySchemas: true,
+	}
+
+	if err := Generate(schemas, opts); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	sf := readSchemasFile(t, baseDir, "my-db", "warehouse")
+	if len(sf.Schemas) != 2 || sf.Schemas[0].Name != "analytics" || sf.Schemas[1].Name != "reporting" {
+		t.Fatalf("_schemas.yml schemas = %+v, want analytics and reporting", sf.Schemas)
+	}
+	schemasDir := filepath.Join(baseDir, "context", "connections", "my-db", "databases", "warehouse", "schemas")
+	for _, name := range []string{"staging", "scratch"} {
+		if _, err := os.Stat(filepath.Join(schemasDir, name)); !os.IsNotExist(err) {
+			t.Fatalf("directory for empty schema %s exists (stat err = %v)", name, err)
+		}
+	}
+	for _, name := range []string{"analytics", "reporting"} {
+		if _, err := os.Stat(filepath.Join(schemasDir, name, "_tables.yml")); err != nil {
+			t.Fatalf("_tables.yml for %s: %v", name, err)
+		}
+	}
+
+	if _, excluded := ExcludeEmptySchemas(schemas); strings.Join(excluded, ",") != "staging,scratch" {
+		t.Fatalf("ExcludeEmptySchemas() excluded = %v, want [staging scratch]", excluded)
+	}
+}
+
 func TestGenerate_SortsSchemasAndTablesAndIncludesTableDetails(t *testing.T) {
 	baseDir := t.TempDir()
 
@@ -220,6 +353,37 @@ func TestUpdateDatabasesFile_WritesDefaultDatabaseFieldWhenProvided(t *testing.T
 	}
 }
 
+func TestUpdateDatabasesFile_WritesConnectionDescription(t *testing.T) {
+	baseDir := t.TempDir()
+
+	opts := Options{
+		ConnectionName:        "replica",
+		ConnectionDescription: "Read replica for analytics,\n24h lag",
+		DatabaseName:          "core",
+		DatabaseType:          "postgres",
+		BaseDir:               baseDir,
+	}
+	if _, err := UpdateDatabasesFile([]string{"core"}, opts); err != nil {
+		t.Fatalf("UpdateDatabasesFile() error = %v", err)
+	}
+
+	df, raw := readDatabasesFile(t, baseDir, "replica")
+	if df.Description != "Read replica for analytics,\n24h lag" {
+		t.Fatalf("description = %q", df.Description)
+	}
+	if !strings.Contains(raw, "# Description: Read replica for analytics, 24h lag\n") {
+		t.Fatalf("expected the header to carry the description, got:\n%s", raw)
+	}
+
+	opts.ConnectionDescription = ""
+	if _, err := UpdateDatabasesFile([]string{"core"}, opts); err != nil {
+		t.Fatalf("UpdateDatabasesFile() error = %v", err)
+	}
+	if _, raw := readDatabasesFile(t, baseDir, "replica"); strings.Contains(raw, "escription") {
+		t.Fatalf("expected no description once it is removed from config, got:\n%s", raw)
+	}
+}
+
 func TestUpdateDatabasesFile_UsesOnlyDatabaseWhenDefaultMissing(t *testing.T) {
 	baseDir := t.TempDir()
 
@@ -264,6 +428,94 @@ func TestUpdateDatabasesFile_UsesSentinelWhenMultipleAndDefaultMissing(t *testin
 	}
 }
 
+func TestUpdateSchemasFile_PreservesDescriptionsAndAppendsNewSchemas(t *testing.T) {
+	baseDir := t.TempDir()
+
+	opts := Options{
+		ConnectionName: "warehouse",
+		DatabaseName:   "core",
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+	}
+
+	initial := []discovery.SchemaInfo{
+		{Name: "public", Tables: []discovery.TableInfo{{Name: "users", TableType: "BASE TABLE"}}},
+		{Name: "legacy", Tables: []discovery.TableInfo{{Name: "old_orders", TableType: "BASE TABLE"}}},
+	}
+	if err := Generate(initial, opts); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	schemasDir := filepath.Join(baseDir, "context", "connections", "warehouse", "databases", "core", "schemas")
+	schemasPath := filepath.Join(schemasDir, "_schemas.yml")
+	sf := readSchemasFile(t, baseDir, "warehouse", "core")
+	for i := range sf.Schemas {
+		if sf.Schemas[i].Name == "public" {
+			sf.Schemas[i].AIDescription = "Core application tables."
+			sf.Schemas[i].Tables[0].AIDescription = "One row per user."
+		}
+	}
+	if err := writeYAMLWithHeaderAtomic(schemasPath, sf, ""); err != nil {
+		t.Fatalf("rewrite _schemas.yml: %v", err)
+	}
+
+	tablesPath := filepath.Join(schemasDir, "public", "_tables.yml")
+	tablesBefore, err := os.ReadFile(tablesPath)
+	if err != nil {
+		t.Fatalf("read _tables.yml: %v", err)
+	}
+
+	current := []discovery.SchemaInfo{
+		{Name: "public", Tables: []discovery.TableInfo{
+			{Name: "users", TableType: "BASE TABLE"},
+			{Name: "active_users", TableType: "VIEW"},
+		}},
+		{Name: "analytics", Tables: []discovery.TableInfo{{Name: "events", TableType: "BASE TABLE"}}},
+	}
+	added, missing, err := UpdateSchemasFile(current, opts)
+	if err != nil {
+		t.Fatalf("UpdateSchemasFile() error = %v", err)
+	}
+	if !reflect.DeepEqual(added, []string{"analytics"}) {
+		t.Fatalf("added = %v, want [analytics]", added)
+	}
+	if !reflect.DeepEqual(missing, []string{"legacy"}) {
+		t.Fatalf("missing = %v, want [legacy]", missing)
+	}
+
+	updated := readSchemasFile(t, baseDir, "warehouse", "core")
+
+	var names []string
+	for _, schema := range updated.Schemas {
+		names = append(names, schema.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"legacy", "public", "analytics"}) {
+		t.Fatalf("schema order = %v, want [legacy public analytics]", names)
+	}
+
+	public := updated.Schemas[1]
+	if public.AIDescription != "Core application tables." {
+		t.Fatalf("public ai_description = %q, want preserved", public.AIDescription)
+	}
+	if public.TableCount != 1 || public.ViewCount != 1 || len(public.Tables) != 2 {
+		t.Fatalf("public counts = %d tables, %d views, %d entries; want 1, 1, 2", public.TableCount, public.ViewCount, len(public.Tables))
+	}
+	if public.Tables[1].Name != "users" || public.Tables[1].AIDescription != "One row per user." {
+		t.Fatalf("public second table = %+v, want users with preserved description", public.Tables[1])
+	}
+
+	tablesAfter, err := os.ReadFile(tablesPath)
+	if err != nil {
+		t.Fatalf("read _tables.yml: %v", err)
+	}
+	if string(tablesAfter) != string(tablesBefore) {
+		t.Fatal("UpdateSchemasFile rewrote public/_tables.yml")
+	}
+	if _, err := os.Stat(filepath.Join(schemasDir, "analytics", "_tables.yml")); !os.IsNotExist(err) {
+		t.Fatalf("analytics/_tables.yml stat error = %v, want not exist", err)
+	}
+}
+
 func readDatabasesFile(t *testing.T, baseDir, connection string) (DatabasesFile, string) {
 	t.Helper()
 
@@ -468,6 +720,137 @@ func TestGenerateTableDetails_SanitizesDirectoryNames(t *testing.T) {
 	}
 }
 
+func TestGenerateTableDetails_MarksEmptyTableAndRemovesStaleSample(t *testing.T) {
+	baseDir := t.TempDir()
+	opts := Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+	}
+	input := TableDetailInput{
+		Schema:  "public",
+		Table:   "staging",
+		Columns: []discovery.ColumnInfo{{Name: "id", DataType: "integer", IsNullable: "NO", OrdinalPosition: 1}},
+		Sample:  &discovery.SampleResult{Columns: []string{"id"}, Rows: [][]string{{"1"}}},
+	}
+	if err := GenerateTableDetails([]TableDetailInput{input}, opts); err != nil {
+		t.Fatalf("GenerateTableDetails() error = %v", err)
+	}
+
+	tableDir := filepath.Join(baseDir, "context", "connections", "my-db", "databases", "analytics", "schemas", "public", "staging")
+	samplePath := filepath.Join(tableDir, "staging__sample.xml")
+	if _, err := os.Stat(samplePath); err != nil {
+		t.Fatalf("sample file not written: %v", err)
+	}
+
+	input.Sample = nil
+	input.Empty = true
+	if err := GenerateTableDetails([]TableDetailInput{input}, opts); err != nil {
+		t.Fatalf("GenerateTableDetails(empty) error = %v", err)
+	}
+	if _, err := os.Stat(samplePath); !os.IsNotExist(err) {
+		t.Fatalf("stale sample file still exists after the table emptied (stat error = %v)", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tableDir, "staging__columns.yml"))
+	if err != nil {
+		t.Fatalf("read columns file: %v", err)
+	}
+	var cf ColumnsFile
+	if err := yaml.Unmarshal(data, &cf); err != nil {
+		t.Fatalf("unmarshal columns file: %v", err)
+	}
+	if !cf.Empty {
+		t.Fatalf("columns file empty = false, want true:\n%s", data)
+	}
+}
+
+func TestGenerateTableDetails_FlagsOrdinalGapsAndRenumbers(t *testing.T) {
+	baseDir := t.TempDir()
+
+	tables := []TableDetailInput{
+		{
+			Schema: "public",
+			Table:  "users",
+			Columns: []discovery.ColumnInfo{
+				{Name: "id", DataType: "integer", IsNullable: "NO", OrdinalPosition: 1},
+				{Name: "email", DataType: "text", IsNullable: "NO", OrdinalPosition: 3},
+				{Name: "created_at", DataType: "timestamp", IsNullable: "NO", OrdinalPosition: 7},
+			},
+		},
+		{
+			Schema: "public",
+			Table:  "teams",
+			Columns: []discovery.ColumnInfo{
+				{Name: "id", DataType: "integer", IsNullable: "NO", OrdinalPosition: 1},
+				{Name: "name", DataType: "text", IsNullable: "NO", OrdinalPosition: 2},
+			},
+		},
+	}
+
+	opts := Options{
+		ConnectionName:  "my-db",
+		DatabaseName:    "analytics",
+		DatabaseType:    "postgres",
+		BaseDir:         baseDir,
+		RenumberColumns: true,
+	}
+	if err := GenerateTableDetails(tables, opts); err != nil {
+		t.Fatalf("GenerateTableDetails() error = %v", err)
+	}
+
+	schemaDir := filepath.Join(baseDir, "context", "connections", "my-db", "databases", "analytics", "schemas", "public")
+	read := func(table string) ColumnsFile {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(schemaDir, table, table+"__columns.yml"))
+		if err != nil {
+			t.Fatalf("read %s columns: %v", table, err)
+		}
+		var cf ColumnsFile
+		if err := yaml.Unmarshal(data, &cf); err != nil {
+			t.Fatalf("unmarshal %s columns: %v", table, err)
+		}
+		return cf
+	}
+
+	users := read("users")
+	if !users.OrdinalGaps {
+		t.Fatal("users ordinal_gaps = false, want true for ordinals 1,3,7")
+	}
+	for i, column := range users.Columns {
+		if column.Position != i+1 {
+			t.Fatalf("users column %s position = %d, want %d", column.Name, column.Position, i+1)
+		}
+	}
+	if users.Columns[2].OrdinalPosition != 7 {
+		t.Fatalf("created_at ordinal_position = %d, want the database's 7", users.Columns[2].OrdinalPosition)
+	}
+
+	if teams := read("teams"); teams.OrdinalGaps {
+		t.Fatal("teams ordinal_gaps = true, want false for ordinals 1,2")
+	}
+}
+
+func TestContiguousPositions(t *testing.T) {
+	tests := []struct {
+		ordinals []int
+		want     []int
+	}{
+		{ordinals: []int{1, 3, 7}, want: []int{1, 2, 3}},
+		{ordinals: []int{5, 2, 9}, want: []int{2, 1, 3}},
+		{ordinals: []int{0, 0}, want: []int{1, 2}},
+	}
+	for _, tt := range tests {
+		if got := contiguousPositions(tt.ordinals); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("contiguousPositions(%v) = %v, want %v", tt.ordinals, got, tt.want)
+		}
+	}
+	if hasOrdinalGaps([]int{0, 0}) || hasOrdinalGaps([]int{2, 1}) || !hasOrdinalGaps([]int{2, 3}) {
+		t.Fatal("hasOrdinalGaps() mismatch")
+	}
+}
+
 func TestWriteEnrichedColumnsFile_WritesEnrichedMetrics(t *testing.T) {
 	baseDir := t.TempDir()
 
@@ -564,29 +947,295 @@ func TestWriteEnrichedColumnsFile_WritesEnrichedMetrics(t *testing.T) {
 	}
 }
 
-func TestWriteEnrichedColumnsFile_RejectsEmptyInput(t *testing.T) {
+func TestWriteEnrichedColumnsFile_WritesPercentiles(t *testing.T) {
+	input := EnrichedColumnsInput{
+		Schema: "public",
+		Table:  "orders",
+		Columns: []discovery.EnrichedColumnInfo{
+			{
+				Name:            "amount",
+				DataType:        "numeric",
+				OrdinalPosition: 1,
+				Percentiles:     &discovery.ColumnPercentiles{P50: 42.5, P95: 310, P99: 1249.99},
+			},
+			{Name: "note", DataType: "text", OrdinalPosition: 2},
+		},
+	}
+	opts := Options{ConnectionName: "my-db", DatabaseName: "analytics", DatabaseType: "postgres", BaseDir: t.TempDir()}
+
+	path, err := WriteEnrichedColumnsFile(input, opts)
+	if err != nil {
+		t.Fatalf("WriteEnrichedColumnsFile() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read enriched columns file: %v", err)
+	}
+
+	var file EnrichedColumnsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		t.Fatalf("unmarshal enriched columns file: %v", err)
+	}
+	want := EnrichedColumnPercentiles{P50: 42.5, P95: 310, P99: 1249.99}
+	if got := file.Columns[0].Percentiles; got == nil || *got != want {
+		t.Fatalf("amount percentiles = %+v, want %+v", got, want)
+	}
+	if file.Columns[1].Percentiles != nil {
+		t.Fatalf("note percentiles = %+v, want none", file.Columns[1].Percentiles)
+	}
+	if strings.Count(string(data), "percentiles:") != 1 {
+		t.Fatalf("expected one percentiles entry, got:\n%s", data)
+	}
+}
+
+func TestWriteEnrichedColumnsFile_JSONMatchesYAML(t *testing.T) {
 	baseDir := t.TempDir()
 
+	input := EnrichedColumnsInput{
+		Schema: "public",
+		Table:  "users",
+		Columns: []discovery.EnrichedColumnInfo{
+			{
+				Name:                  "id",
+				DataType:              "integer",
+				IsNullable:            "NO",
+				OrdinalPosition:       1,
+				ColumnDefault:         "nextval('users_id_seq'::regclass)",
+				TotalRows:             200,
+				NonNullCount:          200,
+				DistinctNonNullCount:  200,
+				DistinctOfNonNullPct:  100,
+				NonNullOfTotalRowsPct: 100,
+				SampleValues:          []string{"1", "2", "3"},
+			},
+			{
+				Name:                  "email",
+				DataType:              "character varying",
+				IsNullable:            "YES",
+				OrdinalPosition:       2,
+				TotalRows:             200,
+				NullCount:             20,
+				NonNullCount:          180,
+				DistinctNonNullCount:  175,
+				DistinctOfNonNullPct:  97.2222,
+				NullOfTotalRowsPct:    10,
+				NonNullOfTotalRowsPct: 90,
+			},
+		},
+	}
+
 	opts := Options{
 		ConnectionName: "my-db",
 		DatabaseName:   "analytics",
 		DatabaseType:   "postgres",
 		BaseDir:        baseDir,
+		ColumnsFormat:  ColumnsFormatBoth,
 	}
 
-	_, err := WriteEnrichedColumnsFile(EnrichedColumnsInput{
-		Schema:  "public",
-		Table:   "users",
-		Columns: nil,
-	}, opts)
-	if err == nil {
-		t.Fatalf("WriteEnrichedColumnsFile() error = nil, want non-nil")
-	}
+	yamlPath, err := WriteEnrichedColumnsFile(input, opts)
+	if err != nil {
+		t.Fatalf("WriteEnrichedColumnsFile() error = %v", err)
+	}
+	if filepath.Ext(yamlPath) != ".yml" {
+		t.Fatalf("WriteEnrichedColumnsFile() path = %q, want the YAML file", yamlPath)
+	}
+
+	yamlData, err := os.ReadFile(yamlPath)
+	if err != nil {
+		t.Fatalf("read yaml columns file: %v", err)
+	}
+	var fromYAML EnrichedColumnsFile
+	if err := yaml.Unmarshal(yamlData, &fromYAML); err != nil {
+		t.Fatalf("unmarshal yaml columns file: %v", err)
+	}
+
+	jsonPath := filepath.Join(filepath.Dir(yamlPath), "users__columns.json")
+	jsonData, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("read json columns file: %v", err)
+	}
+	var fromJSON EnrichedColumnsFile
+	if err := json.Unmarshal(jsonData, &fromJSON); err != nil {
+		t.Fatalf("unmarshal json columns file: %v", err)
+	}
+
+	if !reflect.DeepEqual(fromJSON, fromYAML) {
+		t.Fatalf("json file = %#v, want %#v", fromJSON, fromYAML)
+	}
+	if !strings.Contains(string(jsonData), `"distinct_non_null_count": 175`) {
+		t.Fatalf("expected snake_case keys in JSON, got:\n%s", string(jsonData))
+	}
+
+	// JSON only: no YAML file is written and the JSON path is returned.
+	opts.ConnectionName = "json-only"
+	opts.ColumnsFormat = ColumnsFormatJSON
+	path, err := WriteEnrichedColumnsFile(input, opts)
+	if err != nil {
+		t.Fatalf("WriteEnrichedColumnsFile() error = %v", err)
+	}
+	if filepath.Base(path) != "users__columns.json" {
+		t.Fatalf("WriteEnrichedColumnsFile() path = %q, want users__columns.json", path)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), "users__columns.yml")); !os.IsNotExist(err) {
+		t.Fatalf("yaml columns file exists for json-only format (stat err = %v)", err)
+	}
+
+	complete, err := HasCompleteEnrichedColumnsFile(opts, "public", "users")
+	if err != nil {
+		t.Fatalf("HasCompleteEnrichedColumnsFile() error = %v", err)
+	}
+	if !complete {
+		t.Fatalf("HasCompleteEnrichedColumnsFile() = false for json file, want true")
+	}
+}
+
+func TestWriteEnrichedColumnsFile_RejectsEmptyInput(t *testing.T) {
+	baseDir := t.TempDir()
+
+	opts := Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+	}
+
+	_, err := WriteEnrichedColumnsFile(EnrichedColumnsInput{
+		Schema:  "public",
+		Table:   "users",
+		Columns: nil,
+	}, opts)
+	if err == nil {
+		t.Fatalf("WriteEnrichedColumnsFile() error = nil, want non-nil")
+	}
 	if !strings.Contains(err.Error(), "no columns provided") {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
 
+func TestWriteEnrichedColumnsFile_MergesNarrowedColumns(t *testing.T) {
+	opts := Options{ConnectionName: "my-db", DatabaseName: "analytics", DatabaseType: "postgres", BaseDir: t.TempDir()}
+	tableColumns := []discovery.ColumnInfo{
+		{Name: "id", DataType: "integer", OrdinalPosition: 1},
+		{Name: "payload_raw", DataType: "text", OrdinalPosition: 2},
+		{Name: "created_at", DataType: "timestamp", OrdinalPosition: 3},
+	}
+	write := func(columns ...string) EnrichedColumnsFile {
+		t.Helper()
+		input := EnrichedColumnsInput{Schema: "public", Table: "events", TableColumns: tableColumns}
+		for _, column := range tableColumns {
+			for _, name := range columns {
+				if column.Name == name {
+					input.Columns = append(input.Columns, discovery.EnrichedColumnInfo{
+						Name: column.Name, DataType: column.DataType, OrdinalPosition: column.OrdinalPosition, TotalRows: 10,
+					})
+				}
+			}
+		}
+		path, err := WriteEnrichedColumnsFile(input, opts)
+		if err != nil {
+			t.Fatalf("WriteEnrichedColumnsFile(%v) error = %v", columns, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read columns file: %v", err)
+		}
+		var file EnrichedColumnsFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			t.Fatalf("parse columns file: %v", err)
+		}
+		return file
+	}
+
+	file := write("created_at")
+	if len(file.Columns) != 1 || !reflect.DeepEqual(file.UnprofiledColumns, []string{"id", "payload_raw"}) {
+		t.Fatalf("first write = %+v, want created_at profiled and the rest unprofiled", file)
+	}
+	if file.OrdinalGaps {
+		t.Fatalf("ordinal_gaps = true for a table numbered 1..3")
+	}
+	if complete, err := HasCompleteEnrichedColumnsFile(opts, "public", "events"); err != nil || complete {
+		t.Fatalf("HasCompleteEnrichedColumnsFile() = %v, %v; want false with unprofiled columns", complete, err)
+	}
+
+	file = write("id", "payload_raw")
+	var names []string
+	for _, column := range file.Columns {
+		names = append(names, column.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"id", "payload_raw", "created_at"}) || len(file.UnprofiledColumns) != 0 {
+		t.Fatalf("second write columns = %v, unprofiled %v; want all three kept in table order", names, file.UnprofiledColumns)
+	}
+	if complete, err := HasCompleteEnrichedColumnsFile(opts, "public", "events"); err != nil || !complete {
+		t.Fatalf("HasCompleteEnrichedColumnsFile() = %v, %v; want true once every column is profiled", complete, err)
+	}
+}
+
+func TestHasCompleteEnrichedColumnsFile_DistinguishesBasicAndEnrichedFiles(t *testing.T) {
+	baseDir := t.TempDir()
+
+	opts := Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+	}
+
+	complete, err := HasCompleteEnrichedColumnsFile(opts, "public", "users")
+	if err != nil {
+		t.Fatalf("HasCompleteEnrichedColumnsFile() error = %v", err)
+	}
+	if complete {
+		t.Fatalf("HasCompleteEnrichedColumnsFile() = true for missing file, want false")
+	}
+
+	if err := GenerateTableDetails([]TableDetailInput{
+		{
+			Schema: "public",
+			Table:  "users",
+			Columns: []discovery.ColumnInfo{
+				{Name: "id", DataType: "integer", IsNullable: "NO", OrdinalPosition: 1},
+			},
+		},
+	}, opts); err != nil {
+		t.Fatalf("GenerateTableDetails() error = %v", err)
+	}
+
+	complete, err = HasCompleteEnrichedColumnsFile(opts, "public", "users")
+	if err != nil {
+		t.Fatalf("HasCompleteEnrichedColumnsFile() error = %v", err)
+	}
+	if complete {
+		t.Fatalf("HasCompleteEnrichedColumnsFile() = true for basic columns file, want false")
+	}
+
+	path, err := WriteEnrichedColumnsFile(EnrichedColumnsInput{
+		Schema: "public",
+		Table:  "users",
+		Columns: []discovery.EnrichedColumnInfo{
+			{Name: "id", DataType: "integer", IsNullable: "NO", OrdinalPosition: 1, TotalRows: 3, NonNullCount: 3, DistinctNonNullCount: 3},
+		},
+	}, opts)
+	if err != nil {
+		t.Fatalf("WriteEnrichedColumnsFile() error = %v", err)
+	}
+
+	wantPath, err := EnrichedColumnsFilePath(opts, "public", "users")
+	if err != nil {
+		t.Fatalf("EnrichedColumnsFilePath() error = %v", err)
+	}
+	if path != wantPath {
+		t.Fatalf("EnrichedColumnsFilePath() = %q, want %q", wantPath, path)
+	}
+
+	complete, err = HasCompleteEnrichedColumnsFile(opts, "public", "users")
+	if err != nil {
+		t.Fatalf("HasCompleteEnrichedColumnsFile() error = %v", err)
+	}
+	if !complete {
+		t.Fatalf("HasCompleteEnrichedColumnsFile() = false for enriched file, want true")
+	}
+}
+
 func readSchemasFile(t *testing.T, baseDir, connection, database string) SchemasFile {
 	t.Helper()
 
@@ -611,3 +1260,434 @@ func readSchemasFile(t *testing.T, baseDir, connection, database string) Schemas
 	}
 	return sf
 }
+
+func TestGenerateTableDetails_SampleXMLIsWellFormedWithControlCharacters(t *testing.T) {
+	baseDir := t.TempDir()
+
+	tables := []TableDetailInput{
+		{
+			Schema: "public",
+			Table:  "blobs",
+			Sample: &discovery.SampleResult{
+				Columns: []string{"id", "payload"},
+				Rows: [][]string{
+					{"1", "a\x00b"},
+					{"2", "bell\x07 tab\tok <&>"},
+					{"3", "bad\xffbyte"},
+				},
+			},
+		},
+	}
+
+	opts := Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+	}
+
+	if err := GenerateTableDetails(tables, opts); err != nil {
+		t.Fatalf("GenerateTableDetails() error = %v", err)
+	}
+
+	samplePath := filepath.Join(baseDir, "context", "connections", "my-db", "databases", "analytics", "schemas", "public", "blobs", "blobs__sample.xml")
+	data, err := os.ReadFile(samplePath)
+	if err != nil {
+		t.Fatalf("read sample file: %v", err)
+	}
+
+	var sample SampleXML
+	if err := xml.Unmarshal(data, &sample); err != nil {
+		t.Fatalf("sample XML is not well-formed: %v\n%s", err, string(data))
+	}
+
+	want := []string{`a\u0000b`, "bell\\u0007 tab\tok <&>", `bad\xFFbyte`}
+	if len(sample.Rows) != len(want) {
+		t.Fatalf("row count = %d, want %d", len(sample.Rows), len(want))
+	}
+	for i, row := range sample.Rows {
+		if got := row.Fields[1].Value; got != want[i] {
+			t.Fatalf("row %d payload = %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestGenerateTableDetails_SampleXMLEncoding(t *testing.T) {
+	tables := []TableDetailInput{
+		{
+			Schema: "public",
+			Table:  "cities",
+			Sample: &discovery.SampleResult{
+				Columns: []string{"name"},
+				Rows:    [][]string{{"Zürich"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		encoding XMLEncoding
+		prefix   []byte
+		decode   func([]byte) string
+	}{
+		{encoding: "", prefix: []byte("<?xml"), decode: func(data []byte) string { return string(data) }},
+		{encoding: XMLEncodingUTF8BOM, prefix: []byte{0xEF, 0xBB, 0xBF, '<'}, decode: func(data []byte) string { return string(data[3:]) }},
+		{encoding: XMLEncodingUTF16LE, prefix: []byte{0xFF, 0xFE, '<', 0}, decode: func(data []byte) string {
+			units := make([]uint16, 0, len(data)/2)
+			for i := 2; i+1 < len(data); i += 2 {
+				units = append(units, binary.LittleEndian.Uint16(data[i:]))
+			}
+			return string(utf16.Decode(units))
+		}},
+	}
+	for _, tt := range tests {
+		baseDir := t.TempDir()
+		opts := Options{
+			ConnectionName: "my-db",
+			DatabaseName:   "analytics",
+			DatabaseType:   "postgres",
+			BaseDir:        baseDir,
+			XMLEncoding:    tt.encoding,
+		}
+		if err := GenerateTableDetails(tables, opts); err != nil {
+			t.Fatalf("GenerateTableDetails(%q) error = %v", tt.encoding, err)
+		}
+
+		samplePath := filepath.Join(baseDir, "context", "connections", "my-db", "databases", "analytics", "schemas", "public", "cities", "cities__sample.xml")
+		data, err := os.ReadFile(samplePath)
+		if err != nil {
+			t.Fatalf("read sample file: %v", err)
+		}
+		if !bytes.HasPrefix(data, tt.prefix) {
+			t.Fatalf("%q sample starts with % x, want % x", tt.encoding, data[:len(tt.prefix)], tt.prefix)
+		}
+		text := tt.decode(data)
+		wantHeader := xml.Header
+		if tt.encoding == XMLEncodingUTF16LE {
+			wantHeader = `<?xml version="1.0" encoding="UTF-16"?>` + "\n"
+		}
+		if !strings.HasPrefix(text, wantHeader) || !strings.Contains(text, ">Zürich</field>") {
+			t.Fatalf("%q sample decodes to:\n%s", tt.encoding, text)
+		}
+	}
+}
+
+func TestSanitizeXMLText(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "plain", want: "plain"},
+		{input: "tab\tnewline\ncr\r", want: "tab\tnewline\ncr\r"},
+		{input: "nul\x00", want: `nul\u0000`},
+		{input: "esc\x1b", want: `esc\u001B`},
+		{input: "\ufffe", want: `\uFFFE`},
+		{input: "replacement \ufffd kept", want: "replacement \ufffd kept"},
+		{input: "\xc3\x28", want: `\xC3(`},
+	}
+
+	for _, tt := range tests {
+		if got := sanitizeXMLText(tt.input); got != tt.want {
+			t.Fatalf("sanitizeXMLText(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateTableDetails_TruncatesLongSampleCells(t *testing.T) {
+	baseDir := t.TempDir()
+
+	longValue := strings.Repeat("é", 40)
+	tables := []TableDetailInput{
+		{
+			Schema: "public",
+			Table:  "docs",
+			Sample: &discovery.SampleResult{
+				Columns: []string{"id", "body"},
+				Rows:    [][]string{{"1", longValue}},
+			},
+		},
+	}
+
+	opts := Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+		MaxCellLength:  10,
+	}
+
+	if err := GenerateTableDetails(tables, opts); err != nil {
+		t.Fatalf("GenerateTableDetails() error = %v", err)
+	}
+
+	samplePath := filepath.Join(baseDir, "context", "connections", "my-db", "databases", "analytics", "schemas", "public", "docs", "docs__sample.xml")
+	data, err := os.ReadFile(samplePath)
+	if err != nil {
+		t.Fatalf("read sample file: %v", err)
+	}
+
+	var sample SampleXML
+	if err := xml.Unmarshal(data, &sample); err != nil {
+		t.Fatalf("unmarshal sample XML: %v", err)
+	}
+
+	id, body := sample.Rows[0].Fields[0], sample.Rows[0].Fields[1]
+	if id.Truncated || id.Value != "1" {
+		t.Fatalf("id field = %+v, want untruncated 1", id)
+	}
+	if want := strings.Repeat("é", 7) + "..."; body.Value != want {
+		t.Fatalf("body value = %q, want %q", body.Value, want)
+	}
+	if !body.Truncated {
+		t.Fatalf("body field Truncated = false, want true")
+	}
+	if strings.Contains(string(data), `name="id" truncated`) {
+		t.Fatalf("untruncated field should omit the truncated attr, got:\n%s", string(data))
+	}
+}
+
+func TestQualityFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		column discovery.EnrichedColumnInfo
+		want   []QualityFlag
+	}{
+		{
+			name:   "empty table",
+			column: discovery.EnrichedColumnInfo{TotalRows: 0},
+			want:   nil,
+		},
+		{
+			name:   "all null",
+			column: discovery.EnrichedColumnInfo{TotalRows: 10, NullCount: 10, NullOfTotalRowsPct: 100},
+			want:   []QualityFlag{QualityAllNull},
+		},
+		{
+			name:   "constant",
+			column: discovery.EnrichedColumnInfo{TotalRows: 10, NonNullCount: 10, DistinctNonNullCount: 1},
+			want:   []QualityFlag{QualityConstant},
+		},
+		{
+			name:   "all distinct",
+			column: discovery.EnrichedColumnInfo{TotalRows: 10, NonNullCount: 10, DistinctNonNullCount: 10},
+			want:   []QualityFlag{QualityAllDistinct},
+		},
+		{
+			name:   "high null constant",
+			column: discovery.EnrichedColumnInfo{TotalRows: 100, NullCount: 95, NonNullCount: 5, DistinctNonNullCount: 1, NullOfTotalRowsPct: 95},
+			want:   []QualityFlag{QualityConstant, QualityHighNull},
+		},
+		{
+			name:   "single non-null row",
+			column: discovery.EnrichedColumnInfo{TotalRows: 2, NullCount: 1, NonNullCount: 1, DistinctNonNullCount: 1, NullOfTotalRowsPct: 50},
+			want:   nil,
+		},
+		{
+			name:   "ordinary",
+			column: discovery.EnrichedColumnInfo{TotalRows: 100, NullCount: 10, NonNullCount: 90, DistinctNonNullCount: 40, NullOfTotalRowsPct: 10},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := QualityFlags(tt.column, DefaultHighNullPct)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("QualityFlags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteQualityFile(t *testing.T) {
+	baseDir := t.TempDir()
+	opts := Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+	}
+	items := []QualityFileItem{
+		{Schema: "public", Table: "users", Column: "legacy_flag", Flags: []QualityFlag{QualityAllNull}, TotalRows: 10, NullOfTotalRowsPct: 100},
+	}
+
+	path, err := WriteQualityFile(items, 80, opts)
+	if err != nil {
+		t.Fatalf("WriteQualityFile() error = %v", err)
+	}
+	wantPath := filepath.Join(baseDir, "context", "connections", "my-db", "databases", "analytics", "_quality.yml")
+	if path != wantPath {
+		t.Fatalf("path = %q, want %q", path, wantPath)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read quality file: %v", err)
+	}
+	var file QualityFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		t.Fatalf("parse quality file: %v", err)
+	}
+	if file.Database != "analytics" || file.HighNullPct != 80 {
+		t.Fatalf("file header fields = %+v", file)
+	}
+	if !reflect.DeepEqual(file.Columns, items) {
+		t.Fatalf("columns = %+v, want %+v", file.Columns, items)
+	}
+}
+
+func TestWriteSchemaStatsFile_MergesTablesAcrossRuns(t *testing.T) {
+	baseDir := t.TempDir()
+	opts := Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+	}
+
+	users := NewSchemaStatsTable("users", "", []discovery.EnrichedColumnInfo{
+		{Name: "id", TotalRows: 100},
+		{Name: "email", TotalRows: 100},
+	})
+	if _, err := WriteSchemaStatsFile("public", []SchemaStatsTable{users}, opts); err != nil {
+		t.Fatalf("WriteSchemaStatsFile() error = %v", err)
+	}
+
+	orders := NewSchemaStatsTable("orders", "", []discovery.EnrichedColumnInfo{
+		{Name: "id", TotalRows: 40},
+	})
+	usersAgain := users
+	usersAgain.TotalRows = 120
+	path, err := WriteSchemaStatsFile("public", []SchemaStatsTable{orders, usersAgain}, opts)
+	if err != nil {
+		t.Fatalf("WriteSchemaStatsFile() error = %v", err)
+	}
+	wantPath := filepath.Join(baseDir, "context", "connections", "my-db", "databases", "analytics", "schemas", "public", "_schema_stats.yml")
+	if path != wantPath {
+		t.Fatalf("path = %q, want %q", path, wantPath)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read schema stats file: %v", err)
+	}
+	var file SchemaStatsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		t.Fatalf("parse schema stats file: %v", err)
+	}
+	if file.Schema != "public" || file.TableCount != 2 || file.ColumnsProfiled != 3 || file.TotalRows != 160 {
+		t.Fatalf("roll-up = %+v, want 2 tables, 3 columns, 160 rows", file)
+	}
+	if len(file.Tables) != 2 || file.Tables[0].Table != "orders" || file.Tables[1].Table != "users" {
+		t.Fatalf("tables = %+v, want orders then users", file.Tables)
+	}
+}
+
+func TestWriteSchemaCounts_WritesCountsAndKeepsDescriptions(t *testing.T) {
+	baseDir := t.TempDir()
+	opts := Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+	}
+	schemasPath := filepath.Join(baseDir, "context", "connections", "my-db", "databases", "analytics", "schemas", "_schemas.yml")
+	if err := os.MkdirAll(filepath.Dir(schemasPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	existing := "schemas:\n  - name: public\n    ai_description: Core tables\n    tables:\n      - name: users\n"
+	if err := os.WriteFile(schemasPath, []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	schemas := []discovery.SchemaInfo{
+		{Name: "sales", Tables: []discovery.TableInfo{{Name: "orders", TableType: "BASE TABLE"}}},
+		{Name: "public", Tables: []discovery.TableInfo{
+			{Name: "users", TableType: "BASE TABLE"},
+			{Name: "accounts", TableType: "BASE TABLE"},
+			{Name: "active_users", TableType: "VIEW"},
+		}},
+	}
+	path, err := WriteSchemaCounts(schemas, opts)
+	if err != nil {
+		t.Fatalf("WriteSchemaCounts() error = %v", err)
+	}
+	if path != schemasPath {
+		t.Fatalf("path = %q, want %q", path, schemasPath)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sf SchemasFile
+	if err := yaml.Unmarshal(data, &sf); err != nil {
+		t.Fatalf("parse _schemas.yml: %v", err)
+	}
+	if len(sf.Schemas) != 2 || sf.Schemas[0].Name != "public" || sf.Schemas[1].Name != "sales" {
+		t.Fatalf("schemas = %+v, want public and sales", sf.Schemas)
+	}
+	public := sf.Schemas[0]
+	if public.TableCount != 2 || public.ViewCount != 1 {
+		t.Fatalf("public counts = %d tables, %d views, want 2 and 1", public.TableCount, public.ViewCount)
+	}
+	if public.AIDescription != "Core tables" {
+		t.Fatalf("public ai_description = %q, want it kept", public.AIDescription)
+	}
+	if strings.Contains(string(data), "tables:") {
+		t.Fatalf("_schemas.yml lists tables:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), "sales", "_tables.yml")); !os.IsNotExist(err) {
+		t.Fatalf("_tables.yml written for sales: %v", err)
+	}
+}
+
+func TestGenerate_CollapsesSchemaLevelForSingleLevelBackends(t *testing.T) {
+	baseDir := t.TempDir()
+	opts := Options{
+		ConnectionName: "shop-db",
+		DatabaseName:   "shop",
+		DatabaseType:   "mysql",
+		BaseDir:        baseDir,
+	}
+	schemas := []discovery.SchemaInfo{
+		{Name: "shop", Tables: []discovery.TableInfo{{Name: "orders", TableType: "BASE TABLE"}}},
+	}
+
+	if err := Generate(schemas, opts); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	tables := []TableDetailInput{{
+		Schema:  "shop",
+		Table:   "orders",
+		Columns: []discovery.ColumnInfo{{Name: "id", DataType: "int", IsNullable: "NO", OrdinalPosition: 1}},
+	}}
+	if err := GenerateTableDetails(tables, opts); err != nil {
+		t.Fatalf("GenerateTableDetails() error = %v", err)
+	}
+
+	databaseDir := filepath.Join(baseDir, "context", "connections", "shop-db", "databases", "shop")
+	for _, name := range []string{"_schemas.yml", "_tables.yml", filepath.Join("orders", "orders__columns.yml")} {
+		if _, err := os.Stat(filepath.Join(databaseDir, name)); err != nil {
+			t.Fatalf("expected %s in the database directory: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(databaseDir, "schemas")); !os.IsNotExist(err) {
+		t.Fatalf("schemas directory was created for a mysql connection: %v", err)
+	}
+
+	dir, err := SchemaDir(opts, "shop")
+	if err != nil {
+		t.Fatalf("SchemaDir() error = %v", err)
+	}
+	if dir != databaseDir {
+		t.Fatalf("SchemaDir() = %q, want %q", dir, databaseDir)
+	}
+
+	dbContext, err := LoadDatabaseContext(opts)
+	if err != nil {
+		t.Fatalf("LoadDatabaseContext() error = %v", err)
+	}
+	if len(dbContext.Schemas) != 1 || len(dbContext.Schemas[0].Tables) != 1 || len(dbContext.Schemas[0].Tables[0].Columns) != 1 {
+		t.Fatalf("loaded context = %+v, want shop.orders with one column", dbContext.Schemas)
+	}
+}
@@ -0,0 +1,133 @@
+package contextgen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// EnrichFailuresFileName is the file in the database directory listing the
+// tables dbh columns could not profile, for dbh columns --retry-failed.
+const EnrichFailuresFileName = "_enrich_failures.json"
+
+// EnrichFailures lists the tables of one database whose last dbh columns
+// attempt did not write a columns file.
+type EnrichFailures struct {
+	Connection string          `json:"connection"`
+	Database   string          `json:"database"`
+	UpdatedAt  string          `json:"updated_at"`
+	Tables     []EnrichFailure `json:"tables"`
+}
+
+// EnrichFailure is one table in EnrichFailures and why it was skipped.
+type EnrichFailure struct {
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+	Reason string `json:"reason"`
+}
+
+// LoadEnrichFailures reads the failed tables of the database in opts. It
+// returns an error wrapping os.ErrNotExist when none are recorded.
+func LoadEnrichFailures(opts Options) (*EnrichFailures, error) {
+	path, err := databaseFilePath(opts, EnrichFailuresFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	var failures EnrichFailures
+	if err := json.Unmarshal(data, &failures); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &failures, nil
+}
+
+// UpdateEnrichFailures records the outcome of a run: tables in failed are
+// added or have their reason replaced, and tables in succeeded are removed.
+// Other recorded tables are kept. The file is deleted once no failures
+// remain.
+func UpdateEnrichFailures(opts Options, failed []EnrichFailure, succeeded []EnrichFailure) error {
+	path, err := databaseFilePath(opts, EnrichFailuresFileName)
+	if err != nil {
+		return err
+	}
+
+	byTable := make(map[string]EnrichFailure)
+	existing, err := LoadEnrichFailures(opts)
+	switch {
+	case err == nil:
+		for _, failure := range existing.Tables {
+			byTable[enrichStateKey(failure.Schema, failure.Table)] = failure
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+	for _, table := range succeeded {
+		delete(byTable, enrichStateKey(table.Schema, table.Table))
+	}
+	for _, failure := range failed {
+		byTable[enrichStateKey(failure.Schema, failure.Table)] = failure
+	}
+
+	if len(byTable) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove %s: %w", EnrichFailuresFileName, err)
+		}
+		return nil
+	}
+
+	database, err := resolveGenerationDatabase(opts)
+	if err != nil {
+		return err
+	}
+	failures := EnrichFailures{
+		Connection: opts.ConnectionName,
+		Database:   database,
+		UpdatedAt:  time.Now().UTC().Format(time.RFC3339),
+		Tables:     make([]EnrichFailure, 0, len(byTable)),
+	}
+	for _, failure := range byTable {
+		failures.Tables = append(failures.Tables, failure)
+	}
+	sort.Slice(failures.Tables, func(i, j int) bool {
+		a, b := failures.Tables[i], failures.Tables[j]
+		if a.Schema != b.Schema {
+			return a.Schema < b.Schema
+		}
+		return a.Table < b.Table
+	})
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create database dir: %w", err)
+	}
+	if err := writeJSONAtomic(path, failures); err != nil {
+		return fmt.Errorf("write %s: %w", EnrichFailuresFileName, err)
+	}
+	return nil
+}
+
+// databaseFilePath returns the path of a file in the database directory of
+// opts.
+func databaseFilePath(opts Options, name string) (string, error) {
+	database, err := resolveGenerationDatabase(opts)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(
+		opts.BaseDir,
+		"context",
+		"connections",
+		opts.ConnectionName,
+		"databases",
+		sanitizeName(database),
+		name,
+	), nil
+}
@@ -0,0 +1,243 @@
+package contextgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+)
+
+// EnrichStateFileName is the dbh columns checkpoint file kept in the
+// database directory while a run is in progress.
+const EnrichStateFileName = "_enrich_state.json"
+
+// EnrichState records dbh columns progress for one database: the tables
+// whose columns files were written and the column profiles collected so far
+// for tables that are not finished yet. It is saved after every column so an
+// interrupted run can resume mid-table.
+type EnrichState struct {
+	Connection      string                               `json:"connection"`
+	Database        string                               `json:"database"`
+	Scope           string                               `json:"scope,omitempty"`
+	UpdatedAt       string                               `json:"updated_at"`
+	CompletedTables []string                             `json:"completed_tables"`
+	PartialTables   map[string][]EnrichedColumnsFileItem `json:"partial_tables,omitempty"`
+}
+
+// NewEnrichState returns an empty state for the database in opts. scope is
+// the row filter of the run (see EnrichedColumnsInput.Scope); a state is
+// only resumed by a run with the same scope.
+func NewEnrichState(opts Options, scope string) (*EnrichState, error) {
+	database, err := resolveGenerationDatabase(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &EnrichState{
+		Connection: opts.ConnectionName,
+		Database:   database,
+		Scope:      scope,
+	}, nil
+}
+
+// LoadEnrichState reads the checkpoint for the database in opts. It returns
+// an error wrapping os.ErrNotExist when there is none.
+func LoadEnrichState(opts Options) (*EnrichState, error) {
+	path, err := enrichStateFilePath(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	var state EnrichState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// Save writes the checkpoint atomically.
+func (s *EnrichState) Save(opts Options) error {
+	path, err := enrichStateFilePath(opts)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create database dir: %w", err)
+	}
+
+	s.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if s.CompletedTables == nil {
+		s.CompletedTables = []string{}
+	}
+	if err := writeJSONAtomic(path, s); err != nil {
+		return fmt.Errorf("write %s: %w", EnrichStateFileName, err)
+	}
+	return nil
+}
+
+// RemoveEnrichState deletes the checkpoint for the database in opts. A
+// missing file is not an error.
+func RemoveEnrichState(opts Options) error {
+	path, err := enrichStateFilePath(opts)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove %s: %w", EnrichStateFileName, err)
+	}
+	return nil
+}
+
+// TableComplete reports whether the table's columns file was written.
+func (s *EnrichState) TableComplete(schema, table string) bool {
+	key := enrichStateKey(schema, table)
+	for _, completed := range s.CompletedTables {
+		if completed == key {
+			return true
+		}
+	}
+	return false
+}
+
+// PartialColumns returns the column profiles already collected for an
+// unfinished table, keyed by column name.
+func (s *EnrichState) PartialColumns(schema, table string) map[string]discovery.EnrichedColumnInfo {
+	items := s.PartialTables[enrichStateKey(schema, table)]
+	if len(items) == 0 {
+		return nil
+	}
+	columns := make(map[string]discovery.EnrichedColumnInfo, len(items))
+	for _, item := range items {
+		columns[item.Name] = enrichedColumnInfoFromItem(item)
+	}
+	return columns
+}
+
+// AddColumn records a profiled column of an unfinished table.
+func (s *EnrichState) AddColumn(schema, table string, column discovery.EnrichedColumnInfo) {
+	if s.PartialTables == nil {
+		s.PartialTables = make(map[string][]EnrichedColumnsFileItem)
+	}
+	key := enrichStateKey(schema, table)
+	s.PartialTables[key] = append(s.PartialTables[key], enrichedColumnsFileItem(column))
+}
+
+// CompleteTable marks a table as written and drops its partial profiles.
+func (s *EnrichState) CompleteTable(schema, table string) {
+	key := enrichStateKey(schema, table)
+	delete(s.PartialTables, key)
+	if !s.TableComplete(schema, table) {
+		s.CompletedTables = append(s.CompletedTables, key)
+		sort.Strings(s.CompletedTables)
+	}
+}
+
+func enrichStateKey(schema, table string) string {
+	return schema + "." + table
+}
+
+func enrichStateFilePath(opts Options) (string, error) {
+	database, err := resolveGenerationDatabase(opts)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(
+		opts.BaseDir,
+		"context",
+		"connections",
+		opts.ConnectionName,
+		"databases",
+		sanitizeName(database),
+		EnrichStateFileName,
+	), nil
+}
+
+func enrichedColumnsFileItem(column discovery.EnrichedColumnInfo) EnrichedColumnsFileItem {
+	return EnrichedColumnsFileItem{
+		Name:                  column.Name,
+		DataType:              column.DataType,
+		IsNullable:            column.IsNullable,
+		OrdinalPosition:       column.OrdinalPosition,
+		ColumnDefault:         column.ColumnDefault,
+		IsGenerated:           column.IsGenerated,
+		AIDescription:         column.AIDescription,
+		DBDescription:         column.DBDescription,
+		TotalRows:             column.TotalRows,
+		NullCount:             column.NullCount,
+		NonNullCount:          column.NonNullCount,
+		DistinctNonNullCount:  column.DistinctNonNullCount,
+		DistinctOfNonNullPct:  column.DistinctOfNonNullPct,
+		NullOfTotalRowsPct:    column.NullOfTotalRowsPct,
+		NonNullOfTotalRowsPct: column.NonNullOfTotalRowsPct,
+		SampleValues:          column.SampleValues,
+		Elements:              enrichedColumnElements(column.Elements),
+		Percentiles:           enrichedColumnPercentiles(column.Percentiles),
+	}
+}
+
+func enrichedColumnInfoFromItem(item EnrichedColumnsFileItem) discovery.EnrichedColumnInfo {
+	return discovery.EnrichedColumnInfo{
+		Name:                  item.Name,
+		DataType:              item.DataType,
+		IsNullable:            item.IsNullable,
+		OrdinalPosition:       item.OrdinalPosition,
+		ColumnDefault:         item.ColumnDefault,
+		IsGenerated:           item.IsGenerated,
+		AIDescription:         item.AIDescription,
+		DBDescription:         item.DBDescription,
+		TotalRows:             item.TotalRows,
+		NullCount:             item.NullCount,
+		NonNullCount:          item.NonNullCount,
+		DistinctNonNullCount:  item.DistinctNonNullCount,
+		DistinctOfNonNullPct:  item.DistinctOfNonNullPct,
+		NullOfTotalRowsPct:    item.NullOfTotalRowsPct,
+		NonNullOfTotalRowsPct: item.NonNullOfTotalRowsPct,
+		SampleValues:          item.SampleValues,
+		Elements:              arrayElementStats(item.Elements),
+		Percentiles:           columnPercentiles(item.Percentiles),
+	}
+}
+
+func enrichedColumnElements(stats *discovery.ArrayElementStats) *EnrichedColumnElements {
+	if stats == nil {
+		return nil
+	}
+	return &EnrichedColumnElements{
+		ElementCount:         stats.ElementCount,
+		DistinctElementCount: stats.DistinctElementCount,
+		SampleElements:       stats.SampleElements,
+	}
+}
+
+func arrayElementStats(elements *EnrichedColumnElements) *discovery.ArrayElementStats {
+	if elements == nil {
+		return nil
+	}
+	return &discovery.ArrayElementStats{
+		ElementCount:         elements.ElementCount,
+		DistinctElementCount: elements.DistinctElementCount,
+		SampleElements:       elements.SampleElements,
+	}
+}
+
+func enrichedColumnPercentiles(percentiles *discovery.ColumnPercentiles) *EnrichedColumnPercentiles {
+	if percentiles == nil {
+		return nil
+	}
+	return &EnrichedColumnPercentiles{P50: percentiles.P50, P95: percentiles.P95, P99: percentiles.P99}
+}
+
+func columnPercentiles(percentiles *EnrichedColumnPercentiles) *discovery.ColumnPercentiles {
+	if percentiles == nil {
+		return nil
+	}
+	return &discovery.ColumnPercentiles{P50: percentiles.P50, P95: percentiles.P95, P99: percentiles.P99}
+}
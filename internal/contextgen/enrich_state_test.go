@@ -0,0 +1,109 @@
+package contextgen
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+)
+
+func TestEnrichStateRoundTrip(t *testing.T) {
+	opts := Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        t.TempDir(),
+	}
+
+	state, err := NewEnrichState(opts, "created_at > '2024-01-01'")
+	if err != nil {
+		t.Fatalf("NewEnrichState() error = %v", err)
+	}
+	profile := discovery.EnrichedColumnInfo{
+		Name:                 "id",
+		DataType:             "integer",
+		OrdinalPosition:      1,
+		TotalRows:            3,
+		NonNullCount:         3,
+		DistinctNonNullCount: 3,
+		SampleValues:         []string{"1", "2"},
+	}
+	state.AddColumn("public", "events", profile)
+	state.AddColumn("public", "users", discovery.EnrichedColumnInfo{Name: "id"})
+	state.CompleteTable("public", "users")
+	if err := state.Save(opts); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadEnrichState(opts)
+	if err != nil {
+		t.Fatalf("LoadEnrichState() error = %v", err)
+	}
+	if loaded.Scope != state.Scope || loaded.Database != "analytics" {
+		t.Fatalf("loaded state = %+v", loaded)
+	}
+	if !loaded.TableComplete("public", "users") || loaded.TableComplete("public", "events") {
+		t.Fatalf("completed tables = %v, want only public.users", loaded.CompletedTables)
+	}
+	if got := loaded.PartialColumns("public", "users"); got != nil {
+		t.Fatalf("completed table kept partial columns: %+v", got)
+	}
+	got := loaded.PartialColumns("public", "events")
+	if !reflect.DeepEqual(got, map[string]discovery.EnrichedColumnInfo{"id": profile}) {
+		t.Fatalf("partial columns = %+v, want id profile", got)
+	}
+
+	if err := RemoveEnrichState(opts); err != nil {
+		t.Fatalf("RemoveEnrichState() error = %v", err)
+	}
+	if _, err := LoadEnrichState(opts); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("LoadEnrichState() after remove error = %v, want os.ErrNotExist", err)
+	}
+	if err := RemoveEnrichState(opts); err != nil {
+		t.Fatalf("RemoveEnrichState() on missing file error = %v", err)
+	}
+}
+
+func TestUpdateEnrichFailures(t *testing.T) {
+	opts := Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        t.TempDir(),
+	}
+
+	if _, err := LoadEnrichFailures(opts); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("LoadEnrichFailures() before any run error = %v, want os.ErrNotExist", err)
+	}
+
+	first := []EnrichFailure{
+		{Schema: "public", Table: "users", Reason: "timeout"},
+		{Schema: "public", Table: "events", Reason: "did not fit the 10m0s budget"},
+	}
+	if err := UpdateEnrichFailures(opts, first, nil); err != nil {
+		t.Fatalf("UpdateEnrichFailures() error = %v", err)
+	}
+
+	second := []EnrichFailure{{Schema: "public", Table: "users", Reason: "permission denied"}}
+	succeeded := []EnrichFailure{{Schema: "public", Table: "events"}}
+	if err := UpdateEnrichFailures(opts, second, succeeded); err != nil {
+		t.Fatalf("UpdateEnrichFailures() error = %v", err)
+	}
+
+	loaded, err := LoadEnrichFailures(opts)
+	if err != nil {
+		t.Fatalf("LoadEnrichFailures() error = %v", err)
+	}
+	if loaded.Database != "analytics" || !reflect.DeepEqual(loaded.Tables, second) {
+		t.Fatalf("loaded failures = %+v, want %+v", loaded, second)
+	}
+
+	if err := UpdateEnrichFailures(opts, nil, second); err != nil {
+		t.Fatalf("UpdateEnrichFailures() error = %v", err)
+	}
+	if _, err := LoadEnrichFailures(opts); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("LoadEnrichFailures() after clearing error = %v, want os.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,52 @@
+package contextgen
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMaxFilesExceeded is returned by Generate and GenerateTableDetails when
+// writing their files would go over Options.FileLimit.
+var ErrMaxFilesExceeded = errors.New("file limit exceeded")
+
+// FileLimit caps the number of files written by the Generate and
+// GenerateTableDetails calls that share it, so one limit can span a whole
+// run. A nil FileLimit or a Max of zero allows any number of files.
+type FileLimit struct {
+	Max int
+
+	written  int
+	exceeded bool
+}
+
+// NewFileLimit returns a limit of max files. Zero disables the limit.
+func NewFileLimit(max int) *FileLimit {
+	return &FileLimit{Max: max}
+}
+
+// Written returns the number of files counted against the limit so far.
+func (l *FileLimit) Written() int {
+	if l == nil {
+		return 0
+	}
+	return l.written
+}
+
+// Exceeded reports whether a write was refused because of the limit.
+func (l *FileLimit) Exceeded() bool {
+	return l != nil && l.exceeded
+}
+
+// reserve counts n files about to be written, or returns an error wrapping
+// ErrMaxFilesExceeded, without counting them, when they do not fit.
+func (l *FileLimit) reserve(n int) error {
+	if l == nil || l.Max <= 0 {
+		return nil
+	}
+	if l.written+n > l.Max {
+		l.exceeded = true
+		return fmt.Errorf("%w: %d more file(s) would go over --max-files %d (%d already written)", ErrMaxFilesExceeded, n, l.Max, l.written)
+	}
+	l.written += n
+	return nil
+}
@@ -0,0 +1,90 @@
+package contextgen
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+)
+
+func TestGenerate_MaxFilesAbortsBeforeWriting(t *testing.T) {
+	baseDir := t.TempDir()
+
+	schemas := []discovery.SchemaInfo{
+		{Name: "public", Tables: []discovery.TableInfo{{Name: "users", TableType: "BASE TABLE"}}},
+		{Name: "sales", Tables: []discovery.TableInfo{{Name: "orders", TableType: "BASE TABLE"}}},
+	}
+	limit := NewFileLimit(3)
+	opts := Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+		FileLimit:      limit,
+	}
+
+	err := Generate(schemas, opts)
+	if !errors.Is(err, ErrMaxFilesExceeded) {
+		t.Fatalf("Generate() error = %v, want ErrMaxFilesExceeded", err)
+	}
+	if !limit.Exceeded() || limit.Written() != 0 {
+		t.Fatalf("limit exceeded = %v, written = %d; want true, 0", limit.Exceeded(), limit.Written())
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, "context")); !os.IsNotExist(err) {
+		t.Fatalf("expected no context directory, stat error = %v", err)
+	}
+
+	opts.FileLimit = NewFileLimit(4)
+	if err := Generate(schemas, opts); err != nil {
+		t.Fatalf("Generate() with room for every file error = %v", err)
+	}
+}
+
+func TestGenerateTableDetails_MaxFilesStopsBetweenTables(t *testing.T) {
+	baseDir := t.TempDir()
+
+	table := func(name string) TableDetailInput {
+		return TableDetailInput{
+			Schema:  "public",
+			Table:   name,
+			Columns: []discovery.ColumnInfo{{Name: "id", DataType: "integer", IsNullable: "NO", OrdinalPosition: 1}},
+			Sample:  &discovery.SampleResult{Columns: []string{"id"}, Rows: [][]string{{"1"}}},
+		}
+	}
+	limit := NewFileLimit(3)
+	opts := Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+		FileLimit:      limit,
+	}
+
+	err := GenerateTableDetails([]TableDetailInput{table("users"), table("orders")}, opts)
+	if !errors.Is(err, ErrMaxFilesExceeded) {
+		t.Fatalf("GenerateTableDetails() error = %v, want ErrMaxFilesExceeded", err)
+	}
+	if limit.Written() != 2 {
+		t.Fatalf("written = %d, want 2", limit.Written())
+	}
+
+	schemaDir := filepath.Join(baseDir, "context", "connections", "my-db", "databases", "analytics", "schemas", "public")
+	if _, err := os.Stat(filepath.Join(schemaDir, "users", "users__columns.yml")); err != nil {
+		t.Fatalf("expected the first table's files to be kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(schemaDir, "orders")); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing written for the second table, stat error = %v", err)
+	}
+}
+
+func TestFileLimit_NilAndZeroAllowEverything(t *testing.T) {
+	var nilLimit *FileLimit
+	if err := nilLimit.reserve(1000); err != nil || nilLimit.Exceeded() {
+		t.Fatalf("nil limit: err = %v, exceeded = %v", err, nilLimit.Exceeded())
+	}
+	if err := NewFileLimit(0).reserve(1000); err != nil {
+		t.Fatalf("zero limit: err = %v", err)
+	}
+}
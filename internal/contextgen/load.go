@@ -0,0 +1,143 @@
+package contextgen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DatabaseContext is the generated context for one database, read back from
+// the .dbharness/context tree.
+type DatabaseContext struct {
+	Connection   string
+	Database     string
+	DatabaseType string
+	GeneratedAt  string
+	Schemas      []SchemaContext
+}
+
+// SchemaContext is one schema in a DatabaseContext.
+type SchemaContext struct {
+	Name          string
+	AIDescription string
+	DBDescription string
+	Tables        []TableContext
+}
+
+// TableContext is one table or view in a SchemaContext. Columns is empty
+// when no <table>__columns.yml file exists; Enriched reports whether every
+// column carries the dbh columns profiling stats.
+type TableContext struct {
+	Name          string
+	Type          string
+	AIDescription string
+	DBDescription string
+	Columns       []EnrichedColumnsFileItem
+	Enriched      bool
+}
+
+// LoadDatabaseContext reads _schemas.yml and every per-table columns file for
+// opts.DatabaseName. It returns an error wrapping os.ErrNotExist when the
+// database has no generated _schemas.yml.
+func LoadDatabaseContext(opts Options) (*DatabaseContext, error) {
+	defaultDatabase, err := resolveGenerationDatabase(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	schemasPath := filepath.Join(schemasDirPath(opts, defaultDatabase), "_schemas.yml")
+	data, err := os.ReadFile(schemasPath)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", schemasPath, err)
+	}
+
+	var sf SchemasFile
+	if err := yaml.Unmarshal(data, &sf); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", schemasPath, err)
+	}
+
+	dbContext := &DatabaseContext{
+		Connection:   opts.ConnectionName,
+		Database:     defaultDatabase,
+		DatabaseType: sf.DatabaseType,
+		GeneratedAt:  sf.GeneratedAt,
+	}
+	if dbContext.DatabaseType == "" {
+		dbContext.DatabaseType = opts.DatabaseType
+	}
+
+	for _, schema := range sf.Schemas {
+		schemaContext := SchemaContext{
+			Name:          schema.Name,
+			AIDescription: schema.AIDescription,
+			DBDescription: schema.DBDescription,
+		}
+		for _, table := range schema.Tables {
+			tableContext := TableContext{
+				Name:          table.Name,
+				Type:          table.Type,
+				AIDescription: table.AIDescription,
+				DBDescription: table.DBDescription,
+			}
+
+			colPath := enrichedColumnsFilePath(opts, defaultDatabase, schema.Name, table.Name)
+			columns, enriched, err := readColumnsFile(colPath)
+			if err != nil {
+				return nil, err
+			}
+			tableContext.Columns = columns
+			tableContext.Enriched = enriched
+
+			schemaContext.Tables = append(schemaContext.Tables, tableContext)
+		}
+		dbContext.Schemas = append(dbContext.Schemas, schemaContext)
+	}
+
+	return dbContext, nil
+}
+
+// readColumnsFile parses a basic or enriched <table>__columns.yml file. A
+// missing file yields no columns and no error.
+func readColumnsFile(path string) ([]EnrichedColumnsFileItem, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("read columns file %s: %w", path, err)
+	}
+
+	var file EnrichedColumnsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, false, fmt.Errorf("parse columns file %s: %w", path, err)
+	}
+
+	return file.Columns, columnsHaveStats(data), nil
+}
+
+// columnsHaveStats reports whether every column entry in a columns YAML
+// document carries the enriched stats fields and no column of the table is
+// left unprofiled.
+func columnsHaveStats(data []byte) bool {
+	var file struct {
+		Columns           []map[string]interface{} `yaml:"columns"`
+		UnprofiledColumns []string                 `yaml:"unprofiled_columns"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return false
+	}
+	if len(file.Columns) == 0 || len(file.UnprofiledColumns) > 0 {
+		return false
+	}
+
+	for _, column := range file.Columns {
+		for _, field := range enrichedStatsFields {
+			if _, ok := column[field]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package contextgen
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+)
+
+func TestLoadDatabaseContext_ReadsSchemasTablesAndColumns(t *testing.T) {
+	baseDir := t.TempDir()
+	opts := Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+	}
+
+	schemas := []discovery.SchemaInfo{
+		{
+			Name: "public",
+			Tables: []discovery.TableInfo{
+				{Name: "users", TableType: "BASE TABLE"},
+				{Name: "orders", TableType: "BASE TABLE"},
+				{Name: "events", TableType: "VIEW"},
+			},
+		},
+	}
+	if err := Generate(schemas, opts); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if err := GenerateTableDetails([]TableDetailInput{
+		{
+			Schema: "public",
+			Table:  "orders",
+			Columns: []discovery.ColumnInfo{
+				{Name: "id", DataType: "integer", IsNullable: "NO", OrdinalPosition: 1},
+			},
+		},
+	}, opts); err != nil {
+		t.Fatalf("GenerateTableDetails() error = %v", err)
+	}
+	if _, err := WriteEnrichedColumnsFile(EnrichedColumnsInput{
+		Schema: "public",
+		Table:  "users",
+		Columns: []discovery.EnrichedColumnInfo{
+			{Name: "id", DataType: "integer", IsNullable: "NO", OrdinalPosition: 1, TotalRows: 10, NonNullCount: 10, DistinctNonNullCount: 10},
+		},
+	}, opts); err != nil {
+		t.Fatalf("WriteEnrichedColumnsFile() error = %v", err)
+	}
+
+	dbContext, err := LoadDatabaseContext(opts)
+	if err != nil {
+		t.Fatalf("LoadDatabaseContext() error = %v", err)
+	}
+
+	if dbContext.Database != "analytics" || dbContext.DatabaseType != "postgres" {
+		t.Fatalf("database = %s (%s), want analytics (postgres)", dbContext.Database, dbContext.DatabaseType)
+	}
+	if len(dbContext.Schemas) != 1 || len(dbContext.Schemas[0].Tables) != 3 {
+		t.Fatalf("schemas = %+v, want 1 schema with 3 tables", dbContext.Schemas)
+	}
+
+	tables := make(map[string]TableContext)
+	for _, table := range dbContext.Schemas[0].Tables {
+		tables[table.Name] = table
+	}
+
+	if users := tables["users"]; !users.Enriched || len(users.Columns) != 1 || users.Columns[0].TotalRows != 10 {
+		t.Fatalf("users table = %+v, want enriched with total_rows 10", users)
+	}
+	if orders := tables["orders"]; orders.Enriched || len(orders.Columns) != 1 {
+		t.Fatalf("orders table = %+v, want one non-enriched column", orders)
+	}
+	if events := tables["events"]; events.Type != "VIEW" || len(events.Columns) != 0 {
+		t.Fatalf("events table = %+v, want view without columns", events)
+	}
+}
+
+func TestLoadDatabaseContext_MissingSchemasFile(t *testing.T) {
+	_, err := LoadDatabaseContext(Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        t.TempDir(),
+	})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("LoadDatabaseContext() error = %v, want os.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,289 @@
+package contextgen
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MergedFileName is the manifest at the top of a merged view.
+const MergedFileName = "_merged.yml"
+
+// MergeSource selects generated schemas to include in a merged view: one
+// schema, or every schema in the database's _schemas.yml when Schema is
+// empty.
+type MergeSource struct {
+	Connection   string
+	Database     string
+	DatabaseType string
+	Schema       string
+}
+
+// MergedFile is the context/merged/<name>/_merged.yml manifest recording
+// where each schema in the view comes from.
+type MergedFile struct {
+	Name        string             `yaml:"name"`
+	GeneratedAt string             `yaml:"generated_at"`
+	Mode        string             `yaml:"mode"` // symlink or copy
+	Schemas     []MergedSchemaItem `yaml:"schemas"`
+}
+
+// MergedSchemaItem is one schema in a merged view.
+type MergedSchemaItem struct {
+	Connection   string `yaml:"connection"`
+	Database     string `yaml:"database"`
+	DatabaseType string `yaml:"database_type"`
+	Schema       string `yaml:"schema"`
+	Path         string `yaml:"path"`   // relative to the merged view
+	Source       string `yaml:"source"` // relative to the context directory
+}
+
+// MergedViewDir returns context/merged/<name>.
+func MergedViewDir(baseDir, name string) string {
+	return filepath.Join(baseDir, "context", "merged", name)
+}
+
+// WriteMergedView builds context/merged/<name>/ from the generated schema
+// directories of sources, laid out as <connection>/<database>/<schema>.
+// Each schema directory is a relative symlink to the generated one, or a
+// copy when copyFiles is set. An existing view with the same name is
+// replaced; any other directory in its place is left alone and an error
+// returned. Schemas listed in _schemas.yml without a generated directory
+// (dbh tables has not run for them) are skipped and returned as
+// "connection/database/schema".
+func WriteMergedView(baseDir, name string, sources []MergeSource, copyFiles bool) (string, *MergedFile, []string, error) {
+	if !validMergedViewName(name) {
+		return "", nil, nil, fmt.Errorf("invalid merged view name %q: use letters, digits, - and _", name)
+	}
+
+	contextDir := filepath.Join(baseDir, "context")
+	file := &MergedFile{
+		Name:        name,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Mode:        "symlink",
+	}
+	if copyFiles {
+		file.Mode = "copy"
+	}
+
+	var skipped []string
+	seen := make(map[string]bool)
+	for _, source := range sources {
+		opts := Options{
+			ConnectionName: source.Connection,
+			DatabaseName:   source.Database,
+			DatabaseType:   source.DatabaseType,
+			BaseDir:        baseDir,
+		}
+		database, err := resolveGenerationDatabase(opts)
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		schemas := []string{source.Schema}
+		if source.Schema == "" {
+			if schemas, err = generatedSchemaNames(opts, database); err != nil {
+				return "", nil, nil, err
+			}
+		}
+		for _, schema := range schemas {
+			schemaDir := schemaDirPath(opts, database, schema)
+			label := source.Connection + "/" + database + "/" + schema
+			if info, err := os.Stat(schemaDir); err != nil || !info.IsDir() {
+				if source.Schema != "" {
+					return "", nil, nil, fmt.Errorf("no generated context for %s at %s; run dbh tables first", label, schemaDir)
+				}
+				skipped = append(skipped, label)
+				continue
+			}
+
+			viewPath := filepath.Join(source.Connection, sanitizeName(database), sanitizeName(schema))
+			if seen[viewPath] {
+				continue
+			}
+			seen[viewPath] = true
+
+			sourcePath, err := filepath.Rel(contextDir, schemaDir)
+			if err != nil {
+				return "", nil, nil, err
+			}
+			file.Schemas = append(file.Schemas, MergedSchemaItem{
+				Connection:   source.Connection,
+				Database:     database,
+				DatabaseType: source.DatabaseType,
+				Schema:       schema,
+				Path:         filepath.ToSlash(viewPath),
+				Source:       filepath.ToSlash(sourcePath),
+			})
+		}
+	}
+	if len(file.Schemas) == 0 {
+		return "", nil, skipped, errors.New("no generated schemas to merge")
+	}
+
+	viewDir := MergedViewDir(baseDir, name)
+	if err := checkReplaceableMergedView(viewDir); err != nil {
+		return "", nil, nil, err
+	}
+
+	// Build next to the old view and swap it in at the end. The staging
+	// directory has the same depth, so relative symlinks stay valid.
+	stagingDir := viewDir + ".tmp"
+	if err := os.RemoveAll(stagingDir); err != nil {
+		return "", nil, nil, fmt.Errorf("remove stale staging dir: %w", err)
+	}
+	if err := writeMergedSchemas(stagingDir, contextDir, file, copyFiles); err != nil {
+		os.RemoveAll(stagingDir)
+		return "", nil, nil, err
+	}
+	if err := writeYAMLWithHeader(filepath.Join(stagingDir, MergedFileName), file, mergedHeader()); err != nil {
+		os.RemoveAll(stagingDir)
+		return "", nil, nil, fmt.Errorf("write %s: %w", MergedFileName, err)
+	}
+	if err := os.RemoveAll(viewDir); err != nil {
+		return "", nil, nil, fmt.Errorf("remove previous merged view: %w", err)
+	}
+	if err := os.Rename(stagingDir, viewDir); err != nil {
+		return "", nil, nil, fmt.Errorf("move merged view into place: %w", err)
+	}
+	return viewDir, file, skipped, nil
+}
+
+func validMergedViewName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// generatedSchemaNames lists the schemas in the database's _schemas.yml.
+func generatedSchemaNames(opts Options, database string) ([]string, error) {
+	path := filepath.Join(schemasDirPath(opts, database), "_schemas.yml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no generated schema context for %s/%s; run dbh schemas first", opts.ConnectionName, database)
+		}
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	var sf SchemasFile
+	if err := yaml.Unmarshal(data, &sf); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	names := make([]string, 0, len(sf.Schemas))
+	for _, schema := range sf.Schemas {
+		names = append(names, schema.Name)
+	}
+	return names, nil
+}
+
+// checkReplaceableMergedView returns an error when viewDir exists but was
+// not written by WriteMergedView.
+func checkReplaceableMergedView(viewDir string) error {
+	if _, err := os.Stat(viewDir); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(viewDir, MergedFileName)); err != nil {
+		return fmt.Errorf("%s exists and is not a merged view (no %s); remove it or pick another name", viewDir, MergedFileName)
+	}
+	return nil
+}
+
+func writeMergedSchemas(viewDir, contextDir string, file *MergedFile, copyFiles bool) error {
+	for _, item := range file.Schemas {
+		target := filepath.Join(contextDir, filepath.FromSlash(item.Source))
+		link := filepath.Join(viewDir, filepath.FromSlash(item.Path))
+		if err := os.MkdirAll(filepath.Dir(link), 0o755); err != nil {
+			return fmt.Errorf("create merged view dir: %w", err)
+		}
+		if copyFiles {
+			if err := copyDir(target, link); err != nil {
+				return fmt.Errorf("copy %s: %w", item.Source, err)
+			}
+			continue
+		}
+		relative, err := filepath.Rel(filepath.Dir(link), target)
+		if err != nil {
+			return err
+		}
+		if err := os.Symlink(relative, link); err != nil {
+			return fmt.Errorf("link %s: %w (use --copy where symlinks are not available)", item.Source, err)
+		}
+	}
+	return nil
+}
+
+// copyDir copies the regular files and directories under src to dst.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, relative)
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func mergedHeader() string {
+	return `# =============================================================================
+# Merged view
+# =============================================================================
+#
+# This file was generated by dbh merge. The view combines schemas from
+# several connections and databases; each one is at <path>, laid out as
+# <connection>/<database>/<schema>, with the usual _tables.yml and table
+# directories inside.
+#
+# Schema fields:
+#   connection    - Connection name from config.json
+#   database      - Database the schema belongs to
+#   database_type - Database type, which decides the SQL dialect
+#   schema        - Schema name
+#   path          - Directory in this view
+#   source        - Generated directory it comes from, relative to context/
+#
+# mode: symlink means the directories are links, so they follow later dbh
+# runs; mode: copy means they are a snapshot taken at generated_at.
+# =============================================================================
+
+`
+}
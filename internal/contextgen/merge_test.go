@@ -0,0 +1,116 @@
+package contextgen
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteMergedView(t *testing.T) {
+	baseDir := t.TempDir()
+	generateMergeTestTree(t, baseDir, "billing", "invoices", []string{"public", "archive"})
+	generateMergeTestTree(t, baseDir, "crm", "accounts", []string{"public"})
+	// archive is listed in _schemas.yml but dbh tables never wrote it.
+	archiveDir := filepath.Join(baseDir, "context", "connections", "billing", "databases", "invoices", "schemas", "archive")
+	if err := os.RemoveAll(archiveDir); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, copyFiles := range []bool{false, true} {
+		viewDir, file, skipped, err := WriteMergedView(baseDir, "combined", []MergeSource{
+			{Connection: "billing", Database: "invoices", DatabaseType: "postgres"},
+			{Connection: "crm", Database: "accounts", DatabaseType: "postgres", Schema: "public"},
+		}, copyFiles)
+		if err != nil {
+			t.Fatalf("WriteMergedView(copy=%v) error = %v", copyFiles, err)
+		}
+		if !reflect.DeepEqual(skipped, []string{"billing/invoices/archive"}) {
+			t.Fatalf("skipped = %q, want the ungenerated archive schema", skipped)
+		}
+
+		var paths []string
+		for _, item := range file.Schemas {
+			paths = append(paths, item.Path)
+		}
+		if want := []string{"billing/invoices/public", "crm/accounts/public"}; !reflect.DeepEqual(paths, want) {
+			t.Fatalf("paths = %q, want %q", paths, want)
+		}
+		if got := file.Schemas[1].Source; got != "connections/crm/databases/accounts/schemas/public" {
+			t.Fatalf("source = %q", got)
+		}
+
+		tablesPath := filepath.Join(viewDir, "crm", "accounts", "public", "_tables.yml")
+		if _, err := os.Stat(tablesPath); err != nil {
+			t.Fatalf("copy=%v: merged view does not reach _tables.yml: %v", copyFiles, err)
+		}
+		info, err := os.Lstat(filepath.Join(viewDir, "crm", "accounts", "public"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isLink := info.Mode()&os.ModeSymlink != 0; isLink == copyFiles {
+			t.Fatalf("copy=%v: schema directory symlink = %v", copyFiles, isLink)
+		}
+
+		data, err := os.ReadFile(filepath.Join(viewDir, MergedFileName))
+		if err != nil {
+			t.Fatalf("read manifest: %v", err)
+		}
+		var manifest MergedFile
+		if err := yaml.Unmarshal(data, &manifest); err != nil {
+			t.Fatalf("parse manifest: %v", err)
+		}
+		if manifest.Name != "combined" || len(manifest.Schemas) != 2 || manifest.Mode != file.Mode {
+			t.Fatalf("manifest = %+v", manifest)
+		}
+	}
+}
+
+func TestWriteMergedViewRefusesOtherDirectories(t *testing.T) {
+	baseDir := t.TempDir()
+	generateMergeTestTree(t, baseDir, "crm", "accounts", []string{"public"})
+	notes := filepath.Join(MergedViewDir(baseDir, "notes"), "todo.md")
+	if err := os.MkdirAll(filepath.Dir(notes), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(notes, []byte("keep me"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := []MergeSource{{Connection: "crm", Database: "accounts", DatabaseType: "postgres"}}
+	_, _, _, err := WriteMergedView(baseDir, "notes", sources, false)
+	if err == nil || !strings.Contains(err.Error(), "not a merged view") {
+		t.Fatalf("WriteMergedView() error = %v, want a refusal to replace the directory", err)
+	}
+	if _, err := os.Stat(notes); err != nil {
+		t.Fatalf("existing file removed: %v", err)
+	}
+
+	if _, _, _, err := WriteMergedView(baseDir, "../escape", sources, false); err == nil {
+		t.Fatal("WriteMergedView(../escape) error = nil, want invalid name")
+	}
+}
+
+func generateMergeTestTree(t *testing.T, baseDir, connection, database string, schemas []string) {
+	t.Helper()
+	opts := Options{
+		ConnectionName: connection,
+		DatabaseName:   database,
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+	}
+	var infos []discovery.SchemaInfo
+	for _, schema := range schemas {
+		infos = append(infos, discovery.SchemaInfo{
+			Name:   schema,
+			Tables: []discovery.TableInfo{{Name: "items", TableType: "BASE TABLE"}},
+		})
+	}
+	if err := Generate(infos, opts); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package contextgen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+)
+
+// QualityFlag names a data quality signal derived from a column profile.
+type QualityFlag string
+
+const (
+	// QualityAllNull marks a column that is NULL in every row.
+	QualityAllNull QualityFlag = "all_null"
+	// QualityConstant marks a column whose non-NULL values are all equal.
+	QualityConstant QualityFlag = "constant"
+	// QualityAllDistinct marks a column whose non-NULL values are all
+	// different: a likely key even when no unique constraint declares it.
+	QualityAllDistinct QualityFlag = "all_distinct"
+	// QualityHighNull marks a column whose NULL rate reaches the configured
+	// threshold without being entirely NULL.
+	QualityHighNull QualityFlag = "high_null"
+)
+
+// DefaultHighNullPct is the dbh columns default NULL rate, in percent, at
+// which a column is flagged QualityHighNull.
+const DefaultHighNullPct = 90
+
+// QualityFlags returns the quality flags for one profiled column. It only
+// looks at the counts already in column, so it needs no extra queries.
+// Columns of empty tables are never flagged.
+func QualityFlags(column discovery.EnrichedColumnInfo, highNullPct float64) []QualityFlag {
+	if column.TotalRows <= 0 {
+		return nil
+	}
+	if column.NonNullCount == 0 {
+		return []QualityFlag{QualityAllNull}
+	}
+
+	var flags []QualityFlag
+	if column.NonNullCount > 1 {
+		switch column.DistinctNonNullCount {
+		case 1:
+			flags = append(flags, QualityConstant)
+		case column.NonNullCount:
+			flags = append(flags, QualityAllDistinct)
+		}
+	}
+	if highNullPct > 0 && column.NullOfTotalRowsPct >= highNullPct {
+		flags = append(flags, QualityHighNull)
+	}
+	return flags
+}
+
+// QualityFile is written as _quality.yml in a database directory by dbh
+// columns --quality-report.
+type QualityFile struct {
+	Connection   string            `yaml:"connection"`
+	Database     string            `yaml:"database"`
+	DatabaseType string            `yaml:"database_type"`
+	GeneratedAt  string            `yaml:"generated_at"`
+	HighNullPct  float64           `yaml:"high_null_pct"`
+	Columns      []QualityFileItem `yaml:"columns"`
+}
+
+// QualityFileItem is one flagged column in a QualityFile.
+type QualityFileItem struct {
+	Schema               string        `yaml:"schema"`
+	Table                string        `yaml:"table"`
+	Column               string        `yaml:"column"`
+	Flags                []QualityFlag `yaml:"flags"`
+	TotalRows            int64         `yaml:"total_rows"`
+	NullOfTotalRowsPct   float64       `yaml:"null_of_total_rows_pct"`
+	DistinctNonNullCount int64         `yaml:"distinct_non_null_count"`
+}
+
+// WriteQualityFile writes _quality.yml for the database in opts, replacing
+// any previous report. items are written in the order given.
+func WriteQualityFile(items []QualityFileItem, highNullPct float64, opts Options) (string, error) {
+	defaultDatabase, err := resolveGenerationDatabase(opts)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(
+		opts.BaseDir,
+		"context",
+		"connections",
+		opts.ConnectionName,
+		"databases",
+		sanitizeName(defaultDatabase),
+	)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create database dir: %w", err)
+	}
+
+	file := QualityFile{
+		Connection:   opts.ConnectionName,
+		Database:     defaultDatabase,
+		DatabaseType: opts.DatabaseType,
+		GeneratedAt:  time.Now().UTC().Format(time.RFC3339),
+		HighNullPct:  highNullPct,
+		Columns:      items,
+	}
+	if file.Columns == nil {
+		file.Columns = []QualityFileItem{}
+	}
+
+	path := filepath.Join(dir, "_quality.yml")
+	if err := writeYAMLWithHeaderAtomic(path, file, qualityHeader(opts, defaultDatabase)); err != nil {
+		return "", fmt.Errorf("write _quality.yml: %w", err)
+	}
+	return path, nil
+}
+
+func qualityHeader(opts Options, database string) string {
+	if opts.Dense {
+		return ""
+	}
+	return fmt.Sprintf(`# =============================================================================
+# Data quality flags
+# Connection: %s | Database: %s | Type: %s
+# =============================================================================
+#
+# This file was generated by dbh columns --quality-report from the column
+# profiles of the tables processed in that run.
+#
+# Flags:
+#   all_null     - Every row is NULL
+#   constant     - Every non-NULL value is the same
+#   all_distinct - Every non-NULL value is different (possible undeclared key)
+#   high_null    - NULL in at least high_null_pct percent of rows
+# =============================================================================
+
+`, opts.ConnectionName, database, opts.DatabaseType)
+}
@@ -0,0 +1,177 @@
+package contextgen
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+	"gopkg.in/yaml.v3"
+)
+
+// InferredRelationship is a likely foreign key guessed from column names and
+// types. It is a heuristic: the database does not declare it, and it may be
+// wrong.
+type InferredRelationship struct {
+	Table            string `yaml:"table"`
+	Column           string `yaml:"column"`
+	ReferencesTable  string `yaml:"references_table"`
+	ReferencesColumn string `yaml:"references_column"`
+	Reason           string `yaml:"reason"`
+}
+
+// InferRelationships proposes relationships between the tables of one
+// schema. A column named <name>_id is matched to a table named <name> (or
+// its plural: <name>s, <name>es, or <nam>ies for <name> ending in y) that
+// has an id or <name>_id column of a compatible type. Column and table names
+// are compared case-insensitively. The result is sorted by table and column.
+func InferRelationships(tables []TableDetailInput) []InferredRelationship {
+	byName := make(map[string]TableDetailInput, len(tables))
+	for _, table := range tables {
+		byName[strings.ToLower(table.Table)] = table
+	}
+
+	var relationships []InferredRelationship
+	for _, table := range tables {
+		for _, column := range table.Columns {
+			name := strings.ToLower(column.Name)
+			stem, ok := strings.CutSuffix(name, "_id")
+			if !ok || stem == "" {
+				continue
+			}
+			for _, candidate := range referencedTableNames(stem) {
+				target, ok := byName[candidate]
+				if !ok {
+					continue
+				}
+				key, ok := referencedKeyColumn(target, stem)
+				if !ok {
+					continue
+				}
+				if strings.EqualFold(target.Table, table.Table) && strings.EqualFold(key.Name, column.Name) {
+					// A table's own key column is not a reference.
+					continue
+				}
+				family := dataTypeFamily(column.DataType)
+				if family == "" || family != dataTypeFamily(key.DataType) {
+					continue
+				}
+				relationships = append(relationships, InferredRelationship{
+					Table:            table.Table,
+					Column:           column.Name,
+					ReferencesTable:  target.Table,
+					ReferencesColumn: key.Name,
+					Reason:           fmt.Sprintf("column name matches table %s; both are %s", target.Table, family),
+				})
+				break
+			}
+		}
+	}
+
+	sort.Slice(relationships, func(i, j int) bool {
+		a, b := relationships[i], relationships[j]
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+		return a.Column < b.Column
+	})
+	return relationships
+}
+
+// referencedTableNames returns the table names a <stem>_id column may point
+// at, most likely first.
+func referencedTableNames(stem string) []string {
+	names := []string{stem, stem + "s", stem + "es"}
+	if base, ok := strings.CutSuffix(stem, "y"); ok && base != "" {
+		names = append(names, base+"ies")
+	}
+	return names
+}
+
+// referencedKeyColumn returns the column of target a <stem>_id column most
+// likely references: id, else <stem>_id.
+func referencedKeyColumn(target TableDetailInput, stem string) (discovery.ColumnInfo, bool) {
+	for _, want := range []string{"id", stem + "_id"} {
+		for _, column := range target.Columns {
+			if strings.EqualFold(column.Name, want) {
+				return column, true
+			}
+		}
+	}
+	return discovery.ColumnInfo{}, false
+}
+
+// dataTypeFamily groups data types that can hold the same key values, so an
+// int4 column can reference a bigint id. Unknown types are their own family;
+// an empty type has none.
+func dataTypeFamily(dataType string) string {
+	t := strings.ToLower(strings.TrimSpace(dataType))
+	if i := strings.Index(t, "("); i >= 0 {
+		t = strings.TrimSpace(t[:i])
+	}
+	switch t {
+	case "":
+		return ""
+	case "int", "integer", "int2", "int4", "int8", "int64", "smallint", "mediumint", "bigint", "tinyint",
+		"serial", "bigserial", "smallserial", "number", "numeric", "decimal":
+		return "numeric"
+	case "text", "varchar", "character varying", "char", "character", "string", "nvarchar", "nchar":
+		return "string"
+	case "uuid", "uniqueidentifier":
+		return "uuid"
+	}
+	return t
+}
+
+// WriteInferredRelationships infers the relationships between tables and
+// stores them under inferred_relationships in the schema's _tables.yml. An
+// existing file keeps its table entries and descriptions; a missing one is
+// created from schema.
+func WriteInferredRelationships(schema discovery.SchemaInfo, tables []TableDetailInput, opts Options) ([]InferredRelationship, error) {
+	defaultDatabase, err := resolveGenerationDatabase(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaDir := schemaDirPath(opts, defaultDatabase, schema.Name)
+	if err := os.MkdirAll(schemaDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create schema dir %q: %w", schema.Name, err)
+	}
+	tablesPath := filepath.Join(schemaDir, "_tables.yml")
+
+	var tf TablesFile
+	data, err := os.ReadFile(tablesPath)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &tf); err != nil {
+			return nil, fmt.Errorf("parse _tables.yml for %q: %w", schema.Name, err)
+		}
+	case errors.Is(err, os.ErrNotExist):
+		tf = TablesFile{
+			Schema:       schema.Name,
+			Connection:   opts.ConnectionName,
+			Database:     defaultDatabase,
+			DatabaseType: opts.DatabaseType,
+		}
+		for _, t := range schema.Tables {
+			tf.Tables = append(tf.Tables, TablesEntry{Name: t.Name, Type: t.TableType})
+		}
+	default:
+		return nil, fmt.Errorf("read _tables.yml for %q: %w", schema.Name, err)
+	}
+
+	relationships := InferRelationships(tables)
+	tf.InferredRelationships = relationships
+	tf.GeneratedAt = time.Now().UTC().Format(time.RFC3339)
+
+	headerOpts := opts
+	headerOpts.DatabaseName = defaultDatabase
+	if err := writeYAMLWithHeaderAtomic(tablesPath, tf, tablesHeader(headerOpts, schema.Name)); err != nil {
+		return nil, fmt.Errorf("write _tables.yml for %q: %w", schema.Name, err)
+	}
+	return relationships, nil
+}
@@ -0,0 +1,91 @@
+package contextgen
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+	"gopkg.in/yaml.v3"
+)
+
+func TestInferRelationships(t *testing.T) {
+	tables := []TableDetailInput{
+		{Table: "users", Columns: []discovery.ColumnInfo{
+			{Name: "id", DataType: "bigint"},
+			{Name: "company_id", DataType: "integer"},
+		}},
+		{Table: "companies", Columns: []discovery.ColumnInfo{
+			{Name: "id", DataType: "int4"},
+		}},
+		{Table: "orders", Columns: []discovery.ColumnInfo{
+			{Name: "id", DataType: "bigint"},
+			{Name: "User_ID", DataType: "int8"},
+			{Name: "status_id", DataType: "integer"},
+			{Name: "box_id", DataType: "varchar(36)"},
+			{Name: "region_id", DataType: "integer"},
+		}},
+		{Table: "boxes", Columns: []discovery.ColumnInfo{
+			{Name: "id", DataType: "uuid"},
+		}},
+		{Table: "region", Columns: []discovery.ColumnInfo{
+			{Name: "region_id", DataType: "integer"},
+		}},
+	}
+
+	got := InferRelationships(tables)
+	want := []InferredRelationship{
+		{Table: "orders", Column: "User_ID", ReferencesTable: "users", ReferencesColumn: "id", Reason: "column name matches table users; both are numeric"},
+		{Table: "orders", Column: "region_id", ReferencesTable: "region", ReferencesColumn: "region_id", Reason: "column name matches table region; both are numeric"},
+		{Table: "users", Column: "company_id", ReferencesTable: "companies", ReferencesColumn: "id", Reason: "column name matches table companies; both are numeric"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("InferRelationships() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestWriteInferredRelationships_KeepsTableEntries(t *testing.T) {
+	baseDir := t.TempDir()
+	opts := Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+	}
+	schema := discovery.SchemaInfo{
+		Name:   "public",
+		Tables: []discovery.TableInfo{{Name: "orders", TableType: "BASE TABLE"}, {Name: "users", TableType: "BASE TABLE"}},
+	}
+	if err := Generate([]discovery.SchemaInfo{schema}, opts); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	tables := []TableDetailInput{
+		{Schema: "public", Table: "orders", Columns: []discovery.ColumnInfo{{Name: "user_id", DataType: "integer"}}},
+		{Schema: "public", Table: "users", Columns: []discovery.ColumnInfo{{Name: "id", DataType: "integer"}}},
+	}
+	relationships, err := WriteInferredRelationships(schema, tables, opts)
+	if err != nil {
+		t.Fatalf("WriteInferredRelationships() error = %v", err)
+	}
+	if len(relationships) != 1 {
+		t.Fatalf("WriteInferredRelationships() = %+v, want 1 relationship", relationships)
+	}
+
+	path := filepath.Join(baseDir, "context", "connections", "my-db", "databases", "analytics", "schemas", "public", "_tables.yml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read _tables.yml: %v", err)
+	}
+	var tf TablesFile
+	if err := yaml.Unmarshal(data, &tf); err != nil {
+		t.Fatalf("parse _tables.yml: %v", err)
+	}
+	if len(tf.Tables) != 2 {
+		t.Fatalf("tables = %+v, want orders and users", tf.Tables)
+	}
+	if !reflect.DeepEqual(tf.InferredRelationships, relationships) {
+		t.Fatalf("inferred_relationships = %+v, want %+v", tf.InferredRelationships, relationships)
+	}
+}
@@ -0,0 +1,112 @@
+package contextgen
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaCountsFile is the minimal _schemas.yml written by dbh schemas
+// --count-tables-only: schema names and counts, without table listings.
+type SchemaCountsFile struct {
+	Connection   string            `yaml:"connection"`
+	Database     string            `yaml:"database"`
+	DatabaseType string            `yaml:"database_type"`
+	GeneratedAt  string            `yaml:"generated_at"`
+	Schemas      []SchemaCountItem `yaml:"schemas"`
+}
+
+// SchemaCountItem is one schema in SchemaCountsFile.
+type SchemaCountItem struct {
+	Name          string `yaml:"name"`
+	TableCount    int    `yaml:"table_count"`
+	ViewCount     int    `yaml:"view_count"`
+	AIDescription string `yaml:"ai_description"`
+	DBDescription string `yaml:"db_description"`
+}
+
+// WriteSchemaCounts writes a _schemas.yml listing only the name and table and
+// view counts of each schema, keeping the schema descriptions of an existing
+// file. Table listings in an existing file are dropped, and no _tables.yml
+// files are written. It returns the path written.
+func WriteSchemaCounts(schemas []discovery.SchemaInfo, opts Options) (string, error) {
+	defaultDatabase, err := resolveGenerationDatabase(opts)
+	if err != nil {
+		return "", err
+	}
+	headerOpts := opts
+	headerOpts.DatabaseName = defaultDatabase
+
+	schemasDir := schemasDirPath(opts, defaultDatabase)
+	if err := os.MkdirAll(schemasDir, 0o755); err != nil {
+		return "", fmt.Errorf("create schemas dir: %w", err)
+	}
+	schemasPath := filepath.Join(schemasDir, "_schemas.yml")
+
+	descriptions := make(map[string]SchemaItem)
+	data, err := os.ReadFile(schemasPath)
+	switch {
+	case err == nil:
+		var existing SchemasFile
+		if err := yaml.Unmarshal(data, &existing); err != nil {
+			return "", fmt.Errorf("parse existing _schemas.yml: %w", err)
+		}
+		for _, item := range existing.Schemas {
+			descriptions[item.Name] = item
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return "", fmt.Errorf("read existing _schemas.yml: %w", err)
+	}
+
+	sf := SchemaCountsFile{
+		Connection:   opts.ConnectionName,
+		Database:     defaultDatabase,
+		DatabaseType: opts.DatabaseType,
+		GeneratedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+	if opts.ExcludeEmptySchemas {
+		schemas, _ = ExcludeEmptySchemas(schemas)
+	}
+	for _, s := range sortedSchemaInfos(schemas) {
+		item := SchemaCountItem{
+			Name:          s.Name,
+			AIDescription: descriptions[s.Name].AIDescription,
+			DBDescription: descriptions[s.Name].DBDescription,
+		}
+		for _, t := range s.Tables {
+			if isView(t.TableType) {
+				item.ViewCount++
+			} else {
+				item.TableCount++
+			}
+		}
+		sf.Schemas = append(sf.Schemas, item)
+	}
+
+	if err := writeYAMLWithHeaderAtomic(schemasPath, sf, schemaCountsHeader(headerOpts)); err != nil {
+		return "", fmt.Errorf("write _schemas.yml: %w", err)
+	}
+	return schemasPath, nil
+}
+
+func schemaCountsHeader(opts Options) string {
+	if opts.Dense {
+		return ""
+	}
+	return fmt.Sprintf(`# =============================================================================
+# Database Schema Overview (counts only)
+# Connection: %s | Database: %s | Type: %s
+# =============================================================================
+#
+# This file was generated by dbh schemas --count-tables-only. It lists each
+# schema with its table and view counts, but not the tables themselves.
+# Run dbh schemas for the full listing and per-schema _tables.yml files.
+# =============================================================================
+
+`, opts.ConnectionName, opts.DatabaseName, opts.DatabaseType)
+}
@@ -0,0 +1,186 @@
+package contextgen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SchemaReportFileName is the per-schema roll-up dbh columns
+// --group-by-schema writes in the database directory.
+const SchemaReportFileName = "_schema_report.md"
+
+// SchemaReport summarizes one schema of a dbh columns run: what was
+// profiled, which columns were flagged, and which selected tables were not
+// profiled.
+type SchemaReport struct {
+	Schema          string
+	TablesProfiled  int
+	ColumnsProfiled int
+	TotalRows       int64
+	Flagged         []QualityFileItem
+	Unprofiled      []EnrichFailure
+}
+
+// FlagCount returns the number of flagged columns carrying flag.
+func (r SchemaReport) FlagCount(flag QualityFlag) int {
+	count := 0
+	for _, item := range r.Flagged {
+		if slices.Contains(item.Flags, flag) {
+			count++
+		}
+	}
+	return count
+}
+
+// attention weighs the problems a data steward would act on: mostly or
+// entirely NULL columns, constant columns, and tables without a profile.
+// all_distinct is informational and does not count.
+func (r SchemaReport) attention() int {
+	return r.FlagCount(QualityAllNull) + r.FlagCount(QualityHighNull) + r.FlagCount(QualityConstant) + len(r.Unprofiled)
+}
+
+// BuildSchemaReports groups the in-memory results of a dbh columns run by
+// schema. profiled holds the tables written per schema, flagged the quality
+// flags of their columns, and unprofiled the selected tables that were not
+// written. Schemas needing the most attention come first, ties by name.
+func BuildSchemaReports(profiled map[string][]SchemaStatsTable, flagged []QualityFileItem, unprofiled []EnrichFailure) []SchemaReport {
+	bySchema := make(map[string]*SchemaReport)
+	report := func(schema string) *SchemaReport {
+		if bySchema[schema] == nil {
+			bySchema[schema] = &SchemaReport{Schema: schema}
+		}
+		return bySchema[schema]
+	}
+
+	for schema, tables := range profiled {
+		r := report(schema)
+		for _, table := range tables {
+			r.TablesProfiled++
+			r.ColumnsProfiled += table.ColumnsProfiled
+			r.TotalRows += table.TotalRows
+		}
+	}
+	for _, item := range flagged {
+		r := report(item.Schema)
+		r.Flagged = append(r.Flagged, item)
+	}
+	for _, failure := range unprofiled {
+		r := report(failure.Schema)
+		r.Unprofiled = append(r.Unprofiled, failure)
+	}
+
+	reports := make([]SchemaReport, 0, len(bySchema))
+	for _, r := range bySchema {
+		reports = append(reports, *r)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if a, b := reports[i].attention(), reports[j].attention(); a != b {
+			return a > b
+		}
+		return reports[i].Schema < reports[j].Schema
+	})
+	return reports
+}
+
+// WriteSchemaReport writes _schema_report.md for the database in opts,
+// replacing any previous report.
+func WriteSchemaReport(reports []SchemaReport, highNullPct float64, opts Options) (string, error) {
+	defaultDatabase, err := resolveGenerationDatabase(opts)
+	if err != nil {
+		return "", err
+	}
+
+	dir := databaseDirPath(opts, defaultDatabase)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create database dir: %w", err)
+	}
+
+	path := filepath.Join(dir, SchemaReportFileName)
+	content := renderSchemaReport(reports, highNullPct, opts, defaultDatabase, time.Now().UTC())
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(content), 0o644); err != nil {
+		return "", fmt.Errorf("write %s: %w", SchemaReportFileName, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return "", fmt.Errorf("rename %s: %w", SchemaReportFileName, err)
+	}
+	recordChecksum(path, []byte(content))
+	return path, nil
+}
+
+func renderSchemaReport(reports []SchemaReport, highNullPct float64, opts Options, database string, generatedAt time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Schema report: %s\n\n", database)
+	fmt.Fprintf(&b, "Connection: `%s` | Database: `%s` | Type: `%s`\n\n", opts.ConnectionName, database, opts.DatabaseType)
+	fmt.Fprintf(&b, "Generated by `dbh columns --group-by-schema` at %s from the tables processed in that run. ", generatedAt.Format(time.RFC3339))
+	b.WriteString("Schemas with the most entirely NULL, mostly NULL, and constant columns and unprofiled tables come first.\n\n")
+
+	if len(reports) == 0 {
+		b.WriteString("No tables were processed.\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "| Schema | Tables profiled | Columns profiled | Total rows | All NULL | NULL >= %g%% | Constant | All distinct | Unprofiled tables |\n", highNullPct)
+	b.WriteString("|---|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+	for _, r := range reports {
+		fmt.Fprintf(
+			&b,
+			"| `%s` | %d | %d | %d | %d | %d | %d | %d | %d |\n",
+			r.Schema,
+			r.TablesProfiled,
+			r.ColumnsProfiled,
+			r.TotalRows,
+			r.FlagCount(QualityAllNull),
+			r.FlagCount(QualityHighNull),
+			r.FlagCount(QualityConstant),
+			r.FlagCount(QualityAllDistinct),
+			len(r.Unprofiled),
+		)
+	}
+
+	groups := []struct {
+		flag  QualityFlag
+		label string
+	}{
+		{QualityAllNull, "Entirely NULL"},
+		{QualityHighNull, fmt.Sprintf("NULL in at least %g%% of rows", highNullPct)},
+		{QualityConstant, "Single distinct value"},
+		{QualityAllDistinct, "All values distinct (possible key)"},
+	}
+	for _, r := range reports {
+		fmt.Fprintf(&b, "\n## %s\n\n", r.Schema)
+		if len(r.Flagged) == 0 && len(r.Unprofiled) == 0 {
+			b.WriteString("No flagged columns or unprofiled tables.\n")
+			continue
+		}
+		if len(r.Unprofiled) > 0 {
+			fmt.Fprintf(&b, "Unprofiled tables (%d):\n\n", len(r.Unprofiled))
+			for _, failure := range r.Unprofiled {
+				fmt.Fprintf(&b, "- `%s`: %s\n", failure.Table, failure.Reason)
+			}
+			b.WriteString("\n")
+		}
+		for _, group := range groups {
+			var names []string
+			for _, item := range r.Flagged {
+				if slices.Contains(item.Flags, group.flag) {
+					names = append(names, item.Table+"."+item.Column)
+				}
+			}
+			if len(names) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "%s (%d):\n\n", group.label, len(names))
+			for _, name := range names {
+				fmt.Fprintf(&b, "- `%s`\n", name)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
@@ -0,0 +1,83 @@
+package contextgen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildSchemaReports(t *testing.T) {
+	profiled := map[string][]SchemaStatsTable{
+		"analytics": {{Table: "events", TotalRows: 100, ColumnsProfiled: 4}},
+		"public": {
+			{Table: "orders", TotalRows: 50, ColumnsProfiled: 3},
+			{Table: "users", TotalRows: 10, ColumnsProfiled: 2},
+		},
+	}
+	flagged := []QualityFileItem{
+		{Schema: "public", Table: "orders", Column: "id", Flags: []QualityFlag{QualityAllDistinct}},
+		{Schema: "analytics", Table: "events", Column: "legacy", Flags: []QualityFlag{QualityConstant, QualityHighNull}},
+	}
+	unprofiled := []EnrichFailure{{Schema: "staging", Table: "raw", Reason: "ran out of budget"}}
+
+	reports := BuildSchemaReports(profiled, flagged, unprofiled)
+	var order []string
+	for _, r := range reports {
+		order = append(order, r.Schema)
+	}
+	// all_distinct alone does not call for attention.
+	if got := strings.Join(order, ","); got != "analytics,staging,public" {
+		t.Fatalf("schema order = %s, want analytics,staging,public", got)
+	}
+
+	public := reports[2]
+	if public.TablesProfiled != 2 || public.ColumnsProfiled != 5 || public.TotalRows != 60 {
+		t.Fatalf("public = %+v, want 2 tables, 5 columns, 60 rows", public)
+	}
+	analytics := reports[0]
+	if analytics.FlagCount(QualityConstant) != 1 || analytics.FlagCount(QualityHighNull) != 1 || analytics.FlagCount(QualityAllNull) != 0 {
+		t.Fatalf("analytics flag counts wrong: %+v", analytics.Flagged)
+	}
+	if len(reports[1].Unprofiled) != 1 || reports[1].TablesProfiled != 0 {
+		t.Fatalf("staging = %+v, want one unprofiled table", reports[1])
+	}
+}
+
+func TestWriteSchemaReport(t *testing.T) {
+	opts := Options{
+		ConnectionName: "warehouse",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        t.TempDir(),
+	}
+	reports := BuildSchemaReports(
+		map[string][]SchemaStatsTable{"public": {{Table: "orders", TotalRows: 50, ColumnsProfiled: 3}}},
+		[]QualityFileItem{{Schema: "public", Table: "orders", Column: "note", Flags: []QualityFlag{QualityHighNull}}},
+		[]EnrichFailure{{Schema: "public", Table: "audit", Reason: "did not fit the 1m0s budget"}},
+	)
+
+	path, err := WriteSchemaReport(reports, 75, opts)
+	if err != nil {
+		t.Fatalf("WriteSchemaReport() error = %v", err)
+	}
+	if want := filepath.Join(opts.BaseDir, "context", "connections", "warehouse", "databases", "analytics", SchemaReportFileName); path != want {
+		t.Fatalf("path = %s, want %s", path, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Schema report: analytics",
+		"| `public` | 1 | 3 | 50 | 0 | 1 | 0 | 0 | 1 |",
+		"NULL >= 75%",
+		"- `audit`: did not fit the 1m0s budget",
+		"NULL in at least 75% of rows (1):",
+		"- `orders.note`",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("report missing %q:\n%s", want, data)
+		}
+	}
+}
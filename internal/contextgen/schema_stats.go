@@ -0,0 +1,133 @@
+package contextgen
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaStatsFile is written as _schema_stats.yml in a schema directory by
+// dbh columns. It rolls up the profiled tables of the schema so the schema
+// can be sized up without opening every columns file.
+type SchemaStatsFile struct {
+	Connection      string             `yaml:"connection"`
+	Database        string             `yaml:"database"`
+	DatabaseType    string             `yaml:"database_type"`
+	Schema          string             `yaml:"schema"`
+	GeneratedAt     string             `yaml:"generated_at"`
+	TableCount      int                `yaml:"table_count"`
+	ColumnsProfiled int                `yaml:"columns_profiled"`
+	TotalRows       int64              `yaml:"total_rows"`
+	Tables          []SchemaStatsTable `yaml:"tables"`
+}
+
+// SchemaStatsTable is one profiled table in a SchemaStatsFile.
+type SchemaStatsTable struct {
+	Table           string `yaml:"table"`
+	TotalRows       int64  `yaml:"total_rows"`
+	ColumnsProfiled int    `yaml:"columns_profiled"`
+	Scope           string `yaml:"scope,omitempty"`
+	ProfiledAt      string `yaml:"profiled_at"`
+}
+
+// NewSchemaStatsTable summarizes one profiled table. scope is the --since
+// filter the counts were taken under, if any.
+func NewSchemaStatsTable(table, scope string, columns []discovery.EnrichedColumnInfo) SchemaStatsTable {
+	stats := SchemaStatsTable{
+		Table:           table,
+		ColumnsProfiled: len(columns),
+		Scope:           scope,
+		ProfiledAt:      time.Now().UTC().Format(time.RFC3339),
+	}
+	if len(columns) > 0 {
+		stats.TotalRows = columns[0].TotalRows
+	}
+	return stats
+}
+
+// WriteSchemaStatsFile merges tables into the schema's _schema_stats.yml and
+// recomputes the totals. Tables already in the file that are not in tables
+// keep their previous entry, so the roll-up covers every table profiled so
+// far rather than only the latest run.
+func WriteSchemaStatsFile(schema string, tables []SchemaStatsTable, opts Options) (string, error) {
+	defaultDatabase, err := resolveGenerationDatabase(opts)
+	if err != nil {
+		return "", err
+	}
+
+	dir := schemaDirPath(opts, defaultDatabase, schema)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create schema dir: %w", err)
+	}
+	path := filepath.Join(dir, "_schema_stats.yml")
+
+	byTable := make(map[string]SchemaStatsTable)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		var existing SchemaStatsFile
+		if err := yaml.Unmarshal(data, &existing); err != nil {
+			return "", fmt.Errorf("parse existing _schema_stats.yml: %w", err)
+		}
+		for _, table := range existing.Tables {
+			byTable[table.Table] = table
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return "", fmt.Errorf("read existing _schema_stats.yml: %w", err)
+	}
+	for _, table := range tables {
+		byTable[table.Table] = table
+	}
+
+	file := SchemaStatsFile{
+		Connection:   opts.ConnectionName,
+		Database:     defaultDatabase,
+		DatabaseType: opts.DatabaseType,
+		Schema:       schema,
+		GeneratedAt:  time.Now().UTC().Format(time.RFC3339),
+		Tables:       make([]SchemaStatsTable, 0, len(byTable)),
+	}
+	for _, table := range byTable {
+		file.Tables = append(file.Tables, table)
+		file.ColumnsProfiled += table.ColumnsProfiled
+		file.TotalRows += table.TotalRows
+	}
+	file.TableCount = len(file.Tables)
+	sort.Slice(file.Tables, func(i, j int) bool {
+		return file.Tables[i].Table < file.Tables[j].Table
+	})
+
+	if err := writeYAMLWithHeaderAtomic(path, file, schemaStatsHeader(opts, defaultDatabase, schema)); err != nil {
+		return "", fmt.Errorf("write _schema_stats.yml: %w", err)
+	}
+	return path, nil
+}
+
+func schemaStatsHeader(opts Options, database, schema string) string {
+	if opts.Dense {
+		return ""
+	}
+	return fmt.Sprintf(`# =============================================================================
+# Schema stats: %s
+# Connection: %s | Database: %s | Type: %s
+# =============================================================================
+#
+# This file was generated by dbh columns from the enriched columns files of
+# the profiled tables in this schema. Tables that have not been profiled are
+# not counted.
+#
+# Fields:
+#   table_count      - Profiled tables in this schema
+#   columns_profiled - Profiled columns across those tables
+#   total_rows       - Sum of the tables' total_rows
+#   scope            - The --since filter a table's rows were counted under
+# =============================================================================
+
+`, schema, opts.ConnectionName, database, opts.DatabaseType)
+}
@@ -0,0 +1,102 @@
+package contextgen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// tableNamesCache holds the table names read from each _tables.yml, so
+// profiling many tables of a schema parses the file once.
+var tableNamesCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedTableNames
+}
+
+type cachedTableNames struct {
+	modTime time.Time
+	size    int64
+	names   []string
+}
+
+// tablePathName returns the name of table's directory and the prefix of its
+// __columns.yml and __sample.xml files. The name is lowercased unless
+// opts.PreserveCase is set. Names are compared ignoring case to find
+// collisions, since many file systems do, so when siblings (the other
+// tables of the schema) include names that differ from table only in case,
+// all but one of them get a suffix from a hash of the table name. The one
+// kept as is is the all-lowercase name, else the first name in byte order,
+// so the result does not depend on the order of siblings.
+func tablePathName(opts Options, table string, siblings []string) string {
+	name := sanitizeNameKeepCase(table)
+	if !opts.PreserveCase {
+		name = strings.ToLower(name)
+	}
+	key := strings.ToLower(name)
+
+	group := []string{table}
+	for _, sibling := range siblings {
+		if sibling != table && strings.ToLower(sanitizeNameKeepCase(sibling)) == key && !slices.Contains(group, sibling) {
+			group = append(group, sibling)
+		}
+	}
+	if len(group) == 1 || pathNameKeeper(group, key) == table {
+		return name
+	}
+	sum := sha256.Sum256([]byte(table))
+	return name + "_" + hex.EncodeToString(sum[:4])
+}
+
+// pathNameKeeper returns the name in group that keeps the unsuffixed path.
+func pathNameKeeper(group []string, key string) string {
+	sorted := append([]string(nil), group...)
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		if sanitizeNameKeepCase(name) == key {
+			return name
+		}
+	}
+	return sorted[0]
+}
+
+// schemaTableNames returns the table names listed in the schema's
+// _tables.yml, or nil when it is missing or unreadable.
+func schemaTableNames(opts Options, database, schema string) []string {
+	path := filepath.Join(schemaDirPath(opts, database, schema), "_tables.yml")
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	tableNamesCache.mu.Lock()
+	defer tableNamesCache.mu.Unlock()
+	if cached, ok := tableNamesCache.entries[path]; ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.names
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var file TablesFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(file.Tables))
+	for _, entry := range file.Tables {
+		names = append(names, entry.Name)
+	}
+	if tableNamesCache.entries == nil {
+		tableNamesCache.entries = make(map[string]cachedTableNames)
+	}
+	tableNamesCache.entries[path] = cachedTableNames{modTime: info.ModTime(), size: info.Size(), names: names}
+	return names
+}
@@ -0,0 +1,111 @@
+package contextgen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+	"gopkg.in/yaml.v3"
+)
+
+func TestTablePathName(t *testing.T) {
+	siblings := []string{"users", "Users", "USERS", "orders"}
+	for _, tc := range []struct {
+		table        string
+		preserveCase bool
+		want         string
+	}{
+		{table: "orders", want: "orders"},
+		{table: "users", want: "users"},
+		{table: "Users", want: "users_" + hashSuffix("Users")},
+		{table: "USERS", want: "users_" + hashSuffix("USERS")},
+		{table: "orders", preserveCase: true, want: "orders"},
+		{table: "Users", preserveCase: true, want: "Users_" + hashSuffix("Users")},
+		{table: "Order Items", preserveCase: true, want: "Order_Items"},
+	} {
+		got := tablePathName(Options{PreserveCase: tc.preserveCase}, tc.table, siblings)
+		if got != tc.want {
+			t.Errorf("tablePathName(%q, preserveCase=%v) = %q, want %q", tc.table, tc.preserveCase, got, tc.want)
+		}
+	}
+
+	// Without an all-lowercase name, the first in byte order keeps it.
+	if got := tablePathName(Options{}, "USERS", []string{"Users"}); got != "users" {
+		t.Errorf("tablePathName(USERS) beside Users = %q, want users", got)
+	}
+}
+
+func TestGenerateTableDetails_NamesDifferingOnlyInCaseDoNotOverwrite(t *testing.T) {
+	baseDir := t.TempDir()
+	opts := Options{
+		ConnectionName: "my-db",
+		DatabaseName:   "analytics",
+		DatabaseType:   "postgres",
+		BaseDir:        baseDir,
+	}
+	schemas := []discovery.SchemaInfo{{
+		Name: "public",
+		Tables: []discovery.TableInfo{
+			{Name: "Users", TableType: "BASE TABLE"},
+			{Name: "users", TableType: "BASE TABLE"},
+		},
+	}}
+	if err := Generate(schemas, opts); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	// Each table is written in its own run, as dbh tables --tables does.
+	for _, table := range []string{"Users", "users"} {
+		input := TableDetailInput{
+			Schema:  "public",
+			Table:   table,
+			Columns: []discovery.ColumnInfo{{Name: table + "_id", DataType: "integer", OrdinalPosition: 1}},
+		}
+		if err := GenerateTableDetails([]TableDetailInput{input}, opts); err != nil {
+			t.Fatalf("GenerateTableDetails(%s) error = %v", table, err)
+		}
+	}
+
+	for _, table := range []string{"Users", "users"} {
+		path := enrichedColumnsFilePath(opts, "analytics", "public", table)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read columns file of %s: %v", table, err)
+		}
+		var file ColumnsFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			t.Fatalf("parse %s: %v", path, err)
+		}
+		if file.Table != table || len(file.Columns) != 1 || file.Columns[0].Name != table+"_id" {
+			t.Errorf("%s holds table %q columns %+v, want table %q", path, file.Table, file.Columns, table)
+		}
+	}
+
+	schemaDir := filepath.Join(baseDir, "context", "connections", "my-db", "databases", "analytics", "schemas", "public")
+	data, err := os.ReadFile(filepath.Join(schemaDir, "_tables.yml"))
+	if err != nil {
+		t.Fatalf("read _tables.yml: %v", err)
+	}
+	var tables TablesFile
+	if err := yaml.Unmarshal(data, &tables); err != nil {
+		t.Fatalf("parse _tables.yml: %v", err)
+	}
+	dirs := map[string]string{}
+	for _, entry := range tables.Tables {
+		dirs[entry.Name] = entry.Dir
+	}
+	if dirs["users"] != "" || dirs["Users"] != "users_"+hashSuffix("Users") {
+		t.Errorf("_tables.yml dirs = %v, want only Users to list its suffixed dir", dirs)
+	}
+	if _, err := os.Stat(filepath.Join(schemaDir, dirs["Users"], dirs["Users"]+"__columns.yml")); err != nil {
+		t.Errorf("listed dir of Users has no columns file: %v", err)
+	}
+}
+
+func hashSuffix(table string) string {
+	sum := sha256.Sum256([]byte(table))
+	return hex.EncodeToString(sum[:4])
+}
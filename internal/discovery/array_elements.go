@@ -0,0 +1,111 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// arrayElement is the name the element queries give to one unnested array
+// element.
+const arrayElement = "dbh_element"
+
+// isArrayDataType reports whether dataType is an array type: ARRAY in the
+// Postgres and Snowflake catalogs, ARRAY<T> in BigQuery, or T[] as Postgres
+// formats it.
+func isArrayDataType(dataType string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(dataType))
+	return upper == "ARRAY" || strings.HasPrefix(upper, "ARRAY<") || strings.HasSuffix(upper, "[]")
+}
+
+// unnestColumn reports whether the elements of column are profiled.
+func (o EnrichmentOptions) unnestColumn(column ColumnInfo) bool {
+	return o.UnnestArrays && isArrayDataType(column.DataType)
+}
+
+// arrayElementDialect holds the backend-specific SQL that turns an array
+// column into one row per element.
+type arrayElementDialect struct {
+	// unnest formats the FROM clause joining the table (%[1]s) with the
+	// elements of the column (%[2]s).
+	unnest string
+	// element is the expression for one element in that FROM clause.
+	element string
+	// text formats an element (%[1]s) as text.
+	text string
+	// countDistinct opens the distinct count, e.g. "COUNT(DISTINCT".
+	countDistinct string
+}
+
+// statsQuery builds a query returning the element count and the distinct
+// element count of column. table may include a sampling clause.
+func (d arrayElementDialect) statsQuery(table, column, sinceAnd string) string {
+	return fmt.Sprintf(
+		"SELECT COUNT(%[1]s), %[2]s %[3]s)\nFROM %[4]s\nWHERE %[1]s IS NOT NULL%[5]s",
+		d.element,
+		d.countDistinct,
+		fmt.Sprintf(d.text, d.element),
+		fmt.Sprintf(d.unnest, table, column),
+		sinceAnd,
+	)
+}
+
+// sampleQuery builds a query returning distinct sample elements of column,
+// chosen by opts.SampleStrategy.
+func (d arrayElementDialect) sampleQuery(table, column, sinceAnd string, opts EnrichmentOptions) string {
+	return opts.sampleValuesQuery(
+		fmt.Sprintf("LEFT(%s, %d)", fmt.Sprintf(d.text, d.element), opts.MaxSampleValueLength),
+		fmt.Sprintf(d.unnest, table, column),
+		d.element,
+		sinceAnd,
+	)
+}
+
+// queryArrayElementStats runs statsQuery and, unless it is empty,
+// sampleQuery with args.
+func queryArrayElementStats(ctx context.Context, db *sql.DB, values valueFormatter, statsQuery, sampleQuery string, args []interface{}, opts EnrichmentOptions) (*ArrayElementStats, error) {
+	var count, distinct interface{}
+	if err := db.QueryRowContext(ctx, statsQuery, args...).Scan(&count, &distinct); err != nil {
+		return nil, err
+	}
+	stats, err := parseArrayElementCounts(count, distinct)
+	if err != nil {
+		return nil, err
+	}
+	if sampleQuery == "" {
+		return stats, nil
+	}
+
+	rows, err := db.QueryContext(ctx, sampleQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query sample elements: %w", err)
+	}
+	defer rows.Close()
+
+	var samples []string
+	for rows.Next() {
+		var value interface{}
+		if err := rows.Scan(&value); err != nil {
+			return nil, fmt.Errorf("scan sample element: %w", err)
+		}
+		samples = append(samples, values.format(value))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sample elements: %w", err)
+	}
+	stats.SampleElements = normalizeColumnSampleValues(samples, opts)
+	return stats, nil
+}
+
+func parseArrayElementCounts(count, distinct interface{}) (*ArrayElementStats, error) {
+	elementCount, err := int64FromDBValue(count)
+	if err != nil {
+		return nil, fmt.Errorf("parse element_count: %w", err)
+	}
+	distinctCount, err := int64FromDBValue(distinct)
+	if err != nil {
+		return nil, fmt.Errorf("parse distinct_element_count: %w", err)
+	}
+	return &ArrayElementStats{ElementCount: elementCount, DistinctElementCount: distinctCount}, nil
+}
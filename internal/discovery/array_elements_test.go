@@ -0,0 +1,45 @@
+package discovery
+
+import "testing"
+
+func TestIsArrayDataType(t *testing.T) {
+	tests := map[string]bool{
+		"ARRAY":                          true,
+		"ARRAY<INT64>":                   true,
+		"ARRAY<STRUCT<a INT64, b BOOL>>": true,
+		"integer[]":                      true,
+		"text":                           false,
+		"STRUCT<tags ARRAY<STRING>>":     false,
+		"json":                           false,
+	}
+	for dataType, want := range tests {
+		if got := isArrayDataType(dataType); got != want {
+			t.Errorf("isArrayDataType(%q) = %v, want %v", dataType, got, want)
+		}
+	}
+}
+
+func TestArrayElementDialectQueries(t *testing.T) {
+	dialect := arrayElementDialect{
+		unnest:        "%[1]s CROSS JOIN LATERAL unnest(%[2]s) AS dbh_elements(" + arrayElement + ")",
+		element:       arrayElement,
+		text:          "%[1]s::text",
+		countDistinct: "COUNT(DISTINCT",
+	}
+
+	stats := dialect.statsQuery(`"public"."posts" TABLESAMPLE SYSTEM (10)`, `"tags"`, ` AND "created_at" > $1`)
+	wantStats := "SELECT COUNT(dbh_element), COUNT(DISTINCT dbh_element::text)\n" +
+		`FROM "public"."posts" TABLESAMPLE SYSTEM (10) CROSS JOIN LATERAL unnest("tags") AS dbh_elements(dbh_element)` + "\n" +
+		`WHERE dbh_element IS NOT NULL AND "created_at" > $1`
+	if stats != wantStats {
+		t.Errorf("statsQuery() =\n%s\nwant\n%s", stats, wantStats)
+	}
+
+	sample := dialect.sampleQuery(`"public"."posts"`, `"tags"`, "", DefaultEnrichmentOptions())
+	wantSample := "SELECT DISTINCT LEFT(dbh_element::text, 180)\n" +
+		`FROM "public"."posts" CROSS JOIN LATERAL unnest("tags") AS dbh_elements(dbh_element)` + "\n" +
+		"WHERE dbh_element IS NOT NULL\nLIMIT 5"
+	if sample != wantSample {
+		t.Errorf("sampleQuery() =\n%s\nwant\n%s", sample, wantSample)
+	}
+}
@@ -3,13 +3,17 @@ package discovery
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	gcpbigquery "cloud.google.com/go/bigquery"
 	bigqueryv2 "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
@@ -20,6 +24,13 @@ type bigQueryDiscoverer struct {
 
 	locationMu       sync.Mutex
 	datasetLocations map[string]string
+
+	sinceTypesMu sync.Mutex
+	sinceTypes   map[string]string // "dataset.table.column" -> column type
+
+	statsBatches tableStatsBatcher
+
+	values valueFormatter
 }
 
 type bigQueryDatabaseLister struct {
@@ -29,9 +40,14 @@ type bigQueryDatabaseLister struct {
 }
 
 func newBigQuery(cfg DatabaseConfig) (*bigQueryDiscoverer, error) {
+	values, err := newValueFormatter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	projectID := resolveBigQueryProjectID(cfg)
 	if projectID == "" {
-		return nil, fmt.Errorf("bigquery requires project_id or database (project)")
+		return nil, ErrMissingProject
 	}
 
 	clientOptions := bigQueryClientOptions(cfg)
@@ -44,13 +60,15 @@ func newBigQuery(cfg DatabaseConfig) (*bigQueryDiscoverer, error) {
 		client:           client,
 		projectID:        projectID,
 		datasetLocations: make(map[string]string),
+		sinceTypes:       make(map[string]string),
+		values:           values,
 	}, nil
 }
 
 func newBigQueryDatabaseLister(cfg DatabaseConfig) (*bigQueryDatabaseLister, error) {
 	seedProjectID := resolveBigQuerySeedProjectID(cfg)
 	if seedProjectID == "" {
-		return nil, fmt.Errorf("bigquery requires project_id or database (project)")
+		return nil, ErrMissingProject
 	}
 
 	clientOptions := bigQueryClientOptions(cfg)
@@ -133,8 +151,7 @@ func (b *bigQueryDatabaseLister) ListDatabases(ctx context.Context) ([]string, e
 		}
 	}
 
-	sort.Strings(databases)
-	return databases, nil
+	return NormalizeDatabaseNames(databases), nil
 }
 
 func (b *bigQueryDatabaseLister) Close() error {
@@ -246,6 +263,74 @@ func normalizeBigQueryTableType(tableType gcpbigquery.TableType) string {
 	}
 }
 
+// EstimateRowCounts reads row_count from the dataset's __TABLES__ meta-table,
+// which is free to query and covers tables (not views).
+func (b *bigQueryDiscoverer) EstimateRowCounts(ctx context.Context, schema string) (map[string]int64, error) {
+	queryText := fmt.Sprintf(
+		"SELECT table_id, row_count FROM %s WHERE type = 1",
+		quoteBigQueryTableReference(b.projectID, schema, "__TABLES__"),
+	)
+
+	it, err := b.runQuery(ctx, schema, queryText)
+	if err != nil {
+		return nil, err
+	}
+
+	estimates := make(map[string]int64)
+	for {
+		var row []gcpbigquery.Value
+		if err := it.Next(&row); err != nil {
+			if err == iterator.Done {
+				break
+			}
+			return nil, fmt.Errorf("scan row estimate: %w", err)
+		}
+		if len(row) < 2 {
+			continue
+		}
+		count, err := int64FromDBValue(row[1])
+		if err != nil {
+			return nil, fmt.Errorf("parse row estimate for %v: %w", row[0], err)
+		}
+		estimates[formatBigQueryValue(row[0])] = count
+	}
+	return estimates, nil
+}
+
+// TableLastModified reads last_modified_time, in milliseconds since the
+// epoch, from the dataset's __TABLES__ meta-table.
+func (b *bigQueryDiscoverer) TableLastModified(ctx context.Context, schema string) (map[string]time.Time, error) {
+	queryText := fmt.Sprintf(
+		"SELECT table_id, last_modified_time FROM %s WHERE type = 1",
+		quoteBigQueryTableReference(b.projectID, schema, "__TABLES__"),
+	)
+
+	it, err := b.runQuery(ctx, schema, queryText)
+	if err != nil {
+		return nil, err
+	}
+
+	modified := make(map[string]time.Time)
+	for {
+		var row []gcpbigquery.Value
+		if err := it.Next(&row); err != nil {
+			if err == iterator.Done {
+				break
+			}
+			return nil, fmt.Errorf("scan table modification time: %w", err)
+		}
+		if len(row) < 2 {
+			continue
+		}
+		millis, err := int64FromDBValue(row[1])
+		if err != nil {
+			return nil, fmt.Errorf("parse modification time for %v: %w", row[0], err)
+		}
+		modified[formatBigQueryValue(row[0])] = time.UnixMilli(millis).UTC()
+	}
+	return modified, nil
+}
+
 func (b *bigQueryDiscoverer) GetColumns(ctx context.Context, schema, table string) ([]ColumnInfo, error) {
 	tableMetadata, err := b.client.DatasetInProject(b.projectID, schema).Table(table).Metadata(ctx)
 	if err != nil {
@@ -319,22 +404,26 @@ func bigQueryStructType(schema gcpbigquery.Schema) string {
 	return fmt.Sprintf("STRUCT<%s>", strings.Join(parts, ", "))
 }
 
-func (b *bigQueryDiscoverer) GetColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo) (EnrichedColumnInfo, error) {
+func (b *bigQueryDiscoverer) GetColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (EnrichedColumnInfo, error) {
+	opts = opts.withDefaults()
 	profile := newEnrichedColumnInfo(column)
 
 	quotedTable := quoteBigQueryTableReference(b.projectID, schema, table)
-	quotedColumn := quoteBigQueryColumnPath(column.Name)
 
-	statsQuery := fmt.Sprintf(`
-		SELECT
-			COUNT(1) AS total_rows,
-			COUNTIF(%[1]s IS NULL) AS null_count,
-			COUNTIF(%[1]s IS NOT NULL) AS non_null_count,
-			COUNT(DISTINCT IF(%[1]s IS NULL, NULL, TO_JSON_STRING(%[1]s))) AS distinct_non_null_count
-		FROM %[2]s
-	`, quotedColumn, quotedTable)
+	var sinceWhere, sinceAnd string
+	var sinceParams []gcpbigquery.QueryParameter
+	if !opts.Since.IsZero() {
+		placeholder, err := b.sincePlaceholder(ctx, schema, table, opts.Since.Column)
+		if err != nil {
+			return EnrichedColumnInfo{}, err
+		}
+		var args []interface{}
+		sinceWhere, args = opts.sinceClause("WHERE", quoteBigQueryColumnPath, placeholder)
+		sinceAnd, _ = opts.sinceClause("AND", quoteBigQueryColumnPath, placeholder)
+		sinceParams = []gcpbigquery.QueryParameter{{Name: "since", Value: args[0]}}
+	}
 
-	stats, err := b.readSingleRow(ctx, schema, statsQuery)
+	stats, err := batchedColumnStats(ctx, &b.statsBatches, schema, table, column, opts, b.readColumnStats(schema, quotedTable, opts, sinceWhere, sinceParams), isBigQueryComplexityError)
 	if err != nil {
 		return EnrichedColumnInfo{}, fmt.Errorf(
 			"profile bigquery column %q on %s.%s: %w",
@@ -344,49 +433,37 @@ func (b *bigQueryDiscoverer) GetColumnEnrichment(ctx context.Context, schema, ta
 			err,
 		)
 	}
-	if len(stats) < 4 {
-		return EnrichedColumnInfo{}, fmt.Errorf(
-			"profile bigquery column %q on %s.%s: expected 4 stats values, got %d",
-			column.Name,
-			schema,
-			table,
-			len(stats),
-		)
-	}
+	stats.apply(&profile)
 
-	profile.TotalRows, err = int64FromDBValue(stats[0])
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse total_rows for %q on %s.%s: %w", column.Name, schema, table, err)
-	}
-	profile.NullCount, err = int64FromDBValue(stats[1])
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse null_count for %q on %s.%s: %w", column.Name, schema, table, err)
-	}
-	profile.NonNullCount, err = int64FromDBValue(stats[2])
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse non_null_count for %q on %s.%s: %w", column.Name, schema, table, err)
-	}
-	profile.DistinctNonNullCount, err = int64FromDBValue(stats[3])
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse distinct_non_null_count for %q on %s.%s: %w", column.Name, schema, table, err)
+	if opts.unnestColumn(column) {
+		if profile.Elements, err = b.readArrayElementStats(ctx, schema, table, column, opts); err != nil {
+			return EnrichedColumnInfo{}, fmt.Errorf(
+				"profile bigquery array elements of %q on %s.%s: %w",
+				column.Name,
+				schema,
+				table,
+				err,
+			)
+		}
 	}
 
-	profile.DistinctOfNonNullPct = percentOfTotal(profile.DistinctNonNullCount, profile.NonNullCount)
-	profile.NullOfTotalRowsPct = percentOfTotal(profile.NullCount, profile.TotalRows)
-	profile.NonNullOfTotalRowsPct = percentOfTotal(profile.NonNullCount, profile.TotalRows)
+	if opts.percentileColumn(column) {
+		if profile.Percentiles, err = b.readColumnPercentiles(ctx, schema, table, column, opts); err != nil {
+			return EnrichedColumnInfo{}, fmt.Errorf(
+				"profile bigquery percentiles of %q on %s.%s: %w",
+				column.Name,
+				schema,
+				table,
+				err,
+			)
+		}
+	}
 
-	if shouldSkipColumnSamples(column.DataType) {
+	if opts.skipColumnSamples(column) {
 		return profile, nil
 	}
 
-	sampleQuery := fmt.Sprintf(`
-		SELECT DISTINCT LEFT(TO_JSON_STRING(%[1]s), %[2]d)
-		FROM %[3]s
-		WHERE %[1]s IS NOT NULL
-		LIMIT %[4]d
-	`, quotedColumn, maxColumnSampleValueLength, quotedTable, columnProfileSampleValueLimit)
-
-	samples, err := b.readSingleColumnValues(ctx, schema, sampleQuery)
+	samples, err := b.readSingleColumnValues(ctx, schema, b.sampleValuesSQL(schema, table, column, opts, sinceAnd), sinceParams...)
 	if err != nil {
 		return EnrichedColumnInfo{}, fmt.Errorf(
 			"query bigquery sample values for %q on %s.%s: %w",
@@ -397,10 +474,22 @@ func (b *bigQueryDiscoverer) GetColumnEnrichment(ctx context.Context, schema, ta
 		)
 	}
 
-	profile.SampleValues = normalizeColumnSampleValues(samples)
+	profile.SampleValues = normalizeColumnSampleValues(samples, opts)
 	return profile, nil
 }
 
+// sampleValuesSQL builds the sample values query of column. sinceAnd is the
+// Since predicate, whose placeholder depends on the column type.
+func (b *bigQueryDiscoverer) sampleValuesSQL(schema, table string, column ColumnInfo, opts EnrichmentOptions, sinceAnd string) string {
+	quotedColumn := quoteBigQueryColumnPath(column.Name)
+	return opts.sampleValuesQuery(
+		fmt.Sprintf("LEFT(TO_JSON_STRING(%s), %d)", quotedColumn, opts.MaxSampleValueLength),
+		quoteBigQueryTableReference(b.projectID, schema, table),
+		quotedColumn,
+		sinceAnd,
+	)
+}
+
 func (b *bigQueryDiscoverer) GetSampleRows(ctx context.Context, schema, table string, limit int) (*SampleResult, error) {
 	if limit <= 0 {
 		limit = 10
@@ -417,11 +506,221 @@ func (b *bigQueryDiscoverer) GetSampleRows(ctx context.Context, schema, table st
 		return nil, fmt.Errorf("query bigquery sample rows: %w", err)
 	}
 
-	return scanBigQuerySampleRows(it)
+	return scanBigQuerySampleRows(it, b.values)
 }
 
-func (b *bigQueryDiscoverer) readSingleRow(ctx context.Context, dataset, queryText string) ([]gcpbigquery.Value, error) {
-	it, err := b.runQuery(ctx, dataset, queryText)
+// GetTableColumnStats computes the row count and the null, non-null, and
+// distinct non-null counts of columns with one query per group of up to 100
+// columns instead of one query job per column. Sample values are not
+// collected.
+func (b *bigQueryDiscoverer) GetTableColumnStats(ctx context.Context, schema, table string, columns []ColumnInfo, opts EnrichmentOptions) ([]EnrichedColumnInfo, error) {
+	var sinceWhere string
+	var sinceParams []gcpbigquery.QueryParameter
+	if !opts.Since.IsZero() {
+		placeholder, err := b.sincePlaceholder(ctx, schema, table, opts.Since.Column)
+		if err != nil {
+			return nil, err
+		}
+		var args []interface{}
+		sinceWhere, args = opts.sinceClause("WHERE", quoteBigQueryColumnPath, placeholder)
+		sinceParams = []gcpbigquery.QueryParameter{{Name: "since", Value: args[0]}}
+	}
+
+	quotedTable := quoteBigQueryTableReference(b.projectID, schema, table)
+	profiles, err := tableColumnStats(ctx, columns, b.readColumnStats(schema, quotedTable, opts, sinceWhere, sinceParams), isBigQueryComplexityError)
+	if err != nil {
+		return nil, fmt.Errorf("profile bigquery columns on %s.%s: %w", schema, table, err)
+	}
+	return profiles, nil
+}
+
+// readColumnStats returns a function computing the stats of columns in a
+// single query.
+func (b *bigQueryDiscoverer) readColumnStats(
+	dataset string,
+	quotedTable string,
+	opts EnrichmentOptions,
+	sinceWhere string,
+	sinceParams []gcpbigquery.QueryParameter,
+) readStatsFunc {
+	return func(ctx context.Context, columns []ColumnInfo) (map[string]columnStats, error) {
+		row, err := b.readSingleRow(ctx, dataset, bigQueryStatsQuery(quotedTable, columns, opts, sinceWhere), sinceParams...)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, len(row))
+		for i, value := range row {
+			values[i] = value
+		}
+		return parseColumnStatsRow(values, columns)
+	}
+}
+
+// bigQueryStatsQuery builds a query returning COUNT(1) followed by the null,
+// non-null, and distinct non-null counts of each column in order.
+func bigQueryStatsQuery(quotedTable string, columns []ColumnInfo, opts EnrichmentOptions, sinceWhere string) string {
+	countDistinct := "COUNT(DISTINCT"
+	if opts.ApproxDistinct {
+		countDistinct = "APPROX_COUNT_DISTINCT("
+	}
+	dialect := statsDialect{
+		totalRows:     "COUNT(1)",
+		nullCount:     "COUNTIF(%[1]s IS NULL)",
+		nonNullCount:  "COUNTIF(%[1]s IS NOT NULL)",
+		distinctCount: countDistinct + " IF(%[1]s IS NULL, NULL, TO_JSON_STRING(%[1]s)))",
+	}
+	return fmt.Sprintf(
+		"SELECT\n\t%s\nFROM %s%s%s",
+		dialect.selectList(columns, quoteBigQueryColumnPath),
+		quotedTable,
+		opts.tablesampleClause("TABLESAMPLE SYSTEM (%s PERCENT)"),
+		sinceWhere,
+	)
+}
+
+// readArrayElementStats profiles the elements of an ARRAY<T> column with
+// UNNEST.
+func (b *bigQueryDiscoverer) readArrayElementStats(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (*ArrayElementStats, error) {
+	var sinceAnd string
+	var sinceParams []gcpbigquery.QueryParameter
+	if !opts.Since.IsZero() {
+		placeholder, err := b.sincePlaceholder(ctx, schema, table, opts.Since.Column)
+		if err != nil {
+			return nil, err
+		}
+		var args []interface{}
+		sinceAnd, args = opts.sinceClause("AND", quoteBigQueryColumnPath, placeholder)
+		sinceParams = []gcpbigquery.QueryParameter{{Name: "since", Value: args[0]}}
+	}
+
+	statsQuery, sampleQuery := b.arrayElementSQL(schema, table, column, opts, sinceAnd)
+	row, err := b.readSingleRow(ctx, schema, statsQuery, sinceParams...)
+	if err != nil {
+		return nil, err
+	}
+	if len(row) < 2 {
+		return nil, fmt.Errorf("expected 2 element stats values, got %d", len(row))
+	}
+	stats, err := parseArrayElementCounts(row[0], row[1])
+	if err != nil || sampleQuery == "" {
+		return stats, err
+	}
+
+	samples, err := b.readSingleColumnValues(ctx, schema, sampleQuery, sinceParams...)
+	if err != nil {
+		return nil, fmt.Errorf("query sample elements: %w", err)
+	}
+	stats.SampleElements = normalizeColumnSampleValues(samples, opts)
+	return stats, nil
+}
+
+// arrayElementSQL builds the element stats query of an array column and,
+// unless its samples are skipped, the sample elements query.
+func (b *bigQueryDiscoverer) arrayElementSQL(schema, table string, column ColumnInfo, opts EnrichmentOptions, sinceAnd string) (string, string) {
+	countDistinct := "COUNT(DISTINCT"
+	if opts.ApproxDistinct {
+		countDistinct = "APPROX_COUNT_DISTINCT("
+	}
+	dialect := arrayElementDialect{
+		unnest:        "%[1]s CROSS JOIN UNNEST(%[2]s) AS " + arrayElement,
+		element:       arrayElement,
+		text:          "TO_JSON_STRING(%[1]s)",
+		countDistinct: countDistinct,
+	}
+	quotedTable := quoteBigQueryTableReference(b.projectID, schema, table)
+	quotedColumn := quoteBigQueryColumnPath(column.Name)
+
+	statsQuery := dialect.statsQuery(quotedTable+opts.tablesampleClause("TABLESAMPLE SYSTEM (%s PERCENT)"), quotedColumn, sinceAnd)
+	var sampleQuery string
+	if !opts.skipColumnSamples(column) {
+		sampleQuery = dialect.sampleQuery(quotedTable, quotedColumn, sinceAnd, opts)
+	}
+	return statsQuery, sampleQuery
+}
+
+// readColumnPercentiles computes approximate percentiles from
+// APPROX_QUANTILES with 100 buckets.
+func (b *bigQueryDiscoverer) readColumnPercentiles(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (*ColumnPercentiles, error) {
+	var sinceAnd string
+	var sinceParams []gcpbigquery.QueryParameter
+	if !opts.Since.IsZero() {
+		placeholder, err := b.sincePlaceholder(ctx, schema, table, opts.Since.Column)
+		if err != nil {
+			return nil, err
+		}
+		var args []interface{}
+		sinceAnd, args = opts.sinceClause("AND", quoteBigQueryColumnPath, placeholder)
+		sinceParams = []gcpbigquery.QueryParameter{{Name: "since", Value: args[0]}}
+	}
+
+	row, err := b.readSingleRow(ctx, schema, b.percentilesSQL(schema, table, column, opts, sinceAnd), sinceParams...)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(row))
+	for i, value := range row {
+		values[i] = value
+	}
+	return parseColumnPercentiles(values)
+}
+
+// percentilesSQL builds the percentiles query of a numeric column.
+func (b *bigQueryDiscoverer) percentilesSQL(schema, table string, column ColumnInfo, opts EnrichmentOptions, sinceAnd string) string {
+	quotedTable := quoteBigQueryTableReference(b.projectID, schema, table)
+	return percentilesQuery(func(column string, fraction float64) string {
+		return fmt.Sprintf("CAST(APPROX_QUANTILES(%s, 100)[OFFSET(%d)] AS FLOAT64)", column, int(math.Round(fraction*100)))
+	}, quotedTable+opts.tablesampleClause("TABLESAMPLE SYSTEM (%s PERCENT)"), quoteBigQueryColumnPath(column.Name), sinceAnd)
+}
+
+// isBigQueryComplexityError reports whether err is BigQuery rejecting a
+// query as too large or too complex to plan.
+func isBigQueryComplexityError(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		for _, item := range apiErr.Errors {
+			if item.Reason == "resourcesExceeded" {
+				return true
+			}
+		}
+	}
+	var jobErr *gcpbigquery.Error
+	if errors.As(err, &jobErr) && jobErr.Reason == "resourcesExceeded" {
+		return true
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "too complex") || strings.Contains(message, "query is too large")
+}
+
+// sincePlaceholder returns the bind expression for a since filter on column,
+// casting the STRING @since parameter to the column's type because BigQuery
+// does not coerce query parameters. The type is looked up once per table,
+// since every column of the table is profiled with the same since column.
+func (b *bigQueryDiscoverer) sincePlaceholder(ctx context.Context, dataset, table, column string) (string, error) {
+	key := dataset + "." + table + "." + column
+	b.sinceTypesMu.Lock()
+	dataType, ok := b.sinceTypes[key]
+	b.sinceTypesMu.Unlock()
+	if ok {
+		return fmt.Sprintf("CAST(@since AS %s)", dataType), nil
+	}
+
+	columns, err := b.GetColumns(ctx, dataset, table)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range columns {
+		if c.Name == column {
+			b.sinceTypesMu.Lock()
+			b.sinceTypes[key] = c.DataType
+			b.sinceTypesMu.Unlock()
+			return fmt.Sprintf("CAST(@since AS %s)", c.DataType), nil
+		}
+	}
+	return "", fmt.Errorf("since column %q not found on %s.%s", column, dataset, table)
+}
+
+func (b *bigQueryDiscoverer) readSingleRow(ctx context.Context, dataset, queryText string, params ...gcpbigquery.QueryParameter) ([]gcpbigquery.Value, error) {
+	it, err := b.runQuery(ctx, dataset, queryText, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -436,8 +735,8 @@ func (b *bigQueryDiscoverer) readSingleRow(ctx context.Context, dataset, queryTe
 	return row, nil
 }
 
-func (b *bigQueryDiscoverer) readSingleColumnValues(ctx context.Context, dataset, queryText string) ([]string, error) {
-	it, err := b.runQuery(ctx, dataset, queryText)
+func (b *bigQueryDiscoverer) readSingleColumnValues(ctx context.Context, dataset, queryText string, params ...gcpbigquery.QueryParameter) ([]string, error) {
+	it, err := b.runQuery(ctx, dataset, queryText, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -454,14 +753,21 @@ func (b *bigQueryDiscoverer) readSingleColumnValues(ctx context.Context, dataset
 		if len(row) == 0 {
 			continue
 		}
-		values = append(values, formatBigQueryValue(row[0]))
+		values = append(values, b.values.formatBigQuery(row[0]))
 	}
 
 	return values, nil
 }
 
-func (b *bigQueryDiscoverer) runQuery(ctx context.Context, dataset, queryText string) (*gcpbigquery.RowIterator, error) {
+func (b *bigQueryDiscoverer) runQuery(ctx context.Context, dataset, queryText string, params ...gcpbigquery.QueryParameter) (*gcpbigquery.RowIterator, error) {
+	args := make([]interface{}, len(params))
+	for i, param := range params {
+		args[i] = fmt.Sprintf("@%s=%v", param.Name, param.Value)
+	}
+	logQuery("bigquery", queryText, args)
+
 	query := b.client.Query(queryText)
+	query.Parameters = params
 	if location := b.datasetLocation(ctx, dataset); location != "" {
 		query.Location = location
 	}
@@ -498,7 +804,7 @@ func (b *bigQueryDiscoverer) datasetLocation(ctx context.Context, dataset string
 	return location
 }
 
-func scanBigQuerySampleRows(it *gcpbigquery.RowIterator) (*SampleResult, error) {
+func scanBigQuerySampleRows(it *gcpbigquery.RowIterator, values valueFormatter) (*SampleResult, error) {
 	result := &SampleResult{}
 
 	for {
@@ -516,7 +822,7 @@ func scanBigQuerySampleRows(it *gcpbigquery.RowIterator) (*SampleResult, error)
 
 		formattedRow := make([]string, len(row))
 		for i, value := range row {
-			formattedRow[i] = formatBigQueryValue(value)
+			formattedRow[i] = values.formatBigQuery(value)
 		}
 		result.Rows = append(result.Rows, formattedRow)
 	}
@@ -581,8 +887,14 @@ func quoteBigQueryColumnPath(columnPath string) string {
 	return strings.Join(quoted, ".")
 }
 
+// formatBigQueryValue renders a BigQuery value without time zone
+// conversion.
 func formatBigQueryValue(value interface{}) string {
-	normalized := normalizeBigQueryValue(value)
+	return valueFormatter{}.formatBigQuery(value)
+}
+
+func (f valueFormatter) formatBigQuery(value interface{}) string {
+	normalized := f.normalizeBigQuery(value)
 	if normalized == nil {
 		return ""
 	}
@@ -591,17 +903,17 @@ func formatBigQueryValue(value interface{}) string {
 	case string:
 		return v
 	case bool, int, int32, int64, float32, float64:
-		return formatValue(v)
+		return f.format(v)
 	default:
 		data, err := json.Marshal(v)
 		if err == nil {
 			return string(data)
 		}
-		return formatValue(v)
+		return f.format(v)
 	}
 }
 
-func normalizeBigQueryValue(value interface{}) interface{} {
+func (f valueFormatter) normalizeBigQuery(value interface{}) interface{} {
 	switch v := value.(type) {
 	case nil:
 		return nil
@@ -633,7 +945,7 @@ func normalizeBigQueryValue(value interface{}) interface{} {
 		return nil
 	case gcpbigquery.NullTimestamp:
 		if v.Valid {
-			return formatValue(v.Timestamp)
+			return f.format(v.Timestamp)
 		}
 		return nil
 	case gcpbigquery.NullDate:
@@ -664,29 +976,29 @@ func normalizeBigQueryValue(value interface{}) interface{} {
 	case []gcpbigquery.Value:
 		out := make([]interface{}, len(v))
 		for i, item := range v {
-			out[i] = normalizeBigQueryValue(item)
+			out[i] = f.normalizeBigQuery(item)
 		}
 		return out
 	case []interface{}:
 		out := make([]interface{}, len(v))
 		for i, item := range v {
-			out[i] = normalizeBigQueryValue(item)
+			out[i] = f.normalizeBigQuery(item)
 		}
 		return out
 	case map[string]gcpbigquery.Value:
 		out := make(map[string]interface{}, len(v))
 		for key, item := range v {
-			out[key] = normalizeBigQueryValue(item)
+			out[key] = f.normalizeBigQuery(item)
 		}
 		return out
 	case map[string]interface{}:
 		out := make(map[string]interface{}, len(v))
 		for key, item := range v {
-			out[key] = normalizeBigQueryValue(item)
+			out[key] = f.normalizeBigQuery(item)
 		}
 		return out
 	default:
-		return formatValue(v)
+		return f.format(v)
 	}
 }
 
@@ -0,0 +1,125 @@
+package discovery
+
+import "strings"
+
+// BackendCapabilities lists the optional discovery features a backend
+// supports. Commands check it to skip a step, with a note, instead of
+// failing on a backend that cannot do it.
+type BackendCapabilities struct {
+	Type string
+
+	// Schemas is false for backends whose databases are also their only
+	// schema (see HasSingleLevelNamespace).
+	Schemas bool
+	// RowEstimates reads row counts from catalog statistics (RowEstimator).
+	RowEstimates bool
+	// LastModified reads table modification times (LastModifiedReader),
+	// used by dbh columns --only-changed.
+	LastModified bool
+	// SpreadSampling samples rows across the integer primary key range
+	// (SpreadSampler), used by dbh tables --sample-spread.
+	SpreadSampling bool
+	// ColumnComments writes column comments back (ColumnCommenter), used
+	// by dbh comments push.
+	ColumnComments bool
+	// ApproxDistinct has an approximate distinct count for
+	// EnrichmentOptions.ApproxDistinct.
+	ApproxDistinct bool
+	// Tablesample has a block sampling clause for
+	// EnrichmentOptions.TablesamplePct.
+	Tablesample bool
+	// ArrayElements profiles array elements for
+	// EnrichmentOptions.UnnestArrays.
+	ArrayElements bool
+	// Percentiles has a percentile aggregate for
+	// EnrichmentOptions.Percentiles.
+	Percentiles bool
+	// ShowCommands discovers with SHOW commands (DatabaseConfig.ShowCommands).
+	ShowCommands bool
+	// CatalogStats reads column stats from the database's own statistics
+	// for EnrichmentOptions.CatalogStats.
+	CatalogStats bool
+}
+
+// backendCapabilities holds the capabilities of every supported backend,
+// in the order dbh lists backends.
+var backendCapabilities = []BackendCapabilities{
+	{
+		Type:           "postgres",
+		Schemas:        true,
+		RowEstimates:   true,
+		SpreadSampling: true,
+		ColumnComments: true,
+		Tablesample:    true,
+		ArrayElements:  true,
+		Percentiles:    true,
+		CatalogStats:   true,
+	},
+	{
+		Type:           "redshift",
+		Schemas:        true,
+		RowEstimates:   true,
+		ColumnComments: true,
+		ApproxDistinct: true,
+		Percentiles:    true,
+	},
+	{
+		Type:           "snowflake",
+		Schemas:        true,
+		RowEstimates:   true,
+		LastModified:   true,
+		ColumnComments: true,
+		ApproxDistinct: true,
+		Tablesample:    true,
+		ArrayElements:  true,
+		Percentiles:    true,
+		ShowCommands:   true,
+	},
+	{
+		Type:           "mysql",
+		RowEstimates:   true,
+		LastModified:   true,
+		SpreadSampling: true,
+		ColumnComments: true,
+	},
+	{
+		Type:           "bigquery",
+		Schemas:        true,
+		RowEstimates:   true,
+		LastModified:   true,
+		ApproxDistinct: true,
+		Tablesample:    true,
+		ArrayElements:  true,
+		Percentiles:    true,
+	},
+	{
+		Type:           "databricks",
+		Schemas:        true,
+		ApproxDistinct: true,
+		Tablesample:    true,
+		ArrayElements:  true,
+		Percentiles:    true,
+	},
+	{
+		Type:           "sqlite",
+		Schemas:        true,
+		SpreadSampling: true,
+	},
+}
+
+// Capabilities returns the capabilities of databaseType. An unsupported
+// type has none.
+func Capabilities(databaseType string) BackendCapabilities {
+	databaseType = strings.ToLower(strings.TrimSpace(databaseType))
+	for _, capabilities := range backendCapabilities {
+		if capabilities.Type == databaseType {
+			return capabilities
+		}
+	}
+	return BackendCapabilities{Type: databaseType}
+}
+
+// Backends returns the capabilities of every supported backend.
+func Backends() []BackendCapabilities {
+	return append([]BackendCapabilities(nil), backendCapabilities...)
+}
@@ -0,0 +1,70 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+)
+
+// The capability flags must match what each discoverer implements, so the
+// matrix cannot drift from the code.
+func TestCapabilitiesMatchDiscoverers(t *testing.T) {
+	type percentileReader interface {
+		readColumnPercentiles(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (*ColumnPercentiles, error)
+	}
+	type catalogStatsReader interface {
+		readCatalogColumnProfile(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (EnrichedColumnInfo, error)
+	}
+	type arrayElementReader interface {
+		readArrayElementStats(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (*ArrayElementStats, error)
+	}
+
+	discoverers := map[string]TableDetailDiscoverer{
+		"postgres":   &postgresDiscoverer{},
+		"redshift":   &redshiftDiscoverer{},
+		"snowflake":  &snowflakeDiscoverer{},
+		"mysql":      &mysqlDiscoverer{},
+		"bigquery":   &bigQueryDiscoverer{},
+		"databricks": &databricksDiscoverer{},
+		"sqlite":     &sqliteDiscoverer{},
+	}
+	if len(Backends()) != len(discoverers) {
+		t.Fatalf("Backends() lists %d backends, want %d", len(Backends()), len(discoverers))
+	}
+
+	for _, capabilities := range Backends() {
+		d, ok := discoverers[capabilities.Type]
+		if !ok {
+			t.Errorf("Backends() lists unknown type %q", capabilities.Type)
+			continue
+		}
+		check := func(feature string, want, got bool) {
+			if want != got {
+				t.Errorf("%s %s capability = %v, but the discoverer implements it: %v", capabilities.Type, feature, want, got)
+			}
+		}
+		_, rowEstimator := d.(RowEstimator)
+		_, lastModified := d.(LastModifiedReader)
+		_, spreadSampler := d.(SpreadSampler)
+		_, commenter := d.(ColumnCommenter)
+		_, percentiles := d.(percentileReader)
+		_, arrayElements := d.(arrayElementReader)
+		_, catalogStats := d.(catalogStatsReader)
+		check("Schemas", capabilities.Schemas, !HasSingleLevelNamespace(capabilities.Type))
+		check("RowEstimates", capabilities.RowEstimates, rowEstimator)
+		check("LastModified", capabilities.LastModified, lastModified)
+		check("SpreadSampling", capabilities.SpreadSampling, spreadSampler)
+		check("ColumnComments", capabilities.ColumnComments, commenter)
+		check("Percentiles", capabilities.Percentiles, percentiles)
+		check("ArrayElements", capabilities.ArrayElements, arrayElements)
+		check("CatalogStats", capabilities.CatalogStats, catalogStats)
+	}
+}
+
+func TestCapabilitiesOfUnknownType(t *testing.T) {
+	if got := Capabilities(" Snowflake "); !got.ShowCommands || got.Type != "snowflake" {
+		t.Errorf("Capabilities(Snowflake) = %+v, want the snowflake capabilities", got)
+	}
+	if got := Capabilities("oracle"); got != (BackendCapabilities{Type: "oracle"}) {
+		t.Errorf("Capabilities(oracle) = %+v, want none", got)
+	}
+}
@@ -0,0 +1,267 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	mysqlDriver "github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// CatalogFallbackReporter is implemented by discoverers and listers that
+// switch to backend-specific catalogs when information_schema access is
+// denied. Callers should type-assert for it after discovery and tell the
+// user which path was used.
+type CatalogFallbackReporter interface {
+	// CatalogFallback names the catalog used instead of information_schema,
+	// such as "pg_catalog", or returns "" when no fallback was needed.
+	CatalogFallback() string
+}
+
+// catalogFallback records that information_schema was denied. Once set,
+// every later metadata query goes straight to the fallback catalog.
+type catalogFallback struct {
+	path string
+	used atomic.Bool
+}
+
+func (c *catalogFallback) CatalogFallback() string {
+	if c.used.Load() {
+		return c.path
+	}
+	return ""
+}
+
+// fallBackOn reports whether err is a permission error, and if so records
+// that the fallback is in use.
+func (c *catalogFallback) fallBackOn(err error) bool {
+	if !isPermissionDenied(err) {
+		return false
+	}
+	c.used.Store(true)
+	return true
+}
+
+// isPermissionDenied reports whether err is the database refusing access
+// for lack of privileges: SQLSTATE 42501 on Postgres, or an access denied
+// error on MySQL.
+func isPermissionDenied(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "42501"
+	}
+	var mysqlErr *mysqlDriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1044, 1142, 1143, 1227: // database, table, column, and privilege access denied
+			return true
+		}
+	}
+	return false
+}
+
+// getSchemasFromCatalog lists schemas from pg_namespace, with the same
+// exclusions as the information_schema query.
+func (p *postgresDiscoverer) getSchemasFromCatalog(ctx context.Context) ([]SchemaInfo, error) {
+	query := `
+		SELECT nspname
+		FROM pg_catalog.pg_namespace
+		WHERE nspname NOT IN ('information_schema', 'pg_catalog', 'pg_toast')
+		  AND nspname NOT LIKE 'pg_temp_%'
+		  AND nspname NOT LIKE 'pg_toast_temp_%'
+		ORDER BY nspname
+	`
+
+	rows, err := p.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query postgres schemas from pg_catalog: %w", err)
+	}
+	defer rows.Close()
+
+	var schemas []SchemaInfo
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan schema row: %w", err)
+		}
+		schemas = append(schemas, SchemaInfo{Name: name})
+	}
+	return schemas, rows.Err()
+}
+
+// getTablesFromCatalog lists the relations information_schema.tables would
+// report, with the same table_type labels. Materialized views are left out
+// there, so they are here too.
+func (p *postgresDiscoverer) getTablesFromCatalog(ctx context.Context, schema string) ([]TableInfo, error) {
+	query := `
+		SELECT c.relname,
+			CASE c.relkind
+				WHEN 'v' THEN 'VIEW'
+				WHEN 'f' THEN 'FOREIGN'
+				ELSE 'BASE TABLE'
+			END
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1
+		  AND c.relkind IN ('r', 'p', 'v', 'f')
+		ORDER BY c.relname
+	`
+
+	rows, err := p.db.QueryContext(ctx, query, schema)
+	if err != nil {
+		return nil, fmt.Errorf("query postgres tables from pg_catalog: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []TableInfo
+	for rows.Next() {
+		var t TableInfo
+		if err := rows.Scan(&t.Name, &t.TableType); err != nil {
+			return nil, fmt.Errorf("scan table row: %w", err)
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// getColumnsFromCatalog reads columns from pg_attribute. Data types come
+// from format_type, so arrays read "integer[]" where information_schema
+// says "ARRAY".
+func (p *postgresDiscoverer) getColumnsFromCatalog(ctx context.Context, schema, table string) ([]ColumnInfo, error) {
+	query := `
+		SELECT a.attname,
+			CASE WHEN t.typname IN ('geometry', 'geography')
+				THEN t.typname::text ELSE format_type(a.atttypid, NULL) END,
+			CASE WHEN a.attnotnull THEN 'NO' ELSE 'YES' END,
+			a.attnum,
+			CASE WHEN a.attgenerated = 's' THEN ''
+				ELSE COALESCE(pg_get_expr(d.adbin, d.adrelid), '') END,
+			a.attgenerated = 's'
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_type t ON t.oid = a.atttypid
+		LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE n.nspname = $1 AND c.relname = $2
+		  AND a.attnum > 0
+		  AND NOT a.attisdropped
+		ORDER BY a.attnum
+	`
+
+	rows, err := p.db.QueryContext(ctx, query, schema, table)
+	if err != nil {
+		return nil, fmt.Errorf("query postgres columns from pg_catalog: %w", err)
+	}
+	defer rows.Close()
+
+	var columns []ColumnInfo
+	for rows.Next() {
+		var c ColumnInfo
+		if err := rows.Scan(&c.Name, &c.DataType, &c.IsNullable, &c.OrdinalPosition, &c.ColumnDefault, &c.IsGenerated); err != nil {
+			return nil, fmt.Errorf("scan column row: %w", err)
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// showMySQLDatabases lists databases with SHOW DATABASES, leaving out the
+// system databases.
+func showMySQLDatabases(ctx context.Context, db *sql.DB) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SHOW DATABASES")
+	if err != nil {
+		return nil, fmt.Errorf("show mysql databases: %w", err)
+	}
+	defer rows.Close()
+
+	var databases []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan database row: %w", err)
+		}
+		switch strings.ToLower(name) {
+		case "information_schema", "mysql", "performance_schema", "sys":
+			continue
+		}
+		databases = append(databases, name)
+	}
+	return databases, rows.Err()
+}
+
+func (m *mysqlDiscoverer) getSchemasFromShow(ctx context.Context) ([]SchemaInfo, error) {
+	databases, err := showMySQLDatabases(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var schemas []SchemaInfo
+	for _, name := range NormalizeDatabaseNames(databases) {
+		if m.database != "" && name != m.database {
+			continue
+		}
+		schemas = append(schemas, SchemaInfo{Name: name})
+	}
+	return schemas, nil
+}
+
+func (m *mysqlDiscoverer) getTablesFromShow(ctx context.Context, schema string) ([]TableInfo, error) {
+	rows, err := m.db.QueryContext(ctx, "SHOW FULL TABLES FROM "+quoteMySQLIdentifier(schema))
+	if err != nil {
+		return nil, fmt.Errorf("show mysql tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []TableInfo
+	for rows.Next() {
+		var t TableInfo
+		if err := rows.Scan(&t.Name, &t.TableType); err != nil {
+			return nil, fmt.Errorf("scan table row: %w", err)
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+func (m *mysqlDiscoverer) getColumnsFromShow(ctx context.Context, schema, table string) ([]ColumnInfo, error) {
+	query := fmt.Sprintf("SHOW FULL COLUMNS FROM %s.%s", quoteMySQLIdentifier(schema), quoteMySQLIdentifier(table))
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("show mysql columns: %w", err)
+	}
+	defer rows.Close()
+
+	var columns []ColumnInfo
+	for rows.Next() {
+		// Field, Type, Collation, Null, Key, Default, Extra, Privileges, Comment
+		var field, columnType, null, extra string
+		var collation, key, columnDefault, privileges, comment sql.NullString
+		if err := rows.Scan(&field, &columnType, &collation, &null, &key, &columnDefault, &extra, &privileges, &comment); err != nil {
+			return nil, fmt.Errorf("scan column row: %w", err)
+		}
+		columns = append(columns, ColumnInfo{
+			Name:            field,
+			DataType:        mysqlDataTypeFromColumnType(columnType),
+			IsNullable:      null,
+			OrdinalPosition: len(columns) + 1,
+			ColumnDefault:   columnDefault.String,
+			IsGenerated:     strings.Contains(extra, "VIRTUAL GENERATED") || strings.Contains(extra, "STORED GENERATED"),
+		})
+	}
+	return columns, rows.Err()
+}
+
+// mysqlDataTypeFromColumnType reduces a SHOW COLUMNS type such as
+// "varchar(255)" or "int unsigned" to the information_schema data_type,
+// "varchar" or "int".
+func mysqlDataTypeFromColumnType(columnType string) string {
+	columnType = strings.ToLower(strings.TrimSpace(columnType))
+	if i := strings.IndexAny(columnType, "( "); i >= 0 {
+		columnType = columnType[:i]
+	}
+	return columnType
+}
@@ -0,0 +1,56 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	mysqlDriver "github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+func TestCatalogFallbackOnPermissionDenied(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "postgres insufficient privilege", err: fmt.Errorf("query: %w", &pq.Error{Code: "42501"}), want: true},
+		{name: "postgres undefined table", err: &pq.Error{Code: "42P01"}, want: false},
+		{name: "mysql table access denied", err: &mysqlDriver.MySQLError{Number: 1142}, want: true},
+		{name: "mysql syntax error", err: &mysqlDriver.MySQLError{Number: 1064}, want: false},
+		{name: "other", err: errors.New("connection reset"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fallback := catalogFallback{path: "pg_catalog"}
+			if got := fallback.fallBackOn(tt.err); got != tt.want {
+				t.Fatalf("fallBackOn() = %v, want %v", got, tt.want)
+			}
+			want := ""
+			if tt.want {
+				want = "pg_catalog"
+			}
+			if got := fallback.CatalogFallback(); got != want {
+				t.Fatalf("CatalogFallback() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestMySQLDataTypeFromColumnType(t *testing.T) {
+	tests := map[string]string{
+		"varchar(255)":           "varchar",
+		"int unsigned":           "int",
+		"INT(11) UNSIGNED":       "int",
+		"enum('a','b')":          "enum",
+		"datetime":               "datetime",
+		"decimal(10,2) zerofill": "decimal",
+	}
+	for input, want := range tests {
+		if got := mysqlDataTypeFromColumnType(input); got != want {
+			t.Fatalf("mysqlDataTypeFromColumnType(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
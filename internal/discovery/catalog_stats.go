@@ -0,0 +1,78 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/lib/pq"
+)
+
+// ErrNoCatalogStats is returned by GetColumnEnrichment with
+// EnrichmentOptions.CatalogStats when the database has no statistics for
+// the column, usually because the table was never analyzed.
+var ErrNoCatalogStats = errors.New("no catalog statistics")
+
+// postgresCatalogStatsQuery reads the planner statistics of one column:
+// the row estimate of its table and the pg_stats null fraction, distinct
+// estimate, and most common values. A table without a pg_stats row for the
+// column still returns its row, with NULL statistics. A table that has
+// inheritance children has two pg_stats rows per column; the one for the
+// table alone is read, except for partitioned tables, which only have the
+// row that includes their partitions.
+const postgresCatalogStatsQuery = `SELECT c.reltuples::bigint, s.null_frac, s.n_distinct, s.most_common_vals::text
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN pg_catalog.pg_stats s
+  ON s.schemaname = n.nspname AND s.tablename = c.relname AND s.attname = $3
+  AND s.inherited = (c.relkind = 'p')
+WHERE n.nspname = $1 AND c.relname = $2`
+
+// readCatalogColumnProfile fills the profile of column from pg_class and
+// pg_stats, which ANALYZE and autovacuum maintain, instead of scanning the
+// table. The counts are estimates as of the last ANALYZE, and the sample
+// values are the most common values, most frequent first.
+func (p *postgresDiscoverer) readCatalogColumnProfile(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (EnrichedColumnInfo, error) {
+	profile := newEnrichedColumnInfo(column)
+
+	var totalRows int64
+	var nullFrac, nDistinct sql.NullFloat64
+	var mostCommon pq.StringArray
+	err := p.db.QueryRowContext(ctx, postgresCatalogStatsQuery, schema, table, column.Name).Scan(&totalRows, &nullFrac, &nDistinct, &mostCommon)
+	if errors.Is(err, sql.ErrNoRows) {
+		return EnrichedColumnInfo{}, fmt.Errorf("table %s.%s not found in pg_class", schema, table)
+	}
+	if err != nil {
+		return EnrichedColumnInfo{}, fmt.Errorf("query postgres catalog stats: %w", err)
+	}
+	if totalRows < 0 || !nullFrac.Valid {
+		return EnrichedColumnInfo{}, fmt.Errorf("%w for %q; run ANALYZE %s.%s", ErrNoCatalogStats, column.Name, quotePostgresIdentifier(schema), quotePostgresIdentifier(table))
+	}
+
+	catalogColumnStats(totalRows, nullFrac.Float64, nDistinct.Float64).apply(&profile)
+	if !opts.skipColumnSamples(column) {
+		profile.SampleValues = normalizeColumnSampleValues(mostCommon, opts)
+	}
+	return profile, nil
+}
+
+// catalogColumnStats derives the counts of a column from a row estimate, the
+// fraction of NULL rows, and a pg_stats style distinct estimate, where a
+// negative value is the negated ratio of distinct values to rows.
+func catalogColumnStats(totalRows int64, nullFrac, nDistinct float64) columnStats {
+	stats := columnStats{
+		TotalRows: totalRows,
+		NullCount: int64(math.Round(nullFrac * float64(totalRows))),
+	}
+	stats.NonNullCount = totalRows - stats.NullCount
+	if nDistinct < 0 {
+		stats.DistinctNonNullCount = int64(math.Round(-nDistinct * float64(totalRows)))
+	} else {
+		stats.DistinctNonNullCount = int64(math.Round(nDistinct))
+	}
+	// Estimates can disagree after rows change since the last ANALYZE.
+	stats.DistinctNonNullCount = min(stats.DistinctNonNullCount, stats.NonNullCount)
+	return stats
+}
@@ -0,0 +1,56 @@
+package discovery
+
+import "testing"
+
+func TestCatalogColumnStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		totalRows int64
+		nullFrac  float64
+		nDistinct float64
+		want      columnStats
+	}{
+		{
+			name:      "absolute distinct estimate",
+			totalRows: 1000,
+			nullFrac:  0.1,
+			nDistinct: 42,
+			want:      columnStats{TotalRows: 1000, NullCount: 100, NonNullCount: 900, DistinctNonNullCount: 42},
+		},
+		{
+			name:      "distinct ratio of rows",
+			totalRows: 1000,
+			nDistinct: -1,
+			want:      columnStats{TotalRows: 1000, NonNullCount: 1000, DistinctNonNullCount: 1000},
+		},
+		{
+			name:      "distinct capped at non-null rows",
+			totalRows: 1000,
+			nullFrac:  0.5,
+			nDistinct: -1,
+			want:      columnStats{TotalRows: 1000, NullCount: 500, NonNullCount: 500, DistinctNonNullCount: 500},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := catalogColumnStats(tt.totalRows, tt.nullFrac, tt.nDistinct); got != tt.want {
+				t.Errorf("catalogColumnStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnrichmentOptionsValidateRejectsCatalogStatsWithScans(t *testing.T) {
+	if err := (EnrichmentOptions{CatalogStats: true, StatsOnly: true}).Validate(); err != nil {
+		t.Fatalf("Validate() error = %v, want nil", err)
+	}
+	for _, opts := range []EnrichmentOptions{
+		{CatalogStats: true, Percentiles: true},
+		{CatalogStats: true, TablesamplePct: 10},
+		{CatalogStats: true, Since: SinceFilter{Column: "created_at", Op: ">", Value: "2024-01-01"}},
+	} {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Validate(%+v) error = nil, want an error", opts)
+		}
+	}
+}
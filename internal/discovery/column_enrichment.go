@@ -12,6 +12,205 @@ const (
 	maxColumnSampleValueLength    = 180
 )
 
+// Bounds accepted by EnrichmentOptions.Validate for the sample value flags
+// of dbh columns.
+const (
+	// MaxSampleValueLimit is the largest EnrichmentOptions.SampleValueLimit.
+	MaxSampleValueLimit = 50
+
+	// MinSampleValueLength and MaxSampleValueLengthLimit bound a non-zero
+	// EnrichmentOptions.MaxSampleValueLength.
+	MinSampleValueLength      = 16
+	MaxSampleValueLengthLimit = 2000
+)
+
+// EnrichmentOptions tunes how GetColumnEnrichment collects sample values.
+// Zero-valued fields fall back to the package defaults.
+type EnrichmentOptions struct {
+	// SampleValueLimit is the maximum number of distinct sample values kept
+	// per column.
+	SampleValueLimit int
+	// MaxSampleValueLength is the length at which each sample value is
+	// truncated.
+	MaxSampleValueLength int
+	// ApproxDistinct uses the backend's approximate distinct count where one
+	// exists (Redshift, Snowflake, BigQuery). Other backends count exactly.
+	ApproxDistinct bool
+	// TablesamplePct computes the stats over a block sample of roughly this
+	// percentage of the table where supported (Postgres, Snowflake,
+	// BigQuery). Zero or 100 scans the whole table; other backends always do.
+	TablesamplePct float64
+	// Since restricts the stats and sample queries to rows matching the
+	// filter, e.g. recent rows of an append-only table. The zero value
+	// profiles every row.
+	Since SinceFilter
+	// StatsOnly skips the sample value query for every column, so only the
+	// null and distinct counts are collected and no data values are read.
+	StatsOnly bool
+	// TableColumns lists the columns of the table that the caller is about
+	// to profile one by one. Backends that batch stats (BigQuery) compute
+	// the counts of all of them in the first GetColumnEnrichment call and
+	// reuse them for the rest. Nil profiles every column on its own.
+	TableColumns []ColumnInfo
+	// UnnestArrays also profiles the elements of array columns (Postgres,
+	// Snowflake, BigQuery): the element count, distinct element count, and
+	// sample elements. Other backends have no array types and ignore it.
+	UnnestArrays bool
+	// Percentiles also computes the p50, p95, and p99 of numeric columns
+	// (Postgres, Redshift, Snowflake, BigQuery, Databricks). Each column
+	// costs an extra scan, so it is off by default; MySQL and SQLite have no
+	// percentile aggregate and ignore it.
+	Percentiles bool
+	// SampleStrategy chooses which distinct values become the sample values.
+	// The zero value is SampleStrategyRandom.
+	SampleStrategy SampleStrategy
+	// CatalogStats fills the counts and sample values from the statistics
+	// the database maintains itself (Postgres pg_stats) instead of scanning
+	// the table. The counts are estimates as of the last ANALYZE and the
+	// sample values are the most common values. It cannot be combined with
+	// Since, TablesamplePct, UnnestArrays, or Percentiles.
+	CatalogStats bool
+}
+
+// SinceFilter is a "column > value" predicate applied to enrichment queries.
+// The value is passed as a bind parameter and converted by the database to
+// the column's type.
+type SinceFilter struct {
+	Column string
+	Op     string // ">" or ">="
+	Value  string
+}
+
+// ParseSinceFilter parses "column>value" or "column>=value". Whitespace
+// around the parts and single or double quotes around the value are
+// ignored.
+func ParseSinceFilter(expr string) (SinceFilter, error) {
+	index := strings.Index(expr, ">")
+	if index < 0 {
+		return SinceFilter{}, fmt.Errorf("invalid since filter %q: want column>value or column>=value", expr)
+	}
+
+	filter := SinceFilter{Column: strings.TrimSpace(expr[:index]), Op: ">"}
+	rest := expr[index+1:]
+	if strings.HasPrefix(rest, "=") {
+		filter.Op = ">="
+		rest = rest[1:]
+	}
+	filter.Value = strings.TrimSpace(rest)
+	if len(filter.Value) >= 2 {
+		first, last := filter.Value[0], filter.Value[len(filter.Value)-1]
+		if first == last && (first == '\'' || first == '"') {
+			filter.Value = filter.Value[1 : len(filter.Value)-1]
+		}
+	}
+
+	if err := filter.validate(); err != nil {
+		return SinceFilter{}, fmt.Errorf("invalid since filter %q: %w", expr, err)
+	}
+	return filter, nil
+}
+
+// IsZero reports whether no filter is set.
+func (f SinceFilter) IsZero() bool {
+	return f == SinceFilter{}
+}
+
+// String renders the filter as a SQL-like predicate, e.g.
+// "created_at > '2024-01-01'", for recording the scope of a profile.
+func (f SinceFilter) String() string {
+	if f.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%s %s '%s'", f.Column, f.Op, strings.ReplaceAll(f.Value, "'", "''"))
+}
+
+func (f SinceFilter) validate() error {
+	if f.IsZero() {
+		return nil
+	}
+	if f.Column == "" {
+		return fmt.Errorf("column is required")
+	}
+	if f.Op != ">" && f.Op != ">=" {
+		return fmt.Errorf("operator must be > or >=, got %q", f.Op)
+	}
+	if f.Value == "" {
+		return fmt.Errorf("value is required")
+	}
+	return nil
+}
+
+// DefaultEnrichmentOptions returns the options used when none are provided.
+func DefaultEnrichmentOptions() EnrichmentOptions {
+	return EnrichmentOptions{
+		SampleValueLimit:     columnProfileSampleValueLimit,
+		MaxSampleValueLength: maxColumnSampleValueLength,
+	}
+}
+
+// Validate reports whether the options are within the supported bounds.
+// Zero values are accepted and mean "use the default".
+func (o EnrichmentOptions) Validate() error {
+	if o.SampleValueLimit < 0 || o.SampleValueLimit > MaxSampleValueLimit {
+		return fmt.Errorf("sample value count must be between 1 and %d, got %d", MaxSampleValueLimit, o.SampleValueLimit)
+	}
+	if o.MaxSampleValueLength != 0 &&
+		(o.MaxSampleValueLength < MinSampleValueLength || o.MaxSampleValueLength > MaxSampleValueLengthLimit) {
+		return fmt.Errorf(
+			"sample value length must be between %d and %d, got %d",
+			MinSampleValueLength,
+			MaxSampleValueLengthLimit,
+			o.MaxSampleValueLength,
+		)
+	}
+	if o.TablesamplePct < 0 || o.TablesamplePct > 100 {
+		return fmt.Errorf("tablesample percent must be between 0 and 100, got %v", o.TablesamplePct)
+	}
+	if err := o.Since.validate(); err != nil {
+		return fmt.Errorf("since filter: %w", err)
+	}
+	if _, err := ParseSampleStrategy(string(o.SampleStrategy)); err != nil {
+		return err
+	}
+	if o.CatalogStats && (!o.Since.IsZero() || o.TablesamplePct > 0 || o.UnnestArrays || o.Percentiles) {
+		return fmt.Errorf("catalog stats cannot be combined with a since filter, tablesample, array elements, or percentiles")
+	}
+	return nil
+}
+
+// sinceClause returns the Since predicate prefixed with keyword ("WHERE" or
+// "AND") and its bind arguments, or an empty clause when no filter is set.
+// quote quotes the column identifier and placeholder is the driver's bind
+// marker (e.g. "$1", "?", or "CAST(@since AS TIMESTAMP)").
+func (o EnrichmentOptions) sinceClause(keyword string, quote func(string) string, placeholder string) (string, []interface{}) {
+	if o.Since.IsZero() {
+		return "", nil
+	}
+	clause := fmt.Sprintf(" %s %s %s %s", keyword, quote(o.Since.Column), o.Since.Op, placeholder)
+	return clause, []interface{}{o.Since.Value}
+}
+
+// tablesampleClause formats the backend-specific sampling clause (e.g.
+// "TABLESAMPLE SYSTEM (%s)") with the configured percentage, or returns an
+// empty string when the whole table should be scanned.
+func (o EnrichmentOptions) tablesampleClause(format string) string {
+	if o.TablesamplePct <= 0 || o.TablesamplePct >= 100 {
+		return ""
+	}
+	return " " + fmt.Sprintf(format, strconv.FormatFloat(o.TablesamplePct, 'f', -1, 64))
+}
+
+func (o EnrichmentOptions) withDefaults() EnrichmentOptions {
+	defaults := DefaultEnrichmentOptions()
+	if o.SampleValueLimit <= 0 {
+		o.SampleValueLimit = defaults.SampleValueLimit
+	}
+	if o.MaxSampleValueLength <= 0 {
+		o.MaxSampleValueLength = defaults.MaxSampleValueLength
+	}
+	return o
+}
+
 func newEnrichedColumnInfo(column ColumnInfo) EnrichedColumnInfo {
 	return EnrichedColumnInfo{
 		Name:            column.Name,
@@ -19,6 +218,7 @@ func newEnrichedColumnInfo(column ColumnInfo) EnrichedColumnInfo {
 		IsNullable:      column.IsNullable,
 		OrdinalPosition: column.OrdinalPosition,
 		ColumnDefault:   column.ColumnDefault,
+		IsGenerated:     column.IsGenerated,
 		AIDescription:   "",
 		DBDescription:   "",
 	}
@@ -32,30 +232,38 @@ func percentOfTotal(numerator, denominator int64) float64 {
 	return math.Round(value*10000) / 10000
 }
 
+// skipColumnSamples reports whether sample values are skipped for column.
+// Generated columns are skipped because their values repeat the columns
+// they are computed from, and virtual ones are recomputed on every read.
+func (o EnrichmentOptions) skipColumnSamples(column ColumnInfo) bool {
+	return o.StatsOnly || column.IsGenerated || shouldSkipColumnSamples(column.DataType)
+}
+
 func shouldSkipColumnSamples(dataType string) bool {
 	lower := strings.ToLower(strings.TrimSpace(dataType))
 	return strings.Contains(lower, "vector")
 }
 
-func normalizeColumnSampleValues(values []string) []string {
+func normalizeColumnSampleValues(values []string, opts EnrichmentOptions) []string {
 	if len(values) == 0 {
 		return nil
 	}
+	opts = opts.withDefaults()
 
 	seen := make(map[string]bool, len(values))
-	out := make([]string, 0, columnProfileSampleValueLimit)
+	out := make([]string, 0, opts.SampleValueLimit)
 	for _, raw := range values {
 		value := strings.TrimSpace(raw)
 		if value == "" {
 			continue
 		}
-		value = truncateColumnSampleValue(value)
+		value = truncateColumnSampleValue(value, opts.MaxSampleValueLength)
 		if seen[value] {
 			continue
 		}
 		seen[value] = true
 		out = append(out, value)
-		if len(out) >= columnProfileSampleValueLimit {
+		if len(out) >= opts.SampleValueLimit {
 			break
 		}
 	}
@@ -63,11 +271,11 @@ func normalizeColumnSampleValues(values []string) []string {
 	return out
 }
 
-func truncateColumnSampleValue(value string) string {
-	if len(value) <= maxColumnSampleValueLength {
+func truncateColumnSampleValue(value string, maxLength int) string {
+	if len(value) <= maxLength {
 		return value
 	}
-	return value[:maxColumnSampleValueLength-3] + "..."
+	return value[:maxLength-3] + "..."
 }
 
 func int64FromDBValue(value interface{}) (int64, error) {
@@ -128,6 +336,10 @@ func quoteBigQueryIdentifier(identifier string) string {
 	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
 }
 
+func quoteDatabricksIdentifier(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
 func quoteSQLiteIdentifier(identifier string) string {
 	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
 }
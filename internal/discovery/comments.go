@@ -0,0 +1,138 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ColumnCommenter is implemented by discoverers that can write a column
+// comment back to the database. It is the only part of the package that
+// modifies a database, so callers must confirm with the user first.
+type ColumnCommenter interface {
+	// ColumnCommentStatement returns the statement SetColumnComment would
+	// run, for previews.
+	ColumnCommentStatement(ctx context.Context, schema, table, column, comment string) (string, error)
+	// SetColumnComment replaces the comment of the column.
+	SetColumnComment(ctx context.Context, schema, table, column, comment string) error
+}
+
+// commentOnColumnStatement builds COMMENT ON COLUMN schema.table.column IS
+// '<comment>', the syntax Postgres, Redshift, and Snowflake share.
+func commentOnColumnStatement(schema, table, column, comment string, quote func(string) string, literal func(string) string) string {
+	return fmt.Sprintf(
+		"COMMENT ON COLUMN %s.%s.%s IS %s",
+		quote(schema),
+		quote(table),
+		quote(column),
+		literal(comment),
+	)
+}
+
+// quoteStandardStringLiteral quotes value for backends where a backslash is
+// an ordinary character in string literals (Postgres with
+// standard_conforming_strings, Redshift).
+func quoteStandardStringLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// quoteBackslashStringLiteral quotes value for backends where a backslash
+// starts an escape sequence in string literals (MySQL, Snowflake).
+func quoteBackslashStringLiteral(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+func execColumnComment(ctx context.Context, db *sql.DB, statement string) error {
+	if _, err := db.ExecContext(ctx, statement); err != nil {
+		return fmt.Errorf("set column comment: %w", err)
+	}
+	return nil
+}
+
+func (p *postgresDiscoverer) ColumnCommentStatement(_ context.Context, schema, table, column, comment string) (string, error) {
+	return commentOnColumnStatement(schema, table, column, comment, quotePostgresIdentifier, quoteStandardStringLiteral), nil
+}
+
+func (p *postgresDiscoverer) SetColumnComment(ctx context.Context, schema, table, column, comment string) error {
+	statement, _ := p.ColumnCommentStatement(ctx, schema, table, column, comment)
+	return execColumnComment(ctx, p.db, statement)
+}
+
+func (r *redshiftDiscoverer) ColumnCommentStatement(_ context.Context, schema, table, column, comment string) (string, error) {
+	return commentOnColumnStatement(schema, table, column, comment, quoteRedshiftIdentifier, quoteStandardStringLiteral), nil
+}
+
+func (r *redshiftDiscoverer) SetColumnComment(ctx context.Context, schema, table, column, comment string) error {
+	statement, _ := r.ColumnCommentStatement(ctx, schema, table, column, comment)
+	return execColumnComment(ctx, r.db, statement)
+}
+
+func (s *snowflakeDiscoverer) ColumnCommentStatement(_ context.Context, schema, table, column, comment string) (string, error) {
+	return commentOnColumnStatement(schema, table, column, comment, quoteSnowflakeIdentifier, quoteBackslashStringLiteral), nil
+}
+
+func (s *snowflakeDiscoverer) SetColumnComment(ctx context.Context, schema, table, column, comment string) error {
+	statement, _ := s.ColumnCommentStatement(ctx, schema, table, column, comment)
+	return execColumnComment(ctx, s.db, statement)
+}
+
+// ColumnCommentStatement returns ALTER TABLE ... MODIFY COLUMN with the
+// column's current definition from SHOW CREATE TABLE, so the type, NULL
+// handling, default, and other attributes are restated unchanged and only
+// the comment differs.
+func (m *mysqlDiscoverer) ColumnCommentStatement(ctx context.Context, schema, table, column, comment string) (string, error) {
+	var name, createTable string
+	query := "SHOW CREATE TABLE " + quoteMySQLIdentifier(schema) + "." + quoteMySQLIdentifier(table)
+	if err := m.db.QueryRowContext(ctx, query).Scan(&name, &createTable); err != nil {
+		return "", fmt.Errorf("read definition of %s.%s: %w", schema, table, err)
+	}
+
+	definition, err := mysqlColumnDefinition(createTable, column)
+	if err != nil {
+		return "", fmt.Errorf("%s.%s: %w", schema, table, err)
+	}
+	return fmt.Sprintf(
+		"ALTER TABLE %s.%s MODIFY COLUMN %s",
+		quoteMySQLIdentifier(schema),
+		quoteMySQLIdentifier(table),
+		withMySQLColumnComment(definition, comment),
+	), nil
+}
+
+func (m *mysqlDiscoverer) SetColumnComment(ctx context.Context, schema, table, column, comment string) error {
+	statement, err := m.ColumnCommentStatement(ctx, schema, table, column, comment)
+	if err != nil {
+		return err
+	}
+	return execColumnComment(ctx, m.db, statement)
+}
+
+// mysqlColumnDefinition returns the definition line of column from SHOW
+// CREATE TABLE output, without the trailing comma.
+func mysqlColumnDefinition(createTable, column string) (string, error) {
+	prefix := quoteMySQLIdentifier(column) + " "
+	for _, line := range strings.Split(createTable, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimSuffix(line, ","), nil
+		}
+	}
+	return "", fmt.Errorf("column %q not found in SHOW CREATE TABLE output", column)
+}
+
+// mysqlCommentClause matches the COMMENT clause SHOW CREATE TABLE prints,
+// whose literal doubles single quotes and escapes backslashes.
+var mysqlCommentClause = regexp.MustCompile(` COMMENT '(?:[^'\\]|\\.|'')*'`)
+
+// withMySQLColumnComment replaces the COMMENT clause of a column definition,
+// or appends one when the column has none.
+func withMySQLColumnComment(definition, comment string) string {
+	clause := " COMMENT " + quoteBackslashStringLiteral(comment)
+	if loc := mysqlCommentClause.FindStringIndex(definition); loc != nil {
+		return definition[:loc[0]] + clause + definition[loc[1]:]
+	}
+	return definition + clause
+}
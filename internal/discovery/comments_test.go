@@ -0,0 +1,74 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+)
+
+func TestColumnCommentStatements(t *testing.T) {
+	ctx := context.Background()
+	comment := `Customer's e-mail; see C:\docs`
+
+	tests := []struct {
+		name      string
+		commenter ColumnCommenter
+		want      string
+	}{
+		{
+			name:      "postgres",
+			commenter: &postgresDiscoverer{},
+			want:      `COMMENT ON COLUMN "public"."users"."email" IS 'Customer''s e-mail; see C:\docs'`,
+		},
+		{
+			name:      "redshift",
+			commenter: &redshiftDiscoverer{},
+			want:      `COMMENT ON COLUMN "public"."users"."email" IS 'Customer''s e-mail; see C:\docs'`,
+		},
+		{
+			name:      "snowflake",
+			commenter: &snowflakeDiscoverer{},
+			want:      `COMMENT ON COLUMN "public"."users"."email" IS 'Customer''s e-mail; see C:\\docs'`,
+		},
+	}
+	for _, tt := range tests {
+		got, err := tt.commenter.ColumnCommentStatement(ctx, "public", "users", "email", comment)
+		if err != nil {
+			t.Fatalf("%s: ColumnCommentStatement() error = %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Fatalf("%s: ColumnCommentStatement() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMySQLColumnDefinitionWithComment(t *testing.T) {
+	createTable := "CREATE TABLE `users` (\n" +
+		"  `id` bigint NOT NULL AUTO_INCREMENT,\n" +
+		"  `email` varchar(255) COLLATE utf8mb4_bin NOT NULL DEFAULT '' COMMENT 'old ''note'' \\\\ here',\n" +
+		"  `name` varchar(100) DEFAULT NULL,\n" +
+		"  PRIMARY KEY (`id`)\n" +
+		") ENGINE=InnoDB"
+
+	definition, err := mysqlColumnDefinition(createTable, "email")
+	if err != nil {
+		t.Fatalf("mysqlColumnDefinition() error = %v", err)
+	}
+	got := withMySQLColumnComment(definition, "Login e-mail")
+	want := "`email` varchar(255) COLLATE utf8mb4_bin NOT NULL DEFAULT '' COMMENT 'Login e-mail'"
+	if got != want {
+		t.Fatalf("replaced comment = %s, want %s", got, want)
+	}
+
+	definition, err = mysqlColumnDefinition(createTable, "name")
+	if err != nil {
+		t.Fatalf("mysqlColumnDefinition() error = %v", err)
+	}
+	got = withMySQLColumnComment(definition, `it's`)
+	if want := "`name` varchar(100) DEFAULT NULL COMMENT 'it''s'"; got != want {
+		t.Fatalf("appended comment = %s, want %s", got, want)
+	}
+
+	if _, err := mysqlColumnDefinition(createTable, "missing"); err == nil {
+		t.Fatal("mysqlColumnDefinition() for a missing column error = nil, want non-nil")
+	}
+}
@@ -0,0 +1,391 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// databricksDiscoverer reads a Unity Catalog catalog through a SQL
+// warehouse. Catalogs are dbh databases and their schemas are dbh schemas.
+type databricksDiscoverer struct {
+	db     *sql.DB
+	values valueFormatter
+
+	statsBatches tableStatsBatcher
+}
+
+type databricksDatabaseLister struct {
+	db *sql.DB
+}
+
+func newDatabricks(cfg DatabaseConfig) (*databricksDiscoverer, error) {
+	values, err := newValueFormatter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unqualified information_schema queries resolve against the catalog
+	// the statements run in.
+	connector, err := newDatabricksConnector(cfg, cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+	return &databricksDiscoverer{db: openConnector("databricks", connector), values: values}, nil
+}
+
+func newDatabricksDatabaseLister(cfg DatabaseConfig) (*databricksDatabaseLister, error) {
+	// Deliberately omit the catalog so SHOW CATALOGS is not limited by it.
+	connector, err := newDatabricksConnector(cfg, "")
+	if err != nil {
+		return nil, err
+	}
+	connector.schema = ""
+	return &databricksDatabaseLister{db: openConnector("databricks", connector)}, nil
+}
+
+// ListDatabases returns the catalogs visible to the access token.
+func (d *databricksDatabaseLister) ListDatabases(ctx context.Context) ([]string, error) {
+	rows, err := d.db.QueryContext(ctx, "SHOW CATALOGS")
+	if err != nil {
+		return nil, fmt.Errorf("query databricks catalogs: %w", err)
+	}
+	defer rows.Close()
+
+	var catalogs []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan catalog row: %w", err)
+		}
+		catalogs = append(catalogs, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return NormalizeDatabaseNames(catalogs), nil
+}
+
+func (d *databricksDatabaseLister) Close() error {
+	return d.db.Close()
+}
+
+func (d *databricksDiscoverer) Discover(ctx context.Context) ([]SchemaInfo, error) {
+	schemas, err := d.getSchemas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range schemas {
+		tables, err := d.getTables(ctx, schemas[i].Name)
+		if err != nil {
+			return nil, fmt.Errorf("get tables for schema %q: %w", schemas[i].Name, err)
+		}
+		schemas[i].Tables = tables
+	}
+
+	return schemas, nil
+}
+
+func (d *databricksDiscoverer) getSchemas(ctx context.Context) ([]SchemaInfo, error) {
+	query := `
+		SELECT schema_name
+		FROM information_schema.schemata
+		WHERE schema_name <> 'information_schema'
+		ORDER BY schema_name
+	`
+
+	rows, err := d.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query databricks schemas: %w", err)
+	}
+	defer rows.Close()
+
+	var schemas []SchemaInfo
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan schema row: %w", err)
+		}
+		schemas = append(schemas, SchemaInfo{Name: name})
+	}
+	return schemas, rows.Err()
+}
+
+func (d *databricksDiscoverer) getTables(ctx context.Context, schema string) ([]TableInfo, error) {
+	query := `
+		SELECT table_name, table_type
+		FROM information_schema.tables
+		WHERE table_schema = :p1
+		ORDER BY table_name
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, schema)
+	if err != nil {
+		return nil, fmt.Errorf("query databricks tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []TableInfo
+	for rows.Next() {
+		var t TableInfo
+		if err := rows.Scan(&t.Name, &t.TableType); err != nil {
+			return nil, fmt.Errorf("scan table row: %w", err)
+		}
+		t.TableType = normalizeDatabricksTableType(t.TableType)
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// normalizeDatabricksTableType maps Unity Catalog table types (MANAGED,
+// EXTERNAL, VIEW, MATERIALIZED_VIEW, STREAMING_TABLE, FOREIGN, ...) to the
+// BASE TABLE / VIEW / MATERIALIZED VIEW names the other backends report.
+func normalizeDatabricksTableType(raw string) string {
+	switch tableType := strings.ToUpper(strings.TrimSpace(raw)); tableType {
+	case "VIEW", "MATERIALIZED_VIEW", "METRIC_VIEW":
+		return strings.ReplaceAll(tableType, "_", " ")
+	default:
+		return "BASE TABLE"
+	}
+}
+
+// GetColumns reads information_schema.columns. Its ordinal_position counts
+// from 0, so it is shifted to count from 1 like the other backends.
+func (d *databricksDiscoverer) GetColumns(ctx context.Context, schema, table string) ([]ColumnInfo, error) {
+	query := `
+		SELECT column_name, full_data_type, is_nullable, ordinal_position + 1,
+			COALESCE(column_default, ''), generation_expression IS NOT NULL
+		FROM information_schema.columns
+		WHERE table_schema = :p1 AND table_name = :p2
+		ORDER BY ordinal_position
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, schema, table)
+	if err != nil {
+		return nil, fmt.Errorf("query databricks columns: %w", err)
+	}
+	defer rows.Close()
+
+	var columns []ColumnInfo
+	for rows.Next() {
+		var c ColumnInfo
+		if err := rows.Scan(&c.Name, &c.DataType, &c.IsNullable, &c.OrdinalPosition, &c.ColumnDefault, &c.IsGenerated); err != nil {
+			return nil, fmt.Errorf("scan column row: %w", err)
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+func (d *databricksDiscoverer) GetColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (EnrichedColumnInfo, error) {
+	opts = opts.withDefaults()
+	profile := newEnrichedColumnInfo(column)
+
+	stats, err := batchedColumnStats(ctx, &d.statsBatches, schema, table, column, opts, d.readColumnStats(schema, table, opts), nil)
+	if err != nil {
+		return EnrichedColumnInfo{}, fmt.Errorf(
+			"profile databricks column %q on %s.%s: %w",
+			column.Name,
+			schema,
+			table,
+			err,
+		)
+	}
+	stats.apply(&profile)
+
+	if opts.unnestColumn(column) {
+		if profile.Elements, err = d.readArrayElementStats(ctx, schema, table, column, opts); err != nil {
+			return EnrichedColumnInfo{}, fmt.Errorf(
+				"profile databricks array elements of %q on %s.%s: %w",
+				column.Name,
+				schema,
+				table,
+				err,
+			)
+		}
+	}
+
+	if opts.percentileColumn(column) {
+		if profile.Percentiles, err = d.readColumnPercentiles(ctx, schema, table, column, opts); err != nil {
+			return EnrichedColumnInfo{}, fmt.Errorf(
+				"profile databricks percentiles of %q on %s.%s: %w",
+				column.Name,
+				schema,
+				table,
+				err,
+			)
+		}
+	}
+
+	if opts.skipColumnSamples(column) {
+		return profile, nil
+	}
+
+	_, sinceArgs := opts.sinceClause("AND", quoteDatabricksIdentifier, ":p1")
+	rows, err := d.db.QueryContext(ctx, d.sampleValuesSQL(schema, table, column, opts), sinceArgs...)
+	if err != nil {
+		return EnrichedColumnInfo{}, fmt.Errorf(
+			"query databricks sample values for %q on %s.%s: %w",
+			column.Name,
+			schema,
+			table,
+			err,
+		)
+	}
+	defer rows.Close()
+
+	var samples []string
+	for rows.Next() {
+		var value interface{}
+		if err := rows.Scan(&value); err != nil {
+			return EnrichedColumnInfo{}, fmt.Errorf(
+				"scan databricks sample value for %q on %s.%s: %w",
+				column.Name,
+				schema,
+				table,
+				err,
+			)
+		}
+		samples = append(samples, d.values.formatColumn(value, column.DataType))
+	}
+	if err := rows.Err(); err != nil {
+		return EnrichedColumnInfo{}, fmt.Errorf(
+			"iterate databricks sample values for %q on %s.%s: %w",
+			column.Name,
+			schema,
+			table,
+			err,
+		)
+	}
+
+	profile.SampleValues = normalizeColumnSampleValues(samples, opts)
+	return profile, nil
+}
+
+// GetTableColumnStats computes the row count and the null, non-null, and
+// distinct non-null counts of columns with one query per group of up to 100
+// columns instead of one query per column. Sample values are not collected.
+func (d *databricksDiscoverer) GetTableColumnStats(ctx context.Context, schema, table string, columns []ColumnInfo, opts EnrichmentOptions) ([]EnrichedColumnInfo, error) {
+	profiles, err := tableColumnStats(ctx, columns, d.readColumnStats(schema, table, opts), nil)
+	if err != nil {
+		return nil, fmt.Errorf("profile databricks columns on %s.%s: %w", schema, table, err)
+	}
+	return profiles, nil
+}
+
+// sampleValuesSQL builds the sample values query of column.
+func (d *databricksDiscoverer) sampleValuesSQL(schema, table string, column ColumnInfo, opts EnrichmentOptions) string {
+	quotedColumn := quoteDatabricksIdentifier(column.Name)
+	sinceAnd, _ := opts.sinceClause("AND", quoteDatabricksIdentifier, ":p1")
+	return opts.sampleValuesQuery(
+		fmt.Sprintf("LEFT(CAST(%s AS STRING), %d)", quotedColumn, opts.MaxSampleValueLength),
+		quoteDatabricksIdentifier(schema)+"."+quoteDatabricksIdentifier(table),
+		quotedColumn,
+		sinceAnd,
+	)
+}
+
+// readColumnStats returns a function computing the stats of columns in a
+// single query.
+func (d *databricksDiscoverer) readColumnStats(schema, table string, opts EnrichmentOptions) readStatsFunc {
+	_, sinceArgs := opts.sinceClause("WHERE", quoteDatabricksIdentifier, ":p1")
+	return func(ctx context.Context, columns []ColumnInfo) (map[string]columnStats, error) {
+		return queryColumnStats(ctx, d.db, d.statsSQL(schema, table, columns, opts), sinceArgs, columns)
+	}
+}
+
+// statsSQL builds the stats query of columns. Distinct values are counted
+// on the text form, since Spark cannot compare MAP values.
+func (d *databricksDiscoverer) statsSQL(schema, table string, columns []ColumnInfo, opts EnrichmentOptions) string {
+	countDistinct := "COUNT(DISTINCT"
+	if opts.ApproxDistinct {
+		countDistinct = "APPROX_COUNT_DISTINCT("
+	}
+	sinceWhere, _ := opts.sinceClause("WHERE", quoteDatabricksIdentifier, ":p1")
+	dialect := statsDialect{
+		totalRows:     "COUNT(*)",
+		nullCount:     "COUNT_IF(%[1]s IS NULL)",
+		nonNullCount:  "COUNT(%[1]s)",
+		distinctCount: countDistinct + " CAST(%[1]s AS STRING))",
+	}
+	return fmt.Sprintf(
+		"SELECT\n\t%s\nFROM %s.%s%s%s",
+		dialect.selectList(columns, quoteDatabricksIdentifier),
+		quoteDatabricksIdentifier(schema),
+		quoteDatabricksIdentifier(table),
+		opts.tablesampleClause("TABLESAMPLE (%s PERCENT)"),
+		sinceWhere,
+	)
+}
+
+// readArrayElementStats profiles the elements of an ARRAY column with
+// LATERAL VIEW explode.
+func (d *databricksDiscoverer) readArrayElementStats(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (*ArrayElementStats, error) {
+	_, sinceArgs := opts.sinceClause("AND", quoteDatabricksIdentifier, ":p1")
+	statsQuery, sampleQuery := d.arrayElementSQL(schema, table, column, opts)
+	return queryArrayElementStats(ctx, d.db, d.values, statsQuery, sampleQuery, sinceArgs, opts)
+}
+
+// arrayElementSQL builds the element stats query of an array column and,
+// unless its samples are skipped, the sample elements query.
+func (d *databricksDiscoverer) arrayElementSQL(schema, table string, column ColumnInfo, opts EnrichmentOptions) (string, string) {
+	countDistinct := "COUNT(DISTINCT"
+	if opts.ApproxDistinct {
+		countDistinct = "APPROX_COUNT_DISTINCT("
+	}
+	dialect := arrayElementDialect{
+		unnest:        "%[1]s LATERAL VIEW explode(%[2]s) dbh_elements AS " + arrayElement,
+		element:       arrayElement,
+		text:          "CAST(%[1]s AS STRING)",
+		countDistinct: countDistinct,
+	}
+	quotedTable := quoteDatabricksIdentifier(schema) + "." + quoteDatabricksIdentifier(table)
+	quotedColumn := quoteDatabricksIdentifier(column.Name)
+	sinceAnd, _ := opts.sinceClause("AND", quoteDatabricksIdentifier, ":p1")
+
+	statsQuery := dialect.statsQuery(quotedTable+opts.tablesampleClause("TABLESAMPLE (%s PERCENT)"), quotedColumn, sinceAnd)
+	var sampleQuery string
+	if !opts.skipColumnSamples(column) {
+		sampleQuery = dialect.sampleQuery(quotedTable, quotedColumn, sinceAnd, opts)
+	}
+	return statsQuery, sampleQuery
+}
+
+// readColumnPercentiles computes approximate percentiles with
+// percentile_approx.
+func (d *databricksDiscoverer) readColumnPercentiles(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (*ColumnPercentiles, error) {
+	_, sinceArgs := opts.sinceClause("AND", quoteDatabricksIdentifier, ":p1")
+	return queryColumnPercentiles(ctx, d.db, d.percentilesSQL(schema, table, column, opts), sinceArgs)
+}
+
+// percentilesSQL builds the percentiles query of a numeric column.
+func (d *databricksDiscoverer) percentilesSQL(schema, table string, column ColumnInfo, opts EnrichmentOptions) string {
+	quotedTable := quoteDatabricksIdentifier(schema) + "." + quoteDatabricksIdentifier(table)
+	sinceAnd, _ := opts.sinceClause("AND", quoteDatabricksIdentifier, ":p1")
+	return percentilesQuery(func(column string, fraction float64) string {
+		return fmt.Sprintf("percentile_approx(%s, %v)", column, fraction)
+	}, quotedTable+opts.tablesampleClause("TABLESAMPLE (%s PERCENT)"), quoteDatabricksIdentifier(column.Name), sinceAnd)
+}
+
+func (d *databricksDiscoverer) GetSampleRows(ctx context.Context, schema, table string, limit int) (*SampleResult, error) {
+	query := fmt.Sprintf(
+		"SELECT * FROM %s.%s ORDER BY rand() LIMIT %d",
+		quoteDatabricksIdentifier(schema),
+		quoteDatabricksIdentifier(table),
+		limit,
+	)
+
+	rows, err := d.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query databricks sample rows: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSampleRows(rows, d.values)
+}
+
+func (d *databricksDiscoverer) Close() error {
+	return d.db.Close()
+}
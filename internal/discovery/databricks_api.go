@@ -0,0 +1,464 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// databricksWaitTimeout is how long the statement API holds a request
+	// before answering with a still running statement to poll.
+	databricksWaitTimeout = "10s"
+	// databricksPollInterval and databricksMaxPollInterval bound the delay
+	// between polls of a running statement.
+	databricksPollInterval    = 500 * time.Millisecond
+	databricksMaxPollInterval = 5 * time.Second
+	// databricksCancelTimeout bounds the request canceling a statement whose
+	// context ended.
+	databricksCancelTimeout = 10 * time.Second
+)
+
+// ErrMissingWarehouse is returned when a Databricks connection has no SQL
+// warehouse configured.
+var ErrMissingWarehouse = errors.New("databricks requires a SQL warehouse ID or HTTP path (warehouse)")
+
+// databricksConnector implements driver.Connector on top of the Databricks
+// SQL Statement Execution API, so the Databricks backend can use
+// database/sql like the other backends without a native driver. Every query
+// runs on the configured SQL warehouse and returns its rows inline as JSON.
+// Queries use named parameter markers (:p1, :p2, ...) for their arguments.
+//
+// This stands in for github.com/databricks/databricks-sql-go, which is not a
+// dependency of this module. Only the statement API features listed in the
+// Databricks connection guide are supported; the other backends use their
+// vendor drivers.
+type databricksConnector struct {
+	baseURL     string // https://<workspace host>
+	token       string
+	warehouseID string
+	catalog     string
+	schema      string
+	client      *http.Client
+}
+
+// OpenDatabricks opens a database/sql pool for the Databricks connection in
+// cfg. Nothing is sent until the first query or ping; a ping checks the
+// access token and the warehouse without starting the warehouse.
+func OpenDatabricks(cfg DatabaseConfig) (*sql.DB, error) {
+	connector, err := newDatabricksConnector(cfg, cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+	return openConnector("databricks", connector), nil
+}
+
+// newDatabricksConnector builds the connector for cfg, running queries in
+// catalog (the workspace default when empty). host may be given with or
+// without https://, and warehouse as an ID or as the warehouse's HTTP path
+// (/sql/1.0/warehouses/<id>). The access token is cfg.Password.
+func newDatabricksConnector(cfg DatabaseConfig, catalog string) (*databricksConnector, error) {
+	host := strings.TrimSpace(cfg.Host)
+	if !strings.HasPrefix(host, "https://") && !strings.HasPrefix(host, "http://") {
+		host = "https://" + host
+	}
+	host = strings.TrimRight(host, "/")
+	if host == "https:/" || host == "https://" {
+		return nil, errors.New("databricks requires the workspace host (host)")
+	}
+
+	warehouse := strings.Trim(strings.TrimSpace(cfg.Warehouse), "/")
+	if index := strings.LastIndex(warehouse, "/"); index >= 0 {
+		warehouse = warehouse[index+1:]
+	}
+	if warehouse == "" {
+		return nil, ErrMissingWarehouse
+	}
+	if strings.TrimSpace(cfg.Password) == "" {
+		return nil, errors.New("databricks requires an access token (password)")
+	}
+
+	return &databricksConnector{
+		baseURL:     host,
+		token:       strings.TrimSpace(cfg.Password),
+		warehouseID: warehouse,
+		catalog:     strings.TrimSpace(catalog),
+		schema:      strings.TrimSpace(cfg.Schema),
+		client:      http.DefaultClient,
+	}, nil
+}
+
+func (c *databricksConnector) Connect(context.Context) (driver.Conn, error) {
+	return &databricksConn{connector: c}, nil
+}
+
+func (c *databricksConnector) Driver() driver.Driver {
+	return databricksDriver{}
+}
+
+type databricksDriver struct{}
+
+func (databricksDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("databricks connections are opened with OpenDatabricks")
+}
+
+type databricksStatementRequest struct {
+	Statement     string                `json:"statement"`
+	WarehouseID   string                `json:"warehouse_id"`
+	Catalog       string                `json:"catalog,omitempty"`
+	Schema        string                `json:"schema,omitempty"`
+	Parameters    []databricksParameter `json:"parameters,omitempty"`
+	Disposition   string                `json:"disposition"`
+	Format        string                `json:"format"`
+	WaitTimeout   string                `json:"wait_timeout"`
+	OnWaitTimeout string                `json:"on_wait_timeout"`
+}
+
+type databricksParameter struct {
+	Name  string  `json:"name"`
+	Value *string `json:"value,omitempty"`
+	Type  string  `json:"type,omitempty"`
+}
+
+type databricksStatementResponse struct {
+	StatementID string `json:"statement_id"`
+	Status      struct {
+		State string           `json:"state"`
+		Error *databricksError `json:"error"`
+	} `json:"status"`
+	Manifest *struct {
+		Schema struct {
+			Columns []databricksColumn `json:"columns"`
+		} `json:"schema"`
+	} `json:"manifest"`
+	Result *databricksResultChunk `json:"result"`
+}
+
+type databricksColumn struct {
+	Name     string `json:"name"`
+	TypeName string `json:"type_name"`
+}
+
+type databricksResultChunk struct {
+	DataArray             [][]*string `json:"data_array"`
+	NextChunkInternalLink string      `json:"next_chunk_internal_link"`
+}
+
+// databricksError is an error reported by the Databricks API, either for
+// the request or for a failed statement.
+type databricksError struct {
+	ErrorCode string `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+func (e *databricksError) Error() string {
+	if e.ErrorCode == "" {
+		return e.Message
+	}
+	return e.ErrorCode + ": " + e.Message
+}
+
+// execute runs query and waits for it to finish, canceling it when ctx ends
+// first.
+func (c *databricksConnector) execute(ctx context.Context, query string, args []driver.NamedValue) (*databricksStatementResponse, error) {
+	parameters, err := databricksParameters(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var response databricksStatementResponse
+	err = c.do(ctx, http.MethodPost, "/api/2.0/sql/statements", databricksStatementRequest{
+		Statement:     query,
+		WarehouseID:   c.warehouseID,
+		Catalog:       c.catalog,
+		Schema:        c.schema,
+		Parameters:    parameters,
+		Disposition:   "INLINE",
+		Format:        "JSON_ARRAY",
+		WaitTimeout:   databricksWaitTimeout,
+		OnWaitTimeout: "CONTINUE",
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	delay := databricksPollInterval
+	for response.Status.State == "PENDING" || response.Status.State == "RUNNING" {
+		select {
+		case <-ctx.Done():
+			c.cancel(response.StatementID)
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		if err := c.do(ctx, http.MethodGet, "/api/2.0/sql/statements/"+response.StatementID, nil, &response); err != nil {
+			if ctx.Err() != nil {
+				c.cancel(response.StatementID)
+			}
+			return nil, err
+		}
+		delay = min(2*delay, databricksMaxPollInterval)
+	}
+
+	if response.Status.State != "SUCCEEDED" {
+		if response.Status.Error != nil {
+			return nil, response.Status.Error
+		}
+		return nil, fmt.Errorf("databricks statement %s ended in state %s", response.StatementID, response.Status.State)
+	}
+	return &response, nil
+}
+
+// cancel asks Databricks to stop a statement the caller gave up on.
+// Failures are ignored: the statement then runs to completion unobserved.
+func (c *databricksConnector) cancel(statementID string) {
+	if statementID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), databricksCancelTimeout)
+	defer cancel()
+	_ = c.do(ctx, http.MethodPost, "/api/2.0/sql/statements/"+statementID+"/cancel", nil, nil)
+}
+
+// do sends one API request and decodes the JSON response into out.
+func (c *databricksConnector) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode databricks request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("build databricks request: %w", err)
+	}
+	request.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("databricks request: %w", err)
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("read databricks response: %w", err)
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		var apiErr databricksError
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("databricks API %s: %w", response.Status, &apiErr)
+		}
+		return fmt.Errorf("databricks API %s: %s", response.Status, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode databricks response: %w", err)
+	}
+	return nil
+}
+
+// databricksParameters turns query arguments into named parameters: :p1 for
+// the first argument, :p2 for the second, and so on, unless the argument
+// has a name.
+func databricksParameters(args []driver.NamedValue) ([]databricksParameter, error) {
+	parameters := make([]databricksParameter, 0, len(args))
+	for _, arg := range args {
+		parameter := databricksParameter{Name: arg.Name}
+		if parameter.Name == "" {
+			parameter.Name = "p" + strconv.Itoa(arg.Ordinal)
+		}
+
+		var value string
+		switch v := arg.Value.(type) {
+		case nil:
+			parameters = append(parameters, parameter)
+			continue
+		case string:
+			value, parameter.Type = v, "STRING"
+		case []byte:
+			value, parameter.Type = string(v), "STRING"
+		case int64:
+			value, parameter.Type = strconv.FormatInt(v, 10), "BIGINT"
+		case float64:
+			value, parameter.Type = strconv.FormatFloat(v, 'g', -1, 64), "DOUBLE"
+		case bool:
+			value, parameter.Type = strconv.FormatBool(v), "BOOLEAN"
+		case time.Time:
+			value, parameter.Type = v.Format(time.RFC3339Nano), "TIMESTAMP"
+		default:
+			return nil, fmt.Errorf("unsupported databricks parameter type %T", arg.Value)
+		}
+		parameter.Value = &value
+		parameters = append(parameters, parameter)
+	}
+	return parameters, nil
+}
+
+// databricksValue converts a JSON_ARRAY cell, which is always text, to the
+// Go value database/sql drivers return for the column's type. Values that
+// do not parse stay text.
+func databricksValue(typeName, text string) driver.Value {
+	switch strings.ToUpper(typeName) {
+	case "BYTE", "SHORT", "INT", "LONG":
+		if v, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return v
+		}
+	case "FLOAT", "DOUBLE":
+		if v, err := strconv.ParseFloat(text, 64); err == nil {
+			return v
+		}
+	case "BOOLEAN":
+		if v, err := strconv.ParseBool(text); err == nil {
+			return v
+		}
+	case "TIMESTAMP":
+		if v, err := time.Parse(time.RFC3339Nano, text); err == nil {
+			return v
+		}
+	}
+	return text
+}
+
+type databricksConn struct {
+	connector *databricksConnector
+}
+
+func (c *databricksConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	response, err := c.connector.execute(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	rows := &databricksRows{ctx: ctx, connector: c.connector}
+	if response.Manifest != nil {
+		rows.columns = response.Manifest.Schema.Columns
+	}
+	if response.Result != nil {
+		rows.chunk = response.Result.DataArray
+		rows.next = response.Result.NextChunkInternalLink
+	}
+	return rows, nil
+}
+
+func (c *databricksConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if _, err := c.connector.execute(ctx, query, args); err != nil {
+		return nil, err
+	}
+	return driver.ResultNoRows, nil
+}
+
+// Ping reads the warehouse, which checks the host, the access token, and
+// the warehouse ID without starting a stopped warehouse.
+func (c *databricksConn) Ping(ctx context.Context) error {
+	return c.connector.do(ctx, http.MethodGet, "/api/2.0/sql/warehouses/"+c.connector.warehouseID, nil, nil)
+}
+
+func (c *databricksConn) Prepare(query string) (driver.Stmt, error) {
+	return &databricksStmt{conn: c, query: query}, nil
+}
+
+func (c *databricksConn) Close() error {
+	return nil
+}
+
+func (c *databricksConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("databricks connections do not support transactions")
+}
+
+type databricksStmt struct {
+	conn  *databricksConn
+	query string
+}
+
+func (s *databricksStmt) Close() error {
+	return nil
+}
+
+// NumInput returns -1: parameters are named, so database/sql cannot count
+// them.
+func (s *databricksStmt) NumInput() int {
+	return -1
+}
+
+func (s *databricksStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, namedValues(args))
+}
+
+func (s *databricksStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, namedValues(args))
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
+
+// databricksRows iterates over a statement result, fetching the chunks
+// after the first as they are reached.
+type databricksRows struct {
+	ctx       context.Context
+	connector *databricksConnector
+	columns   []databricksColumn
+	chunk     [][]*string
+	next      string
+	index     int
+}
+
+func (r *databricksRows) Columns() []string {
+	names := make([]string, len(r.columns))
+	for i, column := range r.columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+func (r *databricksRows) Close() error {
+	return nil
+}
+
+func (r *databricksRows) Next(dest []driver.Value) error {
+	for r.index >= len(r.chunk) {
+		if r.next == "" {
+			return io.EOF
+		}
+		var chunk databricksResultChunk
+		if err := r.connector.do(r.ctx, http.MethodGet, r.next, nil, &chunk); err != nil {
+			return err
+		}
+		r.chunk, r.next, r.index = chunk.DataArray, chunk.NextChunkInternalLink, 0
+	}
+
+	row := r.chunk[r.index]
+	r.index++
+	for i := range dest {
+		if i >= len(row) || row[i] == nil {
+			dest[i] = nil
+			continue
+		}
+		typeName := ""
+		if i < len(r.columns) {
+			typeName = r.columns[i].TypeName
+		}
+		dest[i] = databricksValue(typeName, *row[i])
+	}
+	return nil
+}
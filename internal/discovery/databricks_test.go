@@ -0,0 +1,133 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDatabricksConnectorQuery(t *testing.T) {
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer dapi-token" {
+			t.Errorf("Authorization = %q", got)
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/2.0/sql/statements":
+			var request databricksStatementRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				t.Errorf("decode request: %v", err)
+			}
+			if request.WarehouseID != "abc123" || request.Catalog != "main" || request.Format != "JSON_ARRAY" {
+				t.Errorf("request = %+v", request)
+			}
+			if len(request.Parameters) != 1 || request.Parameters[0].Name != "p1" || *request.Parameters[0].Value != "sales" || request.Parameters[0].Type != "STRING" {
+				t.Errorf("parameters = %+v", request.Parameters)
+			}
+			w.Write([]byte(`{"statement_id": "st-1", "status": {"state": "RUNNING"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/2.0/sql/statements/st-1":
+			polls.Add(1)
+			w.Write([]byte(`{
+				"statement_id": "st-1",
+				"status": {"state": "SUCCEEDED"},
+				"manifest": {"schema": {"columns": [
+					{"name": "table_name", "type_name": "STRING"},
+					{"name": "row_count", "type_name": "LONG"}
+				]}},
+				"result": {"data_array": [["orders", "12"]], "next_chunk_internal_link": "/api/2.0/sql/statements/st-1/result/chunks/1"}
+			}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/2.0/sql/statements/st-1/result/chunks/1":
+			w.Write([]byte(`{"data_array": [["users", null]]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	connector, err := newDatabricksConnector(DatabaseConfig{
+		Host:      server.URL,
+		Warehouse: "/sql/1.0/warehouses/abc123",
+		Password:  "dapi-token",
+	}, "main")
+	if err != nil {
+		t.Fatalf("newDatabricksConnector() error = %v", err)
+	}
+	connector.client = server.Client()
+
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	rows, err := db.QueryContext(context.Background(), "SELECT table_name, row_count FROM t WHERE table_schema = :p1", "sales")
+	if err != nil {
+		t.Fatalf("QueryContext() error = %v", err)
+	}
+	defer rows.Close()
+
+	var got [][]interface{}
+	for rows.Next() {
+		var name, count interface{}
+		if err := rows.Scan(&name, &count); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, []interface{}{name, count})
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]interface{}{{"orders", int64(12)}, {"users", nil}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("rows = %v, want %v", got, want)
+	}
+	if polls.Load() != 1 {
+		t.Fatalf("polls = %d, want 1", polls.Load())
+	}
+}
+
+func TestDatabricksConnectorFailedStatement(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"statement_id": "st-2", "status": {"state": "FAILED", "error": {"error_code": "BAD_REQUEST", "message": "[TABLE_OR_VIEW_NOT_FOUND] missing"}}}`))
+	}))
+	defer server.Close()
+
+	connector, err := newDatabricksConnector(DatabaseConfig{Host: server.URL, Warehouse: "abc123", Password: "dapi-token"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	connector.client = server.Client()
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	var one int
+	err = db.QueryRowContext(context.Background(), "SELECT 1 FROM missing").Scan(&one)
+	if err == nil || !strings.Contains(err.Error(), "TABLE_OR_VIEW_NOT_FOUND") {
+		t.Fatalf("error = %v, want the statement error", err)
+	}
+}
+
+func TestNewDatabricksConnectorRequiresWarehouse(t *testing.T) {
+	_, err := newDatabricksConnector(DatabaseConfig{Host: "dbc-1.cloud.databricks.com", Password: "dapi-token"}, "")
+	if err != ErrMissingWarehouse {
+		t.Fatalf("error = %v, want ErrMissingWarehouse", err)
+	}
+}
+
+func TestNormalizeDatabricksTableType(t *testing.T) {
+	tests := map[string]string{
+		"MANAGED":           "BASE TABLE",
+		"EXTERNAL":          "BASE TABLE",
+		"STREAMING_TABLE":   "BASE TABLE",
+		"VIEW":              "VIEW",
+		"MATERIALIZED_VIEW": "MATERIALIZED VIEW",
+	}
+	for raw, want := range tests {
+		if got := normalizeDatabricksTableType(raw); got != want {
+			t.Errorf("normalizeDatabricksTableType(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
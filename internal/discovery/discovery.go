@@ -1,13 +1,20 @@
 // Package discovery provides database schema and table introspection
-// for supported database types (Postgres, Redshift, Snowflake, MySQL, BigQuery, SQLite).
+// for supported database types (Postgres, Redshift, Snowflake, MySQL, BigQuery,
+// Databricks, SQLite).
 package discovery
 
 import (
 	"context"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/big"
+	"net"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -30,6 +37,10 @@ type ColumnInfo struct {
 	IsNullable      string // "YES" or "NO"
 	OrdinalPosition int
 	ColumnDefault   string
+
+	// IsGenerated marks generated (computed, virtual) columns, whose values
+	// the database derives from other columns and which cannot be written.
+	IsGenerated bool
 }
 
 // EnrichedColumnInfo holds detailed profiling metadata about a column.
@@ -41,6 +52,7 @@ type EnrichedColumnInfo struct {
 	IsNullable      string
 	OrdinalPosition int
 	ColumnDefault   string
+	IsGenerated     bool
 	AIDescription   string
 	DBDescription   string
 
@@ -52,6 +64,33 @@ type EnrichedColumnInfo struct {
 	NullOfTotalRowsPct    float64
 	NonNullOfTotalRowsPct float64
 	SampleValues          []string
+
+	// Elements profiles the elements of an array column. It is nil unless
+	// EnrichmentOptions.UnnestArrays is set and the backend has array types.
+	Elements *ArrayElementStats
+
+	// Percentiles holds the p50, p95, and p99 of a numeric column. It is
+	// nil unless EnrichmentOptions.Percentiles is set and the backend has a
+	// percentile aggregate, and for columns with no non-null values.
+	Percentiles *ColumnPercentiles
+}
+
+// ColumnPercentiles are percentiles of a column's non-null values. Backends
+// without an exact aggregate (Snowflake, BigQuery, Databricks) approximate
+// them.
+type ColumnPercentiles struct {
+	P50 float64
+	P95 float64
+	P99 float64
+}
+
+// ArrayElementStats profiles the elements of an array column as if each
+// element were a row, while the column's own stats treat each whole array
+// as one value.
+type ArrayElementStats struct {
+	ElementCount         int64 // non-null elements across all arrays
+	DistinctElementCount int64
+	SampleElements       []string
 }
 
 // SampleResult holds the column headers and row data from a sample query.
@@ -75,15 +114,36 @@ type TableDetailDiscoverer interface {
 	// GetColumns returns column metadata for the given schema and table.
 	GetColumns(ctx context.Context, schema, table string) ([]ColumnInfo, error)
 	// GetColumnEnrichment returns detailed profile stats for a single column.
-	GetColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo) (EnrichedColumnInfo, error)
+	// Zero-valued opts fields fall back to the defaults.
+	GetColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (EnrichedColumnInfo, error)
 	// GetSampleRows returns a random sample of rows from the given table.
 	GetSampleRows(ctx context.Context, schema, table string, limit int) (*SampleResult, error)
 }
 
+// RowEstimator is implemented by discoverers that can report approximate row
+// counts from catalog statistics without scanning tables. Callers should
+// type-assert for it and treat a missing implementation as "no estimates".
+type RowEstimator interface {
+	// EstimateRowCounts returns approximate row counts keyed by table name
+	// for every table in schema that has statistics. Tables without
+	// statistics (never analyzed, views) are omitted.
+	EstimateRowCounts(ctx context.Context, schema string) (map[string]int64, error)
+}
+
+// TableColumnStatsReader is implemented by discoverers that can compute the
+// null and distinct counts of many columns of a table in one query. Every
+// built-in backend implements it. GetColumnEnrichment uses the same batched
+// query when EnrichmentOptions.TableColumns is set.
+type TableColumnStatsReader interface {
+	// GetTableColumnStats returns one profile per column, in order, with
+	// counts and percentages filled in and no sample values.
+	GetTableColumnStats(ctx context.Context, schema, table string, columns []ColumnInfo, opts EnrichmentOptions) ([]EnrichedColumnInfo, error)
+}
+
 // DatabaseLister retrieves the list of databases available in a connection.
 type DatabaseLister interface {
 	// ListDatabases returns the names of all databases accessible to the
-	// current role.
+	// current role, trimmed, de-duplicated, and sorted.
 	ListDatabases(ctx context.Context) ([]string, error)
 	// Close releases the underlying database connection.
 	Close() error
@@ -104,20 +164,107 @@ type DatabaseConfig struct {
 	TLS      string
 
 	// Snowflake
+	// Host and Region, when set, replace the default
+	// <account>.snowflakecomputing.com endpoint, for PrivateLink and
+	// gov-cloud accounts.
 	Account       string
 	Role          string
 	Warehouse     string
 	Schema        string
 	Authenticator string
 
+	// ResumeWarehouse runs ALTER WAREHOUSE ... RESUME IF SUSPENDED on
+	// Warehouse right after connecting, so the first discovery query does
+	// not wait on (or fail against) a suspended warehouse.
+	ResumeWarehouse bool
+
+	// WarehouseSize, when set, resizes Warehouse (ALTER WAREHOUSE ... SET
+	// WAREHOUSE_SIZE) right after connecting. The change is account-wide
+	// and persists after dbh exits.
+	WarehouseSize string
+
+	// TimeZone is an IANA zone name (e.g. "UTC", "America/New_York") that
+	// timestamp values are converted to before they are rendered in sample
+	// rows and sample values. Empty keeps the zone the driver returns.
+	TimeZone string
+
+	// QueryTag is set as the Snowflake QUERY_TAG session parameter so the
+	// queries dbh runs can be attributed in the query history.
+	QueryTag string
+
+	// CacheSSOToken lets externalbrowser connections store the Snowflake ID
+	// token in the local credential cache so later connections reuse it
+	// instead of opening another browser window, and enables
+	// CLIENT_SESSION_KEEP_ALIVE. The account must allow ID tokens
+	// (ALLOW_ID_TOKEN); otherwise every connection still prompts.
+	CacheSSOToken bool
+
+	// Schemas, when set, limits Snowflake discovery to these schemas.
+	// Names are resolved like Snowflake identifiers: uppercased unless
+	// wrapped in double quotes. The filter runs in the schema query, so
+	// other schemas are never crawled.
+	Schemas []string
+
+	// ShowCommands makes Snowflake discovery use SHOW SCHEMAS, SHOW TABLES,
+	// SHOW VIEWS, and SHOW COLUMNS instead of INFORMATION_SCHEMA, which is
+	// often faster on large accounts.
+	ShowCommands bool
+
+	// SampleMethod selects how Postgres picks sample rows: auto (the
+	// default), random, system_rows, or bernoulli. See SampleMethod.
+	SampleMethod string
+
+	// Redshift
+	// Serverless marks a Redshift Serverless workgroup; hosts ending in
+	// .redshift-serverless.amazonaws.com are treated as serverless too.
+	// Workgroup and Region are read from such a host when empty, and an
+	// empty Host is looked up from Workgroup with the AWS API. IAMAuth
+	// replaces User and Password with temporary credentials from
+	// GetCredentials, using the default AWS credential chain.
+	Serverless bool
+	Workgroup  string
+	Region     string
+	IAMAuth    bool
+
 	// BigQuery
 	ProjectID       string
 	CredentialsFile string
 
+	// Databricks
+	// Host is the workspace host, Warehouse the SQL warehouse ID or HTTP
+	// path, Password a personal access token, and Database the catalog.
+
 	// SQLite
 	// Database is the SQLite file path.
 }
 
+// Sentinel errors returned by the discovery package. Callers should match them
+// with errors.Is; the returned errors wrap these with additional context.
+var (
+	// ErrUnsupportedType is returned when a connection type has no backend.
+	ErrUnsupportedType = errors.New("unsupported database type")
+
+	// ErrMissingDatabasePath is returned when a SQLite connection has no
+	// database file path configured.
+	ErrMissingDatabasePath = errors.New("sqlite requires database file path")
+
+	// ErrMissingProject is returned when a BigQuery connection has neither a
+	// project_id nor a database (project) configured.
+	ErrMissingProject = errors.New("bigquery requires project_id or database (project)")
+
+	// ErrNoDatabasesFound is returned when database listing succeeds but
+	// yields no databases.
+	ErrNoDatabasesFound = errors.New("no databases discovered")
+
+	// ErrInvalidTimeZone is returned when DatabaseConfig.TimeZone is not a
+	// known IANA time zone name.
+	ErrInvalidTimeZone = errors.New("unknown time zone")
+
+	// ErrUnknownTable is returned by a RestrictToDiscovered discoverer when
+	// asked about a schema or table that was not in the discovered set.
+	ErrUnknownTable = errors.New("table not found in discovered schemas")
+)
+
 // New creates a Discoverer for the given database configuration.
 func New(cfg DatabaseConfig) (Discoverer, error) {
 	switch cfg.Type {
@@ -131,10 +278,12 @@ func New(cfg DatabaseConfig) (Discoverer, error) {
 		return newMySQL(cfg)
 	case "bigquery":
 		return newBigQuery(cfg)
+	case "databricks":
+		return newDatabricks(cfg)
 	case "sqlite":
 		return newSQLite(cfg)
 	default:
-		return nil, fmt.Errorf("unsupported database type %q", cfg.Type)
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedType, cfg.Type)
 	}
 }
 
@@ -153,10 +302,12 @@ func NewTableDetailDiscoverer(cfg DatabaseConfig) (TableDetailDiscoverer, error)
 		return newMySQL(cfg)
 	case "bigquery":
 		return newBigQuery(cfg)
+	case "databricks":
+		return newDatabricks(cfg)
 	case "sqlite":
 		return newSQLite(cfg)
 	default:
-		return nil, fmt.Errorf("unsupported database type %q for table detail discovery", cfg.Type)
+		return nil, fmt.Errorf("%w %q for table detail discovery", ErrUnsupportedType, cfg.Type)
 	}
 }
 
@@ -174,46 +325,77 @@ func NewDatabaseLister(cfg DatabaseConfig) (DatabaseLister, error) {
 		return newMySQLDatabaseLister(cfg)
 	case "bigquery":
 		return newBigQueryDatabaseLister(cfg)
+	case "databricks":
+		return newDatabricksDatabaseLister(cfg)
 	case "sqlite":
 		return newSQLiteDatabaseLister(cfg)
 	default:
-		return nil, fmt.Errorf("databases discovery is not supported for connection type %q", cfg.Type)
+		return nil, fmt.Errorf("%w %q for databases discovery", ErrUnsupportedType, cfg.Type)
 	}
 }
 
-// openDB is a small helper that opens and pings a database connection.
-func openDB(driverName, dsn string) (*sql.DB, error) {
-	db, err := sql.Open(driverName, dsn)
-	if err != nil {
-		return nil, fmt.Errorf("open %s connection: %w", driverName, err)
+// HasSingleLevelNamespace reports whether databaseType has no namespace
+// between a database and its tables. A MySQL database is also its only
+// schema, so context files for such backends skip the schemas/<schema>
+// directories.
+func HasSingleLevelNamespace(databaseType string) bool {
+	switch strings.ToLower(strings.TrimSpace(databaseType)) {
+	case "mysql":
+		return true
+	default:
+		return false
 	}
-	return db, nil
+}
+
+// NormalizeDatabaseNames trims names, drops empty and duplicate entries, and
+// sorts the rest. Every DatabaseLister returns its names through it, so the
+// order does not depend on the backend's collation.
+func NormalizeDatabaseNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	normalized := make([]string, 0, len(names))
+	for _, raw := range names {
+		name := strings.TrimSpace(raw)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		normalized = append(normalized, name)
+	}
+	sort.Strings(normalized)
+	return normalized
 }
 
 // scanSampleRows reads all rows from a *sql.Rows result set and returns
 // the column names and string-formatted cell values. NULL values are
 // represented as empty strings.
-func scanSampleRows(rows *sql.Rows) (*SampleResult, error) {
+func scanSampleRows(rows *sql.Rows, values valueFormatter) (*SampleResult, error) {
 	cols, err := rows.Columns()
 	if err != nil {
 		return nil, fmt.Errorf("get column names: %w", err)
 	}
 
+	// Column types decide how binary UUIDs are rendered; without them the
+	// values are formatted by their Go type alone.
+	columnTypes, _ := rows.ColumnTypes()
 	result := &SampleResult{Columns: cols}
 
 	for rows.Next() {
-		values := make([]interface{}, len(cols))
+		cells := make([]interface{}, len(cols))
 		ptrs := make([]interface{}, len(cols))
-		for i := range values {
-			ptrs[i] = &values[i]
+		for i := range cells {
+			ptrs[i] = &cells[i]
 		}
 		if err := rows.Scan(ptrs...); err != nil {
 			return nil, fmt.Errorf("scan sample row: %w", err)
 		}
 
 		row := make([]string, len(cols))
-		for i, v := range values {
-			row[i] = formatValue(v)
+		for i, v := range cells {
+			dataType := ""
+			if i < len(columnTypes) {
+				dataType = columnTypes[i].DatabaseTypeName()
+			}
+			row[i] = values.formatColumn(v, dataType)
 		}
 		result.Rows = append(result.Rows, row)
 	}
@@ -221,11 +403,66 @@ func scanSampleRows(rows *sql.Rows) (*SampleResult, error) {
 	return result, rows.Err()
 }
 
-// formatValue converts any database value to a string representation.
-// It handles the full range of types that database/sql drivers may return,
-// including time.Time, bool, numeric types, []byte (binary/JSON), and
-// sql.Null* wrappers, so that sample data from any column type is captured.
+// valueFormatter renders database values as strings for sample rows and
+// sample values. The zero value keeps timestamps in the zone the driver
+// returned them in.
+type valueFormatter struct {
+	location *time.Location
+	// fixedBinary is set for MySQL, whose BINARY columns are fixed length
+	// and reported without it, so a 16-byte BINARY value is a BINARY(16).
+	fixedBinary bool
+}
+
+// newValueFormatter builds the formatter for cfg, loading cfg.TimeZone.
+func newValueFormatter(cfg DatabaseConfig) (valueFormatter, error) {
+	f := valueFormatter{fixedBinary: strings.EqualFold(cfg.Type, "mysql")}
+	name := strings.TrimSpace(cfg.TimeZone)
+	if name == "" {
+		return f, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return valueFormatter{}, fmt.Errorf("%w %q: %v", ErrInvalidTimeZone, name, err)
+	}
+	f.location = location
+	return f, nil
+}
+
+// formatValue converts any database value to a string representation
+// without any time zone conversion.
 func formatValue(v interface{}) string {
+	return valueFormatter{}.format(v)
+}
+
+// formatColumn converts v, read from a column of type dataType, to a
+// string. A 16-byte binary value is rendered as a UUID when the column type
+// holds UUIDs (see isUUIDType); everything else goes through format.
+func (f valueFormatter) formatColumn(v interface{}, dataType string) string {
+	if b, ok := v.([]byte); ok && len(b) == 16 && f.isUUIDType(dataType) {
+		return formatUUID([16]byte(b))
+	}
+	return f.format(v)
+}
+
+// isUUIDType reports whether columns of dataType hold UUIDs: uuid,
+// UNIQUEIDENTIFIER, and BINARY(16), the usual MySQL UUID column.
+func (f valueFormatter) isUUIDType(dataType string) bool {
+	switch strings.ToLower(strings.Join(strings.Fields(dataType), "")) {
+	case "uuid", "uniqueidentifier", "binary(16)":
+		return true
+	case "binary":
+		return f.fixedBinary
+	default:
+		return false
+	}
+}
+
+// format converts any database value to a string representation. It
+// handles the full range of types that database/sql drivers may return,
+// including time.Time, bool, numeric types, []byte (binary/JSON), UUIDs,
+// network addresses, and sql.Null* wrappers, so that sample data from any
+// column type is captured.
+func (f valueFormatter) format(v interface{}) string {
 	if v == nil {
 		return ""
 	}
@@ -234,6 +471,22 @@ func formatValue(v interface{}) string {
 		return val
 	case []byte:
 		return string(val)
+	case [16]byte:
+		return formatUUID(val)
+	case net.IP:
+		if len(val) == 0 {
+			return ""
+		}
+		return val.String()
+	case net.IPNet:
+		return val.String()
+	case *net.IPNet:
+		if val == nil {
+			return ""
+		}
+		return val.String()
+	case net.HardwareAddr:
+		return val.String()
 	case bool:
 		return strconv.FormatBool(val)
 	case int64:
@@ -247,12 +500,31 @@ func formatValue(v interface{}) string {
 	case float32:
 		return strconv.FormatFloat(float64(val), 'f', -1, 32)
 	case time.Time:
+		// Dates arrive as midnight timestamps; converting them would shift
+		// the day, so only values with a time of day are converted.
 		if val.Hour() == 0 && val.Minute() == 0 && val.Second() == 0 && val.Nanosecond() == 0 {
 			return val.Format("2006-01-02")
 		}
+		if f.location != nil {
+			val = val.In(f.location)
+		}
 		return val.Format(time.RFC3339)
 	case json.RawMessage:
 		return string(val)
+	case *big.Rat:
+		if val == nil {
+			return ""
+		}
+		return formatRat(val)
+	case big.Rat:
+		return formatRat(&val)
+	case *big.Float:
+		if val == nil {
+			return ""
+		}
+		return val.Text('f', -1)
+	case big.Float:
+		return val.Text('f', -1)
 	case sql.NullString:
 		if val.Valid {
 			return val.String
@@ -275,7 +547,7 @@ func formatValue(v interface{}) string {
 		return ""
 	case sql.NullTime:
 		if val.Valid {
-			return formatValue(val.Time)
+			return f.format(val.Time)
 		}
 		return ""
 	case fmt.Stringer:
@@ -284,3 +556,73 @@ func formatValue(v interface{}) string {
 		return fmt.Sprintf("%v", val)
 	}
 }
+
+// formatUUID renders b as a hyphenated UUID such as
+// "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11".
+func formatUUID(b [16]byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf[:])
+}
+
+// maxRatDecimalPlaces caps the digits used for fractions that have no exact
+// decimal form (e.g. 1/3). It matches BigQuery BIGNUMERIC's scale, the widest
+// decimal type the drivers return as *big.Rat.
+const maxRatDecimalPlaces = 38
+
+// formatRat renders r as a plain decimal string such as "12.5" instead of
+// big.Rat's "25/2". Fractions with an exact decimal form keep every digit;
+// others are rounded to maxRatDecimalPlaces places. Trailing zeros are
+// dropped.
+func formatRat(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+
+	places := maxRatDecimalPlaces
+	if exact, ok := exactDecimalPlaces(r.Denom()); ok && exact < places {
+		places = exact
+	}
+
+	text := r.FloatString(places)
+	text = strings.TrimRight(text, "0")
+	return strings.TrimSuffix(text, ".")
+}
+
+// exactDecimalPlaces returns the number of decimal places needed to write
+// 1/denom exactly, which is only possible when denom has no prime factors
+// other than 2 and 5.
+func exactDecimalPlaces(denom *big.Int) (int, bool) {
+	d := new(big.Int).Set(denom)
+	two, five := big.NewInt(2), big.NewInt(5)
+	rem := new(big.Int)
+	twos, fives := 0, 0
+	for {
+		q, r := new(big.Int).QuoRem(d, two, rem)
+		if r.Sign() != 0 {
+			break
+		}
+		d = q
+		twos++
+	}
+	for {
+		q, r := new(big.Int).QuoRem(d, five, rem)
+		if r.Sign() != 0 {
+			break
+		}
+		d = q
+		fives++
+	}
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return 0, false
+	}
+	return max(twos, fives), true
+}
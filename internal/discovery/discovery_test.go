@@ -3,12 +3,19 @@ package discovery
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	gcpbigquery "cloud.google.com/go/bigquery"
 	mysqlDriver "github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/snowflakedb/gosnowflake"
 )
 
 func TestFormatValue(t *testing.T) {
@@ -42,6 +49,25 @@ func TestFormatValue(t *testing.T) {
 		{name: "null bool invalid", input: sql.NullBool{Valid: false}, want: ""},
 		{name: "null time valid", input: sql.NullTime{Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Valid: true}, want: "2026-01-01"},
 		{name: "null time invalid", input: sql.NullTime{Valid: false}, want: ""},
+		{name: "big rat decimal", input: big.NewRat(12345, 100), want: "123.45"},
+		{name: "big rat negative", input: big.NewRat(-1, 8), want: "-0.125"},
+		{name: "big rat integer", input: big.NewRat(42, 1), want: "42"},
+		{name: "big rat value", input: *big.NewRat(1, 100), want: "0.01"},
+		{name: "big rat repeating", input: big.NewRat(1, 3), want: "0.33333333333333333333333333333333333333"},
+		{name: "big rat nil", input: (*big.Rat)(nil), want: ""},
+		{name: "big float decimal", input: new(big.Float).SetPrec(200).SetInt64(1234567890123456789), want: "1234567890123456789"},
+		{name: "big float fraction", input: big.NewFloat(0.25), want: "0.25"},
+		{name: "stringer decimal", input: testDecimal{coefficient: 1250, exponent: -2}, want: "12.50"},
+		{name: "uuid text", input: []byte("a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"), want: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"},
+		{name: "uuid array", input: [16]byte{0xa0, 0xee, 0xbc, 0x99, 0x9c, 0x0b, 0x4e, 0xf8, 0xbb, 0x6d, 0x6b, 0xb9, 0xbd, 0x38, 0x0a, 0x11}, want: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"},
+		{name: "16 byte text", input: []byte("0123456789abcdef"), want: "0123456789abcdef"},
+		{name: "inet text", input: []byte("192.168.0.1/24"), want: "192.168.0.1/24"},
+		{name: "net ip", input: net.ParseIP("192.168.0.1"), want: "192.168.0.1"},
+		{name: "net ip v6", input: net.ParseIP("2001:db8::1"), want: "2001:db8::1"},
+		{name: "net ip nil", input: net.IP(nil), want: ""},
+		{name: "net ipnet", input: &net.IPNet{IP: net.IPv4(10, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)}, want: "10.0.0.0/8"},
+		{name: "net ipnet value", input: net.IPNet{IP: net.IPv4(10, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)}, want: "10.0.0.0/8"},
+		{name: "macaddr", input: net.HardwareAddr{0x08, 0x00, 0x2b, 0x01, 0x02, 0x03}, want: "08:00:2b:01:02:03"},
 	}
 
 	for _, tt := range tests {
@@ -54,6 +80,87 @@ func TestFormatValue(t *testing.T) {
 	}
 }
 
+// testDecimal mimics decimal libraries such as shopspring/decimal, whose
+// values are structs that render themselves through String.
+type testDecimal struct {
+	coefficient int64
+	exponent    int
+}
+
+func TestFormatColumnUUIDs(t *testing.T) {
+	uuid := []byte{0xa0, 0xee, 0xbc, 0x99, 0x9c, 0x0b, 0x4e, 0xf8, 0xbb, 0x6d, 0x6b, 0xb9, 0xbd, 0x38, 0x0a, 0x11}
+	const want = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	mysql := valueFormatter{fixedBinary: true}
+
+	tests := []struct {
+		name     string
+		values   valueFormatter
+		dataType string
+		input    interface{}
+		want     string
+	}{
+		{name: "uuid", dataType: "uuid", input: uuid, want: want},
+		{name: "uniqueidentifier", dataType: "UNIQUEIDENTIFIER", input: uuid, want: want},
+		{name: "binary(16)", dataType: "BINARY(16)", input: uuid, want: want},
+		{name: "mysql binary", values: mysql, dataType: "binary", input: uuid, want: want},
+		{name: "variable binary", dataType: "binary", input: uuid, want: string(uuid)},
+		{name: "varbinary", values: mysql, dataType: "varbinary", input: uuid, want: string(uuid)},
+		{name: "blob", dataType: "blob", input: uuid, want: string(uuid)},
+		{name: "not 16 bytes", dataType: "binary(16)", input: []byte("0123456789abcde"), want: "0123456789abcde"},
+		{name: "uuid text", dataType: "uuid", input: []byte(want), want: want},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.values.formatColumn(tt.input, tt.dataType); got != tt.want {
+				t.Fatalf("formatColumn(%v, %q) = %q, want %q", tt.input, tt.dataType, got, tt.want)
+			}
+		})
+	}
+}
+
+func (d testDecimal) String() string {
+	return new(big.Rat).SetFrac(big.NewInt(d.coefficient), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-d.exponent)), nil)).FloatString(-d.exponent)
+}
+
+var _ fmt.Stringer = testDecimal{}
+
+func TestValueFormatterTimeZone(t *testing.T) {
+	values, err := newValueFormatter(DatabaseConfig{TimeZone: "America/New_York"})
+	if err != nil {
+		t.Fatalf("newValueFormatter() error = %v", err)
+	}
+
+	timestamp := time.Date(2026, 7, 1, 14, 30, 0, 0, time.UTC)
+	if got, want := values.format(timestamp), "2026-07-01T10:30:00-04:00"; got != want {
+		t.Fatalf("format(timestamp) = %q, want %q", got, want)
+	}
+	if got, want := values.format(sql.NullTime{Time: timestamp, Valid: true}), "2026-07-01T10:30:00-04:00"; got != want {
+		t.Fatalf("format(NullTime) = %q, want %q", got, want)
+	}
+	date := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	if got, want := values.format(date), "2026-07-01"; got != want {
+		t.Fatalf("format(date) = %q, want %q (dates must not shift)", got, want)
+	}
+	if got, want := formatValue(timestamp), "2026-07-01T14:30:00Z"; got != want {
+		t.Fatalf("formatValue(timestamp) = %q, want %q without a zone", got, want)
+	}
+
+	if _, err := newValueFormatter(DatabaseConfig{TimeZone: "Mars/Olympus"}); !errors.Is(err, ErrInvalidTimeZone) {
+		t.Fatalf("newValueFormatter(invalid) error = %v, want ErrInvalidTimeZone", err)
+	}
+}
+
+func TestNormalizeDatabaseNames(t *testing.T) {
+	got := NormalizeDatabaseNames([]string{" sales ", "Analytics", "", "sales", "analytics", "  "})
+	want := []string{"Analytics", "analytics", "sales"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("NormalizeDatabaseNames() = %q, want %q", got, want)
+	}
+	if got := NormalizeDatabaseNames(nil); got == nil || len(got) != 0 {
+		t.Fatalf("NormalizeDatabaseNames(nil) = %#v, want an empty slice", got)
+	}
+}
+
 func TestPercentOfTotal(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -89,7 +196,7 @@ func TestNormalizeColumnSampleValues(t *testing.T) {
 		"delta",
 		"epsilon",
 		"zeta",
-	})
+	}, EnrichmentOptions{})
 
 	if len(got) != columnProfileSampleValueLimit {
 		t.Fatalf("sample value count = %d, want %d", len(got), columnProfileSampleValueLimit)
@@ -105,6 +212,113 @@ func TestNormalizeColumnSampleValues(t *testing.T) {
 	}
 }
 
+func TestNormalizeColumnSampleValues_CustomOptions(t *testing.T) {
+	got := normalizeColumnSampleValues([]string{
+		"alpha",
+		strings.Repeat("y", 40),
+		"beta",
+		"gamma",
+	}, EnrichmentOptions{SampleValueLimit: 2, MaxSampleValueLength: 20})
+
+	if len(got) != 2 {
+		t.Fatalf("sample value count = %d, want 2", len(got))
+	}
+	if len(got[1]) != 20 || !strings.HasSuffix(got[1], "...") {
+		t.Fatalf("truncated value = %q, want 20 characters ending with ellipsis", got[1])
+	}
+}
+
+func TestEnrichmentOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    EnrichmentOptions
+		wantErr bool
+	}{
+		{name: "zero uses defaults", opts: EnrichmentOptions{}},
+		{name: "defaults", opts: DefaultEnrichmentOptions()},
+		{name: "max values", opts: EnrichmentOptions{SampleValueLimit: MaxSampleValueLimit, MaxSampleValueLength: MaxSampleValueLengthLimit}},
+		{name: "negative count", opts: EnrichmentOptions{SampleValueLimit: -1}, wantErr: true},
+		{name: "count over cap", opts: EnrichmentOptions{SampleValueLimit: MaxSampleValueLimit + 1}, wantErr: true},
+		{name: "length too short", opts: EnrichmentOptions{MaxSampleValueLength: MinSampleValueLength - 1}, wantErr: true},
+		{name: "length over cap", opts: EnrichmentOptions{MaxSampleValueLength: MaxSampleValueLengthLimit + 1}, wantErr: true},
+		{name: "tablesample pct", opts: EnrichmentOptions{TablesamplePct: 12.5}},
+		{name: "negative tablesample pct", opts: EnrichmentOptions{TablesamplePct: -1}, wantErr: true},
+		{name: "tablesample pct over 100", opts: EnrichmentOptions{TablesamplePct: 101}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if tt.wantErr && err == nil {
+				t.Fatalf("Validate(%+v) error = nil, want non-nil", tt.opts)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("Validate(%+v) error = %v, want nil", tt.opts, err)
+			}
+		})
+	}
+}
+
+func TestEnrichmentOptionsTablesampleClause(t *testing.T) {
+	tests := []struct {
+		pct  float64
+		want string
+	}{
+		{pct: 0, want: ""},
+		{pct: 100, want: ""},
+		{pct: 10, want: " TABLESAMPLE SYSTEM (10)"},
+		{pct: 0.5, want: " TABLESAMPLE SYSTEM (0.5)"},
+	}
+
+	for _, tt := range tests {
+		got := EnrichmentOptions{TablesamplePct: tt.pct}.tablesampleClause("TABLESAMPLE SYSTEM (%s)")
+		if got != tt.want {
+			t.Fatalf("tablesampleClause(%v) = %q, want %q", tt.pct, got, tt.want)
+		}
+	}
+}
+
+func TestParseSinceFilter(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    SinceFilter
+		wantErr bool
+	}{
+		{input: "created_at>2024-01-01", want: SinceFilter{Column: "created_at", Op: ">", Value: "2024-01-01"}},
+		{input: " created_at >= '2024-01-01' ", want: SinceFilter{Column: "created_at", Op: ">=", Value: "2024-01-01"}},
+		{input: `id > "100"`, want: SinceFilter{Column: "id", Op: ">", Value: "100"}},
+		{input: "created_at=2024-01-01", wantErr: true},
+		{input: ">2024-01-01", wantErr: true},
+		{input: "created_at>", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSinceFilter(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Fatalf("ParseSinceFilter(%q) error = nil, want non-nil", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("ParseSinceFilter(%q) error = %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Fatalf("ParseSinceFilter(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSinceFilterString(t *testing.T) {
+	filter := SinceFilter{Column: "note", Op: ">=", Value: "it's"}
+	if got, want := filter.String(), "note >= 'it''s'"; got != want {
+		t.Fatalf("String() = %q, want %q", got, want)
+	}
+	if got := (SinceFilter{}).String(); got != "" {
+		t.Fatalf("zero String() = %q, want empty", got)
+	}
+}
+
 func TestInt64FromDBValue(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -163,6 +377,56 @@ func TestQuoteRedshiftIdentifier(t *testing.T) {
 	}
 }
 
+func TestQuotePostgresIdentifier(t *testing.T) {
+	got := quotePostgresIdentifier(`users"; DROP TABLE users; --`)
+	want := `"users""; DROP TABLE users; --"`
+	if got != want {
+		t.Fatalf("quotePostgresIdentifier(injection) = %q, want %q", got, want)
+	}
+}
+
+func TestQuoteSnowflakeIdentifier(t *testing.T) {
+	got := quoteSnowflakeIdentifier(`ORDERS"; DROP TABLE ORDERS; --`)
+	want := `"ORDERS""; DROP TABLE ORDERS; --"`
+	if got != want {
+		t.Fatalf("quoteSnowflakeIdentifier(injection) = %q, want %q", got, want)
+	}
+}
+
+func TestPostgresConnStringQuotesSpecialCharacters(t *testing.T) {
+	passwords := []string{
+		"secret",
+		"pa ss word",
+		"it's",
+		`back\slash`,
+		`tr'icky \ mix=`,
+		"host=evil dbname=other",
+		"user:pass@host",
+		"",
+	}
+	for _, password := range passwords {
+		connString := PostgresConnString("db.example.com", 5432, "ana lyst", password, "my db", "require")
+		cfg, err := pq.NewConfig(connString)
+		if err != nil {
+			t.Fatalf("pq.NewConfig(%q) error = %v", connString, err)
+		}
+		if cfg.Password != password {
+			t.Errorf("password %q parsed back as %q from %q", password, cfg.Password, connString)
+		}
+		if cfg.User != "ana lyst" || cfg.Database != "my db" || cfg.Host != "db.example.com" {
+			t.Errorf("%q parsed as user=%q dbname=%q host=%q", connString, cfg.User, cfg.Database, cfg.Host)
+		}
+	}
+}
+
+func TestPostgresConnStringLeavesPlainValuesUnquoted(t *testing.T) {
+	got := PostgresConnString("localhost", 5432, "postgres", "s3cr3t:x", "app", "disable")
+	want := "host=localhost port=5432 user=postgres password=s3cr3t:x dbname=app sslmode=disable"
+	if got != want {
+		t.Fatalf("PostgresConnString(...) = %q, want %q", got, want)
+	}
+}
+
 func TestBuildRedshiftConnString_DefaultPortAndSSLMode(t *testing.T) {
 	cfg := DatabaseConfig{
 		Host:     "redshift-cluster.amazonaws.com",
@@ -214,6 +478,158 @@ func TestBuildMySQLDSN_DefaultPortAndParseTime(t *testing.T) {
 	}
 }
 
+func TestSnowflakeDSN_CacheSSOToken(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  DatabaseConfig
+		want bool
+	}{
+		{
+			name: "externalbrowser with cache",
+			cfg:  DatabaseConfig{Authenticator: "externalbrowser", CacheSSOToken: true},
+			want: true,
+		},
+		{
+			name: "externalbrowser without cache",
+			cfg:  DatabaseConfig{Authenticator: "externalbrowser"},
+			want: false,
+		},
+		{
+			name: "password auth ignores cache",
+			cfg:  DatabaseConfig{Password: "secret", CacheSSOToken: true},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsn, err := snowflakeDSN(&gosnowflake.Config{Account: "acct", User: "user", Password: tt.cfg.Password}, tt.cfg)
+			if err != nil {
+				t.Fatalf("snowflakeDSN() error = %v", err)
+			}
+			got := strings.Contains(dsn, "clientStoreTemporaryCredential=true")
+			if got != tt.want {
+				t.Fatalf("snowflakeDSN() = %q, token cache enabled = %v, want %v", dsn, got, tt.want)
+			}
+			if keepAlive := strings.Contains(dsn, "client_session_keep_alive=true"); keepAlive != tt.want {
+				t.Fatalf("snowflakeDSN() = %q, session keep-alive = %v, want %v", dsn, keepAlive, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnowflakeDSN_QueryTag(t *testing.T) {
+	cfg := DatabaseConfig{Password: "secret", QueryTag: "dbh columns; team=data"}
+	dsn, err := snowflakeDSN(&gosnowflake.Config{Account: "acct", User: "user", Password: cfg.Password}, cfg)
+	if err != nil {
+		t.Fatalf("snowflakeDSN() error = %v", err)
+	}
+
+	parsed, err := gosnowflake.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("ParseDSN(%q) error = %v", dsn, err)
+	}
+	tag, ok := parsed.Params["query_tag"]
+	if !ok || tag == nil || *tag != cfg.QueryTag {
+		t.Fatalf("query_tag param = %v, want %q (dsn %q)", tag, cfg.QueryTag, dsn)
+	}
+}
+
+func TestSnowflakeDSN_Endpoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        DatabaseConfig
+		wantHost   string
+		wantRegion string
+	}{
+		{
+			name:     "default host from account",
+			cfg:      DatabaseConfig{Password: "secret"},
+			wantHost: "acct.snowflakecomputing.com",
+		},
+		{
+			name:     "privatelink host",
+			cfg:      DatabaseConfig{Password: "secret", Host: "https://acct.privatelink.snowflakecomputing.com/"},
+			wantHost: "acct.privatelink.snowflakecomputing.com",
+		},
+		{
+			name:       "gov-cloud region",
+			cfg:        DatabaseConfig{Password: "secret", Region: "us-gov-west-1.aws"},
+			wantHost:   "acct.us-gov-west-1.aws.snowflakecomputing.com",
+			wantRegion: "us-gov-west-1.aws",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsn, err := snowflakeDSN(&gosnowflake.Config{Account: "acct", User: "user", Password: tt.cfg.Password}, tt.cfg)
+			if err != nil {
+				t.Fatalf("snowflakeDSN() error = %v", err)
+			}
+			parsed, err := gosnowflake.ParseDSN(dsn)
+			if err != nil {
+				t.Fatalf("ParseDSN(%q) error = %v", dsn, err)
+			}
+			if parsed.Host != tt.wantHost || parsed.Region != tt.wantRegion {
+				t.Fatalf("snowflakeDSN() host, region = %q, %q; want %q, %q (dsn %q)", parsed.Host, parsed.Region, tt.wantHost, tt.wantRegion, dsn)
+			}
+		})
+	}
+}
+
+func TestSnowflakeSchemasQuery(t *testing.T) {
+	query, args := snowflakeSchemasQuery(nil)
+	if strings.Contains(query, " IN (") || len(args) != 0 {
+		t.Fatalf("snowflakeSchemasQuery(nil) = %q, %v; want no allowlist filter", query, args)
+	}
+
+	query, args = snowflakeSchemasQuery([]string{"ANALYTICS", " ", " staging ", `"Mixed""Case"`})
+	if !strings.Contains(query, "AND SCHEMA_NAME IN (?, ?, ?)") {
+		t.Fatalf("query = %q, want SCHEMA_NAME IN (?, ?, ?)", query)
+	}
+	if want := []interface{}{"ANALYTICS", "STAGING", `Mixed"Case`}; !reflect.DeepEqual(args, want) {
+		t.Fatalf("args = %v, want %v", args, want)
+	}
+}
+
+func TestSnowflakeWarehouseStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     DatabaseConfig
+		want    []string
+		wantErr bool
+	}{
+		{name: "disabled", cfg: DatabaseConfig{Warehouse: "compute_wh"}},
+		{
+			name: "resume",
+			cfg:  DatabaseConfig{Warehouse: "compute_wh", ResumeWarehouse: true},
+			want: []string{"ALTER WAREHOUSE compute_wh RESUME IF SUSPENDED"},
+		},
+		{
+			name: "resize then resume",
+			cfg:  DatabaseConfig{Warehouse: "Profiling WH", ResumeWarehouse: true, WarehouseSize: "x-small"},
+			want: []string{
+				`ALTER WAREHOUSE "Profiling WH" SET WAREHOUSE_SIZE = 'X-SMALL'`,
+				`ALTER WAREHOUSE "Profiling WH" RESUME IF SUSPENDED`,
+			},
+		},
+		{name: "unknown size", cfg: DatabaseConfig{Warehouse: "compute_wh", WarehouseSize: "huge'; DROP"}, wantErr: true},
+		{name: "missing warehouse", cfg: DatabaseConfig{ResumeWarehouse: true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := snowflakeWarehouseStatements(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("snowflakeWarehouseStatements() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("snowflakeWarehouseStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestQuoteBigQueryIdentifier(t *testing.T) {
 	got := quoteBigQueryIdentifier("project.dataset.table")
 	if got != "`project.dataset.table`" {
@@ -269,6 +685,16 @@ func TestBigQueryFieldDataType(t *testing.T) {
 	}
 }
 
+func TestFormatBigQueryValueNumeric(t *testing.T) {
+	if got := formatBigQueryValue(big.NewRat(19999, 100)); got != "199.99" {
+		t.Fatalf("formatBigQueryValue(NUMERIC) = %q, want %q", got, "199.99")
+	}
+	got := formatBigQueryValue([]gcpbigquery.Value{big.NewRat(1, 2), big.NewRat(3, 1)})
+	if got != `["0.5","3"]` {
+		t.Fatalf("formatBigQueryValue(ARRAY<NUMERIC>) = %q, want %q", got, `["0.5","3"]`)
+	}
+}
+
 func TestNormalizeBigQueryTableType(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -304,3 +730,126 @@ func TestIsBigQuerySystemSchema(t *testing.T) {
 		t.Fatalf("analytics should not be treated as a system schema")
 	}
 }
+
+func TestBigQueryStatsQuery(t *testing.T) {
+	columns := []ColumnInfo{{Name: "id"}, {Name: "created_at"}}
+	query := bigQueryStatsQuery("`p`.`d`.`t`", columns, EnrichmentOptions{ApproxDistinct: true}, "")
+
+	for _, want := range []string{
+		"COUNT(1) AS total_rows",
+		"COUNTIF(`id` IS NULL) AS null_count_0",
+		"COUNTIF(`created_at` IS NOT NULL) AS non_null_count_1",
+		"APPROX_COUNT_DISTINCT( IF(`created_at` IS NULL, NULL, TO_JSON_STRING(`created_at`))) AS distinct_non_null_count_1",
+		"FROM `p`.`d`.`t`",
+	} {
+		if !strings.Contains(query, want) {
+			t.Fatalf("bigQueryStatsQuery() missing %q:\n%s", want, query)
+		}
+	}
+	if got := strings.Count(query, "COUNTIF("); got != 4 {
+		t.Fatalf("bigQueryStatsQuery() has %d COUNTIF expressions, want 4:\n%s", got, query)
+	}
+}
+
+func TestIsBigQueryComplexityError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "resources exceeded",
+			err:  fmt.Errorf("run bigquery query: %w", &gcpbigquery.Error{Reason: "resourcesExceeded", Message: "Resources exceeded during query execution"}),
+			want: true,
+		},
+		{
+			name: "query too complex",
+			err:  errors.New("Not enough resources for query planning - too many subqueries or query is too complex"),
+			want: true,
+		},
+		{
+			name: "access denied",
+			err:  &gcpbigquery.Error{Reason: "accessDenied", Message: "Access Denied"},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		if got := isBigQueryComplexityError(tt.err); got != tt.want {
+			t.Fatalf("%s: isBigQueryComplexityError() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFactoryErrorsAreTyped(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     DatabaseConfig
+		factory func(DatabaseConfig) error
+		want    error
+	}{
+		{
+			name:    "new unsupported type",
+			cfg:     DatabaseConfig{Type: "oracle"},
+			factory: func(cfg DatabaseConfig) error { _, err := New(cfg); return err },
+			want:    ErrUnsupportedType,
+		},
+		{
+			name:    "table detail unsupported type",
+			cfg:     DatabaseConfig{Type: "oracle"},
+			factory: func(cfg DatabaseConfig) error { _, err := NewTableDetailDiscoverer(cfg); return err },
+			want:    ErrUnsupportedType,
+		},
+		{
+			name:    "database lister unsupported type",
+			cfg:     DatabaseConfig{Type: "oracle"},
+			factory: func(cfg DatabaseConfig) error { _, err := NewDatabaseLister(cfg); return err },
+			want:    ErrUnsupportedType,
+		},
+		{
+			name:    "sqlite missing path",
+			cfg:     DatabaseConfig{Type: "sqlite"},
+			factory: func(cfg DatabaseConfig) error { _, err := New(cfg); return err },
+			want:    ErrMissingDatabasePath,
+		},
+		{
+			name:    "sqlite lister missing path",
+			cfg:     DatabaseConfig{Type: "sqlite", Database: "  "},
+			factory: func(cfg DatabaseConfig) error { _, err := NewDatabaseLister(cfg); return err },
+			want:    ErrMissingDatabasePath,
+		},
+		{
+			name:    "bigquery missing project",
+			cfg:     DatabaseConfig{Type: "bigquery"},
+			factory: func(cfg DatabaseConfig) error { _, err := New(cfg); return err },
+			want:    ErrMissingProject,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.factory(tt.cfg)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("factory(%q) error = %v, want %v", tt.cfg.Type, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasSingleLevelNamespace(t *testing.T) {
+	want := map[string]bool{
+		"postgres":  false,
+		"redshift":  false,
+		"snowflake": false,
+		"mysql":     true,
+		"bigquery":  false,
+		"sqlite":    false,
+	}
+	for databaseType, single := range want {
+		if got := HasSingleLevelNamespace(databaseType); got != single {
+			t.Errorf("HasSingleLevelNamespace(%q) = %v, want %v", databaseType, got, single)
+		}
+	}
+	if !HasSingleLevelNamespace(" MySQL ") {
+		t.Errorf("HasSingleLevelNamespace is not case-insensitive")
+	}
+}
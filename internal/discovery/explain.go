@@ -0,0 +1,155 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// ExplainedQuery is one statement GetColumnEnrichment would send.
+type ExplainedQuery struct {
+	// Purpose says what the query reads, e.g. "stats" or "sample values".
+	Purpose string
+	SQL     string
+	// Args are the values bound to the query's placeholders, in order.
+	Args []string
+}
+
+// EnrichmentExplainer is implemented by discoverers that can show the
+// queries GetColumnEnrichment runs for a column without running them.
+// Every built-in backend implements it.
+type EnrichmentExplainer interface {
+	// ExplainColumnEnrichment returns the queries in the order
+	// GetColumnEnrichment sends them. When opts.TableColumns batches the
+	// column's stats, the stats query is the one shared by its batch, so
+	// columns of the same batch return the same stats query. No profiling
+	// query is run; BigQuery reads the table's columns when opts.Since is
+	// set, to render the filter's cast.
+	ExplainColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) ([]ExplainedQuery, error)
+}
+
+// enrichmentSQL holds a backend's builders of the GetColumnEnrichment
+// queries. arrayElements and percentiles are nil for backends that do not
+// profile array elements or percentiles.
+type enrichmentSQL struct {
+	stats         func(schema, table string, columns []ColumnInfo, opts EnrichmentOptions) string
+	sampleValues  func(schema, table string, column ColumnInfo, opts EnrichmentOptions) string
+	arrayElements func(schema, table string, column ColumnInfo, opts EnrichmentOptions) (string, string)
+	percentiles   func(schema, table string, column ColumnInfo, opts EnrichmentOptions) string
+}
+
+// explain renders the queries GetColumnEnrichment sends for column with
+// the builders in s, following the same option checks.
+func (s enrichmentSQL) explain(schema, table string, column ColumnInfo, opts EnrichmentOptions) []ExplainedQuery {
+	opts = opts.withDefaults()
+	var args []string
+	if !opts.Since.IsZero() {
+		args = []string{opts.Since.Value}
+	}
+
+	statsColumns := []ColumnInfo{column}
+	if opts.batchesColumn(column) {
+		for _, chunk := range chunkColumns(opts.TableColumns, columnStatsBatchSize) {
+			if slices.ContainsFunc(chunk, func(c ColumnInfo) bool { return c.Name == column.Name }) {
+				statsColumns = chunk
+				break
+			}
+		}
+	}
+	queries := []ExplainedQuery{{Purpose: "stats", SQL: s.stats(schema, table, statsColumns, opts), Args: args}}
+
+	if s.arrayElements != nil && opts.unnestColumn(column) {
+		statsQuery, sampleQuery := s.arrayElements(schema, table, column, opts)
+		queries = append(queries, ExplainedQuery{Purpose: "array element stats", SQL: statsQuery, Args: args})
+		if sampleQuery != "" {
+			queries = append(queries, ExplainedQuery{Purpose: "sample elements", SQL: sampleQuery, Args: args})
+		}
+	}
+	if s.percentiles != nil && opts.percentileColumn(column) {
+		queries = append(queries, ExplainedQuery{Purpose: "percentiles", SQL: s.percentiles(schema, table, column, opts), Args: args})
+	}
+	if !opts.skipColumnSamples(column) {
+		queries = append(queries, ExplainedQuery{Purpose: "sample values", SQL: s.sampleValues(schema, table, column, opts), Args: args})
+	}
+	return queries
+}
+
+func (p *postgresDiscoverer) ExplainColumnEnrichment(_ context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) ([]ExplainedQuery, error) {
+	if opts.CatalogStats {
+		return []ExplainedQuery{{Purpose: "catalog stats", SQL: postgresCatalogStatsQuery, Args: []string{schema, table, column.Name}}}, nil
+	}
+	return enrichmentSQL{
+		stats:         p.statsSQL,
+		sampleValues:  p.sampleValuesSQL,
+		arrayElements: p.arrayElementSQL,
+		percentiles:   p.percentilesSQL,
+	}.explain(schema, table, column, opts), nil
+}
+
+func (r *redshiftDiscoverer) ExplainColumnEnrichment(_ context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) ([]ExplainedQuery, error) {
+	return enrichmentSQL{
+		stats:        r.statsSQL,
+		sampleValues: r.sampleValuesSQL,
+		percentiles:  r.percentilesSQL,
+	}.explain(schema, table, column, opts), nil
+}
+
+func (s *snowflakeDiscoverer) ExplainColumnEnrichment(_ context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) ([]ExplainedQuery, error) {
+	return enrichmentSQL{
+		stats:         s.statsSQL,
+		sampleValues:  s.sampleValuesSQL,
+		arrayElements: s.arrayElementSQL,
+		percentiles:   s.percentilesSQL,
+	}.explain(schema, table, column, opts), nil
+}
+
+func (m *mysqlDiscoverer) ExplainColumnEnrichment(_ context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) ([]ExplainedQuery, error) {
+	return enrichmentSQL{
+		stats:        m.statsSQL,
+		sampleValues: m.sampleValuesSQL,
+	}.explain(schema, table, column, opts), nil
+}
+
+func (d *databricksDiscoverer) ExplainColumnEnrichment(_ context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) ([]ExplainedQuery, error) {
+	return enrichmentSQL{
+		stats:         d.statsSQL,
+		sampleValues:  d.sampleValuesSQL,
+		arrayElements: d.arrayElementSQL,
+		percentiles:   d.percentilesSQL,
+	}.explain(schema, table, column, opts), nil
+}
+
+func (s *sqliteDiscoverer) ExplainColumnEnrichment(_ context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) ([]ExplainedQuery, error) {
+	return enrichmentSQL{
+		stats:        s.statsSQL,
+		sampleValues: s.sampleValuesSQL,
+	}.explain(schema, table, column, opts), nil
+}
+
+// ExplainColumnEnrichment looks up the type of the Since column, as
+// GetColumnEnrichment does, to render the filter's cast.
+func (b *bigQueryDiscoverer) ExplainColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) ([]ExplainedQuery, error) {
+	var sinceWhere, sinceAnd string
+	if !opts.Since.IsZero() {
+		placeholder, err := b.sincePlaceholder(ctx, schema, table, opts.Since.Column)
+		if err != nil {
+			return nil, fmt.Errorf("explain bigquery column %q on %s.%s: %w", column.Name, schema, table, err)
+		}
+		sinceWhere, _ = opts.sinceClause("WHERE", quoteBigQueryColumnPath, placeholder)
+		sinceAnd, _ = opts.sinceClause("AND", quoteBigQueryColumnPath, placeholder)
+	}
+	return enrichmentSQL{
+		stats: func(schema, table string, columns []ColumnInfo, opts EnrichmentOptions) string {
+			return bigQueryStatsQuery(quoteBigQueryTableReference(b.projectID, schema, table), columns, opts, sinceWhere)
+		},
+		sampleValues: func(schema, table string, column ColumnInfo, opts EnrichmentOptions) string {
+			return b.sampleValuesSQL(schema, table, column, opts, sinceAnd)
+		},
+		arrayElements: func(schema, table string, column ColumnInfo, opts EnrichmentOptions) (string, string) {
+			return b.arrayElementSQL(schema, table, column, opts, sinceAnd)
+		},
+		percentiles: func(schema, table string, column ColumnInfo, opts EnrichmentOptions) string {
+			return b.percentilesSQL(schema, table, column, opts, sinceAnd)
+		},
+	}.explain(schema, table, column, opts), nil
+}
@@ -0,0 +1,68 @@
+package discovery
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPostgresExplainColumnEnrichment(t *testing.T) {
+	opts := EnrichmentOptions{
+		Percentiles: true,
+		Since:       SinceFilter{Column: "created_at", Op: ">", Value: "2024-01-01"},
+	}
+	column := ColumnInfo{Name: "Amount", DataType: "numeric(10,2)"}
+
+	got, err := (&postgresDiscoverer{}).ExplainColumnEnrichment(context.Background(), "public", "orders", column, opts)
+	if err != nil {
+		t.Fatalf("ExplainColumnEnrichment() error = %v", err)
+	}
+	var purposes []string
+	for _, query := range got {
+		purposes = append(purposes, query.Purpose)
+		if !strings.Contains(query.SQL, `"public"."orders"`) || !strings.Contains(query.SQL, `"Amount"`) {
+			t.Errorf("%s query does not quote its identifiers:\n%s", query.Purpose, query.SQL)
+		}
+		if !strings.Contains(query.SQL, `"created_at" > $1`) {
+			t.Errorf("%s query is missing the since filter:\n%s", query.Purpose, query.SQL)
+		}
+		if len(query.Args) != 1 || query.Args[0] != "2024-01-01" {
+			t.Errorf("%s query args = %v, want [2024-01-01]", query.Purpose, query.Args)
+		}
+	}
+	if want := "stats,percentiles,sample values"; strings.Join(purposes, ",") != want {
+		t.Fatalf("ExplainColumnEnrichment() purposes = %v, want %s", purposes, want)
+	}
+}
+
+func TestExplainColumnEnrichmentSkipsSamples(t *testing.T) {
+	column := ColumnInfo{Name: "name", DataType: "TEXT"}
+
+	got, err := (&sqliteDiscoverer{}).ExplainColumnEnrichment(context.Background(), "main", "users", column, EnrichmentOptions{StatsOnly: true})
+	if err != nil {
+		t.Fatalf("ExplainColumnEnrichment() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Purpose != "stats" {
+		t.Fatalf("ExplainColumnEnrichment() = %+v, want only the stats query", got)
+	}
+}
+
+func TestExplainColumnEnrichmentUsesBatchStatsQuery(t *testing.T) {
+	columns := []ColumnInfo{{Name: "id", DataType: "INTEGER"}, {Name: "name", DataType: "TEXT"}}
+	opts := EnrichmentOptions{TableColumns: columns}
+
+	first, err := (&sqliteDiscoverer{}).ExplainColumnEnrichment(context.Background(), "main", "users", columns[0], opts)
+	if err != nil {
+		t.Fatalf("ExplainColumnEnrichment() error = %v", err)
+	}
+	second, err := (&sqliteDiscoverer{}).ExplainColumnEnrichment(context.Background(), "main", "users", columns[1], opts)
+	if err != nil {
+		t.Fatalf("ExplainColumnEnrichment() error = %v", err)
+	}
+	if first[0].SQL != second[0].SQL {
+		t.Fatalf("stats queries differ within a batch:\n%s\n---\n%s", first[0].SQL, second[0].SQL)
+	}
+	if !strings.Contains(first[0].SQL, `"name"`) {
+		t.Fatalf("batched stats query does not cover every column:\n%s", first[0].SQL)
+	}
+}
@@ -0,0 +1,21 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLastModifiedUnavailable is returned by TableLastModified when the
+// backend does not record when tables change. Callers should treat every
+// table as changed.
+var ErrLastModifiedUnavailable = errors.New("table modification times unavailable")
+
+// LastModifiedReader is implemented by discoverers whose catalog records
+// when each table's data last changed: Snowflake, MySQL, and BigQuery.
+type LastModifiedReader interface {
+	// TableLastModified returns the last modification time of the tables
+	// in schema, keyed by table name. Tables without a recorded time, and
+	// views, are omitted.
+	TableLastModified(ctx context.Context, schema string) (map[string]time.Time, error)
+}
@@ -7,31 +7,53 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	mysqlDriver "github.com/go-sql-driver/mysql"
 )
 
 const defaultMySQLPort = 3306
 
+// mysqlShowFallback names the metadata path used when information_schema is
+// denied.
+const mysqlShowFallback = "SHOW statements"
+
 type mysqlDiscoverer struct {
 	db       *sql.DB
 	database string
+	values   valueFormatter
+
+	statsBatches tableStatsBatcher
+
+	// catalogFallback switches metadata queries to SHOW statements when
+	// information_schema is denied.
+	catalogFallback
 }
 
 type mysqlDatabaseLister struct {
 	db *sql.DB
+
+	catalogFallback
 }
 
 func newMySQL(cfg DatabaseConfig) (*mysqlDiscoverer, error) {
+	values, err := newValueFormatter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	dsn := buildMySQLDSN(cfg, cfg.Database)
 	db, err := openDB("mysql", dsn)
 	if err != nil {
 		return nil, err
 	}
-	return &mysqlDiscoverer{
+	d := &mysqlDiscoverer{
 		db:       db,
 		database: strings.TrimSpace(cfg.Database),
-	}, nil
+		values:   values,
+	}
+	d.catalogFallback.path = mysqlShowFallback
+	return d, nil
 }
 
 func newMySQLDatabaseLister(cfg DatabaseConfig) (*mysqlDatabaseLister, error) {
@@ -42,7 +64,9 @@ func newMySQLDatabaseLister(cfg DatabaseConfig) (*mysqlDatabaseLister, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &mysqlDatabaseLister{db: db}, nil
+	lister := &mysqlDatabaseLister{db: db}
+	lister.catalogFallback.path = mysqlShowFallback
+	return lister, nil
 }
 
 func buildMySQLDSN(cfg DatabaseConfig, database string) string {
@@ -68,10 +92,16 @@ func (m *mysqlDatabaseLister) ListDatabases(ctx context.Context) ([]string, erro
 		SELECT schema_name
 		FROM information_schema.schemata
 		WHERE schema_name NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')
-		ORDER BY schema_name
 	`
 
 	rows, err := m.db.QueryContext(ctx, query)
+	if m.fallBackOn(err) {
+		databases, err := showMySQLDatabases(ctx, m.db)
+		if err != nil {
+			return nil, err
+		}
+		return NormalizeDatabaseNames(databases), nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("query mysql databases: %w", err)
 	}
@@ -86,7 +116,10 @@ func (m *mysqlDatabaseLister) ListDatabases(ctx context.Context) ([]string, erro
 		databases = append(databases, name)
 	}
 
-	return databases, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return NormalizeDatabaseNames(databases), nil
 }
 
 func (m *mysqlDatabaseLister) Close() error {
@@ -111,6 +144,9 @@ func (m *mysqlDiscoverer) Discover(ctx context.Context) ([]SchemaInfo, error) {
 }
 
 func (m *mysqlDiscoverer) getSchemas(ctx context.Context) ([]SchemaInfo, error) {
+	if m.CatalogFallback() != "" {
+		return m.getSchemasFromShow(ctx)
+	}
 	baseQuery := `
 		SELECT schema_name
 		FROM information_schema.schemata
@@ -125,6 +161,9 @@ func (m *mysqlDiscoverer) getSchemas(ctx context.Context) ([]SchemaInfo, error)
 	baseQuery += " ORDER BY schema_name"
 
 	rows, err := m.db.QueryContext(ctx, baseQuery, args...)
+	if m.fallBackOn(err) {
+		return m.getSchemasFromShow(ctx)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("query mysql schemas: %w", err)
 	}
@@ -143,6 +182,9 @@ func (m *mysqlDiscoverer) getSchemas(ctx context.Context) ([]SchemaInfo, error)
 }
 
 func (m *mysqlDiscoverer) getTables(ctx context.Context, schema string) ([]TableInfo, error) {
+	if m.CatalogFallback() != "" {
+		return m.getTablesFromShow(ctx, schema)
+	}
 	query := `
 		SELECT table_name, table_type
 		FROM information_schema.tables
@@ -151,6 +193,9 @@ func (m *mysqlDiscoverer) getTables(ctx context.Context, schema string) ([]Table
 	`
 
 	rows, err := m.db.QueryContext(ctx, query, schema)
+	if m.fallBackOn(err) {
+		return m.getTablesFromShow(ctx, schema)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("query mysql tables: %w", err)
 	}
@@ -168,15 +213,83 @@ func (m *mysqlDiscoverer) getTables(ctx context.Context, schema string) ([]Table
 	return tables, rows.Err()
 }
 
+// EstimateRowCounts reads information_schema.tables.table_rows, which is an
+// estimate for InnoDB and exact for MyISAM.
+func (m *mysqlDiscoverer) EstimateRowCounts(ctx context.Context, schema string) (map[string]int64, error) {
+	query := `
+		SELECT table_name, table_rows
+		FROM information_schema.tables
+		WHERE table_schema = ?
+		  AND table_type = 'BASE TABLE'
+		  AND table_rows IS NOT NULL
+	`
+
+	rows, err := m.db.QueryContext(ctx, query, schema)
+	if err != nil {
+		return nil, fmt.Errorf("query mysql row estimates: %w", err)
+	}
+	defer rows.Close()
+
+	estimates := make(map[string]int64)
+	for rows.Next() {
+		var name string
+		var count int64
+		if err := rows.Scan(&name, &count); err != nil {
+			return nil, fmt.Errorf("scan row estimate: %w", err)
+		}
+		estimates[name] = count
+	}
+	return estimates, rows.Err()
+}
+
+// TableLastModified reads information_schema.tables.update_time. InnoDB
+// keeps it in memory only, so it is NULL for tables not written since the
+// server started, and those tables are omitted.
+func (m *mysqlDiscoverer) TableLastModified(ctx context.Context, schema string) (map[string]time.Time, error) {
+	query := `
+		SELECT table_name, update_time
+		FROM information_schema.tables
+		WHERE table_schema = ?
+		  AND table_type = 'BASE TABLE'
+		  AND update_time IS NOT NULL
+	`
+
+	rows, err := m.db.QueryContext(ctx, query, schema)
+	if err != nil {
+		return nil, fmt.Errorf("query mysql table modification times: %w", err)
+	}
+	defer rows.Close()
+
+	modified := make(map[string]time.Time)
+	for rows.Next() {
+		var name string
+		var updateTime time.Time
+		if err := rows.Scan(&name, &updateTime); err != nil {
+			return nil, fmt.Errorf("scan table modification time: %w", err)
+		}
+		modified[name] = updateTime.UTC()
+	}
+	return modified, rows.Err()
+}
+
 func (m *mysqlDiscoverer) GetColumns(ctx context.Context, schema, table string) ([]ColumnInfo, error) {
+	if m.CatalogFallback() != "" {
+		return m.getColumnsFromShow(ctx, schema, table)
+	}
+	// EXTRA is VIRTUAL GENERATED or STORED GENERATED for generated columns.
+	// DEFAULT_GENERATED only marks an expression default.
 	query := `
-		SELECT column_name, data_type, is_nullable, ordinal_position, COALESCE(column_default, '')
+		SELECT column_name, data_type, is_nullable, ordinal_position, COALESCE(column_default, ''),
+			extra LIKE '%VIRTUAL GENERATED%' OR extra LIKE '%STORED GENERATED%'
 		FROM information_schema.columns
 		WHERE table_schema = ? AND table_name = ?
 		ORDER BY ordinal_position
 	`
 
 	rows, err := m.db.QueryContext(ctx, query, schema, table)
+	if m.fallBackOn(err) {
+		return m.getColumnsFromShow(ctx, schema, table)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("query mysql columns: %w", err)
 	}
@@ -185,7 +298,7 @@ func (m *mysqlDiscoverer) GetColumns(ctx context.Context, schema, table string)
 	var columns []ColumnInfo
 	for rows.Next() {
 		var c ColumnInfo
-		if err := rows.Scan(&c.Name, &c.DataType, &c.IsNullable, &c.OrdinalPosition, &c.ColumnDefault); err != nil {
+		if err := rows.Scan(&c.Name, &c.DataType, &c.IsNullable, &c.OrdinalPosition, &c.ColumnDefault, &c.IsGenerated); err != nil {
 			return nil, fmt.Errorf("scan column row: %w", err)
 		}
 		columns = append(columns, c)
@@ -194,29 +307,13 @@ func (m *mysqlDiscoverer) GetColumns(ctx context.Context, schema, table string)
 	return columns, rows.Err()
 }
 
-func (m *mysqlDiscoverer) GetColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo) (EnrichedColumnInfo, error) {
+func (m *mysqlDiscoverer) GetColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (EnrichedColumnInfo, error) {
+	opts = opts.withDefaults()
 	profile := newEnrichedColumnInfo(column)
 
-	quotedSchema := quoteMySQLIdentifier(schema)
-	quotedTable := quoteMySQLIdentifier(table)
-	quotedColumn := quoteMySQLIdentifier(column.Name)
-
-	statsQuery := fmt.Sprintf(`
-		SELECT
-			COUNT(*) AS total_rows,
-			SUM(CASE WHEN %[1]s IS NULL THEN 1 ELSE 0 END) AS null_count,
-			COUNT(%[1]s) AS non_null_count,
-			COUNT(DISTINCT CAST(%[1]s AS CHAR)) AS distinct_non_null_count
-		FROM %[2]s.%[3]s
-	`, quotedColumn, quotedSchema, quotedTable)
-
-	var totalRowsRaw, nullCountRaw, nonNullCountRaw, distinctCountRaw interface{}
-	if err := m.db.QueryRowContext(ctx, statsQuery).Scan(
-		&totalRowsRaw,
-		&nullCountRaw,
-		&nonNullCountRaw,
-		&distinctCountRaw,
-	); err != nil {
+	_, sinceArgs := opts.sinceClause("WHERE", quoteMySQLIdentifier, "?")
+	stats, err := batchedColumnStats(ctx, &m.statsBatches, schema, table, column, opts, m.readColumnStats(schema, table, opts), nil)
+	if err != nil {
 		return EnrichedColumnInfo{}, fmt.Errorf(
 			"profile mysql column %q on %s.%s: %w",
 			column.Name,
@@ -225,41 +322,13 @@ func (m *mysqlDiscoverer) GetColumnEnrichment(ctx context.Context, schema, table
 			err,
 		)
 	}
+	stats.apply(&profile)
 
-	var err error
-	profile.TotalRows, err = int64FromDBValue(totalRowsRaw)
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse total_rows for %q on %s.%s: %w", column.Name, schema, table, err)
-	}
-	profile.NullCount, err = int64FromDBValue(nullCountRaw)
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse null_count for %q on %s.%s: %w", column.Name, schema, table, err)
-	}
-	profile.NonNullCount, err = int64FromDBValue(nonNullCountRaw)
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse non_null_count for %q on %s.%s: %w", column.Name, schema, table, err)
-	}
-	profile.DistinctNonNullCount, err = int64FromDBValue(distinctCountRaw)
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse distinct_non_null_count for %q on %s.%s: %w", column.Name, schema, table, err)
-	}
-
-	profile.DistinctOfNonNullPct = percentOfTotal(profile.DistinctNonNullCount, profile.NonNullCount)
-	profile.NullOfTotalRowsPct = percentOfTotal(profile.NullCount, profile.TotalRows)
-	profile.NonNullOfTotalRowsPct = percentOfTotal(profile.NonNullCount, profile.TotalRows)
-
-	if shouldSkipColumnSamples(column.DataType) {
+	if opts.skipColumnSamples(column) {
 		return profile, nil
 	}
 
-	sampleQuery := fmt.Sprintf(`
-		SELECT DISTINCT LEFT(CAST(%[1]s AS CHAR), %[2]d)
-		FROM %[3]s.%[4]s
-		WHERE %[1]s IS NOT NULL
-		LIMIT %[5]d
-	`, quotedColumn, maxColumnSampleValueLength, quotedSchema, quotedTable, columnProfileSampleValueLimit)
-
-	rows, err := m.db.QueryContext(ctx, sampleQuery)
+	rows, err := m.db.QueryContext(ctx, m.sampleValuesSQL(schema, table, column, opts), sinceArgs...)
 	if err != nil {
 		return EnrichedColumnInfo{}, fmt.Errorf(
 			"query mysql sample values for %q on %s.%s: %w",
@@ -283,7 +352,7 @@ func (m *mysqlDiscoverer) GetColumnEnrichment(ctx context.Context, schema, table
 				err,
 			)
 		}
-		samples = append(samples, formatValue(value))
+		samples = append(samples, m.values.formatColumn(value, column.DataType))
 	}
 	if err := rows.Err(); err != nil {
 		return EnrichedColumnInfo{}, fmt.Errorf(
@@ -295,10 +364,60 @@ func (m *mysqlDiscoverer) GetColumnEnrichment(ctx context.Context, schema, table
 		)
 	}
 
-	profile.SampleValues = normalizeColumnSampleValues(samples)
+	profile.SampleValues = normalizeColumnSampleValues(samples, opts)
 	return profile, nil
 }
 
+// sampleValuesSQL builds the sample values query of column.
+func (m *mysqlDiscoverer) sampleValuesSQL(schema, table string, column ColumnInfo, opts EnrichmentOptions) string {
+	quotedColumn := quoteMySQLIdentifier(column.Name)
+	sinceAnd, _ := opts.sinceClause("AND", quoteMySQLIdentifier, "?")
+	return opts.sampleValuesQuery(
+		fmt.Sprintf("LEFT(CAST(%s AS CHAR), %d)", quotedColumn, opts.MaxSampleValueLength),
+		quoteMySQLIdentifier(schema)+"."+quoteMySQLIdentifier(table),
+		quotedColumn,
+		sinceAnd,
+	)
+}
+
+// GetTableColumnStats computes the row count and the null, non-null, and
+// distinct non-null counts of columns with one query per group of up to 100
+// columns instead of one query per column. Sample values are not collected.
+func (m *mysqlDiscoverer) GetTableColumnStats(ctx context.Context, schema, table string, columns []ColumnInfo, opts EnrichmentOptions) ([]EnrichedColumnInfo, error) {
+	profiles, err := tableColumnStats(ctx, columns, m.readColumnStats(schema, table, opts), nil)
+	if err != nil {
+		return nil, fmt.Errorf("profile mysql columns on %s.%s: %w", schema, table, err)
+	}
+	return profiles, nil
+}
+
+// readColumnStats returns a function computing the stats of columns in a
+// single query.
+func (m *mysqlDiscoverer) readColumnStats(schema, table string, opts EnrichmentOptions) readStatsFunc {
+	_, sinceArgs := opts.sinceClause("WHERE", quoteMySQLIdentifier, "?")
+	return func(ctx context.Context, columns []ColumnInfo) (map[string]columnStats, error) {
+		return queryColumnStats(ctx, m.db, m.statsSQL(schema, table, columns, opts), sinceArgs, columns)
+	}
+}
+
+// statsSQL builds the stats query of columns.
+func (m *mysqlDiscoverer) statsSQL(schema, table string, columns []ColumnInfo, opts EnrichmentOptions) string {
+	sinceWhere, _ := opts.sinceClause("WHERE", quoteMySQLIdentifier, "?")
+	dialect := statsDialect{
+		totalRows:     "COUNT(*)",
+		nullCount:     "SUM(CASE WHEN %[1]s IS NULL THEN 1 ELSE 0 END)",
+		nonNullCount:  "COUNT(%[1]s)",
+		distinctCount: "COUNT(DISTINCT CAST(%[1]s AS CHAR))",
+	}
+	return fmt.Sprintf(
+		"SELECT\n\t%s\nFROM %s.%s%s",
+		dialect.selectList(columns, quoteMySQLIdentifier),
+		quoteMySQLIdentifier(schema),
+		quoteMySQLIdentifier(table),
+		sinceWhere,
+	)
+}
+
 func (m *mysqlDiscoverer) GetSampleRows(ctx context.Context, schema, table string, limit int) (*SampleResult, error) {
 	query := fmt.Sprintf(
 		"SELECT * FROM %s.%s ORDER BY RAND() LIMIT %d",
@@ -313,7 +432,7 @@ func (m *mysqlDiscoverer) GetSampleRows(ctx context.Context, schema, table strin
 	}
 	defer rows.Close()
 
-	return scanSampleRows(rows)
+	return scanSampleRows(rows, m.values)
 }
 
 func (m *mysqlDiscoverer) Close() error {
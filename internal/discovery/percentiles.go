@@ -0,0 +1,122 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// percentileFractions are the percentiles EnrichmentOptions.Percentiles
+// computes, in the order the percentile queries return them.
+var percentileFractions = []float64{0.5, 0.95, 0.99}
+
+// numericDataTypes are the lowercased base types isNumericDataType accepts,
+// across the Postgres, Redshift, Snowflake, BigQuery, and Databricks
+// catalogs. money is left out: Postgres cannot order it as a number.
+var numericDataTypes = map[string]bool{
+	"smallint": true, "integer": true, "int": true, "bigint": true,
+	"tinyint": true, "mediumint": true, "int2": true, "int4": true,
+	"int8": true, "int64": true, "numeric": true, "decimal": true,
+	"number": true, "bignumeric": true, "real": true, "float": true,
+	"float4": true, "float8": true, "float64": true, "double": true,
+	"double precision": true,
+}
+
+// isNumericDataType reports whether dataType is an integer, decimal, or
+// floating point type, ignoring any precision and scale such as
+// "numeric(10,2)" and an "unsigned" suffix. Array types are not numeric.
+func isNumericDataType(dataType string) bool {
+	lower := strings.ToLower(strings.TrimSpace(dataType))
+	if i := strings.Index(lower, "("); i >= 0 {
+		lower = lower[:i]
+	}
+	lower = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(lower), "unsigned"))
+	return numericDataTypes[lower]
+}
+
+// percentileColumn reports whether the percentiles of column are computed.
+func (o EnrichmentOptions) percentileColumn(column ColumnInfo) bool {
+	return o.Percentiles && isNumericDataType(column.DataType)
+}
+
+// percentilesQuery builds a query returning the p50, p95, and p99 of column
+// over its non-null values. percentile formats the backend's percentile
+// aggregate of column at fraction. table may include a sampling clause.
+func percentilesQuery(percentile func(column string, fraction float64) string, table, column, sinceAnd string) string {
+	expressions := make([]string, 0, len(percentileFractions))
+	for _, fraction := range percentileFractions {
+		expressions = append(expressions, percentile(column, fraction))
+	}
+	return fmt.Sprintf(
+		"SELECT %[1]s\nFROM %[2]s\nWHERE %[3]s IS NOT NULL%[4]s",
+		strings.Join(expressions, ", "),
+		table,
+		column,
+		sinceAnd,
+	)
+}
+
+// queryColumnPercentiles runs a query built by percentilesQuery with args.
+func queryColumnPercentiles(ctx context.Context, db *sql.DB, query string, args []interface{}) (*ColumnPercentiles, error) {
+	values := make([]interface{}, len(percentileFractions))
+	targets := make([]interface{}, len(values))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	if err := db.QueryRowContext(ctx, query, args...).Scan(targets...); err != nil {
+		return nil, err
+	}
+	return parseColumnPercentiles(values)
+}
+
+// parseColumnPercentiles converts the p50, p95, and p99 values of a
+// percentile query. A column with no non-null values returns NULLs, which
+// come back as nil percentiles.
+func parseColumnPercentiles(values []interface{}) (*ColumnPercentiles, error) {
+	if len(values) != len(percentileFractions) {
+		return nil, fmt.Errorf("expected %d percentile values, got %d", len(percentileFractions), len(values))
+	}
+	parsed := make([]float64, len(values))
+	for i, value := range values {
+		if value == nil {
+			return nil, nil
+		}
+		f, err := float64FromDBValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("parse p%s: %w", strconv.FormatFloat(percentileFractions[i]*100, 'f', -1, 64), err)
+		}
+		parsed[i] = f
+	}
+	return &ColumnPercentiles{P50: parsed[0], P95: parsed[1], P99: parsed[2]}, nil
+}
+
+func float64FromDBValue(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case []byte:
+		return parseFloat64String(string(v))
+	case string:
+		return parseFloat64String(v)
+	default:
+		return parseFloat64String(formatValue(v))
+	}
+}
+
+func parseFloat64String(raw string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse float64 from %q: %w", raw, err)
+	}
+	return f, nil
+}
@@ -0,0 +1,77 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsNumericDataType(t *testing.T) {
+	tests := map[string]bool{
+		"integer":          true,
+		"bigint":           true,
+		"numeric(10,2)":    true,
+		"double precision": true,
+		"NUMBER(38,0)":     true,
+		"FLOAT64":          true,
+		"BIGNUMERIC":       true,
+		"decimal(18,4)":    true,
+		"int unsigned":     true,
+		"integer[]":        false,
+		"ARRAY<INT64>":     false,
+		"money":            false,
+		"text":             false,
+		"interval":         false,
+		"timestamp":        false,
+	}
+	for dataType, want := range tests {
+		if got := isNumericDataType(dataType); got != want {
+			t.Errorf("isNumericDataType(%q) = %v, want %v", dataType, got, want)
+		}
+	}
+}
+
+func TestPercentilesQuery(t *testing.T) {
+	got := percentilesQuery(func(column string, fraction float64) string {
+		return fmt.Sprintf("APPROX_PERCENTILE(%s, %v)", column, fraction)
+	}, `"PUBLIC"."ORDERS" SAMPLE SYSTEM (10)`, `"AMOUNT"`, ` AND "CREATED_AT" > ?`)
+	want := `SELECT APPROX_PERCENTILE("AMOUNT", 0.5), APPROX_PERCENTILE("AMOUNT", 0.95), APPROX_PERCENTILE("AMOUNT", 0.99)` + "\n" +
+		`FROM "PUBLIC"."ORDERS" SAMPLE SYSTEM (10)` + "\n" +
+		`WHERE "AMOUNT" IS NOT NULL AND "CREATED_AT" > ?`
+	if got != want {
+		t.Errorf("percentilesQuery() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestQueryColumnPercentiles(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "percentiles.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	got, err := queryColumnPercentiles(context.Background(), db, "SELECT 12.5, '310', 1249", nil)
+	if err != nil {
+		t.Fatalf("queryColumnPercentiles() error = %v", err)
+	}
+	want := ColumnPercentiles{P50: 12.5, P95: 310, P99: 1249}
+	if got == nil || *got != want {
+		t.Fatalf("queryColumnPercentiles() = %+v, want %+v", got, want)
+	}
+
+	got, err = queryColumnPercentiles(context.Background(), db, "SELECT NULL, NULL, NULL", nil)
+	if err != nil {
+		t.Fatalf("queryColumnPercentiles() on all NULLs error = %v", err)
+	}
+	if got != nil {
+		t.Fatalf("queryColumnPercentiles() on all NULLs = %+v, want nil", got)
+	}
+}
+
+func TestParseColumnPercentilesRejectsNonNumbers(t *testing.T) {
+	if _, err := parseColumnPercentiles([]interface{}{"1", "abc", "3"}); err == nil {
+		t.Fatal("parseColumnPercentiles() error = nil, want error for non-numeric p95")
+	}
+}
@@ -4,34 +4,82 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"sync"
 
 	_ "github.com/lib/pq"
 )
 
 type postgresDiscoverer struct {
-	db *sql.DB
+	db     *sql.DB
+	values valueFormatter
+
+	// sampleMethod is how GetSampleRows picks its rows.
+	sampleMethod   SampleMethod
+	systemRowsOnce sync.Once
+	systemRows     bool
+
+	statsBatches tableStatsBatcher
+
+	// catalogFallback switches metadata queries to pg_catalog when
+	// information_schema is denied.
+	catalogFallback
 }
 
 type postgresDatabaseLister struct {
-	db *sql.DB
+	db       *sql.DB
+	database string
 }
 
 func newPostgres(cfg DatabaseConfig) (*postgresDiscoverer, error) {
+	values, err := newValueFormatter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sampleMethod, err := ParseSampleMethod(cfg.SampleMethod)
+	if err != nil {
+		return nil, err
+	}
+
 	sslMode := cfg.SSLMode
 	if sslMode == "" {
 		sslMode = "disable"
 	}
 
-	connStr := fmt.Sprintf(
+	db, err := openDB("postgres", PostgresConnString(cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Database, sslMode))
+	if err != nil {
+		return nil, err
+	}
+	d := &postgresDiscoverer{db: db, values: values, sampleMethod: sampleMethod}
+	d.catalogFallback.path = "pg_catalog"
+	return d, nil
+}
+
+// PostgresConnString builds the libpq keyword/value connection string used
+// for Postgres and Redshift. Values that are empty or contain whitespace,
+// quotes, backslashes, or '=' are single-quoted with quotes and backslashes
+// escaped, so a password like `p@ss word's\` reaches the server intact.
+func PostgresConnString(host string, port int, user, password, database, sslMode string) string {
+	return fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Database, sslMode,
+		quotePostgresConnValue(host),
+		port,
+		quotePostgresConnValue(user),
+		quotePostgresConnValue(password),
+		quotePostgresConnValue(database),
+		quotePostgresConnValue(sslMode),
 	)
+}
 
-	db, err := openDB("postgres", connStr)
-	if err != nil {
-		return nil, err
+// quotePostgresConnValue quotes value for a libpq keyword/value string when
+// it would otherwise end early or be misread. Plain values are left as is.
+func quotePostgresConnValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\r\v\f'\\=") {
+		return value
 	}
-	return &postgresDiscoverer{db: db}, nil
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
 }
 
 func newPostgresDatabaseLister(cfg DatabaseConfig) (*postgresDatabaseLister, error) {
@@ -40,22 +88,22 @@ func newPostgresDatabaseLister(cfg DatabaseConfig) (*postgresDatabaseLister, err
 		sslMode = "disable"
 	}
 
-	// Connect to the "postgres" default database to list all databases.
-	dbName := cfg.Database
-	if dbName == "" {
-		dbName = "postgres"
-	}
-
-	connStr := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, dbName, sslMode,
-	)
-
-	db, err := openDB("postgres", connStr)
+	// Any database on the cluster can list pg_database. Try the configured
+	// one first, then the databases that exist on most clusters, so listing
+	// still works where "postgres" was dropped.
+	candidates := candidateDatabases(cfg.Database, "postgres", "template1")
+	db, database, err := probeCandidateDatabases(candidates, func(dbName string) (*sql.DB, error) {
+		return openDB("postgres", PostgresConnString(cfg.Host, cfg.Port, cfg.User, cfg.Password, dbName, sslMode))
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &postgresDatabaseLister{db: db}, nil
+	return &postgresDatabaseLister{db: db, database: database}, nil
+}
+
+// ConnectedDatabase returns the database the lister connected through.
+func (p *postgresDatabaseLister) ConnectedDatabase() string {
+	return p.database
 }
 
 func (p *postgresDatabaseLister) ListDatabases(ctx context.Context) ([]string, error) {
@@ -63,7 +111,6 @@ func (p *postgresDatabaseLister) ListDatabases(ctx context.Context) ([]string, e
 		SELECT datname
 		FROM pg_database
 		WHERE datistemplate = false
-		ORDER BY datname
 	`
 
 	rows, err := p.db.QueryContext(ctx, query)
@@ -80,7 +127,10 @@ func (p *postgresDatabaseLister) ListDatabases(ctx context.Context) ([]string, e
 		}
 		databases = append(databases, name)
 	}
-	return databases, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return NormalizeDatabaseNames(databases), nil
 }
 
 func (p *postgresDatabaseLister) Close() error {
@@ -105,6 +155,9 @@ func (p *postgresDiscoverer) Discover(ctx context.Context) ([]SchemaInfo, error)
 }
 
 func (p *postgresDiscoverer) getSchemas(ctx context.Context) ([]SchemaInfo, error) {
+	if p.CatalogFallback() != "" {
+		return p.getSchemasFromCatalog(ctx)
+	}
 	query := `
 		SELECT schema_name
 		FROM information_schema.schemata
@@ -115,6 +168,9 @@ func (p *postgresDiscoverer) getSchemas(ctx context.Context) ([]SchemaInfo, erro
 	`
 
 	rows, err := p.db.QueryContext(ctx, query)
+	if p.fallBackOn(err) {
+		return p.getSchemasFromCatalog(ctx)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("query postgres schemas: %w", err)
 	}
@@ -132,6 +188,9 @@ func (p *postgresDiscoverer) getSchemas(ctx context.Context) ([]SchemaInfo, erro
 }
 
 func (p *postgresDiscoverer) getTables(ctx context.Context, schema string) ([]TableInfo, error) {
+	if p.CatalogFallback() != "" {
+		return p.getTablesFromCatalog(ctx, schema)
+	}
 	query := `
 		SELECT table_name, table_type
 		FROM information_schema.tables
@@ -140,6 +199,9 @@ func (p *postgresDiscoverer) getTables(ctx context.Context, schema string) ([]Ta
 	`
 
 	rows, err := p.db.QueryContext(ctx, query, schema)
+	if p.fallBackOn(err) {
+		return p.getTablesFromCatalog(ctx, schema)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("query postgres tables: %w", err)
 	}
@@ -156,15 +218,56 @@ func (p *postgresDiscoverer) getTables(ctx context.Context, schema string) ([]Ta
 	return tables, rows.Err()
 }
 
+// EstimateRowCounts reads pg_class.reltuples, which ANALYZE and autovacuum
+// keep up to date. Relations that were never analyzed report -1 and are
+// skipped.
+func (p *postgresDiscoverer) EstimateRowCounts(ctx context.Context, schema string) (map[string]int64, error) {
+	query := `
+		SELECT c.relname, c.reltuples::bigint
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1
+		  AND c.relkind IN ('r', 'p', 'm')
+		  AND c.reltuples >= 0
+	`
+
+	rows, err := p.db.QueryContext(ctx, query, schema)
+	if err != nil {
+		return nil, fmt.Errorf("query postgres row estimates: %w", err)
+	}
+	defer rows.Close()
+
+	estimates := make(map[string]int64)
+	for rows.Next() {
+		var name string
+		var count int64
+		if err := rows.Scan(&name, &count); err != nil {
+			return nil, fmt.Errorf("scan row estimate: %w", err)
+		}
+		estimates[name] = count
+	}
+	return estimates, rows.Err()
+}
+
 func (p *postgresDiscoverer) GetColumns(ctx context.Context, schema, table string) ([]ColumnInfo, error) {
+	if p.CatalogFallback() != "" {
+		return p.getColumnsFromCatalog(ctx, schema, table)
+	}
 	query := `
-		SELECT column_name, data_type, is_nullable, ordinal_position, COALESCE(column_default, '')
+		SELECT column_name,
+			CASE WHEN data_type = 'USER-DEFINED' AND udt_name IN ('geometry', 'geography')
+				THEN udt_name::text ELSE data_type END,
+			is_nullable, ordinal_position, COALESCE(column_default, ''),
+			COALESCE(is_generated, 'NEVER') = 'ALWAYS'
 		FROM information_schema.columns
 		WHERE table_schema = $1 AND table_name = $2
 		ORDER BY ordinal_position
 	`
 
 	rows, err := p.db.QueryContext(ctx, query, schema, table)
+	if p.fallBackOn(err) {
+		return p.getColumnsFromCatalog(ctx, schema, table)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("query postgres columns: %w", err)
 	}
@@ -173,7 +276,7 @@ func (p *postgresDiscoverer) GetColumns(ctx context.Context, schema, table strin
 	var columns []ColumnInfo
 	for rows.Next() {
 		var c ColumnInfo
-		if err := rows.Scan(&c.Name, &c.DataType, &c.IsNullable, &c.OrdinalPosition, &c.ColumnDefault); err != nil {
+		if err := rows.Scan(&c.Name, &c.DataType, &c.IsNullable, &c.OrdinalPosition, &c.ColumnDefault, &c.IsGenerated); err != nil {
 			return nil, fmt.Errorf("scan column row: %w", err)
 		}
 		columns = append(columns, c)
@@ -181,28 +284,26 @@ func (p *postgresDiscoverer) GetColumns(ctx context.Context, schema, table strin
 	return columns, rows.Err()
 }
 
-func (p *postgresDiscoverer) GetColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo) (EnrichedColumnInfo, error) {
+func (p *postgresDiscoverer) GetColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (EnrichedColumnInfo, error) {
+	opts = opts.withDefaults()
+	if opts.CatalogStats {
+		profile, err := p.readCatalogColumnProfile(ctx, schema, table, column, opts)
+		if err != nil {
+			return EnrichedColumnInfo{}, fmt.Errorf(
+				"read postgres catalog stats of %q on %s.%s: %w",
+				column.Name,
+				schema,
+				table,
+				err,
+			)
+		}
+		return profile, nil
+	}
 	profile := newEnrichedColumnInfo(column)
 
-	quotedSchema := quotePostgresIdentifier(schema)
-	quotedTable := quotePostgresIdentifier(table)
-	quotedColumn := quotePostgresIdentifier(column.Name)
-
-	statsQuery := fmt.Sprintf(`
-		SELECT
-			COUNT(*)::bigint AS total_rows,
-			COUNT(*) FILTER (WHERE %[1]s IS NULL)::bigint AS null_count,
-			COUNT(%[1]s)::bigint AS non_null_count,
-			COUNT(DISTINCT %[1]s::text)::bigint AS distinct_non_null_count
-		FROM %[2]s.%[3]s
-	`, quotedColumn, quotedSchema, quotedTable)
-
-	if err := p.db.QueryRowContext(ctx, statsQuery).Scan(
-		&profile.TotalRows,
-		&profile.NullCount,
-		&profile.NonNullCount,
-		&profile.DistinctNonNullCount,
-	); err != nil {
+	_, sinceArgs := opts.sinceClause("WHERE", quotePostgresIdentifier, "$1")
+	stats, err := batchedColumnStats(ctx, &p.statsBatches, schema, table, column, opts, p.readColumnStats(schema, table, opts), nil)
+	if err != nil {
 		return EnrichedColumnInfo{}, fmt.Errorf(
 			"profile postgres column %q on %s.%s: %w",
 			column.Name,
@@ -211,23 +312,37 @@ func (p *postgresDiscoverer) GetColumnEnrichment(ctx context.Context, schema, ta
 			err,
 		)
 	}
+	stats.apply(&profile)
 
-	profile.DistinctOfNonNullPct = percentOfTotal(profile.DistinctNonNullCount, profile.NonNullCount)
-	profile.NullOfTotalRowsPct = percentOfTotal(profile.NullCount, profile.TotalRows)
-	profile.NonNullOfTotalRowsPct = percentOfTotal(profile.NonNullCount, profile.TotalRows)
+	if opts.unnestColumn(column) {
+		if profile.Elements, err = p.readArrayElementStats(ctx, schema, table, column, opts); err != nil {
+			return EnrichedColumnInfo{}, fmt.Errorf(
+				"profile postgres array elements of %q on %s.%s: %w",
+				column.Name,
+				schema,
+				table,
+				err,
+			)
+		}
+	}
 
-	if shouldSkipColumnSamples(column.DataType) {
-		return profile, nil
+	if opts.percentileColumn(column) {
+		if profile.Percentiles, err = p.readColumnPercentiles(ctx, schema, table, column, opts); err != nil {
+			return EnrichedColumnInfo{}, fmt.Errorf(
+				"profile postgres percentiles of %q on %s.%s: %w",
+				column.Name,
+				schema,
+				table,
+				err,
+			)
+		}
 	}
 
-	sampleQuery := fmt.Sprintf(`
-		SELECT DISTINCT LEFT(%[1]s::text, %[2]d)
-		FROM %[3]s.%[4]s
-		WHERE %[1]s IS NOT NULL
-		LIMIT %[5]d
-	`, quotedColumn, maxColumnSampleValueLength, quotedSchema, quotedTable, columnProfileSampleValueLimit)
+	if opts.skipColumnSamples(column) {
+		return profile, nil
+	}
 
-	rows, err := p.db.QueryContext(ctx, sampleQuery)
+	rows, err := p.db.QueryContext(ctx, p.sampleValuesSQL(schema, table, column, opts), sinceArgs...)
 	if err != nil {
 		return EnrichedColumnInfo{}, fmt.Errorf(
 			"query postgres sample values for %q on %s.%s: %w",
@@ -251,7 +366,7 @@ func (p *postgresDiscoverer) GetColumnEnrichment(ctx context.Context, schema, ta
 				err,
 			)
 		}
-		samples = append(samples, formatValue(value))
+		samples = append(samples, p.values.formatColumn(value, column.DataType))
 	}
 	if err := rows.Err(); err != nil {
 		return EnrichedColumnInfo{}, fmt.Errorf(
@@ -263,15 +378,124 @@ func (p *postgresDiscoverer) GetColumnEnrichment(ctx context.Context, schema, ta
 		)
 	}
 
-	profile.SampleValues = normalizeColumnSampleValues(samples)
+	profile.SampleValues = normalizeColumnSampleValues(samples, opts)
 	return profile, nil
 }
 
-func (p *postgresDiscoverer) GetSampleRows(ctx context.Context, schema, table string, limit int) (*SampleResult, error) {
-	query := fmt.Sprintf(
-		`SELECT * FROM %q.%q ORDER BY RANDOM() LIMIT %d`,
-		schema, table, limit,
+// sampleValuesSQL builds the sample values query of column. PostGIS
+// geometries are sampled as WKT.
+func (p *postgresDiscoverer) sampleValuesSQL(schema, table string, column ColumnInfo, opts EnrichmentOptions) string {
+	quotedColumn := quotePostgresIdentifier(column.Name)
+	sampleValue := quotedColumn + "::text"
+	if isPostGISType(column.DataType) {
+		sampleValue = "ST_AsText(" + quotedColumn + ")"
+	}
+	sinceAnd, _ := opts.sinceClause("AND", quotePostgresIdentifier, "$1")
+	return opts.sampleValuesQuery(
+		fmt.Sprintf("LEFT(%s, %d)", sampleValue, opts.MaxSampleValueLength),
+		quotePostgresIdentifier(schema)+"."+quotePostgresIdentifier(table),
+		quotedColumn,
+		sinceAnd,
+	)
+}
+
+// GetTableColumnStats computes the row count and the null, non-null, and
+// distinct non-null counts of columns with one query per group of up to 100
+// columns instead of one query per column. Sample values are not collected.
+func (p *postgresDiscoverer) GetTableColumnStats(ctx context.Context, schema, table string, columns []ColumnInfo, opts EnrichmentOptions) ([]EnrichedColumnInfo, error) {
+	profiles, err := tableColumnStats(ctx, columns, p.readColumnStats(schema, table, opts), nil)
+	if err != nil {
+		return nil, fmt.Errorf("profile postgres columns on %s.%s: %w", schema, table, err)
+	}
+	return profiles, nil
+}
+
+// readColumnStats returns a function computing the stats of columns in a
+// single query.
+func (p *postgresDiscoverer) readColumnStats(schema, table string, opts EnrichmentOptions) readStatsFunc {
+	_, sinceArgs := opts.sinceClause("WHERE", quotePostgresIdentifier, "$1")
+	return func(ctx context.Context, columns []ColumnInfo) (map[string]columnStats, error) {
+		return queryColumnStats(ctx, p.db, p.statsSQL(schema, table, columns, opts), sinceArgs, columns)
+	}
+}
+
+// statsSQL builds the stats query of columns.
+func (p *postgresDiscoverer) statsSQL(schema, table string, columns []ColumnInfo, opts EnrichmentOptions) string {
+	sinceWhere, _ := opts.sinceClause("WHERE", quotePostgresIdentifier, "$1")
+	dialect := statsDialect{
+		totalRows:     "COUNT(*)::bigint",
+		nullCount:     "COUNT(*) FILTER (WHERE %[1]s IS NULL)::bigint",
+		nonNullCount:  "COUNT(%[1]s)::bigint",
+		distinctCount: "COUNT(DISTINCT %[1]s::text)::bigint",
+	}
+	return fmt.Sprintf(
+		"SELECT\n\t%s\nFROM %s.%s%s%s",
+		dialect.selectList(columns, quotePostgresIdentifier),
+		quotePostgresIdentifier(schema),
+		quotePostgresIdentifier(table),
+		opts.tablesampleClause("TABLESAMPLE SYSTEM (%s)"),
+		sinceWhere,
 	)
+}
+
+// readArrayElementStats profiles the elements of an array column with
+// unnest. Multidimensional arrays are flattened to their elements.
+func (p *postgresDiscoverer) readArrayElementStats(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (*ArrayElementStats, error) {
+	_, sinceArgs := opts.sinceClause("AND", quotePostgresIdentifier, "$1")
+	statsQuery, sampleQuery := p.arrayElementSQL(schema, table, column, opts)
+	return queryArrayElementStats(ctx, p.db, p.values, statsQuery, sampleQuery, sinceArgs, opts)
+}
+
+// arrayElementSQL builds the element stats query of an array column and,
+// unless its samples are skipped, the sample elements query.
+func (p *postgresDiscoverer) arrayElementSQL(schema, table string, column ColumnInfo, opts EnrichmentOptions) (string, string) {
+	dialect := arrayElementDialect{
+		unnest:        "%[1]s CROSS JOIN LATERAL unnest(%[2]s) AS dbh_elements(" + arrayElement + ")",
+		element:       arrayElement,
+		text:          "%[1]s::text",
+		countDistinct: "COUNT(DISTINCT",
+	}
+	quotedTable := quotePostgresIdentifier(schema) + "." + quotePostgresIdentifier(table)
+	quotedColumn := quotePostgresIdentifier(column.Name)
+	sinceAnd, _ := opts.sinceClause("AND", quotePostgresIdentifier, "$1")
+
+	statsQuery := dialect.statsQuery(quotedTable+opts.tablesampleClause("TABLESAMPLE SYSTEM (%s)"), quotedColumn, sinceAnd)
+	var sampleQuery string
+	if !opts.skipColumnSamples(column) {
+		sampleQuery = dialect.sampleQuery(quotedTable, quotedColumn, sinceAnd, opts)
+	}
+	return statsQuery, sampleQuery
+}
+
+// readColumnPercentiles computes exact percentiles with PERCENTILE_CONT.
+func (p *postgresDiscoverer) readColumnPercentiles(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (*ColumnPercentiles, error) {
+	_, sinceArgs := opts.sinceClause("AND", quotePostgresIdentifier, "$1")
+	return queryColumnPercentiles(ctx, p.db, p.percentilesSQL(schema, table, column, opts), sinceArgs)
+}
+
+// percentilesSQL builds the percentiles query of a numeric column.
+func (p *postgresDiscoverer) percentilesSQL(schema, table string, column ColumnInfo, opts EnrichmentOptions) string {
+	quotedTable := quotePostgresIdentifier(schema) + "." + quotePostgresIdentifier(table)
+	sinceAnd, _ := opts.sinceClause("AND", quotePostgresIdentifier, "$1")
+	return percentilesQuery(func(column string, fraction float64) string {
+		return fmt.Sprintf("PERCENTILE_CONT(%v) WITHIN GROUP (ORDER BY %s::double precision)", fraction, column)
+	}, quotedTable+opts.tablesampleClause("TABLESAMPLE SYSTEM (%s)"), quotePostgresIdentifier(column.Name), sinceAnd)
+}
+
+func (p *postgresDiscoverer) GetSampleRows(ctx context.Context, schema, table string, limit int) (*SampleResult, error) {
+	selectList, err := p.sampleSelectList(ctx, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	method, estimate := SampleMethodRandom, int64(-1)
+	if p.sampleMethod != SampleMethodRandom {
+		if estimate, err = p.tableRowEstimate(ctx, schema, table); err != nil {
+			return nil, err
+		}
+		method = postgresSampleMethod(p.sampleMethod, estimate, estimate >= 0 && p.hasSystemRows(ctx))
+	}
+	from := quotePostgresIdentifier(schema) + "." + quotePostgresIdentifier(table)
+	query := postgresSampleQuery(method, selectList, from, limit, estimate)
 
 	rows, err := p.db.QueryContext(ctx, query)
 	if err != nil {
@@ -279,7 +503,7 @@ func (p *postgresDiscoverer) GetSampleRows(ctx context.Context, schema, table st
 	}
 	defer rows.Close()
 
-	return scanSampleRows(rows)
+	return scanSampleRows(rows, p.values)
 }
 
 func (p *postgresDiscoverer) Close() error {
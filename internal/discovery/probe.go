@@ -0,0 +1,87 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// candidateProbeTimeout bounds each connection attempt made while choosing
+// the database a lister connects through.
+const candidateProbeTimeout = 15 * time.Second
+
+// ConnectedDatabaseReporter is implemented by database listers that try
+// several databases to connect through. ConnectedDatabase returns the one
+// that accepted the connection.
+type ConnectedDatabaseReporter interface {
+	ConnectedDatabase() string
+}
+
+// candidateDatabases returns configured followed by fallbacks, without blanks
+// or duplicates.
+func candidateDatabases(configured string, fallbacks ...string) []string {
+	seen := make(map[string]bool, len(fallbacks)+1)
+	var candidates []string
+	for _, name := range append([]string{configured}, fallbacks...) {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		candidates = append(candidates, name)
+	}
+	return candidates
+}
+
+// probeCandidateDatabases connects to every candidate at once and returns
+// the first one, in candidate order, that answers a ping. The other
+// connections are closed. When none connects, the error lists each failure.
+func probeCandidateDatabases(candidates []string, open func(database string) (*sql.DB, error)) (*sql.DB, string, error) {
+	type probeResult struct {
+		db  *sql.DB
+		err error
+	}
+
+	results := make([]probeResult, len(candidates))
+	var wg sync.WaitGroup
+	for i, database := range candidates {
+		wg.Add(1)
+		go func(i int, database string) {
+			defer wg.Done()
+			db, err := open(database)
+			if err == nil {
+				ctx, cancel := context.WithTimeout(context.Background(), candidateProbeTimeout)
+				err = db.PingContext(ctx)
+				cancel()
+				if err != nil {
+					db.Close()
+					db = nil
+				}
+			}
+			results[i] = probeResult{db: db, err: err}
+		}(i, database)
+	}
+	wg.Wait()
+
+	chosen := -1
+	var errs []error
+	for i, result := range results {
+		if result.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", candidates[i], result.err))
+			continue
+		}
+		if chosen < 0 {
+			chosen = i
+			continue
+		}
+		result.db.Close()
+	}
+	if chosen < 0 {
+		return nil, "", fmt.Errorf("could not connect to any of %s: %w", strings.Join(candidates, ", "), errors.Join(errs...))
+	}
+	return results[chosen].db, candidates[chosen], nil
+}
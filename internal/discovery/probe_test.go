@@ -0,0 +1,69 @@
+package discovery
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCandidateDatabases(t *testing.T) {
+	got := candidateDatabases(" analytics ", "postgres", "template1")
+	want := []string{"analytics", "postgres", "template1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("candidateDatabases() = %v, want %v", got, want)
+	}
+
+	got = candidateDatabases("", "dev", "template1")
+	want = []string{"dev", "template1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("candidateDatabases(unset) = %v, want %v", got, want)
+	}
+
+	got = candidateDatabases("postgres", "postgres", "template1")
+	want = []string{"postgres", "template1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("candidateDatabases(duplicate) = %v, want %v", got, want)
+	}
+}
+
+func TestProbeCandidateDatabasesPrefersEarliestWorkingCandidate(t *testing.T) {
+	dbPath := createSQLiteTestDatabase(t)
+	errDropped := errors.New("database does not exist")
+	open := func(database string) (*sql.DB, error) {
+		if database == "postgres" {
+			return nil, errDropped
+		}
+		return sql.Open("sqlite", dbPath)
+	}
+
+	db, database, err := probeCandidateDatabases([]string{"postgres", "template1", "analytics"}, open)
+	if err != nil {
+		t.Fatalf("probeCandidateDatabases() error = %v", err)
+	}
+	defer db.Close()
+	if database != "template1" {
+		t.Fatalf("connected database = %q, want template1", database)
+	}
+	if err := db.Ping(); err != nil {
+		t.Fatalf("returned connection is not usable: %v", err)
+	}
+}
+
+func TestProbeCandidateDatabasesReportsEveryFailure(t *testing.T) {
+	errDropped := errors.New("database does not exist")
+	open := func(database string) (*sql.DB, error) {
+		return nil, errDropped
+	}
+
+	_, _, err := probeCandidateDatabases([]string{"dev", "template1"}, open)
+	if !errors.Is(err, errDropped) {
+		t.Fatalf("probeCandidateDatabases() error = %v, want wrapped errDropped", err)
+	}
+	for _, want := range []string{"dev:", "template1:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not mention %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// queryLog receives a line for every statement the discoverers send when
+// set with SetQueryLog.
+var queryLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// SetQueryLog makes every backend write each SQL statement, with its bind
+// arguments, to w just before sending it. Only the statements are written,
+// never the rows they return, so sample values stay out of the log. Pools
+// opened before the call are not affected. A nil w turns logging off.
+func SetQueryLog(w io.Writer) {
+	queryLog.mu.Lock()
+	defer queryLog.mu.Unlock()
+	queryLog.w = w
+}
+
+func queryLogEnabled() bool {
+	queryLog.mu.Lock()
+	defer queryLog.mu.Unlock()
+	return queryLog.w != nil
+}
+
+// logQuery writes one statement to the query log on a single line, with
+// runs of whitespace collapsed so the indented queries stay readable.
+func logQuery(backend, query string, args []interface{}) {
+	queryLog.mu.Lock()
+	defer queryLog.mu.Unlock()
+	if queryLog.w == nil {
+		return
+	}
+	line := fmt.Sprintf("[query %s] %s", backend, strings.Join(strings.Fields(query), " "))
+	if len(args) > 0 {
+		formatted := make([]string, len(args))
+		for i, arg := range args {
+			formatted[i] = fmt.Sprintf("%q", fmt.Sprint(arg))
+		}
+		line += " -- args: " + strings.Join(formatted, ", ")
+	}
+	fmt.Fprintln(queryLog.w, line)
+}
+
+// openDB opens a database/sql pool, logging its statements when the query
+// log is on.
+func openDB(driverName, dsn string) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open %s connection: %w", driverName, err)
+	}
+	if !queryLogEnabled() {
+		return db, nil
+	}
+
+	var connector driver.Connector = dsnConnector{dsn: dsn, driver: db.Driver()}
+	if driverContext, ok := db.Driver().(driver.DriverContext); ok {
+		if connector, err = driverContext.OpenConnector(dsn); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("open %s connection: %w", driverName, err)
+		}
+	}
+	db.Close()
+	return openConnector(driverName, connector), nil
+}
+
+// openConnector opens a pool on connector, logging its statements under
+// backend when the query log is on.
+func openConnector(backend string, connector driver.Connector) *sql.DB {
+	if queryLogEnabled() {
+		connector = loggingConnector{Connector: connector, backend: backend}
+	}
+	return sql.OpenDB(connector)
+}
+
+// dsnConnector adapts a driver without a Connector of its own.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.driver }
+
+// loggingConnector hands out connections that log their statements.
+type loggingConnector struct {
+	driver.Connector
+	backend string
+}
+
+func (c loggingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &loggingConn{conn: conn, backend: c.backend}, nil
+}
+
+// loggingConn logs each statement before passing it to the wrapped
+// connection. database/sql uses a connection from one goroutine at a time.
+type loggingConn struct {
+	conn    driver.Conn
+	backend string
+	// skipped is a statement logged by QueryContext or ExecContext that the
+	// driver answered with driver.ErrSkip. database/sql prepares it next,
+	// and that prepare is not logged again.
+	skipped string
+}
+
+func (c *loggingConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *loggingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if c.skipped == query {
+		c.skipped = ""
+	} else {
+		logQuery(c.backend, query, nil)
+	}
+	if preparer, ok := c.conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.conn.Prepare(query)
+}
+
+func (c *loggingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	logQuery(c.backend, query, namedValueArgs(args))
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err == driver.ErrSkip {
+		c.skipped = query
+	}
+	return rows, err
+}
+
+func (c *loggingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	logQuery(c.backend, query, namedValueArgs(args))
+	result, err := execer.ExecContext(ctx, query, args)
+	if err == driver.ErrSkip {
+		c.skipped = query
+	}
+	return result, err
+}
+
+func (c *loggingConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *loggingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.conn.Begin()
+}
+
+func (c *loggingConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *loggingConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *loggingConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *loggingConn) IsValid() bool {
+	if validator, ok := c.conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *loggingConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+func namedValueArgs(args []driver.NamedValue) []interface{} {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
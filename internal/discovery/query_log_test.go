@@ -0,0 +1,115 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func useQueryLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	SetQueryLog(&buf)
+	t.Cleanup(func() { SetQueryLog(nil) })
+	return &buf
+}
+
+func TestOpenDBLogsStatementsButNotValues(t *testing.T) {
+	log := useQueryLog(t)
+	db, err := openDB("sqlite", filepath.Join(t.TempDir(), "log.db"))
+	if err != nil {
+		t.Fatalf("openDB() error = %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, "CREATE TABLE users (email TEXT)"); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO users VALUES ('secret@example.com')"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	log.Reset()
+
+	var email string
+	if err := db.QueryRowContext(ctx, "SELECT email\n\t\tFROM users\n\t\tWHERE email LIKE ?", "%@example.com").Scan(&email); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	want := `[query sqlite] SELECT email FROM users WHERE email LIKE ? -- args: "%@example.com"` + "\n"
+	if log.String() != want {
+		t.Fatalf("query log = %q, want %q", log.String(), want)
+	}
+	if strings.Contains(log.String(), "secret@") {
+		t.Fatal("query log contains a returned value")
+	}
+}
+
+func TestOpenDBWithoutQueryLogLogsNothing(t *testing.T) {
+	var buf bytes.Buffer
+	SetQueryLog(nil)
+	db, err := openDB("sqlite", filepath.Join(t.TempDir(), "quiet.db"))
+	if err != nil {
+		t.Fatalf("openDB() error = %v", err)
+	}
+	defer db.Close()
+	SetQueryLog(&buf)
+	t.Cleanup(func() { SetQueryLog(nil) })
+
+	if _, err := db.ExecContext(context.Background(), "SELECT 1"); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("pool opened before SetQueryLog logged %q", buf.String())
+	}
+}
+
+// skippingConn answers every query with driver.ErrSkip, like drivers that
+// only run parameterized queries as prepared statements.
+type skippingConn struct{}
+
+func (skippingConn) Prepare(query string) (driver.Stmt, error) { return emptyStmt{}, nil }
+func (skippingConn) Close() error                              { return nil }
+func (skippingConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+func (skippingConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return nil, driver.ErrSkip
+}
+
+type emptyStmt struct{}
+
+func (emptyStmt) Close() error                                    { return nil }
+func (emptyStmt) NumInput() int                                   { return -1 }
+func (emptyStmt) Exec(args []driver.Value) (driver.Result, error) { return driver.RowsAffected(0), nil }
+func (emptyStmt) Query(args []driver.Value) (driver.Rows, error)  { return emptyRows{}, nil }
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string              { return []string{"n"} }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
+
+type skippingConnector struct{}
+
+func (skippingConnector) Connect(context.Context) (driver.Conn, error) { return skippingConn{}, nil }
+func (skippingConnector) Driver() driver.Driver                        { return nil }
+
+func TestQueryLogDoesNotRepeatSkippedQueries(t *testing.T) {
+	log := useQueryLog(t)
+	db := openConnector("fake", skippingConnector{})
+	defer db.Close()
+
+	rows, err := db.QueryContext(context.Background(), "SELECT n FROM t WHERE id = ?", 7)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	rows.Close()
+
+	want := `[query fake] SELECT n FROM t WHERE id = ? -- args: "7"` + "\n"
+	if log.String() != want {
+		t.Fatalf("query log = %q, want %q", log.String(), want)
+	}
+}
@@ -8,39 +8,78 @@ import (
 )
 
 const (
-	defaultRedshiftPort    = 5439
-	defaultRedshiftSSLMode = "require"
+	defaultRedshiftPort     = 5439
+	defaultRedshiftSSLMode  = "require"
+	defaultRedshiftDatabase = "dev"
 )
 
 type redshiftDiscoverer struct {
-	db *sql.DB
+	db     *sql.DB
+	values valueFormatter
+
+	statsBatches tableStatsBatcher
 }
 
 type redshiftDatabaseLister struct {
-	db *sql.DB
+	db       *sql.DB
+	database string
 }
 
 func newRedshift(cfg DatabaseConfig) (*redshiftDiscoverer, error) {
-	db, err := openDB("postgres", buildRedshiftConnString(cfg, cfg.Database))
+	values, err := newValueFormatter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redshiftServerlessTimeout)
+	defer cancel()
+	cfg, err = resolveRedshiftServerlessEndpoint(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
-	return &redshiftDiscoverer{db: db}, nil
+	db, err := openRedshift(ctx, cfg, redshiftDatabaseOrDefault(cfg.Database))
+	if err != nil {
+		return nil, err
+	}
+	return &redshiftDiscoverer{db: db, values: values}, nil
 }
 
 func newRedshiftDatabaseLister(cfg DatabaseConfig) (*redshiftDatabaseLister, error) {
-	// Redshift requires a database in the connection. Use "dev" when one
-	// is not configured yet so database listing still works.
-	dbName := strings.TrimSpace(cfg.Database)
-	if dbName == "" {
-		dbName = "dev"
+	ctx, cancel := context.WithTimeout(context.Background(), redshiftServerlessTimeout)
+	defer cancel()
+	cfg, err := resolveRedshiftServerlessEndpoint(ctx, cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	db, err := openDB("postgres", buildRedshiftConnString(cfg, dbName))
+	// Redshift requires a database in the connection. Try the configured
+	// one, then "dev" (the default database of new clusters and serverless
+	// namespaces) and, on clusters, "template1", so listing works before a
+	// database is configured.
+	candidates := candidateDatabases(cfg.Database, defaultRedshiftDatabase, "template1")
+	if cfg.Serverless {
+		candidates = candidateDatabases(cfg.Database, defaultRedshiftDatabase)
+	}
+	db, database, err := probeCandidateDatabases(candidates, func(dbName string) (*sql.DB, error) {
+		return openRedshift(ctx, cfg, dbName)
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &redshiftDatabaseLister{db: db}, nil
+	return &redshiftDatabaseLister{db: db, database: database}, nil
+}
+
+// ConnectedDatabase returns the database the lister connected through.
+func (r *redshiftDatabaseLister) ConnectedDatabase() string {
+	return r.database
+}
+
+// redshiftDatabaseOrDefault returns database, or "dev" when it is empty.
+func redshiftDatabaseOrDefault(database string) string {
+	if database = strings.TrimSpace(database); database != "" {
+		return database
+	}
+	return defaultRedshiftDatabase
 }
 
 func buildRedshiftConnString(cfg DatabaseConfig, database string) string {
@@ -54,8 +93,7 @@ func buildRedshiftConnString(cfg DatabaseConfig, database string) string {
 		sslMode = defaultRedshiftSSLMode
 	}
 
-	return fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+	return PostgresConnString(
 		strings.TrimSpace(cfg.Host),
 		port,
 		strings.TrimSpace(cfg.User),
@@ -71,7 +109,6 @@ func (r *redshiftDatabaseLister) ListDatabases(ctx context.Context) ([]string, e
 		FROM pg_database
 		WHERE datallowconn = true
 		  AND datistemplate = false
-		ORDER BY datname
 	`
 
 	rows, err := r.db.QueryContext(ctx, query)
@@ -88,7 +125,10 @@ func (r *redshiftDatabaseLister) ListDatabases(ctx context.Context) ([]string, e
 		}
 		databases = append(databases, name)
 	}
-	return databases, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return NormalizeDatabaseNames(databases), nil
 }
 
 func (r *redshiftDatabaseLister) Close() error {
@@ -163,6 +203,34 @@ func (r *redshiftDiscoverer) getTables(ctx context.Context, schema string) ([]Ta
 	return tables, rows.Err()
 }
 
+// EstimateRowCounts reads tbl_rows from SVV_TABLE_INFO, which only lists
+// tables that hold data.
+func (r *redshiftDiscoverer) EstimateRowCounts(ctx context.Context, schema string) (map[string]int64, error) {
+	query := `
+		SELECT "table", tbl_rows::bigint
+		FROM svv_table_info
+		WHERE "schema" = $1
+		  AND tbl_rows IS NOT NULL
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, schema)
+	if err != nil {
+		return nil, fmt.Errorf("query redshift row estimates: %w", err)
+	}
+	defer rows.Close()
+
+	estimates := make(map[string]int64)
+	for rows.Next() {
+		var name string
+		var count int64
+		if err := rows.Scan(&name, &count); err != nil {
+			return nil, fmt.Errorf("scan row estimate: %w", err)
+		}
+		estimates[name] = count
+	}
+	return estimates, rows.Err()
+}
+
 func (r *redshiftDiscoverer) GetColumns(ctx context.Context, schema, table string) ([]ColumnInfo, error) {
 	query := `
 		SELECT column_name, data_type, is_nullable, ordinal_position, COALESCE(column_default, '')
@@ -188,29 +256,13 @@ func (r *redshiftDiscoverer) GetColumns(ctx context.Context, schema, table strin
 	return columns, rows.Err()
 }
 
-func (r *redshiftDiscoverer) GetColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo) (EnrichedColumnInfo, error) {
+func (r *redshiftDiscoverer) GetColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (EnrichedColumnInfo, error) {
+	opts = opts.withDefaults()
 	profile := newEnrichedColumnInfo(column)
 
-	quotedSchema := quoteRedshiftIdentifier(schema)
-	quotedTable := quoteRedshiftIdentifier(table)
-	quotedColumn := quoteRedshiftIdentifier(column.Name)
-
-	statsQuery := fmt.Sprintf(`
-		SELECT
-			COUNT(*)::bigint AS total_rows,
-			SUM(CASE WHEN %[1]s IS NULL THEN 1 ELSE 0 END)::bigint AS null_count,
-			COUNT(%[1]s)::bigint AS non_null_count,
-			COUNT(DISTINCT CASE WHEN %[1]s IS NULL THEN NULL ELSE CAST(%[1]s AS VARCHAR(65535)) END)::bigint AS distinct_non_null_count
-		FROM %[2]s.%[3]s
-	`, quotedColumn, quotedSchema, quotedTable)
-
-	var totalRowsRaw, nullCountRaw, nonNullCountRaw, distinctCountRaw interface{}
-	if err := r.db.QueryRowContext(ctx, statsQuery).Scan(
-		&totalRowsRaw,
-		&nullCountRaw,
-		&nonNullCountRaw,
-		&distinctCountRaw,
-	); err != nil {
+	_, sinceArgs := opts.sinceClause("WHERE", quoteRedshiftIdentifier, "$1")
+	stats, err := batchedColumnStats(ctx, &r.statsBatches, schema, table, column, opts, r.readColumnStats(schema, table, opts), nil)
+	if err != nil {
 		return EnrichedColumnInfo{}, fmt.Errorf(
 			"profile redshift column %q on %s.%s: %w",
 			column.Name,
@@ -219,41 +271,25 @@ func (r *redshiftDiscoverer) GetColumnEnrichment(ctx context.Context, schema, ta
 			err,
 		)
 	}
+	stats.apply(&profile)
 
-	var err error
-	profile.TotalRows, err = int64FromDBValue(totalRowsRaw)
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse total_rows for %q on %s.%s: %w", column.Name, schema, table, err)
-	}
-	profile.NullCount, err = int64FromDBValue(nullCountRaw)
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse null_count for %q on %s.%s: %w", column.Name, schema, table, err)
-	}
-	profile.NonNullCount, err = int64FromDBValue(nonNullCountRaw)
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse non_null_count for %q on %s.%s: %w", column.Name, schema, table, err)
-	}
-	profile.DistinctNonNullCount, err = int64FromDBValue(distinctCountRaw)
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse distinct_non_null_count for %q on %s.%s: %w", column.Name, schema, table, err)
+	if opts.percentileColumn(column) {
+		if profile.Percentiles, err = r.readColumnPercentiles(ctx, schema, table, column, opts); err != nil {
+			return EnrichedColumnInfo{}, fmt.Errorf(
+				"profile redshift percentiles of %q on %s.%s: %w",
+				column.Name,
+				schema,
+				table,
+				err,
+			)
+		}
 	}
 
-	profile.DistinctOfNonNullPct = percentOfTotal(profile.DistinctNonNullCount, profile.NonNullCount)
-	profile.NullOfTotalRowsPct = percentOfTotal(profile.NullCount, profile.TotalRows)
-	profile.NonNullOfTotalRowsPct = percentOfTotal(profile.NonNullCount, profile.TotalRows)
-
-	if shouldSkipColumnSamples(column.DataType) {
+	if opts.skipColumnSamples(column) {
 		return profile, nil
 	}
 
-	sampleQuery := fmt.Sprintf(`
-		SELECT DISTINCT LEFT(CAST(%[1]s AS VARCHAR(65535)), %[2]d)
-		FROM %[3]s.%[4]s
-		WHERE %[1]s IS NOT NULL
-		LIMIT %[5]d
-	`, quotedColumn, maxColumnSampleValueLength, quotedSchema, quotedTable, columnProfileSampleValueLimit)
-
-	rows, err := r.db.QueryContext(ctx, sampleQuery)
+	rows, err := r.db.QueryContext(ctx, r.sampleValuesSQL(schema, table, column, opts), sinceArgs...)
 	if err != nil {
 		return EnrichedColumnInfo{}, fmt.Errorf(
 			"query redshift sample values for %q on %s.%s: %w",
@@ -277,7 +313,7 @@ func (r *redshiftDiscoverer) GetColumnEnrichment(ctx context.Context, schema, ta
 				err,
 			)
 		}
-		samples = append(samples, formatValue(value))
+		samples = append(samples, r.values.formatColumn(value, column.DataType))
 	}
 	if err := rows.Err(); err != nil {
 		return EnrichedColumnInfo{}, fmt.Errorf(
@@ -289,10 +325,79 @@ func (r *redshiftDiscoverer) GetColumnEnrichment(ctx context.Context, schema, ta
 		)
 	}
 
-	profile.SampleValues = normalizeColumnSampleValues(samples)
+	profile.SampleValues = normalizeColumnSampleValues(samples, opts)
 	return profile, nil
 }
 
+// sampleValuesSQL builds the sample values query of column.
+func (r *redshiftDiscoverer) sampleValuesSQL(schema, table string, column ColumnInfo, opts EnrichmentOptions) string {
+	quotedColumn := quoteRedshiftIdentifier(column.Name)
+	sinceAnd, _ := opts.sinceClause("AND", quoteRedshiftIdentifier, "$1")
+	return opts.sampleValuesQuery(
+		fmt.Sprintf("LEFT(CAST(%s AS VARCHAR(65535)), %d)", quotedColumn, opts.MaxSampleValueLength),
+		quoteRedshiftIdentifier(schema)+"."+quoteRedshiftIdentifier(table),
+		quotedColumn,
+		sinceAnd,
+	)
+}
+
+// GetTableColumnStats computes the row count and the null, non-null, and
+// distinct non-null counts of columns with one query per group of up to 100
+// columns instead of one query per column. Sample values are not collected.
+func (r *redshiftDiscoverer) GetTableColumnStats(ctx context.Context, schema, table string, columns []ColumnInfo, opts EnrichmentOptions) ([]EnrichedColumnInfo, error) {
+	profiles, err := tableColumnStats(ctx, columns, r.readColumnStats(schema, table, opts), nil)
+	if err != nil {
+		return nil, fmt.Errorf("profile redshift columns on %s.%s: %w", schema, table, err)
+	}
+	return profiles, nil
+}
+
+// readColumnStats returns a function computing the stats of columns in a
+// single query.
+func (r *redshiftDiscoverer) readColumnStats(schema, table string, opts EnrichmentOptions) readStatsFunc {
+	_, sinceArgs := opts.sinceClause("WHERE", quoteRedshiftIdentifier, "$1")
+	return func(ctx context.Context, columns []ColumnInfo) (map[string]columnStats, error) {
+		return queryColumnStats(ctx, r.db, r.statsSQL(schema, table, columns, opts), sinceArgs, columns)
+	}
+}
+
+// statsSQL builds the stats query of columns.
+func (r *redshiftDiscoverer) statsSQL(schema, table string, columns []ColumnInfo, opts EnrichmentOptions) string {
+	countDistinct := "COUNT(DISTINCT"
+	if opts.ApproxDistinct {
+		countDistinct = "APPROXIMATE COUNT(DISTINCT"
+	}
+	sinceWhere, _ := opts.sinceClause("WHERE", quoteRedshiftIdentifier, "$1")
+	dialect := statsDialect{
+		totalRows:     "COUNT(*)::bigint",
+		nullCount:     "SUM(CASE WHEN %[1]s IS NULL THEN 1 ELSE 0 END)::bigint",
+		nonNullCount:  "COUNT(%[1]s)::bigint",
+		distinctCount: countDistinct + " CASE WHEN %[1]s IS NULL THEN NULL ELSE CAST(%[1]s AS VARCHAR(65535)) END)::bigint",
+	}
+	return fmt.Sprintf(
+		"SELECT\n\t%s\nFROM %s.%s%s",
+		dialect.selectList(columns, quoteRedshiftIdentifier),
+		quoteRedshiftIdentifier(schema),
+		quoteRedshiftIdentifier(table),
+		sinceWhere,
+	)
+}
+
+// readColumnPercentiles computes exact percentiles with PERCENTILE_CONT.
+func (r *redshiftDiscoverer) readColumnPercentiles(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (*ColumnPercentiles, error) {
+	_, sinceArgs := opts.sinceClause("AND", quoteRedshiftIdentifier, "$1")
+	return queryColumnPercentiles(ctx, r.db, r.percentilesSQL(schema, table, column, opts), sinceArgs)
+}
+
+// percentilesSQL builds the percentiles query of a numeric column.
+func (r *redshiftDiscoverer) percentilesSQL(schema, table string, column ColumnInfo, opts EnrichmentOptions) string {
+	quotedTable := quoteRedshiftIdentifier(schema) + "." + quoteRedshiftIdentifier(table)
+	sinceAnd, _ := opts.sinceClause("AND", quoteRedshiftIdentifier, "$1")
+	return percentilesQuery(func(column string, fraction float64) string {
+		return fmt.Sprintf("PERCENTILE_CONT(%v) WITHIN GROUP (ORDER BY %s)", fraction, column)
+	}, quotedTable, quoteRedshiftIdentifier(column.Name), sinceAnd)
+}
+
 func (r *redshiftDiscoverer) GetSampleRows(ctx context.Context, schema, table string, limit int) (*SampleResult, error) {
 	query := fmt.Sprintf(
 		"SELECT * FROM %s.%s ORDER BY RANDOM() LIMIT %d",
@@ -307,7 +412,7 @@ func (r *redshiftDiscoverer) GetSampleRows(ctx context.Context, schema, table st
 	}
 	defer rows.Close()
 
-	return scanSampleRows(rows)
+	return scanSampleRows(rows, r.values)
 }
 
 func (r *redshiftDiscoverer) Close() error {
@@ -0,0 +1,246 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/redshiftserverless"
+	"github.com/lib/pq"
+)
+
+// redshiftServerlessHostSuffix ends the default endpoint of every Redshift
+// Serverless workgroup: <workgroup>.<account>.<region>.redshift-serverless.amazonaws.com.
+const redshiftServerlessHostSuffix = ".redshift-serverless.amazonaws.com"
+
+// redshiftServerlessTimeout bounds the AWS API calls made before connecting.
+const redshiftServerlessTimeout = 30 * time.Second
+
+// redshiftServerlessCredentialSeconds is how long the temporary credentials
+// from GetCredentials stay valid: the API's maximum of one hour, instead of
+// its 15 minute default.
+const redshiftServerlessCredentialSeconds = 3600
+
+// redshiftServerlessRefreshMargin is how close to expiring cached
+// credentials may be before a new connection fetches fresh ones.
+const redshiftServerlessRefreshMargin = 5 * time.Minute
+
+// ErrMissingWorkgroup is returned when a Redshift Serverless connection
+// needs the AWS API but names no workgroup, directly or through its host.
+var ErrMissingWorkgroup = errors.New("redshift serverless requires a workgroup or a workgroup endpoint host")
+
+// redshiftServerlessAPI is the part of the Redshift Serverless client dbh
+// uses. Tests replace newRedshiftServerlessAPI to avoid calling AWS.
+type redshiftServerlessAPI interface {
+	GetWorkgroup(ctx context.Context, params *redshiftserverless.GetWorkgroupInput, optFns ...func(*redshiftserverless.Options)) (*redshiftserverless.GetWorkgroupOutput, error)
+	GetCredentials(ctx context.Context, params *redshiftserverless.GetCredentialsInput, optFns ...func(*redshiftserverless.Options)) (*redshiftserverless.GetCredentialsOutput, error)
+}
+
+// newRedshiftServerlessAPI builds a client from the default AWS credential
+// chain (environment, shared config and profiles, SSO, instance roles).
+// An empty region falls back to AWS_REGION or the profile's region.
+var newRedshiftServerlessAPI = func(ctx context.Context, region string) (redshiftServerlessAPI, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+	return redshiftserverless.NewFromConfig(cfg), nil
+}
+
+// isRedshiftServerless reports whether cfg points at a Redshift Serverless
+// workgroup, either by the Serverless setting or by its endpoint host.
+func isRedshiftServerless(cfg DatabaseConfig) bool {
+	if cfg.Serverless {
+		return true
+	}
+	_, _, ok := parseRedshiftServerlessHost(cfg.Host)
+	return ok
+}
+
+// parseRedshiftServerlessHost reads the workgroup and region from a
+// workgroup endpoint host.
+func parseRedshiftServerlessHost(host string) (workgroup, region string, ok bool) {
+	host = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+	prefix, found := strings.CutSuffix(host, redshiftServerlessHostSuffix)
+	if !found {
+		return "", "", false
+	}
+	parts := strings.Split(prefix, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[2], true
+}
+
+// resolveRedshiftServerlessEndpoint fills in the workgroup and region from
+// the host and, when no host is configured, looks the endpoint address and
+// port up with GetWorkgroup. Provisioned clusters are returned unchanged.
+func resolveRedshiftServerlessEndpoint(ctx context.Context, cfg DatabaseConfig) (DatabaseConfig, error) {
+	if !isRedshiftServerless(cfg) {
+		if cfg.IAMAuth {
+			return DatabaseConfig{}, fmt.Errorf("redshift iam_auth is only supported for serverless workgroups")
+		}
+		return cfg, nil
+	}
+	cfg.Serverless = true
+	cfg.Host = strings.TrimSpace(cfg.Host)
+	if workgroup, region, ok := parseRedshiftServerlessHost(cfg.Host); ok {
+		if strings.TrimSpace(cfg.Workgroup) == "" {
+			cfg.Workgroup = workgroup
+		}
+		if strings.TrimSpace(cfg.Region) == "" {
+			cfg.Region = region
+		}
+	}
+	if cfg.Host != "" {
+		return cfg, nil
+	}
+	if strings.TrimSpace(cfg.Workgroup) == "" {
+		return DatabaseConfig{}, ErrMissingWorkgroup
+	}
+
+	api, err := newRedshiftServerlessAPI(ctx, cfg.Region)
+	if err != nil {
+		return DatabaseConfig{}, err
+	}
+	out, err := api.GetWorkgroup(ctx, &redshiftserverless.GetWorkgroupInput{WorkgroupName: aws.String(cfg.Workgroup)})
+	if err != nil {
+		return DatabaseConfig{}, fmt.Errorf("look up redshift serverless workgroup %q: %w", cfg.Workgroup, err)
+	}
+	if out.Workgroup == nil || out.Workgroup.Endpoint == nil || aws.ToString(out.Workgroup.Endpoint.Address) == "" {
+		return DatabaseConfig{}, fmt.Errorf("redshift serverless workgroup %q has no endpoint yet", cfg.Workgroup)
+	}
+	cfg.Host = aws.ToString(out.Workgroup.Endpoint.Address)
+	if cfg.Port <= 0 {
+		cfg.Port = int(aws.ToInt32(out.Workgroup.Endpoint.Port))
+	}
+	return cfg, nil
+}
+
+// redshiftServerlessCredentials replaces User and Password with temporary
+// credentials for database from GetCredentials when IAMAuth is set, and
+// returns when they expire. cfg must have been through
+// resolveRedshiftServerlessEndpoint.
+func redshiftServerlessCredentials(ctx context.Context, cfg DatabaseConfig, database string) (DatabaseConfig, time.Time, error) {
+	if !cfg.Serverless || !cfg.IAMAuth {
+		return cfg, time.Time{}, nil
+	}
+	if strings.TrimSpace(cfg.Workgroup) == "" {
+		return DatabaseConfig{}, time.Time{}, ErrMissingWorkgroup
+	}
+
+	api, err := newRedshiftServerlessAPI(ctx, cfg.Region)
+	if err != nil {
+		return DatabaseConfig{}, time.Time{}, err
+	}
+	input := &redshiftserverless.GetCredentialsInput{
+		WorkgroupName:   aws.String(cfg.Workgroup),
+		DurationSeconds: aws.Int32(redshiftServerlessCredentialSeconds),
+	}
+	if database = strings.TrimSpace(database); database != "" {
+		input.DbName = aws.String(database)
+	}
+	requestedAt := time.Now()
+	out, err := api.GetCredentials(ctx, input)
+	if err != nil {
+		return DatabaseConfig{}, time.Time{}, fmt.Errorf("get redshift serverless credentials for workgroup %q: %w", cfg.Workgroup, err)
+	}
+	cfg.User = aws.ToString(out.DbUser)
+	cfg.Password = aws.ToString(out.DbPassword)
+	expires := requestedAt.Add(redshiftServerlessCredentialSeconds * time.Second)
+	if out.Expiration != nil {
+		expires = *out.Expiration
+	}
+	return cfg, expires, nil
+}
+
+// openRedshift opens the pool for database on cfg, which must have been
+// through resolveRedshiftServerlessEndpoint. A serverless workgroup with
+// IAMAuth gets a redshiftIAMConnector, so connections opened after the
+// first credentials expire still log in; its first credentials are
+// fetched here, so a denied GetCredentials fails right away.
+func openRedshift(ctx context.Context, cfg DatabaseConfig, database string) (*sql.DB, error) {
+	if !cfg.Serverless || !cfg.IAMAuth {
+		return openDB("postgres", buildRedshiftConnString(cfg, database))
+	}
+	connector := &redshiftIAMConnector{cfg: cfg, database: database}
+	if _, err := connector.connString(ctx); err != nil {
+		return nil, err
+	}
+	return openConnector("postgres", connector), nil
+}
+
+// redshiftIAMConnector implements driver.Connector for a Redshift
+// Serverless workgroup with IAMAuth. Each connection logs in with the
+// cached temporary credentials, fetching new ones from GetCredentials once
+// they are within redshiftServerlessRefreshMargin of expiring.
+type redshiftIAMConnector struct {
+	cfg      DatabaseConfig
+	database string
+
+	mu      sync.Mutex
+	dsn     string
+	expires time.Time
+}
+
+func (c *redshiftIAMConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	dsn, err := c.connString(ctx)
+	if err != nil {
+		return nil, err
+	}
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+func (c *redshiftIAMConnector) Driver() driver.Driver { return &pq.Driver{} }
+
+// connString returns the connection string with current credentials.
+func (c *redshiftIAMConnector) connString(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dsn != "" && time.Until(c.expires) > redshiftServerlessRefreshMargin {
+		return c.dsn, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, redshiftServerlessTimeout)
+	defer cancel()
+	cfg, expires, err := redshiftServerlessCredentials(ctx, c.cfg, c.database)
+	if err != nil {
+		return "", err
+	}
+	c.dsn = buildRedshiftConnString(cfg, c.database)
+	c.expires = expires
+	return c.dsn, nil
+}
+
+// RedshiftConnString returns the connection string dbh uses for a Redshift
+// connection, after resolving a serverless workgroup's endpoint and IAM
+// credentials.
+func RedshiftConnString(cfg DatabaseConfig) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redshiftServerlessTimeout)
+	defer cancel()
+
+	cfg, err := resolveRedshiftServerlessEndpoint(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	database := redshiftDatabaseOrDefault(cfg.Database)
+	if cfg, _, err = redshiftServerlessCredentials(ctx, cfg, database); err != nil {
+		return "", err
+	}
+	return buildRedshiftConnString(cfg, database), nil
+}
@@ -0,0 +1,186 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/redshiftserverless"
+	"github.com/aws/aws-sdk-go-v2/service/redshiftserverless/types"
+)
+
+type fakeRedshiftServerlessAPI struct {
+	region         string
+	workgroupCalls int
+	credentialDBs  []string
+	durations      []int32
+	expiration     *time.Time
+}
+
+func (f *fakeRedshiftServerlessAPI) GetWorkgroup(ctx context.Context, params *redshiftserverless.GetWorkgroupInput, optFns ...func(*redshiftserverless.Options)) (*redshiftserverless.GetWorkgroupOutput, error) {
+	f.workgroupCalls++
+	if aws.ToString(params.WorkgroupName) != "analytics" {
+		return nil, errors.New("workgroup not found")
+	}
+	return &redshiftserverless.GetWorkgroupOutput{Workgroup: &types.Workgroup{
+		Endpoint: &types.Endpoint{
+			Address: aws.String("analytics.123456789012.us-west-2.redshift-serverless.amazonaws.com"),
+			Port:    aws.Int32(5440),
+		},
+	}}, nil
+}
+
+func (f *fakeRedshiftServerlessAPI) GetCredentials(ctx context.Context, params *redshiftserverless.GetCredentialsInput, optFns ...func(*redshiftserverless.Options)) (*redshiftserverless.GetCredentialsOutput, error) {
+	f.credentialDBs = append(f.credentialDBs, aws.ToString(params.DbName))
+	f.durations = append(f.durations, aws.ToInt32(params.DurationSeconds))
+	return &redshiftserverless.GetCredentialsOutput{
+		DbUser:     aws.String("IAMR:analyst"),
+		DbPassword: aws.String("temp pass'word"),
+		Expiration: f.expiration,
+	}, nil
+}
+
+func useFakeRedshiftServerlessAPI(t *testing.T) *fakeRedshiftServerlessAPI {
+	t.Helper()
+	fake := &fakeRedshiftServerlessAPI{}
+	original := newRedshiftServerlessAPI
+	newRedshiftServerlessAPI = func(ctx context.Context, region string) (redshiftServerlessAPI, error) {
+		fake.region = region
+		return fake, nil
+	}
+	t.Cleanup(func() { newRedshiftServerlessAPI = original })
+	return fake
+}
+
+func TestParseRedshiftServerlessHost(t *testing.T) {
+	workgroup, region, ok := parseRedshiftServerlessHost("Analytics.123456789012.us-east-1.redshift-serverless.amazonaws.com")
+	if !ok || workgroup != "analytics" || region != "us-east-1" {
+		t.Fatalf("parseRedshiftServerlessHost() = %q, %q, %v; want analytics, us-east-1, true", workgroup, region, ok)
+	}
+	for _, host := range []string{
+		"cluster.abc123.us-east-1.redshift.amazonaws.com",
+		"123456789012.us-east-1.redshift-serverless.amazonaws.com",
+		"",
+	} {
+		if _, _, ok := parseRedshiftServerlessHost(host); ok {
+			t.Errorf("parseRedshiftServerlessHost(%q) ok = true, want false", host)
+		}
+	}
+}
+
+func TestResolveRedshiftServerlessEndpointLeavesClustersAlone(t *testing.T) {
+	fake := useFakeRedshiftServerlessAPI(t)
+	cfg := DatabaseConfig{Type: "redshift", Host: "cluster.abc123.us-east-1.redshift.amazonaws.com"}
+
+	got, err := resolveRedshiftServerlessEndpoint(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("resolveRedshiftServerlessEndpoint() error = %v", err)
+	}
+	if got.Serverless || got.Host != cfg.Host || fake.workgroupCalls != 0 {
+		t.Fatalf("cluster config changed to %+v (GetWorkgroup calls %d)", got, fake.workgroupCalls)
+	}
+
+	cfg.IAMAuth = true
+	if _, err := resolveRedshiftServerlessEndpoint(context.Background(), cfg); err == nil {
+		t.Fatal("resolveRedshiftServerlessEndpoint() with iam_auth on a cluster error = nil, want error")
+	}
+}
+
+func TestResolveRedshiftServerlessEndpointReadsHost(t *testing.T) {
+	fake := useFakeRedshiftServerlessAPI(t)
+	cfg := DatabaseConfig{Type: "redshift", Host: "analytics.123456789012.us-west-2.redshift-serverless.amazonaws.com"}
+
+	got, err := resolveRedshiftServerlessEndpoint(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("resolveRedshiftServerlessEndpoint() error = %v", err)
+	}
+	if !got.Serverless || got.Workgroup != "analytics" || got.Region != "us-west-2" {
+		t.Fatalf("resolved config = %+v, want serverless workgroup analytics in us-west-2", got)
+	}
+	if fake.workgroupCalls != 0 {
+		t.Fatalf("GetWorkgroup calls = %d, want 0 when the host is configured", fake.workgroupCalls)
+	}
+}
+
+func TestResolveRedshiftServerlessEndpointLooksUpHost(t *testing.T) {
+	fake := useFakeRedshiftServerlessAPI(t)
+	cfg := DatabaseConfig{Type: "redshift", Serverless: true, Workgroup: "analytics", Region: "us-west-2"}
+
+	got, err := resolveRedshiftServerlessEndpoint(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("resolveRedshiftServerlessEndpoint() error = %v", err)
+	}
+	if got.Host != "analytics.123456789012.us-west-2.redshift-serverless.amazonaws.com" || got.Port != 5440 {
+		t.Fatalf("resolved endpoint = %s:%d, want the workgroup endpoint on 5440", got.Host, got.Port)
+	}
+	if fake.region != "us-west-2" {
+		t.Fatalf("client region = %q, want us-west-2", fake.region)
+	}
+
+	if _, err := resolveRedshiftServerlessEndpoint(context.Background(), DatabaseConfig{Serverless: true}); !errors.Is(err, ErrMissingWorkgroup) {
+		t.Fatalf("resolveRedshiftServerlessEndpoint() without workgroup error = %v, want ErrMissingWorkgroup", err)
+	}
+}
+
+func TestRedshiftConnStringUsesServerlessIAMCredentials(t *testing.T) {
+	fake := useFakeRedshiftServerlessAPI(t)
+	cfg := DatabaseConfig{
+		Type:     "redshift",
+		Host:     "analytics.123456789012.us-west-2.redshift-serverless.amazonaws.com",
+		IAMAuth:  true,
+		User:     "ignored",
+		Password: "ignored",
+	}
+
+	got, err := RedshiftConnString(cfg)
+	if err != nil {
+		t.Fatalf("RedshiftConnString() error = %v", err)
+	}
+	want := `host=analytics.123456789012.us-west-2.redshift-serverless.amazonaws.com port=5439 user=IAMR:analyst password='temp pass\'word' dbname=dev sslmode=require`
+	if got != want {
+		t.Fatalf("RedshiftConnString() = %q, want %q", got, want)
+	}
+	if strings.Join(fake.credentialDBs, ",") != "dev" {
+		t.Fatalf("GetCredentials databases = %v, want [dev]", fake.credentialDBs)
+	}
+}
+
+func TestRedshiftIAMConnectorRefreshesExpiringCredentials(t *testing.T) {
+	fake := useFakeRedshiftServerlessAPI(t)
+	cfg := DatabaseConfig{
+		Type:       "redshift",
+		Host:       "analytics.123456789012.us-west-2.redshift-serverless.amazonaws.com",
+		Serverless: true,
+		Workgroup:  "analytics",
+		IAMAuth:    true,
+	}
+	connector := &redshiftIAMConnector{cfg: cfg, database: "dev"}
+
+	expiration := time.Now().Add(time.Hour)
+	fake.expiration = &expiration
+	for i := 0; i < 2; i++ {
+		if _, err := connector.connString(context.Background()); err != nil {
+			t.Fatalf("connString() error = %v", err)
+		}
+	}
+	if len(fake.credentialDBs) != 1 {
+		t.Fatalf("GetCredentials calls = %d, want 1 while the credentials are fresh", len(fake.credentialDBs))
+	}
+
+	expiring := time.Now().Add(time.Minute)
+	connector.expires = expiring
+	if _, err := connector.connString(context.Background()); err != nil {
+		t.Fatalf("connString() error = %v", err)
+	}
+	if len(fake.credentialDBs) != 2 {
+		t.Fatalf("GetCredentials calls = %d, want 2 once the credentials are about to expire", len(fake.credentialDBs))
+	}
+	for _, duration := range fake.durations {
+		if duration != 3600 {
+			t.Fatalf("GetCredentials durations = %v, want 3600", fake.durations)
+		}
+	}
+}
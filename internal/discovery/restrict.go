@@ -0,0 +1,131 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// restrictedDiscoverer rejects per-table calls for names outside a
+// previously discovered set, so user-supplied schema and table names never
+// reach query construction unless the database reported them.
+type restrictedDiscoverer struct {
+	TableDetailDiscoverer
+	tables map[string]map[string]bool
+}
+
+// RestrictToDiscovered wraps d so that GetColumns, GetColumnEnrichment, and
+// GetSampleRows return an error wrapping ErrUnknownTable for any schema/table
+// pair not present in schemas. Names must match exactly. Optional interfaces
+// such as RowEstimator are still reachable via the wrapped discoverer's
+// methods when d implements them.
+func RestrictToDiscovered(d TableDetailDiscoverer, schemas []SchemaInfo) TableDetailDiscoverer {
+	tables := make(map[string]map[string]bool, len(schemas))
+	for _, schema := range schemas {
+		names := make(map[string]bool, len(schema.Tables))
+		for _, table := range schema.Tables {
+			names[table.Name] = true
+		}
+		tables[schema.Name] = names
+	}
+
+	restricted := &restrictedDiscoverer{TableDetailDiscoverer: d, tables: tables}
+	if estimator, ok := d.(RowEstimator); ok {
+		return &restrictedEstimatingDiscoverer{restrictedDiscoverer: restricted, estimator: estimator}
+	}
+	return restricted
+}
+
+func (r *restrictedDiscoverer) checkTable(schema, table string) error {
+	if !r.tables[schema][table] {
+		return fmt.Errorf("%w: %q.%q", ErrUnknownTable, schema, table)
+	}
+	return nil
+}
+
+func (r *restrictedDiscoverer) GetColumns(ctx context.Context, schema, table string) ([]ColumnInfo, error) {
+	if err := r.checkTable(schema, table); err != nil {
+		return nil, err
+	}
+	return r.TableDetailDiscoverer.GetColumns(ctx, schema, table)
+}
+
+func (r *restrictedDiscoverer) GetColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (EnrichedColumnInfo, error) {
+	if err := r.checkTable(schema, table); err != nil {
+		return EnrichedColumnInfo{}, err
+	}
+	return r.TableDetailDiscoverer.GetColumnEnrichment(ctx, schema, table, column, opts)
+}
+
+func (r *restrictedDiscoverer) GetSampleRows(ctx context.Context, schema, table string, limit int) (*SampleResult, error) {
+	if err := r.checkTable(schema, table); err != nil {
+		return nil, err
+	}
+	return r.TableDetailDiscoverer.GetSampleRows(ctx, schema, table, limit)
+}
+
+// ExplainColumnEnrichment forwards to the wrapped discoverer when it is an
+// EnrichmentExplainer, and otherwise returns an error.
+func (r *restrictedDiscoverer) ExplainColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) ([]ExplainedQuery, error) {
+	if err := r.checkTable(schema, table); err != nil {
+		return nil, err
+	}
+	explainer, ok := r.TableDetailDiscoverer.(EnrichmentExplainer)
+	if !ok {
+		return nil, fmt.Errorf("explaining enrichment queries is not supported by this backend")
+	}
+	return explainer.ExplainColumnEnrichment(ctx, schema, table, column, opts)
+}
+
+// GetSpreadSampleRows forwards to the wrapped discoverer when it is a
+// SpreadSampler, and otherwise returns ErrSpreadUnavailable.
+func (r *restrictedDiscoverer) GetSpreadSampleRows(ctx context.Context, schema, table string, limit int) (*SampleResult, error) {
+	if err := r.checkTable(schema, table); err != nil {
+		return nil, err
+	}
+	sampler, ok := r.TableDetailDiscoverer.(SpreadSampler)
+	if !ok {
+		return nil, fmt.Errorf("%w: not supported by this backend", ErrSpreadUnavailable)
+	}
+	return sampler.GetSpreadSampleRows(ctx, schema, table, limit)
+}
+
+// HasRows forwards to the wrapped discoverer when it is a RowChecker, and
+// otherwise reports that the table has rows.
+func (r *restrictedDiscoverer) HasRows(ctx context.Context, schema, table string) (bool, error) {
+	if err := r.checkTable(schema, table); err != nil {
+		return false, err
+	}
+	checker, ok := r.TableDetailDiscoverer.(RowChecker)
+	if !ok {
+		return true, nil
+	}
+	return checker.HasRows(ctx, schema, table)
+}
+
+// TableLastModified forwards to the wrapped discoverer when it is a
+// LastModifiedReader, and otherwise returns ErrLastModifiedUnavailable.
+func (r *restrictedDiscoverer) TableLastModified(ctx context.Context, schema string) (map[string]time.Time, error) {
+	if _, ok := r.tables[schema]; !ok {
+		return nil, fmt.Errorf("%w: schema %q", ErrUnknownTable, schema)
+	}
+	reader, ok := r.TableDetailDiscoverer.(LastModifiedReader)
+	if !ok {
+		return nil, fmt.Errorf("%w: not supported by this backend", ErrLastModifiedUnavailable)
+	}
+	return reader.TableLastModified(ctx, schema)
+}
+
+// restrictedEstimatingDiscoverer keeps RowEstimator visible through the
+// wrapper, limited to discovered schemas.
+type restrictedEstimatingDiscoverer struct {
+	*restrictedDiscoverer
+	estimator RowEstimator
+}
+
+func (r *restrictedEstimatingDiscoverer) EstimateRowCounts(ctx context.Context, schema string) (map[string]int64, error) {
+	if _, ok := r.tables[schema]; !ok {
+		return nil, fmt.Errorf("%w: schema %q", ErrUnknownTable, schema)
+	}
+	return r.estimator.EstimateRowCounts(ctx, schema)
+}
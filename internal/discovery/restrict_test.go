@@ -0,0 +1,72 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRestrictToDiscovered_RejectsUnknownNames(t *testing.T) {
+	dbPath := createSQLiteTestDatabase(t)
+	discoverer, err := newSQLite(DatabaseConfig{Database: dbPath})
+	if err != nil {
+		t.Fatalf("newSQLite() error = %v", err)
+	}
+	defer discoverer.Close()
+
+	ctx := context.Background()
+	schemas, err := discoverer.Discover(ctx)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	restricted := RestrictToDiscovered(discoverer, schemas)
+
+	tests := []struct {
+		name   string
+		schema string
+		table  string
+	}{
+		{name: "quote and semicolon in table", schema: "main", table: `users"; DROP TABLE users; --`},
+		{name: "single quote in table", schema: "main", table: "o'brien; SELECT 1"},
+		{name: "semicolon in schema", schema: "main; DROP TABLE users", table: "users"},
+		{name: "case mismatch", schema: "main", table: "USERS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := restricted.GetSampleRows(ctx, tt.schema, tt.table, 2); !errors.Is(err, ErrUnknownTable) {
+				t.Fatalf("GetSampleRows() error = %v, want ErrUnknownTable", err)
+			}
+			if _, err := restricted.GetColumns(ctx, tt.schema, tt.table); !errors.Is(err, ErrUnknownTable) {
+				t.Fatalf("GetColumns() error = %v, want ErrUnknownTable", err)
+			}
+			column := ColumnInfo{Name: "name", DataType: "TEXT"}
+			if _, err := restricted.GetColumnEnrichment(ctx, tt.schema, tt.table, column, EnrichmentOptions{}); !errors.Is(err, ErrUnknownTable) {
+				t.Fatalf("GetColumnEnrichment() error = %v, want ErrUnknownTable", err)
+			}
+		})
+	}
+
+	sample, err := restricted.GetSampleRows(ctx, "main", "users", 2)
+	if err != nil {
+		t.Fatalf("GetSampleRows(main.users) error = %v", err)
+	}
+	if len(sample.Columns) != 3 {
+		t.Fatalf("sample column count = %d, want 3", len(sample.Columns))
+	}
+}
+
+func TestRestrictToDiscovered_KeepsRowEstimator(t *testing.T) {
+	estimating := RestrictToDiscovered(&postgresDiscoverer{}, []SchemaInfo{{Name: "public"}})
+	estimator, ok := estimating.(RowEstimator)
+	if !ok {
+		t.Fatalf("restricted postgres discoverer does not implement RowEstimator")
+	}
+	if _, err := estimator.EstimateRowCounts(context.Background(), `public"; --`); !errors.Is(err, ErrUnknownTable) {
+		t.Fatalf("EstimateRowCounts() error = %v, want ErrUnknownTable", err)
+	}
+
+	plain := RestrictToDiscovered(&sqliteDiscoverer{}, nil)
+	if _, ok := plain.(RowEstimator); ok {
+		t.Fatalf("restricted sqlite discoverer unexpectedly implements RowEstimator")
+	}
+}
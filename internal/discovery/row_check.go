@@ -0,0 +1,71 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	gcpbigquery "cloud.google.com/go/bigquery"
+)
+
+// RowChecker is implemented by discoverers that can tell whether a table
+// has any rows without reading more than one. Every built-in backend
+// implements it. dbh tables uses it to skip the sample query, which sorts
+// the whole table, for empty tables.
+type RowChecker interface {
+	// HasRows reports whether the table has at least one row.
+	HasRows(ctx context.Context, schema, table string) (bool, error)
+}
+
+// queryHasRows runs SELECT 1 ... LIMIT 1 against quotedTable.
+func queryHasRows(ctx context.Context, db *sql.DB, quotedTable string) (bool, error) {
+	var one int
+	err := db.QueryRowContext(ctx, "SELECT 1 FROM "+quotedTable+" LIMIT 1").Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("check rows of %s: %w", quotedTable, err)
+	}
+	return true, nil
+}
+
+func (p *postgresDiscoverer) HasRows(ctx context.Context, schema, table string) (bool, error) {
+	return queryHasRows(ctx, p.db, quotePostgresIdentifier(schema)+"."+quotePostgresIdentifier(table))
+}
+
+func (r *redshiftDiscoverer) HasRows(ctx context.Context, schema, table string) (bool, error) {
+	return queryHasRows(ctx, r.db, quoteRedshiftIdentifier(schema)+"."+quoteRedshiftIdentifier(table))
+}
+
+func (s *snowflakeDiscoverer) HasRows(ctx context.Context, schema, table string) (bool, error) {
+	return queryHasRows(ctx, s.db, quoteSnowflakeIdentifier(schema)+"."+quoteSnowflakeIdentifier(table))
+}
+
+func (m *mysqlDiscoverer) HasRows(ctx context.Context, schema, table string) (bool, error) {
+	return queryHasRows(ctx, m.db, quoteMySQLIdentifier(schema)+"."+quoteMySQLIdentifier(table))
+}
+
+func (d *databricksDiscoverer) HasRows(ctx context.Context, schema, table string) (bool, error) {
+	return queryHasRows(ctx, d.db, quoteDatabricksIdentifier(schema)+"."+quoteDatabricksIdentifier(table))
+}
+
+func (s *sqliteDiscoverer) HasRows(ctx context.Context, schema, table string) (bool, error) {
+	return queryHasRows(ctx, s.db, quoteSQLiteIdentifier(normalizeSQLiteSchemaName(schema))+"."+quoteSQLiteIdentifier(table))
+}
+
+// HasRows reads the row count from the table metadata instead of running a
+// query job. Rows still in the streaming buffer count as rows. Views and
+// other non-table types have no stored count and are reported as having
+// rows.
+func (b *bigQueryDiscoverer) HasRows(ctx context.Context, schema, table string) (bool, error) {
+	metadata, err := b.client.DatasetInProject(b.projectID, schema).Table(table).Metadata(ctx)
+	if err != nil {
+		return false, fmt.Errorf("read bigquery table metadata for %s.%s: %w", schema, table, err)
+	}
+	if metadata.Type != gcpbigquery.RegularTable {
+		return true, nil
+	}
+	return metadata.NumRows > 0 || metadata.StreamingBuffer != nil, nil
+}
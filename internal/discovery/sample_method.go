@@ -0,0 +1,140 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SampleMethod selects how Postgres GetSampleRows picks its random rows.
+type SampleMethod string
+
+const (
+	// SampleMethodAuto sorts small tables with ORDER BY RANDOM() and reads
+	// tables above postgresRandomSampleMaxRows estimated rows with
+	// TABLESAMPLE: SYSTEM_ROWS when the tsm_system_rows extension is
+	// installed, else BERNOULLI.
+	SampleMethodAuto SampleMethod = "auto"
+	// SampleMethodRandom always sorts the whole table with ORDER BY
+	// RANDOM(), the most even sample and the slowest on large tables.
+	SampleMethodRandom SampleMethod = "random"
+	// SampleMethodSystemRows reads whole blocks with TABLESAMPLE
+	// SYSTEM_ROWS(n) from the tsm_system_rows extension. It stops after n
+	// rows, so it is the cheapest, but rows from the same block come
+	// together.
+	SampleMethodSystemRows SampleMethod = "system_rows"
+	// SampleMethodBernoulli keeps each row with TABLESAMPLE BERNOULLI at a
+	// percentage sized from the row estimate and shuffles what it keeps.
+	// It scans the table once without sorting it.
+	SampleMethodBernoulli SampleMethod = "bernoulli"
+)
+
+// postgresRandomSampleMaxRows is the largest row estimate SampleMethodAuto
+// still samples with ORDER BY RANDOM().
+const postgresRandomSampleMaxRows = 100_000
+
+// bernoulliOversample is how many times the requested rows a BERNOULLI
+// sample aims to keep, so a slightly stale estimate still fills the limit.
+const bernoulliOversample = 4
+
+// ParseSampleMethod parses a sample_method value. An empty value is
+// SampleMethodAuto.
+func ParseSampleMethod(value string) (SampleMethod, error) {
+	switch method := SampleMethod(strings.ToLower(strings.TrimSpace(value))); method {
+	case "", SampleMethodAuto:
+		return SampleMethodAuto, nil
+	case SampleMethodRandom, SampleMethodSystemRows, SampleMethodBernoulli:
+		return method, nil
+	default:
+		return "", fmt.Errorf("unsupported sample method %q (use auto, random, system_rows, or bernoulli)", value)
+	}
+}
+
+// postgresSampleMethod resolves method for a table. estimate is the
+// table's row estimate, or -1 when there is none (a view, or a table never
+// analyzed); TABLESAMPLE needs a table, and BERNOULLI needs the estimate to
+// size its percentage, so both fall back to ORDER BY RANDOM() without one.
+// SYSTEM_ROWS falls back to BERNOULLI when tsm_system_rows is missing.
+func postgresSampleMethod(method SampleMethod, estimate int64, hasSystemRows bool) SampleMethod {
+	if method == SampleMethodRandom || estimate < 0 {
+		return SampleMethodRandom
+	}
+	if method == SampleMethodAuto {
+		if estimate <= postgresRandomSampleMaxRows {
+			return SampleMethodRandom
+		}
+		method = SampleMethodSystemRows
+	}
+	if method == SampleMethodSystemRows && !hasSystemRows {
+		return SampleMethodBernoulli
+	}
+	return method
+}
+
+// postgresSampleQuery builds the sample rows query of method, which must
+// already be resolved by postgresSampleMethod.
+func postgresSampleQuery(method SampleMethod, selectList, from string, limit int, estimate int64) string {
+	switch method {
+	case SampleMethodSystemRows:
+		return fmt.Sprintf("SELECT %s FROM %s TABLESAMPLE SYSTEM_ROWS(%d)", selectList, from, limit)
+	case SampleMethodBernoulli:
+		return fmt.Sprintf(
+			"SELECT %s FROM %s TABLESAMPLE BERNOULLI (%s) ORDER BY RANDOM() LIMIT %d",
+			selectList, from, bernoulliPercent(limit, estimate), limit,
+		)
+	default:
+		return fmt.Sprintf("SELECT %s FROM %s ORDER BY RANDOM() LIMIT %d", selectList, from, limit)
+	}
+}
+
+// bernoulliPercent returns the percentage of estimate rows that keeps about
+// bernoulliOversample times limit of them, at most 100.
+func bernoulliPercent(limit int, estimate int64) string {
+	if estimate <= 0 {
+		return "100"
+	}
+	percent := 100 * float64(limit*bernoulliOversample) / float64(estimate)
+	if percent >= 100 {
+		return "100"
+	}
+	return strconv.FormatFloat(percent, 'g', 6, 64)
+}
+
+// tableRowEstimate returns pg_class.reltuples of a table or materialized
+// view, or -1 when it is not one, or was never analyzed.
+func (p *postgresDiscoverer) tableRowEstimate(ctx context.Context, schema, table string) (int64, error) {
+	var estimate int64
+	err := p.db.QueryRowContext(ctx, `
+		SELECT c.reltuples::bigint
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1
+		  AND c.relname = $2
+		  AND c.relkind IN ('r', 'p', 'm')`,
+		schema, table,
+	).Scan(&estimate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return -1, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("query postgres row estimate: %w", err)
+	}
+	return estimate, nil
+}
+
+// hasSystemRows reports whether the tsm_system_rows extension is installed
+// in the database, checking once per discoverer.
+func (p *postgresDiscoverer) hasSystemRows(ctx context.Context) bool {
+	p.systemRowsOnce.Do(func() {
+		err := p.db.QueryRowContext(ctx,
+			"SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'tsm_system_rows')",
+		).Scan(&p.systemRows)
+		if err != nil {
+			p.systemRows = false
+		}
+	})
+	return p.systemRows
+}
@@ -0,0 +1,61 @@
+package discovery
+
+import "testing"
+
+func TestParseSampleMethod(t *testing.T) {
+	for value, want := range map[string]SampleMethod{
+		"":            SampleMethodAuto,
+		"auto":        SampleMethodAuto,
+		" Bernoulli ": SampleMethodBernoulli,
+		"system_rows": SampleMethodSystemRows,
+		"random":      SampleMethodRandom,
+	} {
+		got, err := ParseSampleMethod(value)
+		if err != nil || got != want {
+			t.Errorf("ParseSampleMethod(%q) = %q, %v, want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseSampleMethod("system"); err == nil {
+		t.Error("ParseSampleMethod(system) succeeded, want an error")
+	}
+}
+
+func TestPostgresSampleMethod(t *testing.T) {
+	for _, tc := range []struct {
+		method        SampleMethod
+		estimate      int64
+		hasSystemRows bool
+		want          SampleMethod
+	}{
+		{SampleMethodAuto, 5_000, true, SampleMethodRandom},
+		{SampleMethodAuto, 5_000_000, true, SampleMethodSystemRows},
+		{SampleMethodAuto, 5_000_000, false, SampleMethodBernoulli},
+		{SampleMethodAuto, -1, true, SampleMethodRandom},
+		{SampleMethodSystemRows, 10, true, SampleMethodSystemRows},
+		{SampleMethodSystemRows, 10, false, SampleMethodBernoulli},
+		{SampleMethodBernoulli, -1, true, SampleMethodRandom},
+		{SampleMethodRandom, 5_000_000, true, SampleMethodRandom},
+	} {
+		if got := postgresSampleMethod(tc.method, tc.estimate, tc.hasSystemRows); got != tc.want {
+			t.Errorf("postgresSampleMethod(%s, %d, %v) = %s, want %s", tc.method, tc.estimate, tc.hasSystemRows, got, tc.want)
+		}
+	}
+}
+
+func TestPostgresSampleQuery(t *testing.T) {
+	from := `"public"."events"`
+	for _, tc := range []struct {
+		method   SampleMethod
+		estimate int64
+		want     string
+	}{
+		{SampleMethodRandom, -1, `SELECT * FROM "public"."events" ORDER BY RANDOM() LIMIT 10`},
+		{SampleMethodSystemRows, 1_000_000, `SELECT * FROM "public"."events" TABLESAMPLE SYSTEM_ROWS(10)`},
+		{SampleMethodBernoulli, 1_000_000, `SELECT * FROM "public"."events" TABLESAMPLE BERNOULLI (0.004) ORDER BY RANDOM() LIMIT 10`},
+		{SampleMethodBernoulli, 20, `SELECT * FROM "public"."events" TABLESAMPLE BERNOULLI (100) ORDER BY RANDOM() LIMIT 10`},
+	} {
+		if got := postgresSampleQuery(tc.method, "*", from, 10, tc.estimate); got != tc.want {
+			t.Errorf("postgresSampleQuery(%s, %d) =\n%s\nwant\n%s", tc.method, tc.estimate, got, tc.want)
+		}
+	}
+}
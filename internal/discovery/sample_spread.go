@@ -0,0 +1,232 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strings"
+)
+
+// ErrSpreadUnavailable is returned by GetSpreadSampleRows when the table
+// cannot be sampled across its key range, for example because it has no
+// single-column integer primary key or is empty. Callers should fall back
+// to GetSampleRows.
+var ErrSpreadUnavailable = errors.New("spread sampling unavailable")
+
+// SpreadSampler is implemented by discoverers that can sample rows spread
+// across a table's primary key range instead of at random, which avoids
+// returning near-duplicate rows from skewed tables.
+type SpreadSampler interface {
+	// GetSpreadSampleRows splits the range of the table's integer primary
+	// key into limit equal parts and returns the first row of each part, in
+	// key order. Parts without rows are skipped, so fewer than limit rows
+	// may be returned.
+	GetSpreadSampleRows(ctx context.Context, schema, table string, limit int) (*SampleResult, error)
+}
+
+// spreadKeyRanges splits [minKey, maxKey] into at most n contiguous,
+// non-empty, inclusive ranges of near-equal width.
+func spreadKeyRanges(minKey, maxKey int64, n int) [][2]int64 {
+	if n <= 0 || maxKey < minKey {
+		return nil
+	}
+	// The width can exceed int64, so bounds are computed as unsigned
+	// offsets from minKey.
+	width := uint64(maxKey) - uint64(minKey) + 1
+	if width == 0 {
+		// The full int64 range overflows; one key short does not matter.
+		width = math.MaxUint64
+	}
+	start := func(i int) uint64 {
+		hi, lo := bits.Mul64(width, uint64(i))
+		q, _ := bits.Div64(hi, lo, uint64(n))
+		return q
+	}
+
+	ranges := make([][2]int64, 0, n)
+	for i := 0; i < n; i++ {
+		from := start(i)
+		var to uint64
+		if i == n-1 {
+			to = uint64(maxKey) - uint64(minKey)
+		} else {
+			next := start(i + 1)
+			if next == from {
+				continue
+			}
+			to = next - 1
+		}
+		ranges = append(ranges, [2]int64{int64(uint64(minKey) + from), int64(uint64(minKey) + to)})
+	}
+	return ranges
+}
+
+// spreadSampleRows reads the first row of each spreadKeyRanges part of the
+// integer column key in from, selecting selectList ("*" for every column).
+// from and key must already be quoted.
+func spreadSampleRows(ctx context.Context, db *sql.DB, selectList, from, key string, limit int, values valueFormatter) (*SampleResult, error) {
+	var minKey, maxKey sql.NullInt64
+	query := fmt.Sprintf("SELECT MIN(%s), MAX(%s) FROM %s", key, key, from)
+	if err := db.QueryRowContext(ctx, query).Scan(&minKey, &maxKey); err != nil {
+		return nil, fmt.Errorf("read key range: %w", err)
+	}
+	if !minKey.Valid || !maxKey.Valid {
+		return nil, fmt.Errorf("%w: table is empty", ErrSpreadUnavailable)
+	}
+
+	result := &SampleResult{}
+	for _, keyRange := range spreadKeyRanges(minKey.Int64, maxKey.Int64, limit) {
+		query := fmt.Sprintf(
+			"SELECT %s FROM %s WHERE %s BETWEEN %d AND %d ORDER BY %s LIMIT 1",
+			selectList,
+			from,
+			key,
+			keyRange[0],
+			keyRange[1],
+			key,
+		)
+		part, err := querySampleRows(ctx, db, query, values)
+		if err != nil {
+			return nil, err
+		}
+		result.Columns = part.Columns
+		result.Rows = append(result.Rows, part.Rows...)
+	}
+	return result, nil
+}
+
+func querySampleRows(ctx context.Context, db *sql.DB, query string, values valueFormatter) (*SampleResult, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query spread sample rows: %w", err)
+	}
+	defer rows.Close()
+	return scanSampleRows(rows, values)
+}
+
+// singleIntegerKey returns the only primary key column when there is
+// exactly one and isInteger accepts its type.
+func singleIntegerKey(columns, types []string, isInteger func(string) bool) (string, error) {
+	if len(columns) != 1 {
+		return "", fmt.Errorf("%w: no single-column primary key", ErrSpreadUnavailable)
+	}
+	if !isInteger(types[0]) {
+		return "", fmt.Errorf("%w: primary key %s is %s, not an integer", ErrSpreadUnavailable, columns[0], types[0])
+	}
+	return columns[0], nil
+}
+
+func scanPrimaryKeyColumns(rows *sql.Rows) (columns, types []string, err error) {
+	defer rows.Close()
+	for rows.Next() {
+		var column, dataType string
+		if err := rows.Scan(&column, &dataType); err != nil {
+			return nil, nil, fmt.Errorf("scan primary key column: %w", err)
+		}
+		columns = append(columns, column)
+		types = append(types, dataType)
+	}
+	return columns, types, rows.Err()
+}
+
+func (p *postgresDiscoverer) GetSpreadSampleRows(ctx context.Context, schema, table string, limit int) (*SampleResult, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT a.attname, t.typname
+		FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum = ANY(i.indkey)
+		JOIN pg_type t ON t.oid = a.atttypid
+		WHERE i.indisprimary AND n.nspname = $1 AND c.relname = $2`,
+		schema, table,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query postgres primary key: %w", err)
+	}
+	columns, types, err := scanPrimaryKeyColumns(rows)
+	if err != nil {
+		return nil, err
+	}
+	key, err := singleIntegerKey(columns, types, func(t string) bool {
+		return t == "int2" || t == "int4" || t == "int8"
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	selectList, err := p.sampleSelectList(ctx, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	from := quotePostgresIdentifier(schema) + "." + quotePostgresIdentifier(table)
+	return spreadSampleRows(ctx, p.db, selectList, from, quotePostgresIdentifier(key), limit, p.values)
+}
+
+func (m *mysqlDiscoverer) GetSpreadSampleRows(ctx context.Context, schema, table string, limit int) (*SampleResult, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT k.COLUMN_NAME, c.COLUMN_TYPE
+		FROM information_schema.KEY_COLUMN_USAGE k
+		JOIN information_schema.COLUMNS c
+			ON c.TABLE_SCHEMA = k.TABLE_SCHEMA AND c.TABLE_NAME = k.TABLE_NAME AND c.COLUMN_NAME = k.COLUMN_NAME
+		WHERE k.CONSTRAINT_NAME = 'PRIMARY' AND k.TABLE_SCHEMA = ? AND k.TABLE_NAME = ?`,
+		schema, table,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query mysql primary key: %w", err)
+	}
+	columns, types, err := scanPrimaryKeyColumns(rows)
+	if err != nil {
+		return nil, err
+	}
+	key, err := singleIntegerKey(columns, types, mysqlSpreadKeyType)
+	if err != nil {
+		return nil, err
+	}
+
+	from := quoteMySQLIdentifier(schema) + "." + quoteMySQLIdentifier(table)
+	return spreadSampleRows(ctx, m.db, "*", from, quoteMySQLIdentifier(key), limit, m.values)
+}
+
+// mysqlSpreadKeyType reports whether a COLUMN_TYPE such as "int unsigned"
+// holds integers that fit in int64. BIGINT UNSIGNED may not.
+func mysqlSpreadKeyType(columnType string) bool {
+	t := strings.ToLower(strings.TrimSpace(columnType))
+	if strings.HasPrefix(t, "bigint") {
+		return !strings.Contains(t, "unsigned")
+	}
+	for _, prefix := range []string{"tinyint", "smallint", "mediumint", "int"} {
+		if strings.HasPrefix(t, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *sqliteDiscoverer) GetSpreadSampleRows(ctx context.Context, schema, table string, limit int) (*SampleResult, error) {
+	schemaName := normalizeSQLiteSchemaName(schema)
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT name, type FROM pragma_table_info(%s, %s) WHERE pk > 0",
+		quoteSQLiteStringLiteral(table),
+		quoteSQLiteStringLiteral(schemaName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("query sqlite primary key: %w", err)
+	}
+	columns, types, err := scanPrimaryKeyColumns(rows)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite gives a column integer affinity when its type contains INT.
+	key, err := singleIntegerKey(columns, types, func(t string) bool {
+		return strings.Contains(strings.ToUpper(t), "INT")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	from := quoteSQLiteIdentifier(schemaName) + "." + quoteSQLiteIdentifier(table)
+	return spreadSampleRows(ctx, s.db, "*", from, quoteSQLiteIdentifier(key), limit, s.values)
+}
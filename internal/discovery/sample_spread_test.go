@@ -0,0 +1,86 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSpreadKeyRanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max int64
+		n        int
+		want     [][2]int64
+	}{
+		{name: "even split", min: 1, max: 100, n: 4, want: [][2]int64{{1, 25}, {26, 50}, {51, 75}, {76, 100}}},
+		{name: "fewer keys than ranges", min: 1, max: 3, n: 10, want: [][2]int64{{1, 1}, {2, 2}, {3, 3}}},
+		{name: "single key", min: 7, max: 7, n: 3, want: [][2]int64{{7, 7}}},
+		{name: "negative keys", min: -10, max: 9, n: 2, want: [][2]int64{{-10, -1}, {0, 9}}},
+		{name: "empty", min: 5, max: 4, n: 3, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := spreadKeyRanges(tt.min, tt.max, tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("spreadKeyRanges(%d, %d, %d) = %v, want %v", tt.min, tt.max, tt.n, got, tt.want)
+			}
+		})
+	}
+
+	full := spreadKeyRanges(math.MinInt64, math.MaxInt64, 2)
+	if len(full) != 2 || full[0][0] != math.MinInt64 || full[1][1] != math.MaxInt64 || full[0][1]+1 != full[1][0] {
+		t.Fatalf("spreadKeyRanges(full int64 range) = %v", full)
+	}
+}
+
+func TestMySQLSpreadKeyType(t *testing.T) {
+	for columnType, want := range map[string]bool{
+		"int":                 true,
+		"int(11) unsigned":    true,
+		"bigint":              true,
+		"bigint(20) unsigned": false,
+		"varchar(36)":         false,
+		"point":               false,
+	} {
+		if got := mysqlSpreadKeyType(columnType); got != want {
+			t.Fatalf("mysqlSpreadKeyType(%q) = %v, want %v", columnType, got, want)
+		}
+	}
+}
+
+func TestSQLiteDiscoverer_GetSpreadSampleRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spread.db")
+	db := openSQLiteForTest(t, path)
+	execSQLite(t, db, `CREATE TABLE events (id INTEGER PRIMARY KEY, kind TEXT)`)
+	execSQLite(t, db, `CREATE TABLE tags (name TEXT PRIMARY KEY)`)
+	// Skewed keys: most rows are bunched at the start of the range.
+	execSQLite(t, db, `
+		INSERT INTO events (id, kind) VALUES
+			(1, 'a'), (2, 'a'), (3, 'a'), (4, 'a'), (5, 'a'),
+			(50, 'b'), (100, 'c');
+	`)
+	db.Close()
+
+	discoverer, err := newSQLite(DatabaseConfig{Database: path})
+	if err != nil {
+		t.Fatalf("newSQLite() error = %v", err)
+	}
+	defer discoverer.Close()
+
+	sample, err := discoverer.GetSpreadSampleRows(context.Background(), "main", "events", 3)
+	if err != nil {
+		t.Fatalf("GetSpreadSampleRows() error = %v", err)
+	}
+	want := [][]string{{"1", "a"}, {"50", "b"}, {"100", "c"}}
+	if !reflect.DeepEqual(sample.Columns, []string{"id", "kind"}) || !reflect.DeepEqual(sample.Rows, want) {
+		t.Fatalf("GetSpreadSampleRows() = %v %v, want [id kind] %v", sample.Columns, sample.Rows, want)
+	}
+
+	_, err = discoverer.GetSpreadSampleRows(context.Background(), "main", "tags", 3)
+	if !errors.Is(err, ErrSpreadUnavailable) {
+		t.Fatalf("GetSpreadSampleRows(text key) error = %v, want ErrSpreadUnavailable", err)
+	}
+}
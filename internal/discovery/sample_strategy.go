@@ -0,0 +1,59 @@
+package discovery
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SampleStrategy selects which distinct values GetColumnEnrichment keeps as
+// a column's sample values.
+type SampleStrategy string
+
+const (
+	// SampleStrategyRandom keeps the first distinct values the database
+	// returns, which is cheap but may all come from one corner of the table.
+	SampleStrategyRandom SampleStrategy = "random"
+	// SampleStrategyFrequent keeps the most frequent distinct values, most
+	// frequent first, so the samples show the column's common data. It
+	// counts every distinct value, which costs a grouped scan per column.
+	SampleStrategyFrequent SampleStrategy = "frequent"
+)
+
+// ParseSampleStrategy parses a --sample-strategy value. An empty value is
+// SampleStrategyRandom.
+func ParseSampleStrategy(value string) (SampleStrategy, error) {
+	switch strategy := SampleStrategy(strings.ToLower(strings.TrimSpace(value))); strategy {
+	case "", SampleStrategyRandom:
+		return SampleStrategyRandom, nil
+	case SampleStrategyFrequent:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unsupported sample strategy %q (use frequent or random)", value)
+	}
+}
+
+// sampleValuesQuery builds the query returning a column's sample values.
+// value is the truncated text expression of column to return, and from may
+// include joins such as an UNNEST. The frequent strategy groups the
+// truncated values and orders them by count, ties by value, so the result
+// is stable across runs.
+func (o EnrichmentOptions) sampleValuesQuery(value, from, column, sinceAnd string) string {
+	if o.SampleStrategy == SampleStrategyFrequent {
+		return fmt.Sprintf(
+			"SELECT %[1]s\nFROM %[2]s\nWHERE %[3]s IS NOT NULL%[4]s\nGROUP BY 1\nORDER BY COUNT(*) DESC, 1\nLIMIT %[5]d",
+			value,
+			from,
+			column,
+			sinceAnd,
+			o.SampleValueLimit,
+		)
+	}
+	return fmt.Sprintf(
+		"SELECT DISTINCT %[1]s\nFROM %[2]s\nWHERE %[3]s IS NOT NULL%[4]s\nLIMIT %[5]d",
+		value,
+		from,
+		column,
+		sinceAnd,
+		o.SampleValueLimit,
+	)
+}
@@ -0,0 +1,28 @@
+package discovery
+
+import "testing"
+
+func TestParseSampleStrategy(t *testing.T) {
+	for input, want := range map[string]SampleStrategy{
+		"":          SampleStrategyRandom,
+		"random":    SampleStrategyRandom,
+		" Frequent": SampleStrategyFrequent,
+	} {
+		got, err := ParseSampleStrategy(input)
+		if err != nil || got != want {
+			t.Errorf("ParseSampleStrategy(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseSampleStrategy("weighted"); err == nil {
+		t.Error("ParseSampleStrategy(weighted) error = nil, want error")
+	}
+}
+
+func TestSampleValuesQueryFrequent(t *testing.T) {
+	opts := EnrichmentOptions{SampleValueLimit: 5, SampleStrategy: SampleStrategyFrequent}
+	got := opts.sampleValuesQuery(`LEFT("status"::text, 180)`, `"public"."orders"`, `"status"`, "")
+	want := "SELECT LEFT(\"status\"::text, 180)\nFROM \"public\".\"orders\"\nWHERE \"status\" IS NOT NULL\nGROUP BY 1\nORDER BY COUNT(*) DESC, 1\nLIMIT 5"
+	if got != want {
+		t.Fatalf("sampleValuesQuery() =\n%s\nwant\n%s", got, want)
+	}
+}
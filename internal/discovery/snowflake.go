@@ -4,14 +4,28 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/snowflakedb/gosnowflake"
 )
 
+// snowflakeWarehousePrepareTimeout bounds the warehouse resize/resume
+// statements, including an externalbrowser login on the first connection.
+const snowflakeWarehousePrepareTimeout = 2 * time.Minute
+
 type snowflakeDiscoverer struct {
 	db       *sql.DB
 	database string
+	schemas  []string
+	values   valueFormatter
+
+	// showCommands replaces the INFORMATION_SCHEMA queries with SHOW
+	// commands (see snowflake_show.go).
+	showCommands bool
+
+	statsBatches tableStatsBatcher
 }
 
 type snowflakeDatabaseLister struct {
@@ -19,6 +33,11 @@ type snowflakeDatabaseLister struct {
 }
 
 func newSnowflake(cfg DatabaseConfig) (*snowflakeDiscoverer, error) {
+	values, err := newValueFormatter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	sfConfig := &gosnowflake.Config{
 		Account:   cfg.Account,
 		User:      cfg.User,
@@ -29,16 +48,9 @@ func newSnowflake(cfg DatabaseConfig) (*snowflakeDiscoverer, error) {
 		Schema:    cfg.Schema,
 	}
 
-	switch cfg.Authenticator {
-	case "externalbrowser":
-		sfConfig.Authenticator = gosnowflake.AuthTypeExternalBrowser
-	default:
-		sfConfig.Authenticator = gosnowflake.AuthTypeSnowflake
-	}
-
-	dsn, err := gosnowflake.DSN(sfConfig)
+	dsn, err := snowflakeDSN(sfConfig, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("build snowflake DSN: %w", err)
+		return nil, err
 	}
 
 	db, err := openDB("snowflake", dsn)
@@ -46,7 +58,12 @@ func newSnowflake(cfg DatabaseConfig) (*snowflakeDiscoverer, error) {
 		return nil, err
 	}
 
-	return &snowflakeDiscoverer{db: db, database: cfg.Database}, nil
+	if err := prepareSnowflakeWarehouse(db, cfg); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &snowflakeDiscoverer{db: db, database: cfg.Database, schemas: cfg.Schemas, values: values, showCommands: cfg.ShowCommands}, nil
 }
 
 func newSnowflakeDatabaseLister(cfg DatabaseConfig) (*snowflakeDatabaseLister, error) {
@@ -59,24 +76,151 @@ func newSnowflakeDatabaseLister(cfg DatabaseConfig) (*snowflakeDatabaseLister, e
 		// Deliberately omit Database and Schema so we connect at the account level.
 	}
 
+	dsn, err := snowflakeDSN(sfConfig, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := openDB("snowflake", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := prepareSnowflakeWarehouse(db, cfg); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &snowflakeDatabaseLister{db: db}, nil
+}
+
+// snowflakeDSN applies the endpoint and authenticator settings from cfg to
+// sfConfig and builds the driver DSN.
+func snowflakeDSN(sfConfig *gosnowflake.Config, cfg DatabaseConfig) (string, error) {
+	setSnowflakeEndpoint(sfConfig, cfg.Host, cfg.Port, cfg.Region)
+
 	switch cfg.Authenticator {
 	case "externalbrowser":
 		sfConfig.Authenticator = gosnowflake.AuthTypeExternalBrowser
+		if cfg.CacheSSOToken {
+			// Cache the ID token for later connections and keep this session
+			// alive so long-running stages do not trigger a fresh login.
+			sfConfig.ClientStoreTemporaryCredential = gosnowflake.ConfigBoolTrue
+			setSnowflakeParam(sfConfig, "client_session_keep_alive", "true")
+		}
 	default:
 		sfConfig.Authenticator = gosnowflake.AuthTypeSnowflake
 	}
 
+	if tag := strings.TrimSpace(cfg.QueryTag); tag != "" {
+		// Session parameters in the DSN apply to every pooled connection,
+		// which an ALTER SESSION on one connection would not.
+		setSnowflakeParam(sfConfig, "query_tag", tag)
+	}
+
 	dsn, err := gosnowflake.DSN(sfConfig)
 	if err != nil {
-		return nil, fmt.Errorf("build snowflake DSN: %w", err)
+		return "", fmt.Errorf("build snowflake DSN: %w", err)
+	}
+	return dsn, nil
+}
+
+// setSnowflakeEndpoint points sfConfig at an explicit host, such as a
+// PrivateLink endpoint, and region, such as a gov-cloud one. Empty values keep
+// the driver's default <account>.snowflakecomputing.com host.
+func setSnowflakeEndpoint(sfConfig *gosnowflake.Config, host string, port int, region string) {
+	if host = SnowflakeHost(host); host != "" {
+		sfConfig.Host = host
+		if port > 0 {
+			sfConfig.Port = port
+		}
+	}
+	if region = strings.TrimSpace(region); region != "" {
+		sfConfig.Region = region
 	}
+}
 
-	db, err := openDB("snowflake", dsn)
-	if err != nil {
-		return nil, err
+// SnowflakeHost normalizes a configured Snowflake host, such as a
+// PrivateLink endpoint, to a bare host name without a scheme or trailing
+// slash. Empty means the default <account>.snowflakecomputing.com host.
+func SnowflakeHost(host string) string {
+	host = strings.TrimSpace(host)
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+	return strings.TrimSuffix(host, "/")
+}
+
+// setSnowflakeParam sets a session parameter sent with the login request.
+func setSnowflakeParam(sfConfig *gosnowflake.Config, name, value string) {
+	if sfConfig.Params == nil {
+		sfConfig.Params = make(map[string]*string)
 	}
+	sfConfig.Params[name] = &value
+}
 
-	return &snowflakeDatabaseLister{db: db}, nil
+// snowflakeWarehouseSizes lists the WAREHOUSE_SIZE values Snowflake accepts.
+var snowflakeWarehouseSizes = map[string]bool{
+	"XSMALL": true, "X-SMALL": true,
+	"SMALL": true, "MEDIUM": true, "LARGE": true,
+	"XLARGE": true, "X-LARGE": true,
+	"XXLARGE": true, "X2LARGE": true, "2X-LARGE": true,
+	"XXXLARGE": true, "X3LARGE": true, "3X-LARGE": true,
+	"X4LARGE": true, "4X-LARGE": true,
+	"X5LARGE": true, "5X-LARGE": true,
+	"X6LARGE": true, "6X-LARGE": true,
+}
+
+var snowflakeUnquotedIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// snowflakeWarehouseStatements returns the ALTER WAREHOUSE statements that
+// cfg asks for, resize first so a resumed warehouse starts at the new size.
+// The warehouse itself is selected for every pooled session by the DSN, so
+// no USE WAREHOUSE is needed.
+func snowflakeWarehouseStatements(cfg DatabaseConfig) ([]string, error) {
+	size := strings.ToUpper(strings.TrimSpace(cfg.WarehouseSize))
+	if size == "" && !cfg.ResumeWarehouse {
+		return nil, nil
+	}
+
+	warehouse := strings.TrimSpace(cfg.Warehouse)
+	if warehouse == "" {
+		return nil, fmt.Errorf("snowflake warehouse_size and resume_warehouse require a warehouse")
+	}
+	// Config files usually hold unquoted names such as compute_wh, which
+	// Snowflake resolves case-insensitively; only quote names that need it.
+	if !snowflakeUnquotedIdentifier.MatchString(warehouse) {
+		warehouse = quoteSnowflakeIdentifier(warehouse)
+	}
+
+	var statements []string
+	if size != "" {
+		if !snowflakeWarehouseSizes[size] {
+			return nil, fmt.Errorf("unsupported snowflake warehouse_size %q", cfg.WarehouseSize)
+		}
+		statements = append(statements, fmt.Sprintf("ALTER WAREHOUSE %s SET WAREHOUSE_SIZE = '%s'", warehouse, size))
+	}
+	if cfg.ResumeWarehouse {
+		statements = append(statements, fmt.Sprintf("ALTER WAREHOUSE %s RESUME IF SUSPENDED", warehouse))
+	}
+	return statements, nil
+}
+
+// prepareSnowflakeWarehouse runs the warehouse statements from
+// snowflakeWarehouseStatements. It connects eagerly, so an externalbrowser
+// login happens here instead of on the first discovery query.
+func prepareSnowflakeWarehouse(db *sql.DB, cfg DatabaseConfig) error {
+	statements, err := snowflakeWarehouseStatements(cfg)
+	if err != nil || len(statements) == 0 {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), snowflakeWarehousePrepareTimeout)
+	defer cancel()
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("prepare snowflake warehouse (%s): %w", statement, err)
+		}
+	}
+	return nil
 }
 
 func (s *snowflakeDatabaseLister) ListDatabases(ctx context.Context) ([]string, error) {
@@ -120,7 +264,10 @@ func (s *snowflakeDatabaseLister) ListDatabases(ctx context.Context) ([]string,
 		databases = append(databases, name)
 	}
 
-	return databases, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return NormalizeDatabaseNames(databases), nil
 }
 
 func (s *snowflakeDatabaseLister) Close() error {
@@ -145,15 +292,11 @@ func (s *snowflakeDiscoverer) Discover(ctx context.Context) ([]SchemaInfo, error
 }
 
 func (s *snowflakeDiscoverer) getSchemas(ctx context.Context) ([]SchemaInfo, error) {
-	// Use INFORMATION_SCHEMA for consistency. Filter out the INFORMATION_SCHEMA itself.
-	query := `
-		SELECT SCHEMA_NAME
-		FROM INFORMATION_SCHEMA.SCHEMATA
-		WHERE SCHEMA_NAME != 'INFORMATION_SCHEMA'
-		ORDER BY SCHEMA_NAME
-	`
-
-	rows, err := s.db.QueryContext(ctx, query)
+	if s.showCommands {
+		return s.showSchemas(ctx)
+	}
+	query, args := snowflakeSchemasQuery(s.schemas)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query snowflake schemas: %w", err)
 	}
@@ -170,7 +313,52 @@ func (s *snowflakeDiscoverer) getSchemas(ctx context.Context) ([]SchemaInfo, err
 	return schemas, rows.Err()
 }
 
+// snowflakeSchemasQuery returns the schema listing query. Use
+// INFORMATION_SCHEMA for consistency and filter out the INFORMATION_SCHEMA
+// itself. A non-empty allowlist restricts the result to those schemas.
+func snowflakeSchemasQuery(allowlist []string) (string, []interface{}) {
+	var filter string
+	var args []interface{}
+	for _, name := range snowflakeSchemaAllowlist(allowlist) {
+		args = append(args, name)
+	}
+	if len(args) > 0 {
+		filter = "\n\t\t  AND SCHEMA_NAME IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ") + ")"
+	}
+
+	query := `
+		SELECT SCHEMA_NAME
+		FROM INFORMATION_SCHEMA.SCHEMATA
+		WHERE SCHEMA_NAME != 'INFORMATION_SCHEMA'` + filter + `
+		ORDER BY SCHEMA_NAME
+	`
+	return query, args
+}
+
+// snowflakeSchemaAllowlist resolves the configured schema names the way
+// Snowflake resolves identifiers: a name in double quotes is kept as
+// written, and any other name is uppercased.
+func snowflakeSchemaAllowlist(allowlist []string) []string {
+	var names []string
+	for _, name := range allowlist {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "":
+			continue
+		case len(name) >= 2 && strings.HasPrefix(name, `"`) && strings.HasSuffix(name, `"`):
+			name = strings.ReplaceAll(name[1:len(name)-1], `""`, `"`)
+		default:
+			name = strings.ToUpper(name)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
 func (s *snowflakeDiscoverer) getTables(ctx context.Context, schema string) ([]TableInfo, error) {
+	if s.showCommands {
+		return s.showTables(ctx, schema)
+	}
 	query := `
 		SELECT TABLE_NAME, TABLE_TYPE
 		FROM INFORMATION_SCHEMA.TABLES
@@ -197,7 +385,73 @@ func (s *snowflakeDiscoverer) getTables(ctx context.Context, schema string) ([]T
 	return tables, rows.Err()
 }
 
+// EstimateRowCounts reads INFORMATION_SCHEMA.TABLES.ROW_COUNT, which
+// Snowflake maintains from micro-partition metadata. Views have no row count.
+func (s *snowflakeDiscoverer) EstimateRowCounts(ctx context.Context, schema string) (map[string]int64, error) {
+	if s.showCommands {
+		return s.showRowCounts(ctx, schema)
+	}
+	query := `
+		SELECT TABLE_NAME, ROW_COUNT
+		FROM INFORMATION_SCHEMA.TABLES
+		WHERE TABLE_SCHEMA = ?
+		  AND ROW_COUNT IS NOT NULL
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, schema)
+	if err != nil {
+		return nil, fmt.Errorf("query snowflake row estimates: %w", err)
+	}
+	defer rows.Close()
+
+	estimates := make(map[string]int64)
+	for rows.Next() {
+		var name string
+		var count int64
+		if err := rows.Scan(&name, &count); err != nil {
+			return nil, fmt.Errorf("scan row estimate: %w", err)
+		}
+		estimates[name] = count
+	}
+	return estimates, rows.Err()
+}
+
+// TableLastModified reads INFORMATION_SCHEMA.TABLES.LAST_ALTERED, which
+// Snowflake updates on DML and DDL. SHOW TABLES does not report it, so this
+// query runs even with ShowCommands set.
+func (s *snowflakeDiscoverer) TableLastModified(ctx context.Context, schema string) (map[string]time.Time, error) {
+	query := `
+		SELECT TABLE_NAME, LAST_ALTERED
+		FROM INFORMATION_SCHEMA.TABLES
+		WHERE TABLE_SCHEMA = ?
+		  AND TABLE_TYPE NOT IN ('VIEW', 'MATERIALIZED VIEW')
+		  AND LAST_ALTERED IS NOT NULL
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, schema)
+	if err != nil {
+		return nil, fmt.Errorf("query snowflake table modification times: %w", err)
+	}
+	defer rows.Close()
+
+	modified := make(map[string]time.Time)
+	for rows.Next() {
+		var name string
+		var lastAltered time.Time
+		if err := rows.Scan(&name, &lastAltered); err != nil {
+			return nil, fmt.Errorf("scan table modification time: %w", err)
+		}
+		modified[name] = lastAltered.UTC()
+	}
+	return modified, rows.Err()
+}
+
 func (s *snowflakeDiscoverer) GetColumns(ctx context.Context, schema, table string) ([]ColumnInfo, error) {
+	if s.showCommands {
+		// SHOW COLUMNS reports virtual columns itself, so DESC TABLE is
+		// not needed.
+		return s.showColumns(ctx, schema, table)
+	}
 	query := `
 		SELECT COLUMN_NAME, DATA_TYPE, IS_NULLABLE, ORDINAL_POSITION, COALESCE(COLUMN_DEFAULT, '')
 		FROM INFORMATION_SCHEMA.COLUMNS
@@ -219,32 +473,73 @@ func (s *snowflakeDiscoverer) GetColumns(ctx context.Context, schema, table stri
 		}
 		columns = append(columns, c)
 	}
-	return columns, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The flag is informational, so columns are still returned when DESC
+	// TABLE is rejected (for example on some views and shared tables).
+	_ = s.markVirtualColumns(ctx, schema, table, columns)
+	return columns, nil
 }
 
-func (s *snowflakeDiscoverer) GetColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo) (EnrichedColumnInfo, error) {
-	profile := newEnrichedColumnInfo(column)
+// markVirtualColumns sets IsGenerated on the columns DESC TABLE reports with
+// kind VIRTUAL. INFORMATION_SCHEMA.COLUMNS does not tell computed columns
+// apart.
+func (s *snowflakeDiscoverer) markVirtualColumns(ctx context.Context, schema, table string, columns []ColumnInfo) error {
+	rows, err := s.db.QueryContext(ctx, "DESC TABLE "+quoteSnowflakeIdentifier(schema)+"."+quoteSnowflakeIdentifier(table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
 
-	quotedSchema := quoteSnowflakeIdentifier(schema)
-	quotedTable := quoteSnowflakeIdentifier(table)
-	quotedColumn := quoteSnowflakeIdentifier(column.Name)
+	names, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	nameIndex, kindIndex := -1, -1
+	for i, name := range names {
+		switch strings.ToLower(name) {
+		case "name":
+			nameIndex = i
+		case "kind":
+			kindIndex = i
+		}
+	}
+	if nameIndex < 0 || kindIndex < 0 {
+		return fmt.Errorf("DESC TABLE returned no name or kind column")
+	}
+
+	virtual := make(map[string]bool)
+	for rows.Next() {
+		cells := make([]sql.NullString, len(names))
+		ptrs := make([]interface{}, len(names))
+		for i := range cells {
+			ptrs[i] = &cells[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		if strings.EqualFold(cells[kindIndex].String, "VIRTUAL") {
+			virtual[cells[nameIndex].String] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := range columns {
+		columns[i].IsGenerated = virtual[columns[i].Name]
+	}
+	return nil
+}
 
-	statsQuery := fmt.Sprintf(`
-		SELECT
-			COUNT(*) AS total_rows,
-			COUNT_IF(%[1]s IS NULL) AS null_count,
-			COUNT(%[1]s) AS non_null_count,
-			COUNT(DISTINCT IFF(%[1]s IS NULL, NULL, TO_VARCHAR(%[1]s))) AS distinct_non_null_count
-		FROM %[2]s.%[3]s
-	`, quotedColumn, quotedSchema, quotedTable)
-
-	var totalRowsRaw, nullCountRaw, nonNullCountRaw, distinctCountRaw interface{}
-	if err := s.db.QueryRowContext(ctx, statsQuery).Scan(
-		&totalRowsRaw,
-		&nullCountRaw,
-		&nonNullCountRaw,
-		&distinctCountRaw,
-	); err != nil {
+func (s *snowflakeDiscoverer) GetColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (EnrichedColumnInfo, error) {
+	opts = opts.withDefaults()
+	profile := newEnrichedColumnInfo(column)
+
+	_, sinceArgs := opts.sinceClause("WHERE", quoteSnowflakeIdentifier, "?")
+	stats, err := batchedColumnStats(ctx, &s.statsBatches, schema, table, column, opts, s.readColumnStats(schema, table, opts), nil)
+	if err != nil {
 		return EnrichedColumnInfo{}, fmt.Errorf(
 			"profile snowflake column %q on %s.%s: %w",
 			column.Name,
@@ -253,41 +548,37 @@ func (s *snowflakeDiscoverer) GetColumnEnrichment(ctx context.Context, schema, t
 			err,
 		)
 	}
+	stats.apply(&profile)
 
-	var err error
-	profile.TotalRows, err = int64FromDBValue(totalRowsRaw)
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse total_rows for %q on %s.%s: %w", column.Name, schema, table, err)
-	}
-	profile.NullCount, err = int64FromDBValue(nullCountRaw)
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse null_count for %q on %s.%s: %w", column.Name, schema, table, err)
-	}
-	profile.NonNullCount, err = int64FromDBValue(nonNullCountRaw)
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse non_null_count for %q on %s.%s: %w", column.Name, schema, table, err)
-	}
-	profile.DistinctNonNullCount, err = int64FromDBValue(distinctCountRaw)
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse distinct_non_null_count for %q on %s.%s: %w", column.Name, schema, table, err)
+	if opts.unnestColumn(column) {
+		if profile.Elements, err = s.readArrayElementStats(ctx, schema, table, column, opts); err != nil {
+			return EnrichedColumnInfo{}, fmt.Errorf(
+				"profile snowflake array elements of %q on %s.%s: %w",
+				column.Name,
+				schema,
+				table,
+				err,
+			)
+		}
 	}
 
-	profile.DistinctOfNonNullPct = percentOfTotal(profile.DistinctNonNullCount, profile.NonNullCount)
-	profile.NullOfTotalRowsPct = percentOfTotal(profile.NullCount, profile.TotalRows)
-	profile.NonNullOfTotalRowsPct = percentOfTotal(profile.NonNullCount, profile.TotalRows)
+	if opts.percentileColumn(column) {
+		if profile.Percentiles, err = s.readColumnPercentiles(ctx, schema, table, column, opts); err != nil {
+			return EnrichedColumnInfo{}, fmt.Errorf(
+				"profile snowflake percentiles of %q on %s.%s: %w",
+				column.Name,
+				schema,
+				table,
+				err,
+			)
+		}
+	}
 
-	if shouldSkipColumnSamples(column.DataType) {
+	if opts.skipColumnSamples(column) {
 		return profile, nil
 	}
 
-	sampleQuery := fmt.Sprintf(`
-		SELECT DISTINCT LEFT(TO_VARCHAR(%[1]s), %[2]d)
-		FROM %[3]s.%[4]s
-		WHERE %[1]s IS NOT NULL
-		LIMIT %[5]d
-	`, quotedColumn, maxColumnSampleValueLength, quotedSchema, quotedTable, columnProfileSampleValueLimit)
-
-	rows, err := s.db.QueryContext(ctx, sampleQuery)
+	rows, err := s.db.QueryContext(ctx, s.sampleValuesSQL(schema, table, column, opts), sinceArgs...)
 	if err != nil {
 		return EnrichedColumnInfo{}, fmt.Errorf(
 			"query snowflake sample values for %q on %s.%s: %w",
@@ -311,7 +602,7 @@ func (s *snowflakeDiscoverer) GetColumnEnrichment(ctx context.Context, schema, t
 				err,
 			)
 		}
-		samples = append(samples, formatValue(value))
+		samples = append(samples, s.values.formatColumn(value, column.DataType))
 	}
 	if err := rows.Err(); err != nil {
 		return EnrichedColumnInfo{}, fmt.Errorf(
@@ -323,14 +614,120 @@ func (s *snowflakeDiscoverer) GetColumnEnrichment(ctx context.Context, schema, t
 		)
 	}
 
-	profile.SampleValues = normalizeColumnSampleValues(samples)
+	profile.SampleValues = normalizeColumnSampleValues(samples, opts)
 	return profile, nil
 }
 
+// GetTableColumnStats computes the row count and the null, non-null, and
+// distinct non-null counts of columns with one query per group of up to 100
+// columns instead of one query per column. Sample values are not collected.
+func (s *snowflakeDiscoverer) GetTableColumnStats(ctx context.Context, schema, table string, columns []ColumnInfo, opts EnrichmentOptions) ([]EnrichedColumnInfo, error) {
+	profiles, err := tableColumnStats(ctx, columns, s.readColumnStats(schema, table, opts), nil)
+	if err != nil {
+		return nil, fmt.Errorf("profile snowflake columns on %s.%s: %w", schema, table, err)
+	}
+	return profiles, nil
+}
+
+// sampleValuesSQL builds the sample values query of column.
+func (s *snowflakeDiscoverer) sampleValuesSQL(schema, table string, column ColumnInfo, opts EnrichmentOptions) string {
+	quotedColumn := quoteSnowflakeIdentifier(column.Name)
+	sinceAnd, _ := opts.sinceClause("AND", quoteSnowflakeIdentifier, "?")
+	return opts.sampleValuesQuery(
+		fmt.Sprintf("LEFT(TO_VARCHAR(%s), %d)", quotedColumn, opts.MaxSampleValueLength),
+		quoteSnowflakeIdentifier(schema)+"."+quoteSnowflakeIdentifier(table),
+		quotedColumn,
+		sinceAnd,
+	)
+}
+
+// readColumnStats returns a function computing the stats of columns in a
+// single query.
+func (s *snowflakeDiscoverer) readColumnStats(schema, table string, opts EnrichmentOptions) readStatsFunc {
+	_, sinceArgs := opts.sinceClause("WHERE", quoteSnowflakeIdentifier, "?")
+	return func(ctx context.Context, columns []ColumnInfo) (map[string]columnStats, error) {
+		return queryColumnStats(ctx, s.db, s.statsSQL(schema, table, columns, opts), sinceArgs, columns)
+	}
+}
+
+// statsSQL builds the stats query of columns.
+func (s *snowflakeDiscoverer) statsSQL(schema, table string, columns []ColumnInfo, opts EnrichmentOptions) string {
+	countDistinct := "COUNT(DISTINCT"
+	if opts.ApproxDistinct {
+		countDistinct = "APPROX_COUNT_DISTINCT("
+	}
+	sinceWhere, _ := opts.sinceClause("WHERE", quoteSnowflakeIdentifier, "?")
+	dialect := statsDialect{
+		totalRows:     "COUNT(*)",
+		nullCount:     "COUNT_IF(%[1]s IS NULL)",
+		nonNullCount:  "COUNT(%[1]s)",
+		distinctCount: countDistinct + " IFF(%[1]s IS NULL, NULL, TO_VARCHAR(%[1]s)))",
+	}
+	return fmt.Sprintf(
+		"SELECT\n\t%s\nFROM %s.%s%s%s",
+		dialect.selectList(columns, quoteSnowflakeIdentifier),
+		quoteSnowflakeIdentifier(schema),
+		quoteSnowflakeIdentifier(table),
+		opts.tablesampleClause("SAMPLE SYSTEM (%s)"),
+		sinceWhere,
+	)
+}
+
+// readArrayElementStats profiles the elements of an ARRAY column with
+// LATERAL FLATTEN.
+func (s *snowflakeDiscoverer) readArrayElementStats(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (*ArrayElementStats, error) {
+	_, sinceArgs := opts.sinceClause("AND", quoteSnowflakeIdentifier, "?")
+	statsQuery, sampleQuery := s.arrayElementSQL(schema, table, column, opts)
+	return queryArrayElementStats(ctx, s.db, s.values, statsQuery, sampleQuery, sinceArgs, opts)
+}
+
+// arrayElementSQL builds the element stats query of an array column and,
+// unless its samples are skipped, the sample elements query.
+func (s *snowflakeDiscoverer) arrayElementSQL(schema, table string, column ColumnInfo, opts EnrichmentOptions) (string, string) {
+	countDistinct := "COUNT(DISTINCT"
+	if opts.ApproxDistinct {
+		countDistinct = "APPROX_COUNT_DISTINCT("
+	}
+	dialect := arrayElementDialect{
+		unnest:        "%[1]s, LATERAL FLATTEN(input => %[2]s) AS " + arrayElement,
+		element:       arrayElement + ".value",
+		text:          "TO_VARCHAR(%[1]s)",
+		countDistinct: countDistinct,
+	}
+	quotedTable := quoteSnowflakeIdentifier(schema) + "." + quoteSnowflakeIdentifier(table)
+	quotedColumn := quoteSnowflakeIdentifier(column.Name)
+	sinceAnd, _ := opts.sinceClause("AND", quoteSnowflakeIdentifier, "?")
+
+	statsQuery := dialect.statsQuery(quotedTable+opts.tablesampleClause("SAMPLE SYSTEM (%s)"), quotedColumn, sinceAnd)
+	var sampleQuery string
+	if !opts.skipColumnSamples(column) {
+		sampleQuery = dialect.sampleQuery(quotedTable, quotedColumn, sinceAnd, opts)
+	}
+	return statsQuery, sampleQuery
+}
+
+// readColumnPercentiles computes approximate percentiles with
+// APPROX_PERCENTILE.
+func (s *snowflakeDiscoverer) readColumnPercentiles(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (*ColumnPercentiles, error) {
+	_, sinceArgs := opts.sinceClause("AND", quoteSnowflakeIdentifier, "?")
+	return queryColumnPercentiles(ctx, s.db, s.percentilesSQL(schema, table, column, opts), sinceArgs)
+}
+
+// percentilesSQL builds the percentiles query of a numeric column.
+func (s *snowflakeDiscoverer) percentilesSQL(schema, table string, column ColumnInfo, opts EnrichmentOptions) string {
+	quotedTable := quoteSnowflakeIdentifier(schema) + "." + quoteSnowflakeIdentifier(table)
+	sinceAnd, _ := opts.sinceClause("AND", quoteSnowflakeIdentifier, "?")
+	return percentilesQuery(func(column string, fraction float64) string {
+		return fmt.Sprintf("APPROX_PERCENTILE(%s, %v)", column, fraction)
+	}, quotedTable+opts.tablesampleClause("SAMPLE SYSTEM (%s)"), quoteSnowflakeIdentifier(column.Name), sinceAnd)
+}
+
 func (s *snowflakeDiscoverer) GetSampleRows(ctx context.Context, schema, table string, limit int) (*SampleResult, error) {
 	query := fmt.Sprintf(
-		`SELECT * FROM "%s"."%s" ORDER BY RANDOM() LIMIT %d`,
-		schema, table, limit,
+		"SELECT * FROM %s.%s ORDER BY RANDOM() LIMIT %d",
+		quoteSnowflakeIdentifier(schema),
+		quoteSnowflakeIdentifier(table),
+		limit,
 	)
 
 	rows, err := s.db.QueryContext(ctx, query)
@@ -339,7 +736,7 @@ func (s *snowflakeDiscoverer) GetSampleRows(ctx context.Context, schema, table s
 	}
 	defer rows.Close()
 
-	return scanSampleRows(rows)
+	return scanSampleRows(rows, s.values)
 }
 
 func (s *snowflakeDiscoverer) Close() error {
@@ -0,0 +1,241 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The SHOW commands read Snowflake's metadata service instead of
+// INFORMATION_SCHEMA, which only covers the session's database and can take
+// tens of seconds on large accounts. DatabaseConfig.ShowCommands selects
+// them for Discover, EstimateRowCounts, and GetColumns.
+
+// snowflakeShowRecord is one SHOW result row keyed by lowercase column
+// name. SHOW output columns vary between Snowflake releases, so they are
+// looked up by name rather than position.
+type snowflakeShowRecord map[string]string
+
+// queryShowRecords runs a SHOW statement and returns its rows as records.
+func queryShowRecords(ctx context.Context, db *sql.DB, statement string) ([]snowflakeShowRecord, error) {
+	rows, err := db.QueryContext(ctx, statement)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanShowRecords(rows)
+}
+
+func scanShowRecords(rows *sql.Rows) ([]snowflakeShowRecord, error) {
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("get column names: %w", err)
+	}
+
+	var records []snowflakeShowRecord
+	for rows.Next() {
+		cells := make([]sql.NullString, len(names))
+		ptrs := make([]interface{}, len(names))
+		for i := range cells {
+			ptrs[i] = &cells[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("scan SHOW row: %w", err)
+		}
+		record := make(snowflakeShowRecord, len(names))
+		for i, name := range names {
+			record[strings.ToLower(name)] = cells[i].String
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// showDatabaseScope returns the IN clause target for the connection's
+// database. Names from config files are usually unquoted, so only names
+// that need it are quoted; an empty database means the session's.
+func (s *snowflakeDiscoverer) showDatabaseScope() string {
+	database := strings.TrimSpace(s.database)
+	if database == "" || snowflakeUnquotedIdentifier.MatchString(database) {
+		return database
+	}
+	return quoteSnowflakeIdentifier(database)
+}
+
+// showSchemaScope returns the IN SCHEMA target for a discovered schema.
+func (s *snowflakeDiscoverer) showSchemaScope(schema string) string {
+	if database := s.showDatabaseScope(); database != "" {
+		return database + "." + quoteSnowflakeIdentifier(schema)
+	}
+	return quoteSnowflakeIdentifier(schema)
+}
+
+func (s *snowflakeDiscoverer) showSchemas(ctx context.Context) ([]SchemaInfo, error) {
+	statement := "SHOW SCHEMAS"
+	if database := s.showDatabaseScope(); database != "" {
+		statement += " IN DATABASE " + database
+	}
+	records, err := queryShowRecords(ctx, s.db, statement)
+	if err != nil {
+		return nil, fmt.Errorf("show snowflake schemas: %w", err)
+	}
+	return snowflakeSchemasFromShow(records, s.schemas), nil
+}
+
+// snowflakeSchemasFromShow keeps the schemas the INFORMATION_SCHEMA query
+// would return: everything but INFORMATION_SCHEMA, limited to allowlist
+// when it is set, sorted by name.
+func snowflakeSchemasFromShow(records []snowflakeShowRecord, allowlist []string) []SchemaInfo {
+	allowed := make(map[string]bool)
+	for _, name := range snowflakeSchemaAllowlist(allowlist) {
+		allowed[name] = true
+	}
+
+	var schemas []SchemaInfo
+	for _, record := range records {
+		name := record["name"]
+		if name == "" || name == "INFORMATION_SCHEMA" {
+			continue
+		}
+		if len(allowed) > 0 && !allowed[name] {
+			continue
+		}
+		schemas = append(schemas, SchemaInfo{Name: name})
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Name < schemas[j].Name })
+	return schemas
+}
+
+// showTables lists a schema's tables and views. SHOW TABLES leaves views
+// out, so SHOW VIEWS is run as well.
+func (s *snowflakeDiscoverer) showTables(ctx context.Context, schema string) ([]TableInfo, error) {
+	tables, err := queryShowRecords(ctx, s.db, "SHOW TABLES IN SCHEMA "+s.showSchemaScope(schema))
+	if err != nil {
+		return nil, fmt.Errorf("show snowflake tables: %w", err)
+	}
+	views, err := queryShowRecords(ctx, s.db, "SHOW VIEWS IN SCHEMA "+s.showSchemaScope(schema))
+	if err != nil {
+		return nil, fmt.Errorf("show snowflake views: %w", err)
+	}
+	return snowflakeTablesFromShow(tables, views), nil
+}
+
+// snowflakeTablesFromShow merges SHOW TABLES and SHOW VIEWS rows, with the
+// TABLE_TYPE labels INFORMATION_SCHEMA.TABLES uses, sorted by name.
+func snowflakeTablesFromShow(tableRecords, viewRecords []snowflakeShowRecord) []TableInfo {
+	var tables []TableInfo
+	for _, record := range tableRecords {
+		tableType := "BASE TABLE"
+		switch {
+		case strings.EqualFold(record["kind"], "TEMPORARY"):
+			tableType = "TEMPORARY TABLE"
+		case showFlag(record["is_external"]):
+			tableType = "EXTERNAL TABLE"
+		case showFlag(record["is_dynamic"]):
+			tableType = "DYNAMIC TABLE"
+		}
+		tables = append(tables, TableInfo{Name: record["name"], TableType: tableType})
+	}
+	for _, record := range viewRecords {
+		tableType := "VIEW"
+		if showFlag(record["is_materialized"]) {
+			tableType = "MATERIALIZED VIEW"
+		}
+		tables = append(tables, TableInfo{Name: record["name"], TableType: tableType})
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables
+}
+
+// showRowCounts reads the rows column of SHOW TABLES, the same
+// micro-partition count INFORMATION_SCHEMA.TABLES.ROW_COUNT reports.
+func (s *snowflakeDiscoverer) showRowCounts(ctx context.Context, schema string) (map[string]int64, error) {
+	records, err := queryShowRecords(ctx, s.db, "SHOW TABLES IN SCHEMA "+s.showSchemaScope(schema))
+	if err != nil {
+		return nil, fmt.Errorf("show snowflake tables: %w", err)
+	}
+
+	estimates := make(map[string]int64)
+	for _, record := range records {
+		count, err := strconv.ParseInt(record["rows"], 10, 64)
+		if err != nil {
+			continue
+		}
+		estimates[record["name"]] = count
+	}
+	return estimates, nil
+}
+
+func (s *snowflakeDiscoverer) showColumns(ctx context.Context, schema, table string) ([]ColumnInfo, error) {
+	statement := "SHOW COLUMNS IN TABLE " + s.showSchemaScope(schema) + "." + quoteSnowflakeIdentifier(table)
+	records, err := queryShowRecords(ctx, s.db, statement)
+	if err != nil {
+		return nil, fmt.Errorf("show snowflake columns: %w", err)
+	}
+	return snowflakeColumnsFromShow(records)
+}
+
+// snowflakeShowDataType is the JSON in the data_type column of SHOW
+// COLUMNS, such as {"type":"FIXED","precision":38,"scale":0,"nullable":true}.
+type snowflakeShowDataType struct {
+	Type     string `json:"type"`
+	Nullable *bool  `json:"nullable"`
+}
+
+// snowflakeColumnsFromShow maps SHOW COLUMNS rows, which come back in
+// column order, to ColumnInfo. Ordinal positions are assigned from that
+// order because SHOW COLUMNS does not report them.
+func snowflakeColumnsFromShow(records []snowflakeShowRecord) ([]ColumnInfo, error) {
+	columns := make([]ColumnInfo, 0, len(records))
+	for _, record := range records {
+		var dataType snowflakeShowDataType
+		if err := json.Unmarshal([]byte(record["data_type"]), &dataType); err != nil {
+			return nil, fmt.Errorf("parse data_type of column %q: %w", record["column_name"], err)
+		}
+
+		nullable := strings.EqualFold(record["null?"], "true")
+		if dataType.Nullable != nil {
+			nullable = *dataType.Nullable
+		}
+		isNullable := "NO"
+		if nullable {
+			isNullable = "YES"
+		}
+
+		columns = append(columns, ColumnInfo{
+			Name:            record["column_name"],
+			DataType:        snowflakeDataTypeFromShow(dataType.Type),
+			IsNullable:      isNullable,
+			OrdinalPosition: len(columns) + 1,
+			ColumnDefault:   record["default"],
+			IsGenerated:     strings.Contains(strings.ToUpper(record["kind"]), "VIRTUAL"),
+		})
+	}
+	return columns, nil
+}
+
+// snowflakeDataTypeFromShow converts the internal type names SHOW COLUMNS
+// reports to the DATA_TYPE spelling of INFORMATION_SCHEMA.COLUMNS.
+func snowflakeDataTypeFromShow(showType string) string {
+	switch showType = strings.ToUpper(showType); showType {
+	case "FIXED":
+		return "NUMBER"
+	case "REAL":
+		return "FLOAT"
+	default:
+		return showType
+	}
+}
+
+// showFlag reports whether a SHOW column holds Snowflake's "Y" or "true".
+func showFlag(value string) bool {
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "Y", "YES", "TRUE":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package discovery
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// showRecordsFromSQLite runs query against an empty SQLite database, so a
+// SELECT with aliased literals can stand in for a SHOW result set.
+func showRecordsFromSQLite(t *testing.T, query string) []snowflakeShowRecord {
+	t.Helper()
+
+	db := openSQLiteForTest(t, filepath.Join(t.TempDir(), "show.db"))
+	defer db.Close()
+	records, err := queryShowRecords(context.Background(), db, query)
+	if err != nil {
+		t.Fatalf("queryShowRecords() error = %v", err)
+	}
+	return records
+}
+
+func TestSnowflakeSchemasFromShow(t *testing.T) {
+	// SHOW SCHEMAS: created_on, name, is_default, is_current, database_name, ...
+	records := showRecordsFromSQLite(t, `
+		SELECT '2024-01-01' AS created_on, 'SALES' AS name, 'N' AS is_default, 'N' AS is_current, 'ANALYTICS' AS database_name
+		UNION ALL SELECT '2024-01-01', 'INFORMATION_SCHEMA', 'N', 'N', 'ANALYTICS'
+		UNION ALL SELECT '2024-01-01', 'PUBLIC', 'Y', 'Y', 'ANALYTICS'
+		UNION ALL SELECT '2024-01-01', 'STAGING', 'N', 'N', 'ANALYTICS'
+	`)
+
+	got := snowflakeSchemasFromShow(records, nil)
+	want := []SchemaInfo{{Name: "PUBLIC"}, {Name: "SALES"}, {Name: "STAGING"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("snowflakeSchemasFromShow() = %#v, want %#v", got, want)
+	}
+
+	got = snowflakeSchemasFromShow(records, []string{"SALES", " PUBLIC "})
+	want = []SchemaInfo{{Name: "PUBLIC"}, {Name: "SALES"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("snowflakeSchemasFromShow(allowlist) = %#v, want %#v", got, want)
+	}
+}
+
+func TestSnowflakeTablesFromShow(t *testing.T) {
+	// SHOW TABLES: created_on, name, database_name, schema_name, kind, comment, cluster_by, rows, ...
+	tables := showRecordsFromSQLite(t, `
+		SELECT '2024-01-01' AS created_on, 'ORDERS' AS name, 'ANALYTICS' AS database_name, 'PUBLIC' AS schema_name,
+			'TABLE' AS kind, '' AS comment, '' AS cluster_by, 120 AS rows, 'N' AS is_external, 'N' AS is_dynamic
+		UNION ALL SELECT '2024-01-01', 'SCRATCH', 'ANALYTICS', 'PUBLIC', 'TEMPORARY', '', '', 0, 'N', 'N'
+		UNION ALL SELECT '2024-01-01', 'DAILY', 'ANALYTICS', 'PUBLIC', 'TABLE', '', '', 7, 'N', 'Y'
+		UNION ALL SELECT '2024-01-01', 'AUDIT', 'ANALYTICS', 'PUBLIC', 'TRANSIENT', '', '', NULL, 'N', 'N'
+	`)
+	// SHOW VIEWS: created_on, name, reserved, database_name, schema_name, owner, comment, text, is_secure, is_materialized, ...
+	views := showRecordsFromSQLite(t, `
+		SELECT '2024-01-01' AS created_on, 'ACTIVE_USERS' AS name, '' AS reserved, 'ANALYTICS' AS database_name,
+			'PUBLIC' AS schema_name, 'SYSADMIN' AS owner, '' AS comment, 'select 1' AS text, 'false' AS is_secure, 'false' AS is_materialized
+		UNION ALL SELECT '2024-01-01', 'REVENUE_MV', '', 'ANALYTICS', 'PUBLIC', 'SYSADMIN', '', 'select 1', 'false', 'true'
+	`)
+
+	got := snowflakeTablesFromShow(tables, views)
+	want := []TableInfo{
+		{Name: "ACTIVE_USERS", TableType: "VIEW"},
+		{Name: "AUDIT", TableType: "BASE TABLE"},
+		{Name: "DAILY", TableType: "DYNAMIC TABLE"},
+		{Name: "ORDERS", TableType: "BASE TABLE"},
+		{Name: "REVENUE_MV", TableType: "MATERIALIZED VIEW"},
+		{Name: "SCRATCH", TableType: "TEMPORARY TABLE"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("snowflakeTablesFromShow() = %#v, want %#v", got, want)
+	}
+}
+
+func TestSnowflakeColumnsFromShow(t *testing.T) {
+	// SHOW COLUMNS: table_name, schema_name, column_name, data_type, null?, default, kind, expression, comment, ...
+	records := showRecordsFromSQLite(t, `
+		SELECT 'ORDERS' AS table_name, 'PUBLIC' AS schema_name, 'ID' AS column_name,
+			'{"type":"FIXED","precision":38,"scale":0,"nullable":false}' AS data_type, 'false' AS "null?",
+			'ANALYTICS.PUBLIC.ORDERS_SEQ.NEXTVAL' AS "default", 'COLUMN' AS kind, NULL AS expression, '' AS comment
+		UNION ALL SELECT 'ORDERS', 'PUBLIC', 'AMOUNT', '{"type":"REAL","nullable":true}', 'true', NULL, 'COLUMN', NULL, ''
+		UNION ALL SELECT 'ORDERS', 'PUBLIC', 'NOTE', '{"type":"TEXT","length":16777216,"nullable":true}', 'true', '', 'COLUMN', NULL, ''
+		UNION ALL SELECT 'ORDERS', 'PUBLIC', 'AMOUNT_CENTS', '{"type":"FIXED","precision":38,"scale":0,"nullable":true}', 'true', '', 'VIRTUAL_COLUMN', 'AMOUNT * 100', ''
+	`)
+
+	got, err := snowflakeColumnsFromShow(records)
+	if err != nil {
+		t.Fatalf("snowflakeColumnsFromShow() error = %v", err)
+	}
+	want := []ColumnInfo{
+		{Name: "ID", DataType: "NUMBER", IsNullable: "NO", OrdinalPosition: 1, ColumnDefault: "ANALYTICS.PUBLIC.ORDERS_SEQ.NEXTVAL"},
+		{Name: "AMOUNT", DataType: "FLOAT", IsNullable: "YES", OrdinalPosition: 2},
+		{Name: "NOTE", DataType: "TEXT", IsNullable: "YES", OrdinalPosition: 3},
+		{Name: "AMOUNT_CENTS", DataType: "NUMBER", IsNullable: "YES", OrdinalPosition: 4, IsGenerated: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("snowflakeColumnsFromShow() = %#v, want %#v", got, want)
+	}
+
+	if _, err := snowflakeColumnsFromShow([]snowflakeShowRecord{{"column_name": "BAD", "data_type": "not json"}}); err == nil {
+		t.Fatal("snowflakeColumnsFromShow(invalid data_type) error = nil, want error")
+	}
+}
+
+func TestSnowflakeShowScopeQuotesOnlyWhenNeeded(t *testing.T) {
+	tests := []struct {
+		database string
+		want     string
+	}{
+		{database: "analytics", want: `analytics."Sales"`},
+		{database: "my-db", want: `"my-db"."Sales"`},
+		{database: "", want: `"Sales"`},
+	}
+	for _, tt := range tests {
+		s := &snowflakeDiscoverer{database: tt.database}
+		if got := s.showSchemaScope("Sales"); got != tt.want {
+			t.Errorf("showSchemaScope(%q) with database %q = %q, want %q", "Sales", tt.database, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// isPostGISType reports whether dataType is a PostGIS geometry or geography
+// column type. Postgres returns their values as hex-encoded EWKB, so
+// samples render them as WKT with ST_AsText instead.
+func isPostGISType(dataType string) bool {
+	switch strings.ToLower(strings.TrimSpace(dataType)) {
+	case "geometry", "geography":
+		return true
+	}
+	return false
+}
+
+// postgresSampleSelectList returns the select list of a sample row query
+// over columns: "*", or, when any of them is a PostGIS column, every column
+// in order with the PostGIS ones converted to WKT under their own names.
+// Polygons can run to megabytes of WKT, so the text is cut at
+// maxColumnSampleValueLength characters.
+func postgresSampleSelectList(columns []ColumnInfo) string {
+	spatial := false
+	items := make([]string, len(columns))
+	for i, column := range columns {
+		quoted := quotePostgresIdentifier(column.Name)
+		items[i] = quoted
+		if isPostGISType(column.DataType) {
+			spatial = true
+			items[i] = fmt.Sprintf("LEFT(ST_AsText(%[1]s), %[2]d) AS %[1]s", quoted, maxColumnSampleValueLength)
+		}
+	}
+	if !spatial {
+		return "*"
+	}
+	return strings.Join(items, ", ")
+}
+
+// sampleSelectList returns the postgresSampleSelectList of the table.
+func (p *postgresDiscoverer) sampleSelectList(ctx context.Context, schema, table string) (string, error) {
+	columns, err := p.GetColumns(ctx, schema, table)
+	if err != nil {
+		return "", err
+	}
+	return postgresSampleSelectList(columns), nil
+}
@@ -0,0 +1,35 @@
+package discovery
+
+import "testing"
+
+func TestIsPostGISType(t *testing.T) {
+	tests := map[string]bool{
+		"geometry":         true,
+		"GEOGRAPHY":        true,
+		"USER-DEFINED":     false,
+		"text":             false,
+		"geometry_columns": false,
+	}
+	for dataType, want := range tests {
+		if got := isPostGISType(dataType); got != want {
+			t.Errorf("isPostGISType(%q) = %v, want %v", dataType, got, want)
+		}
+	}
+}
+
+func TestPostgresSampleSelectList(t *testing.T) {
+	plain := []ColumnInfo{{Name: "id", DataType: "integer"}, {Name: "name", DataType: "text"}}
+	if got := postgresSampleSelectList(plain); got != "*" {
+		t.Errorf("postgresSampleSelectList(plain) = %q, want *", got)
+	}
+
+	spatial := []ColumnInfo{
+		{Name: "id", DataType: "integer"},
+		{Name: "Shape", DataType: "geometry"},
+		{Name: "area", DataType: "geography"},
+	}
+	want := `"id", LEFT(ST_AsText("Shape"), 180) AS "Shape", LEFT(ST_AsText("area"), 180) AS "area"`
+	if got := postgresSampleSelectList(spatial); got != want {
+		t.Errorf("postgresSampleSelectList(spatial) = %q, want %q", got, want)
+	}
+}
@@ -10,7 +10,10 @@ import (
 )
 
 type sqliteDiscoverer struct {
-	db *sql.DB
+	db     *sql.DB
+	values valueFormatter
+
+	statsBatches tableStatsBatcher
 }
 
 type sqliteDatabaseLister struct {
@@ -18,22 +21,27 @@ type sqliteDatabaseLister struct {
 }
 
 func newSQLite(cfg DatabaseConfig) (*sqliteDiscoverer, error) {
+	values, err := newValueFormatter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	databasePath := strings.TrimSpace(cfg.Database)
 	if databasePath == "" {
-		return nil, fmt.Errorf("sqlite requires database file path")
+		return nil, ErrMissingDatabasePath
 	}
 
 	db, err := openDB("sqlite", databasePath)
 	if err != nil {
 		return nil, err
 	}
-	return &sqliteDiscoverer{db: db}, nil
+	return &sqliteDiscoverer{db: db, values: values}, nil
 }
 
 func newSQLiteDatabaseLister(cfg DatabaseConfig) (*sqliteDatabaseLister, error) {
 	databasePath := strings.TrimSpace(cfg.Database)
 	if databasePath == "" {
-		return nil, fmt.Errorf("sqlite requires database file path")
+		return nil, ErrMissingDatabasePath
 	}
 
 	db, err := openDB("sqlite", databasePath)
@@ -44,7 +52,11 @@ func newSQLiteDatabaseLister(cfg DatabaseConfig) (*sqliteDatabaseLister, error)
 }
 
 func (s *sqliteDatabaseLister) ListDatabases(ctx context.Context) ([]string, error) {
-	return listSQLiteDatabases(ctx, s.db)
+	databases, err := listSQLiteDatabases(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	return NormalizeDatabaseNames(databases), nil
 }
 
 func (s *sqliteDatabaseLister) Close() error {
@@ -162,7 +174,7 @@ func normalizeSQLiteTableType(rawType string) string {
 func (s *sqliteDiscoverer) GetColumns(ctx context.Context, schema, table string) ([]ColumnInfo, error) {
 	schemaName := normalizeSQLiteSchemaName(schema)
 	query := fmt.Sprintf(
-		"PRAGMA %s.table_info(%s)",
+		"PRAGMA %s.table_xinfo(%s)",
 		quoteSQLiteIdentifier(schemaName),
 		quoteSQLiteStringLiteral(table),
 	)
@@ -182,10 +194,16 @@ func (s *sqliteDiscoverer) GetColumns(ctx context.Context, schema, table string)
 			notNull      int
 			defaultValue sql.NullString
 			primaryKey   int
+			hidden       int
 		)
-		if err := rows.Scan(&cid, &name, &dataType, &notNull, &defaultValue, &primaryKey); err != nil {
+		if err := rows.Scan(&cid, &name, &dataType, &notNull, &defaultValue, &primaryKey, &hidden); err != nil {
 			return nil, fmt.Errorf("scan sqlite column row: %w", err)
 		}
+		// hidden is 1 for hidden columns of virtual tables, which table_info
+		// leaves out, and 2 or 3 for virtual or stored generated columns.
+		if hidden == 1 {
+			continue
+		}
 
 		isNullable := "YES"
 		if notNull != 0 || primaryKey != 0 {
@@ -203,39 +221,22 @@ func (s *sqliteDiscoverer) GetColumns(ctx context.Context, schema, table string)
 			IsNullable:      isNullable,
 			OrdinalPosition: cid + 1,
 			ColumnDefault:   columnDefault,
+			IsGenerated:     hidden == 2 || hidden == 3,
 		})
 	}
 
 	return columns, rows.Err()
 }
 
-func (s *sqliteDiscoverer) GetColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo) (EnrichedColumnInfo, error) {
+func (s *sqliteDiscoverer) GetColumnEnrichment(ctx context.Context, schema, table string, column ColumnInfo, opts EnrichmentOptions) (EnrichedColumnInfo, error) {
+	opts = opts.withDefaults()
 	profile := newEnrichedColumnInfo(column)
 
 	schemaName := normalizeSQLiteSchemaName(schema)
-	quotedTable := fmt.Sprintf(
-		"%s.%s",
-		quoteSQLiteIdentifier(schemaName),
-		quoteSQLiteIdentifier(table),
-	)
-	quotedColumn := quoteSQLiteIdentifier(column.Name)
 
-	statsQuery := fmt.Sprintf(`
-		SELECT
-			COUNT(*) AS total_rows,
-			SUM(CASE WHEN %[1]s IS NULL THEN 1 ELSE 0 END) AS null_count,
-			COUNT(%[1]s) AS non_null_count,
-			COUNT(DISTINCT CASE WHEN %[1]s IS NULL THEN NULL ELSE CAST(%[1]s AS TEXT) END) AS distinct_non_null_count
-		FROM %[2]s
-	`, quotedColumn, quotedTable)
-
-	var totalRowsRaw, nullCountRaw, nonNullCountRaw, distinctCountRaw interface{}
-	if err := s.db.QueryRowContext(ctx, statsQuery).Scan(
-		&totalRowsRaw,
-		&nullCountRaw,
-		&nonNullCountRaw,
-		&distinctCountRaw,
-	); err != nil {
+	_, sinceArgs := opts.sinceClause("WHERE", quoteSQLiteIdentifier, "?")
+	stats, err := batchedColumnStats(ctx, &s.statsBatches, schema, table, column, opts, s.readColumnStats(schema, table, opts), nil)
+	if err != nil {
 		return EnrichedColumnInfo{}, fmt.Errorf(
 			"profile sqlite column %q on %s.%s: %w",
 			column.Name,
@@ -244,41 +245,13 @@ func (s *sqliteDiscoverer) GetColumnEnrichment(ctx context.Context, schema, tabl
 			err,
 		)
 	}
+	stats.apply(&profile)
 
-	var err error
-	profile.TotalRows, err = int64FromDBValue(totalRowsRaw)
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse total_rows for %q on %s.%s: %w", column.Name, schemaName, table, err)
-	}
-	profile.NullCount, err = int64FromDBValue(nullCountRaw)
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse null_count for %q on %s.%s: %w", column.Name, schemaName, table, err)
-	}
-	profile.NonNullCount, err = int64FromDBValue(nonNullCountRaw)
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse non_null_count for %q on %s.%s: %w", column.Name, schemaName, table, err)
-	}
-	profile.DistinctNonNullCount, err = int64FromDBValue(distinctCountRaw)
-	if err != nil {
-		return EnrichedColumnInfo{}, fmt.Errorf("parse distinct_non_null_count for %q on %s.%s: %w", column.Name, schemaName, table, err)
-	}
-
-	profile.DistinctOfNonNullPct = percentOfTotal(profile.DistinctNonNullCount, profile.NonNullCount)
-	profile.NullOfTotalRowsPct = percentOfTotal(profile.NullCount, profile.TotalRows)
-	profile.NonNullOfTotalRowsPct = percentOfTotal(profile.NonNullCount, profile.TotalRows)
-
-	if shouldSkipColumnSamples(column.DataType) {
+	if opts.skipColumnSamples(column) {
 		return profile, nil
 	}
 
-	sampleQuery := fmt.Sprintf(`
-		SELECT DISTINCT SUBSTR(CAST(%[1]s AS TEXT), 1, %[2]d)
-		FROM %[3]s
-		WHERE %[1]s IS NOT NULL
-		LIMIT %[4]d
-	`, quotedColumn, maxColumnSampleValueLength, quotedTable, columnProfileSampleValueLimit)
-
-	rows, err := s.db.QueryContext(ctx, sampleQuery)
+	rows, err := s.db.QueryContext(ctx, s.sampleValuesSQL(schema, table, column, opts), sinceArgs...)
 	if err != nil {
 		return EnrichedColumnInfo{}, fmt.Errorf(
 			"query sqlite sample values for %q on %s.%s: %w",
@@ -302,7 +275,7 @@ func (s *sqliteDiscoverer) GetColumnEnrichment(ctx context.Context, schema, tabl
 				err,
 			)
 		}
-		samples = append(samples, formatValue(value))
+		samples = append(samples, s.values.formatColumn(value, column.DataType))
 	}
 	if err := rows.Err(); err != nil {
 		return EnrichedColumnInfo{}, fmt.Errorf(
@@ -314,10 +287,60 @@ func (s *sqliteDiscoverer) GetColumnEnrichment(ctx context.Context, schema, tabl
 		)
 	}
 
-	profile.SampleValues = normalizeColumnSampleValues(samples)
+	profile.SampleValues = normalizeColumnSampleValues(samples, opts)
 	return profile, nil
 }
 
+// sampleValuesSQL builds the sample values query of column.
+func (s *sqliteDiscoverer) sampleValuesSQL(schema, table string, column ColumnInfo, opts EnrichmentOptions) string {
+	quotedColumn := quoteSQLiteIdentifier(column.Name)
+	sinceAnd, _ := opts.sinceClause("AND", quoteSQLiteIdentifier, "?")
+	return opts.sampleValuesQuery(
+		fmt.Sprintf("SUBSTR(CAST(%s AS TEXT), 1, %d)", quotedColumn, opts.MaxSampleValueLength),
+		quoteSQLiteIdentifier(normalizeSQLiteSchemaName(schema))+"."+quoteSQLiteIdentifier(table),
+		quotedColumn,
+		sinceAnd,
+	)
+}
+
+// GetTableColumnStats computes the row count and the null, non-null, and
+// distinct non-null counts of columns with one query per group of up to 100
+// columns instead of one query per column. Sample values are not collected.
+func (s *sqliteDiscoverer) GetTableColumnStats(ctx context.Context, schema, table string, columns []ColumnInfo, opts EnrichmentOptions) ([]EnrichedColumnInfo, error) {
+	profiles, err := tableColumnStats(ctx, columns, s.readColumnStats(schema, table, opts), nil)
+	if err != nil {
+		return nil, fmt.Errorf("profile sqlite columns on %s.%s: %w", schema, table, err)
+	}
+	return profiles, nil
+}
+
+// readColumnStats returns a function computing the stats of columns in a
+// single query.
+func (s *sqliteDiscoverer) readColumnStats(schema, table string, opts EnrichmentOptions) readStatsFunc {
+	_, sinceArgs := opts.sinceClause("WHERE", quoteSQLiteIdentifier, "?")
+	return func(ctx context.Context, columns []ColumnInfo) (map[string]columnStats, error) {
+		return queryColumnStats(ctx, s.db, s.statsSQL(schema, table, columns, opts), sinceArgs, columns)
+	}
+}
+
+// statsSQL builds the stats query of columns.
+func (s *sqliteDiscoverer) statsSQL(schema, table string, columns []ColumnInfo, opts EnrichmentOptions) string {
+	sinceWhere, _ := opts.sinceClause("WHERE", quoteSQLiteIdentifier, "?")
+	dialect := statsDialect{
+		totalRows:     "COUNT(*)",
+		nullCount:     "SUM(CASE WHEN %[1]s IS NULL THEN 1 ELSE 0 END)",
+		nonNullCount:  "COUNT(%[1]s)",
+		distinctCount: "COUNT(DISTINCT CASE WHEN %[1]s IS NULL THEN NULL ELSE CAST(%[1]s AS TEXT) END)",
+	}
+	return fmt.Sprintf(
+		"SELECT\n\t%s\nFROM %s.%s%s",
+		dialect.selectList(columns, quoteSQLiteIdentifier),
+		quoteSQLiteIdentifier(normalizeSQLiteSchemaName(schema)),
+		quoteSQLiteIdentifier(table),
+		sinceWhere,
+	)
+}
+
 func (s *sqliteDiscoverer) GetSampleRows(ctx context.Context, schema, table string, limit int) (*SampleResult, error) {
 	if limit <= 0 {
 		limit = 10
@@ -337,7 +360,7 @@ func (s *sqliteDiscoverer) GetSampleRows(ctx context.Context, schema, table stri
 	}
 	defer rows.Close()
 
-	return scanSampleRows(rows)
+	return scanSampleRows(rows, s.values)
 }
 
 func normalizeSQLiteSchemaName(schema string) string {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"path/filepath"
+	"reflect"
 	"slices"
 	"testing"
 )
@@ -126,6 +127,57 @@ func TestSQLiteDiscoverer_GetColumns(t *testing.T) {
 	}
 }
 
+func TestSQLiteDiscoverer_GetColumnsFlagsGeneratedColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "generated.db")
+	db := openSQLiteForTest(t, path)
+	execSQLite(t, db, `
+		CREATE TABLE prices (
+			id INTEGER PRIMARY KEY,
+			amount INTEGER NOT NULL,
+			doubled INTEGER GENERATED ALWAYS AS (amount * 2) VIRTUAL,
+			label TEXT GENERATED ALWAYS AS ('price ' || amount) STORED
+		);
+	`)
+	execSQLite(t, db, `INSERT INTO prices (amount) VALUES (1), (2), (3);`)
+	db.Close()
+
+	discoverer, err := newSQLite(DatabaseConfig{Database: path})
+	if err != nil {
+		t.Fatalf("newSQLite() error = %v", err)
+	}
+	defer discoverer.Close()
+
+	ctx := context.Background()
+	columns, err := discoverer.GetColumns(ctx, "main", "prices")
+	if err != nil {
+		t.Fatalf("GetColumns() error = %v", err)
+	}
+	generated := make(map[string]bool)
+	var names []string
+	for _, column := range columns {
+		names = append(names, column.Name)
+		generated[column.Name] = column.IsGenerated
+	}
+	if want := []string{"id", "amount", "doubled", "label"}; !slices.Equal(names, want) {
+		t.Fatalf("columns = %v, want %v", names, want)
+	}
+	want := map[string]bool{"id": false, "amount": false, "doubled": true, "label": true}
+	if !reflect.DeepEqual(generated, want) {
+		t.Fatalf("IsGenerated = %v, want %v", generated, want)
+	}
+
+	enriched, err := discoverer.GetColumnEnrichment(ctx, "main", "prices", columns[2], EnrichmentOptions{})
+	if err != nil {
+		t.Fatalf("GetColumnEnrichment() error = %v", err)
+	}
+	if !enriched.IsGenerated {
+		t.Fatalf("enriched IsGenerated = false, want true")
+	}
+	if len(enriched.SampleValues) != 0 {
+		t.Fatalf("generated column sample values = %v, want none", enriched.SampleValues)
+	}
+}
+
 func TestSQLiteDiscoverer_GetColumnEnrichment(t *testing.T) {
 	dbPath := createSQLiteTestDatabase(t)
 	discoverer, err := newSQLite(DatabaseConfig{Database: dbPath})
@@ -153,7 +205,7 @@ func TestSQLiteDiscoverer_GetColumnEnrichment(t *testing.T) {
 		t.Fatalf("email column not found")
 	}
 
-	profile, err := discoverer.GetColumnEnrichment(ctx, "main", "users", emailColumn)
+	profile, err := discoverer.GetColumnEnrichment(ctx, "main", "users", emailColumn, EnrichmentOptions{})
 	if err != nil {
 		t.Fatalf("GetColumnEnrichment() error = %v", err)
 	}
@@ -192,6 +244,162 @@ func TestSQLiteDiscoverer_GetColumnEnrichment(t *testing.T) {
 	}
 }
 
+func TestSQLiteDiscoverer_GetColumnEnrichmentHonorsSampleLimit(t *testing.T) {
+	dbPath := createSQLiteTestDatabase(t)
+	discoverer, err := newSQLite(DatabaseConfig{Database: dbPath})
+	if err != nil {
+		t.Fatalf("newSQLite() error = %v", err)
+	}
+	defer discoverer.Close()
+
+	profile, err := discoverer.GetColumnEnrichment(
+		context.Background(),
+		"main",
+		"users",
+		ColumnInfo{Name: "email", DataType: "TEXT"},
+		EnrichmentOptions{SampleValueLimit: 1},
+	)
+	if err != nil {
+		t.Fatalf("GetColumnEnrichment() error = %v", err)
+	}
+	if len(profile.SampleValues) != 1 {
+		t.Fatalf("sample values = %v, want exactly 1 value", profile.SampleValues)
+	}
+}
+
+func TestSQLiteDiscoverer_GetColumnEnrichmentFrequentSampleStrategy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.db")
+	db := openSQLiteForTest(t, path)
+	execSQLite(t, db, `CREATE TABLE orders (id INTEGER PRIMARY KEY, status TEXT)`)
+	execSQLite(t, db, `
+		INSERT INTO orders (status) VALUES
+			('archived'), ('cancelled'), ('pending'), ('pending'),
+			('shipped'), ('shipped'), ('shipped'), (NULL);
+	`)
+	db.Close()
+
+	discoverer, err := newSQLite(DatabaseConfig{Database: path})
+	if err != nil {
+		t.Fatalf("newSQLite() error = %v", err)
+	}
+	defer discoverer.Close()
+
+	profile, err := discoverer.GetColumnEnrichment(
+		context.Background(),
+		"main",
+		"orders",
+		ColumnInfo{Name: "status", DataType: "TEXT"},
+		EnrichmentOptions{SampleValueLimit: 3, SampleStrategy: SampleStrategyFrequent},
+	)
+	if err != nil {
+		t.Fatalf("GetColumnEnrichment() error = %v", err)
+	}
+	if want := []string{"shipped", "pending", "archived"}; !slices.Equal(profile.SampleValues, want) {
+		t.Fatalf("sample values = %v, want %v", profile.SampleValues, want)
+	}
+}
+
+func TestSQLiteDiscoverer_GetColumnEnrichmentStatsOnly(t *testing.T) {
+	dbPath := createSQLiteTestDatabase(t)
+	discoverer, err := newSQLite(DatabaseConfig{Database: dbPath})
+	if err != nil {
+		t.Fatalf("newSQLite() error = %v", err)
+	}
+	defer discoverer.Close()
+
+	profile, err := discoverer.GetColumnEnrichment(
+		context.Background(),
+		"main",
+		"users",
+		ColumnInfo{Name: "email", DataType: "TEXT"},
+		EnrichmentOptions{StatsOnly: true},
+	)
+	if err != nil {
+		t.Fatalf("GetColumnEnrichment() error = %v", err)
+	}
+	if profile.TotalRows != 3 || profile.DistinctNonNullCount == 0 {
+		t.Fatalf("counts = total %d distinct %d, want stats to be collected", profile.TotalRows, profile.DistinctNonNullCount)
+	}
+	if len(profile.SampleValues) != 0 {
+		t.Fatalf("sample values = %v, want none with StatsOnly", profile.SampleValues)
+	}
+}
+
+func TestSQLiteDiscoverer_GetColumnEnrichmentAppliesSinceFilter(t *testing.T) {
+	dbPath := createSQLiteTestDatabase(t)
+	discoverer, err := newSQLite(DatabaseConfig{Database: dbPath})
+	if err != nil {
+		t.Fatalf("newSQLite() error = %v", err)
+	}
+	defer discoverer.Close()
+
+	since, err := ParseSinceFilter("id>1")
+	if err != nil {
+		t.Fatalf("ParseSinceFilter() error = %v", err)
+	}
+
+	profile, err := discoverer.GetColumnEnrichment(
+		context.Background(),
+		"main",
+		"users",
+		ColumnInfo{Name: "email", DataType: "TEXT"},
+		EnrichmentOptions{Since: since},
+	)
+	if err != nil {
+		t.Fatalf("GetColumnEnrichment() error = %v", err)
+	}
+
+	if profile.TotalRows != 2 || profile.NullCount != 1 || profile.NonNullCount != 1 {
+		t.Fatalf("counts = total %d null %d non-null %d, want 2/1/1", profile.TotalRows, profile.NullCount, profile.NonNullCount)
+	}
+	if !slices.Equal(profile.SampleValues, []string{"cara@example.com"}) {
+		t.Fatalf("sample values = %v, want [cara@example.com]", profile.SampleValues)
+	}
+}
+
+func TestSQLiteDiscoverer_GetTableColumnStatsMatchesPerColumnStats(t *testing.T) {
+	dbPath := createSQLiteTestDatabase(t)
+	discoverer, err := newSQLite(DatabaseConfig{Database: dbPath})
+	if err != nil {
+		t.Fatalf("newSQLite() error = %v", err)
+	}
+	defer discoverer.Close()
+
+	ctx := context.Background()
+	columns, err := discoverer.GetColumns(ctx, "main", "users")
+	if err != nil {
+		t.Fatalf("GetColumns() error = %v", err)
+	}
+
+	var reader TableColumnStatsReader = discoverer
+	batched, err := reader.GetTableColumnStats(ctx, "main", "users", columns, EnrichmentOptions{})
+	if err != nil {
+		t.Fatalf("GetTableColumnStats() error = %v", err)
+	}
+	if len(batched) != len(columns) {
+		t.Fatalf("GetTableColumnStats() returned %d profiles, want %d", len(batched), len(columns))
+	}
+
+	opts := EnrichmentOptions{StatsOnly: true}
+	shared := EnrichmentOptions{StatsOnly: true, TableColumns: columns}
+	for i, column := range columns {
+		single, err := discoverer.GetColumnEnrichment(ctx, "main", "users", column, opts)
+		if err != nil {
+			t.Fatalf("GetColumnEnrichment(%s) error = %v", column.Name, err)
+		}
+		fromBatch, err := discoverer.GetColumnEnrichment(ctx, "main", "users", column, shared)
+		if err != nil {
+			t.Fatalf("GetColumnEnrichment(%s, TableColumns) error = %v", column.Name, err)
+		}
+		if !reflect.DeepEqual(batched[i], single) || !reflect.DeepEqual(fromBatch, single) {
+			t.Fatalf("column %s: batched %+v, from batch %+v, want %+v", column.Name, batched[i], fromBatch, single)
+		}
+	}
+	if len(discoverer.statsBatches.batches) != 0 {
+		t.Fatalf("stats batch kept after every column was profiled")
+	}
+}
+
 func TestSQLiteDiscoverer_GetSampleRows(t *testing.T) {
 	dbPath := createSQLiteTestDatabase(t)
 	discoverer, err := newSQLite(DatabaseConfig{Database: dbPath})
@@ -215,6 +423,29 @@ func TestSQLiteDiscoverer_GetSampleRows(t *testing.T) {
 	}
 }
 
+func TestSQLiteDiscoverer_HasRows(t *testing.T) {
+	dbPath := createSQLiteTestDatabase(t)
+	db := openSQLiteForTest(t, dbPath)
+	execSQLite(t, db, `CREATE TABLE staging_users (id INTEGER)`)
+	db.Close()
+
+	discoverer, err := newSQLite(DatabaseConfig{Database: dbPath})
+	if err != nil {
+		t.Fatalf("newSQLite() error = %v", err)
+	}
+	defer discoverer.Close()
+
+	for table, want := range map[string]bool{"users": true, "staging_users": false} {
+		got, err := discoverer.HasRows(context.Background(), "main", table)
+		if err != nil {
+			t.Fatalf("HasRows(%q) error = %v", table, err)
+		}
+		if got != want {
+			t.Fatalf("HasRows(%q) = %v, want %v", table, got, want)
+		}
+	}
+}
+
 func TestSQLiteDatabaseLister_IncludesAttachedDatabases(t *testing.T) {
 	dbPath := createSQLiteTestDatabase(t)
 	attachedPath := createAttachedSQLiteDatabase(t)
@@ -237,8 +468,8 @@ func TestSQLiteDatabaseLister_IncludesAttachedDatabases(t *testing.T) {
 	if len(databases) != 2 {
 		t.Fatalf("database count = %d, want 2", len(databases))
 	}
-	if databases[0] != "main" || databases[1] != "analytics" {
-		t.Fatalf("databases = %v, want [main analytics]", databases)
+	if databases[0] != "analytics" || databases[1] != "main" {
+		t.Fatalf("databases = %v, want [analytics main]", databases)
 	}
 }
 
@@ -0,0 +1,309 @@
+package discovery
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// columnStatsBatchSize caps how many columns share one stats query. Three
+// aggregates per column keep the select list well below every backend's
+// result column limit (about 1600 on Postgres and Redshift) and BigQuery's
+// query complexity limits.
+const columnStatsBatchSize = 100
+
+// columnStats holds the null and distinct counts of one column.
+type columnStats struct {
+	TotalRows            int64
+	NullCount            int64
+	NonNullCount         int64
+	DistinctNonNullCount int64
+}
+
+// apply copies the counts into profile and derives its percentages.
+func (s columnStats) apply(profile *EnrichedColumnInfo) {
+	profile.TotalRows = s.TotalRows
+	profile.NullCount = s.NullCount
+	profile.NonNullCount = s.NonNullCount
+	profile.DistinctNonNullCount = s.DistinctNonNullCount
+	profile.DistinctOfNonNullPct = percentOfTotal(s.DistinctNonNullCount, s.NonNullCount)
+	profile.NullOfTotalRowsPct = percentOfTotal(s.NullCount, s.TotalRows)
+	profile.NonNullOfTotalRowsPct = percentOfTotal(s.NonNullCount, s.TotalRows)
+}
+
+// tableStatsBatcher shares one batched stats query per table between the
+// GetColumnEnrichment calls for that table's columns. The first call runs
+// the query; concurrent and later calls for the same batch wait for it and
+// take their column's result instead of issuing their own job. Each result
+// is handed out once, and a batch is dropped when all of its columns have
+// been served.
+type tableStatsBatcher struct {
+	mu      sync.Mutex
+	batches map[string]*statsBatch
+}
+
+type statsBatch struct {
+	done  chan struct{}
+	stats map[string]columnStats
+	err   error
+}
+
+// loadStatsFunc computes the stats of the batch's columns. Columns it omits
+// from the result are profiled on their own.
+type loadStatsFunc func(ctx context.Context) (map[string]columnStats, error)
+
+// columnStats returns the batched stats for column, running load for the
+// batch identified by key if no call has yet. ok is false when the batch
+// has no result for column, in which case the caller profiles it alone.
+func (b *tableStatsBatcher) columnStats(ctx context.Context, key, column string, load loadStatsFunc) (columnStats, bool, error) {
+	b.mu.Lock()
+	if b.batches == nil {
+		b.batches = make(map[string]*statsBatch)
+	}
+	batch, running := b.batches[key]
+	if !running {
+		batch = &statsBatch{done: make(chan struct{})}
+		b.batches[key] = batch
+	}
+	b.mu.Unlock()
+
+	if !running {
+		batch.stats, batch.err = load(ctx)
+		close(batch.done)
+	} else {
+		select {
+		case <-batch.done:
+		case <-ctx.Done():
+			return columnStats{}, false, ctx.Err()
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if batch.err != nil {
+		// Let the next call retry rather than repeating a stale error.
+		if b.batches[key] == batch {
+			delete(b.batches, key)
+		}
+		return columnStats{}, false, batch.err
+	}
+	stats, ok := batch.stats[column]
+	if ok {
+		delete(batch.stats, column)
+		if len(batch.stats) == 0 && b.batches[key] == batch {
+			delete(b.batches, key)
+		}
+	}
+	return stats, ok, nil
+}
+
+// statsBatchKey identifies a batch by table, the options that change the
+// stats, and the set of columns being profiled.
+func statsBatchKey(schema, table string, columns []ColumnInfo, opts EnrichmentOptions) string {
+	parts := make([]string, 0, len(columns)+5)
+	parts = append(parts, schema, table, opts.Since.String())
+	if opts.ApproxDistinct {
+		parts = append(parts, "approx")
+	}
+	parts = append(parts, opts.tablesampleClause("%s"))
+	for _, column := range columns {
+		parts = append(parts, column.Name)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// batchesColumn reports whether column is one of opts.TableColumns, so its
+// stats can come from a table batch.
+func (o EnrichmentOptions) batchesColumn(column ColumnInfo) bool {
+	if len(o.TableColumns) < 2 {
+		return false
+	}
+	for _, c := range o.TableColumns {
+		if c.Name == column.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// readStatsFunc computes the stats of columns with a single query.
+type readStatsFunc func(ctx context.Context, columns []ColumnInfo) (map[string]columnStats, error)
+
+// batchedColumnStats returns the stats of column. When opts.TableColumns
+// lists it, the stats of its group of up to columnStatsBatchSize listed
+// columns are read together, once, via batcher; otherwise, or when the
+// batch has no result for the column, the column is read on its own. Each
+// group's query runs under the ctx of the first call for one of its
+// columns, so every group gets the time of a single column.
+func batchedColumnStats(
+	ctx context.Context,
+	batcher *tableStatsBatcher,
+	schema, table string,
+	column ColumnInfo,
+	opts EnrichmentOptions,
+	read readStatsFunc,
+	tooComplex func(error) bool,
+) (columnStats, error) {
+	if opts.batchesColumn(column) {
+		chunk := columnChunk(opts.TableColumns, column, columnStatsBatchSize)
+		key := statsBatchKey(schema, table, chunk, opts)
+		stats, ok, err := batcher.columnStats(ctx, key, column.Name, func(ctx context.Context) (map[string]columnStats, error) {
+			return readColumnStatsInBatches(ctx, chunk, read, tooComplex)
+		})
+		if err != nil {
+			return columnStats{}, err
+		}
+		if ok {
+			return stats, nil
+		}
+	}
+
+	stats, err := read(ctx, []ColumnInfo{column})
+	if err != nil {
+		return columnStats{}, err
+	}
+	return stats[column.Name], nil
+}
+
+// readColumnStatsInBatches runs read once per group of columnStatsBatchSize
+// columns. When tooComplex reports that a group's query was rejected for its
+// size, the group is left out of the result so its columns are profiled one
+// query each; any other error is returned. tooComplex may be nil.
+func readColumnStatsInBatches(ctx context.Context, columns []ColumnInfo, read readStatsFunc, tooComplex func(error) bool) (map[string]columnStats, error) {
+	stats := make(map[string]columnStats, len(columns))
+	for _, chunk := range chunkColumns(columns, columnStatsBatchSize) {
+		chunkStats, err := read(ctx, chunk)
+		if err != nil {
+			if tooComplex != nil && tooComplex(err) {
+				continue
+			}
+			return nil, err
+		}
+		for name, s := range chunkStats {
+			stats[name] = s
+		}
+	}
+	return stats, nil
+}
+
+// tableColumnStats implements GetTableColumnStats on top of a backend's
+// read function: one profile per column, in order, without sample values.
+func tableColumnStats(ctx context.Context, columns []ColumnInfo, read readStatsFunc, tooComplex func(error) bool) ([]EnrichedColumnInfo, error) {
+	stats, err := readColumnStatsInBatches(ctx, columns, read, tooComplex)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make([]EnrichedColumnInfo, 0, len(columns))
+	for _, column := range columns {
+		s, ok := stats[column.Name]
+		if !ok {
+			single, err := read(ctx, []ColumnInfo{column})
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", column.Name, err)
+			}
+			s = single[column.Name]
+		}
+		profile := newEnrichedColumnInfo(column)
+		s.apply(&profile)
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// statsDialect is how a backend writes the aggregates of a stats query. The
+// per-column expressions are format strings whose %[1]s is the quoted
+// column.
+type statsDialect struct {
+	totalRows     string
+	nullCount     string
+	nonNullCount  string
+	distinctCount string
+}
+
+// selectList returns the total row count followed by the null, non-null,
+// and distinct non-null counts of each column, in order.
+func (d statsDialect) selectList(columns []ColumnInfo, quote func(string) string) string {
+	selects := make([]string, 0, 1+3*len(columns))
+	selects = append(selects, d.totalRows+" AS total_rows")
+	for i, column := range columns {
+		quoted := quote(column.Name)
+		selects = append(selects,
+			fmt.Sprintf(d.nullCount+" AS null_count_%[2]d", quoted, i),
+			fmt.Sprintf(d.nonNullCount+" AS non_null_count_%[2]d", quoted, i),
+			fmt.Sprintf(d.distinctCount+" AS distinct_non_null_count_%[2]d", quoted, i),
+		)
+	}
+	return strings.Join(selects, ",\n\t")
+}
+
+// queryColumnStats runs a stats query built with statsDialect.selectList
+// and parses its row.
+func queryColumnStats(ctx context.Context, db *sql.DB, query string, args []interface{}, columns []ColumnInfo) (map[string]columnStats, error) {
+	values := make([]interface{}, 1+3*len(columns))
+	dest := make([]interface{}, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := db.QueryRowContext(ctx, query, args...).Scan(dest...); err != nil {
+		return nil, err
+	}
+	return parseColumnStatsRow(values, columns)
+}
+
+// parseColumnStatsRow splits a stats row (total rows, then three counts per
+// column) into per-column stats.
+func parseColumnStatsRow(row []interface{}, columns []ColumnInfo) (map[string]columnStats, error) {
+	if want := 1 + 3*len(columns); len(row) < want {
+		return nil, fmt.Errorf("expected %d stats values, got %d", want, len(row))
+	}
+
+	totalRows, err := int64FromDBValue(row[0])
+	if err != nil {
+		return nil, fmt.Errorf("parse total_rows: %w", err)
+	}
+	stats := make(map[string]columnStats, len(columns))
+	for i, column := range columns {
+		values := row[1+3*i : 4+3*i]
+		s := columnStats{TotalRows: totalRows}
+		if s.NullCount, err = int64FromDBValue(values[0]); err != nil {
+			return nil, fmt.Errorf("parse null_count for %q: %w", column.Name, err)
+		}
+		if s.NonNullCount, err = int64FromDBValue(values[1]); err != nil {
+			return nil, fmt.Errorf("parse non_null_count for %q: %w", column.Name, err)
+		}
+		if s.DistinctNonNullCount, err = int64FromDBValue(values[2]); err != nil {
+			return nil, fmt.Errorf("parse distinct_non_null_count for %q: %w", column.Name, err)
+		}
+		stats[column.Name] = s
+	}
+	return stats, nil
+}
+
+// columnChunk returns the group of chunkColumns(columns, size) that holds
+// column, or nil when none does.
+func columnChunk(columns []ColumnInfo, column ColumnInfo, size int) []ColumnInfo {
+	for _, chunk := range chunkColumns(columns, size) {
+		for _, c := range chunk {
+			if c.Name == column.Name {
+				return chunk
+			}
+		}
+	}
+	return nil
+}
+
+// chunkColumns splits columns into consecutive groups of at most size.
+func chunkColumns(columns []ColumnInfo, size int) [][]ColumnInfo {
+	var chunks [][]ColumnInfo
+	for len(columns) > size {
+		chunks = append(chunks, columns[:size])
+		columns = columns[size:]
+	}
+	if len(columns) > 0 {
+		chunks = append(chunks, columns)
+	}
+	return chunks
+}
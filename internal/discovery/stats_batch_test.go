@@ -0,0 +1,146 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTableStatsBatcherSharesOneLoad(t *testing.T) {
+	var batcher tableStatsBatcher
+	var loads atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	load := func(context.Context) (map[string]columnStats, error) {
+		if loads.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return map[string]columnStats{
+			"id":   {TotalRows: 10, NonNullCount: 10, DistinctNonNullCount: 10},
+			"name": {TotalRows: 10, NullCount: 2, NonNullCount: 8, DistinctNonNullCount: 5},
+		}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make(map[string]columnStats)
+	var mu sync.Mutex
+	for _, column := range []string{"id", "name"} {
+		wg.Add(1)
+		go func(column string) {
+			defer wg.Done()
+			stats, ok, err := batcher.columnStats(context.Background(), "t", column, load)
+			if err != nil || !ok {
+				t.Errorf("columnStats(%q) = ok %v, err %v", column, ok, err)
+				return
+			}
+			mu.Lock()
+			results[column] = stats
+			mu.Unlock()
+		}(column)
+	}
+	<-started
+	close(release)
+	wg.Wait()
+
+	if got := loads.Load(); got != 1 {
+		t.Fatalf("load ran %d times, want 1", got)
+	}
+	if results["name"].NullCount != 2 || results["id"].DistinctNonNullCount != 10 {
+		t.Fatalf("results = %+v", results)
+	}
+	if len(batcher.batches) != 0 {
+		t.Fatalf("batch kept after every column was served: %+v", batcher.batches)
+	}
+}
+
+func TestTableStatsBatcherMissingColumnAndRetryAfterError(t *testing.T) {
+	var batcher tableStatsBatcher
+	loadErr := errors.New("boom")
+
+	_, ok, err := batcher.columnStats(context.Background(), "t", "id", func(context.Context) (map[string]columnStats, error) {
+		return nil, loadErr
+	})
+	if !errors.Is(err, loadErr) || ok {
+		t.Fatalf("columnStats() = ok %v, err %v; want the load error", ok, err)
+	}
+
+	load := func(context.Context) (map[string]columnStats, error) {
+		return map[string]columnStats{"id": {TotalRows: 1}}, nil
+	}
+	if _, ok, err := batcher.columnStats(context.Background(), "t", "payload", load); err != nil || ok {
+		t.Fatalf("columnStats(payload) = ok %v, err %v; want not batched", ok, err)
+	}
+	if stats, ok, err := batcher.columnStats(context.Background(), "t", "id", load); err != nil || !ok || stats.TotalRows != 1 {
+		t.Fatalf("columnStats(id) = %+v, ok %v, err %v", stats, ok, err)
+	}
+}
+
+func TestColumnStatsApply(t *testing.T) {
+	var profile EnrichedColumnInfo
+	columnStats{TotalRows: 4, NullCount: 1, NonNullCount: 3, DistinctNonNullCount: 3}.apply(&profile)
+	if profile.NullOfTotalRowsPct != 25 || profile.NonNullOfTotalRowsPct != 75 || profile.DistinctOfNonNullPct != 100 {
+		t.Fatalf("apply() percentages = %+v", profile)
+	}
+}
+
+func TestStatsBatchKeyAndChunks(t *testing.T) {
+	columns := []ColumnInfo{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	base := statsBatchKey("s", "t", columns, EnrichmentOptions{})
+	if base == statsBatchKey("s", "t", columns, EnrichmentOptions{ApproxDistinct: true}) {
+		t.Fatalf("statsBatchKey() ignores ApproxDistinct")
+	}
+	if base == statsBatchKey("s", "t", columns[:2], EnrichmentOptions{}) {
+		t.Fatalf("statsBatchKey() ignores the column set")
+	}
+
+	chunks := chunkColumns(columns, 2)
+	if len(chunks) != 2 || len(chunks[0]) != 2 || chunks[1][0].Name != "c" {
+		t.Fatalf("chunkColumns() = %+v", chunks)
+	}
+
+	opts := EnrichmentOptions{TableColumns: columns}
+	if !opts.batchesColumn(ColumnInfo{Name: "b"}) || opts.batchesColumn(ColumnInfo{Name: "z"}) {
+		t.Fatalf("batchesColumn() does not follow TableColumns")
+	}
+	if (EnrichmentOptions{TableColumns: columns[:1]}).batchesColumn(columns[0]) {
+		t.Fatalf("batchesColumn() batches a single column")
+	}
+}
+
+func TestBatchedColumnStatsReadsChunksLazily(t *testing.T) {
+	columns := make([]ColumnInfo, columnStatsBatchSize+1)
+	for i := range columns {
+		columns[i] = ColumnInfo{Name: fmt.Sprintf("c%d", i)}
+	}
+	var reads [][]ColumnInfo
+	read := func(_ context.Context, chunk []ColumnInfo) (map[string]columnStats, error) {
+		reads = append(reads, chunk)
+		stats := make(map[string]columnStats, len(chunk))
+		for _, column := range chunk {
+			stats[column.Name] = columnStats{TotalRows: 1}
+		}
+		return stats, nil
+	}
+
+	var batcher tableStatsBatcher
+	opts := EnrichmentOptions{TableColumns: columns}
+	for _, column := range columns[:2] {
+		if _, err := batchedColumnStats(context.Background(), &batcher, "s", "t", column, opts, read, nil); err != nil {
+			t.Fatalf("batchedColumnStats(%s) error = %v", column.Name, err)
+		}
+	}
+	if len(reads) != 1 || len(reads[0]) != columnStatsBatchSize {
+		t.Fatalf("reads after the first chunk's columns = %d, want one read of %d columns", len(reads), columnStatsBatchSize)
+	}
+
+	if _, err := batchedColumnStats(context.Background(), &batcher, "s", "t", columns[columnStatsBatchSize], opts, read, nil); err != nil {
+		t.Fatalf("batchedColumnStats(last) error = %v", err)
+	}
+	if len(reads) != 2 || len(reads[1]) != 1 {
+		t.Fatalf("reads = %d, want the second chunk read on its own call", len(reads))
+	}
+}
@@ -0,0 +1,208 @@
+// Package export renders generated database context into formats aimed at
+// humans and other tools, such as a Markdown data dictionary.
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/genesisdayrit/dbharness/internal/contextgen"
+)
+
+// DictionaryFileName is the file written per database by the Markdown
+// exporter.
+const DictionaryFileName = "DICTIONARY.md"
+
+// Markdown renders a data dictionary for one database: a table of contents
+// followed by one section per schema and one column table per relation.
+// Enrichment stats are included for tables profiled by dbh columns.
+func Markdown(db *contextgen.DatabaseContext) []byte {
+	var buf bytes.Buffer
+	anchors := newAnchorSet()
+
+	fmt.Fprintf(&buf, "# Data dictionary: %s\n\n", db.Database)
+	fmt.Fprintf(&buf, "- Connection: `%s`\n", db.Connection)
+	if db.DatabaseType != "" {
+		fmt.Fprintf(&buf, "- Database type: `%s`\n", db.DatabaseType)
+	}
+	if db.GeneratedAt != "" {
+		fmt.Fprintf(&buf, "- Generated at: %s\n", db.GeneratedAt)
+	}
+	buf.WriteString("\n")
+
+	// Anchors are assigned in document order so the table of contents links
+	// match GitHub's de-duplicated heading IDs.
+	anchors.add("Data dictionary: " + db.Database)
+	anchors.add("Contents")
+	schemaAnchors := make([]string, len(db.Schemas))
+	tableAnchors := make([][]string, len(db.Schemas))
+	for i, schema := range db.Schemas {
+		schemaAnchors[i] = anchors.add(schemaHeading(schema))
+		tableAnchors[i] = make([]string, len(schema.Tables))
+		for j, table := range schema.Tables {
+			tableAnchors[i][j] = anchors.add(tableHeading(schema, table))
+		}
+	}
+
+	buf.WriteString("## Contents\n\n")
+	if len(db.Schemas) == 0 {
+		buf.WriteString("No schemas found.\n\n")
+	}
+	for i, schema := range db.Schemas {
+		fmt.Fprintf(&buf, "- [%s](#%s)\n", escapeLinkText(schema.Name), schemaAnchors[i])
+		for j, table := range schema.Tables {
+			fmt.Fprintf(&buf, "  - [%s](#%s)\n", escapeLinkText(table.Name), tableAnchors[i][j])
+		}
+	}
+	if len(db.Schemas) > 0 {
+		buf.WriteString("\n")
+	}
+
+	for _, schema := range db.Schemas {
+		fmt.Fprintf(&buf, "## %s\n\n", schemaHeading(schema))
+		if description := joinDescriptions(schema.DBDescription, schema.AIDescription); description != "" {
+			fmt.Fprintf(&buf, "%s\n\n", description)
+		}
+		if len(schema.Tables) == 0 {
+			buf.WriteString("No tables.\n\n")
+		}
+
+		for _, table := range schema.Tables {
+			writeTableSection(&buf, schema, table)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+func writeTableSection(buf *bytes.Buffer, schema contextgen.SchemaContext, table contextgen.TableContext) {
+	fmt.Fprintf(buf, "### %s\n\n", tableHeading(schema, table))
+
+	var facts []string
+	if table.Type != "" {
+		facts = append(facts, "Type: "+table.Type)
+	}
+	if table.Enriched && len(table.Columns) > 0 {
+		facts = append(facts, "Rows: "+strconv.FormatInt(table.Columns[0].TotalRows, 10))
+	}
+	if len(facts) > 0 {
+		fmt.Fprintf(buf, "%s\n\n", strings.Join(facts, " · "))
+	}
+	if description := joinDescriptions(table.DBDescription, table.AIDescription); description != "" {
+		fmt.Fprintf(buf, "%s\n\n", description)
+	}
+
+	if len(table.Columns) == 0 {
+		buf.WriteString("_No column metadata. Run `dbh tables` to generate it._\n\n")
+		return
+	}
+
+	if table.Enriched {
+		buf.WriteString("| Column | Type | Nullable | Default | Description | Null % | Distinct | Sample values |\n")
+		buf.WriteString("|---|---|---|---|---|---|---|---|\n")
+	} else {
+		buf.WriteString("| Column | Type | Nullable | Default | Description |\n")
+		buf.WriteString("|---|---|---|---|---|\n")
+	}
+
+	for _, column := range table.Columns {
+		dataType := escapeCell(column.DataType)
+		if column.IsGenerated {
+			dataType += " (generated)"
+		}
+		cells := []string{
+			"`" + escapeCell(column.Name) + "`",
+			dataType,
+			escapeCell(column.IsNullable),
+			escapeCell(column.ColumnDefault),
+			escapeCell(joinDescriptions(column.DBDescription, column.AIDescription)),
+		}
+		if table.Enriched {
+			cells = append(cells,
+				strconv.FormatFloat(column.NullOfTotalRowsPct, 'f', 2, 64),
+				strconv.FormatInt(column.DistinctNonNullCount, 10),
+				escapeCell(formatSampleValues(column.SampleValues)),
+			)
+		}
+		fmt.Fprintf(buf, "| %s |\n", strings.Join(cells, " | "))
+	}
+	buf.WriteString("\n")
+}
+
+func schemaHeading(schema contextgen.SchemaContext) string {
+	return schema.Name
+}
+
+func tableHeading(schema contextgen.SchemaContext, table contextgen.TableContext) string {
+	return schema.Name + "." + table.Name
+}
+
+// joinDescriptions combines the database-native and AI-generated
+// descriptions, skipping blanks.
+func joinDescriptions(dbDescription, aiDescription string) string {
+	parts := make([]string, 0, 2)
+	for _, description := range []string{dbDescription, aiDescription} {
+		if description = strings.TrimSpace(description); description != "" {
+			parts = append(parts, description)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatSampleValues(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, "`"+strings.ReplaceAll(value, "`", "'")+"`")
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// escapeCell makes a value safe inside a Markdown table cell.
+func escapeCell(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", " ")
+	value = strings.ReplaceAll(value, "\n", " ")
+	return strings.ReplaceAll(value, "|", `\|`)
+}
+
+func escapeLinkText(value string) string {
+	replacer := strings.NewReplacer("[", `\[`, "]", `\]`)
+	return replacer.Replace(value)
+}
+
+// anchorSet generates GitHub-style heading anchors, suffixing duplicates
+// with -1, -2, ... in document order.
+type anchorSet struct {
+	seen map[string]int
+}
+
+func newAnchorSet() *anchorSet {
+	return &anchorSet{seen: make(map[string]int)}
+}
+
+func (a *anchorSet) add(heading string) string {
+	base := headingAnchor(heading)
+	count := a.seen[base]
+	a.seen[base] = count + 1
+	if count == 0 {
+		return base
+	}
+	return base + "-" + strconv.Itoa(count)
+}
+
+// headingAnchor lowercases the heading, turns spaces into hyphens, and drops
+// punctuation other than hyphens and underscores.
+func headingAnchor(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(heading)) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/genesisdayrit/dbharness/internal/contextgen"
+)
+
+func TestMarkdown_RendersTOCAndColumnTables(t *testing.T) {
+	db := &contextgen.DatabaseContext{
+		Connection:   "my-db",
+		Database:     "analytics",
+		DatabaseType: "postgres",
+		GeneratedAt:  "2026-02-22T15:30:45Z",
+		Schemas: []contextgen.SchemaContext{
+			{
+				Name:          "public",
+				DBDescription: "Core tables",
+				Tables: []contextgen.TableContext{
+					{
+						Name:     "users",
+						Type:     "BASE TABLE",
+						Enriched: true,
+						Columns: []contextgen.EnrichedColumnsFileItem{
+							{
+								Name:                 "email",
+								DataType:             "character varying",
+								IsNullable:           "YES",
+								DBDescription:        "Login | contact address",
+								TotalRows:            200,
+								DistinctNonNullCount: 175,
+								NullOfTotalRowsPct:   10,
+								SampleValues:         []string{"alice@example.com"},
+							},
+						},
+					},
+					{
+						Name: "orders",
+						Type: "BASE TABLE",
+						Columns: []contextgen.EnrichedColumnsFileItem{
+							{Name: "id", DataType: "integer", IsNullable: "NO", ColumnDefault: "nextval('orders_id_seq')"},
+						},
+					},
+					{Name: "events", Type: "VIEW"},
+				},
+			},
+		},
+	}
+
+	got := string(Markdown(db))
+
+	for _, want := range []string{
+		"# Data dictionary: analytics\n",
+		"- Connection: `my-db`\n",
+		"- [public](#public)\n",
+		"  - [users](#publicusers)\n",
+		"## public\n\nCore tables\n",
+		"### public.users\n\nType: BASE TABLE · Rows: 200\n",
+		"| Column | Type | Nullable | Default | Description | Null % | Distinct | Sample values |\n",
+		"| `email` | character varying | YES |  | Login \\| contact address | 10.00 | 175 | `alice@example.com` |\n",
+		"| Column | Type | Nullable | Default | Description |\n",
+		"| `id` | integer | NO | nextval('orders_id_seq') |  |\n",
+		"_No column metadata. Run `dbh tables` to generate it._\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("Markdown() missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestAnchorSet_DeduplicatesHeadings(t *testing.T) {
+	anchors := newAnchorSet()
+
+	tests := []struct {
+		heading string
+		want    string
+	}{
+		{heading: "public", want: "public"},
+		{heading: "public.users", want: "publicusers"},
+		{heading: "Public", want: "public-1"},
+		{heading: "My Schema.order items", want: "my-schemaorder-items"},
+		{heading: "public", want: "public-2"},
+	}
+
+	for _, tt := range tests {
+		if got := anchors.add(tt.heading); got != tt.want {
+			t.Fatalf("anchors.add(%q) = %q, want %q", tt.heading, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+)
+
+// Sample export formats accepted by WriteSample.
+const (
+	SampleFormatCSV     = "csv"
+	SampleFormatParquet = "parquet"
+)
+
+// SampleFormats lists the sample export formats in the order they are
+// documented.
+var SampleFormats = []string{SampleFormatCSV, SampleFormatParquet}
+
+// WriteSample writes sample rows to w in format, with a header row (CSV) or
+// schema (Parquet) naming the columns. Every column is written as text,
+// holding the same values as the XML sample file; NULL is an empty string.
+func WriteSample(w io.Writer, sample *discovery.SampleResult, format string) error {
+	switch format {
+	case SampleFormatCSV:
+		return writeSampleCSV(w, sample)
+	case SampleFormatParquet:
+		return writeSampleParquet(w, sample)
+	}
+	return fmt.Errorf("unsupported sample export format %q", format)
+}
+
+func writeSampleCSV(w io.Writer, sample *discovery.SampleResult) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(sample.Columns); err != nil {
+		return fmt.Errorf("write csv header: %w", err)
+	}
+	if err := cw.WriteAll(sample.Rows); err != nil {
+		return fmt.Errorf("write csv rows: %w", err)
+	}
+	return nil
+}
+
+func writeSampleParquet(w io.Writer, sample *discovery.SampleResult) error {
+	fields := make([]arrow.Field, len(sample.Columns))
+	for i, column := range sample.Columns {
+		fields[i] = arrow.Field{Name: column, Type: arrow.BinaryTypes.String}
+	}
+	schema := arrow.NewSchema(fields, nil)
+
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+	for _, row := range sample.Rows {
+		for i := range fields {
+			value := ""
+			if i < len(row) {
+				value = row[i]
+			}
+			builder.Field(i).(*array.StringBuilder).Append(value)
+		}
+	}
+	record := builder.NewRecord()
+	defer record.Release()
+
+	writer, err := pqarrow.NewFileWriter(schema, w, parquet.NewWriterProperties(), pqarrow.DefaultWriterProps())
+	if err != nil {
+		return fmt.Errorf("create parquet writer: %w", err)
+	}
+	if err := writer.Write(record); err != nil {
+		writer.Close()
+		return fmt.Errorf("write parquet rows: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("close parquet writer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+)
+
+func testSample() *discovery.SampleResult {
+	return &discovery.SampleResult{
+		Columns: []string{"id", "note"},
+		Rows: [][]string{
+			{"1", "plain"},
+			{"2", "has, comma and \"quotes\""},
+			{"3", ""},
+		},
+	}
+}
+
+func TestWriteSample_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSample(&buf, testSample(), SampleFormatCSV); err != nil {
+		t.Fatalf("WriteSample() error = %v", err)
+	}
+
+	want := "id,note\n1,plain\n2,\"has, comma and \"\"quotes\"\"\"\n3,\n"
+	if buf.String() != want {
+		t.Fatalf("csv = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWriteSample_ParquetRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSample(&buf, testSample(), SampleFormatParquet); err != nil {
+		t.Fatalf("WriteSample() error = %v", err)
+	}
+
+	table, err := pqarrow.ReadTable(context.Background(), bytes.NewReader(buf.Bytes()), parquet.NewReaderProperties(memory.DefaultAllocator), pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatalf("ReadTable() error = %v", err)
+	}
+	defer table.Release()
+
+	if table.NumRows() != 3 || table.NumCols() != 2 {
+		t.Fatalf("table is %d rows x %d cols, want 3 x 2", table.NumRows(), table.NumCols())
+	}
+	if name := table.Schema().Field(1).Name; name != "note" {
+		t.Fatalf("second column = %q, want note", name)
+	}
+	notes := table.Column(1).Data().Chunk(0).(*array.String)
+	if got := notes.Value(1); got != "has, comma and \"quotes\"" {
+		t.Fatalf("note[1] = %q", got)
+	}
+}
+
+func TestWriteSample_RejectsUnknownFormat(t *testing.T) {
+	err := WriteSample(&bytes.Buffer{}, testSample(), "xlsx")
+	if err == nil || !strings.Contains(err.Error(), "xlsx") {
+		t.Fatalf("WriteSample(xlsx) error = %v, want unsupported format", err)
+	}
+}
@@ -0,0 +1,161 @@
+// Package secrets resolves secret references such as ${env:PGPASSWORD} in
+// configuration values, so credentials can stay out of config.json.
+//
+// A reference has the form ${scheme:ref}. Each scheme is handled by a
+// Resolver; Default registers env, vault, and op (1Password). Resolvers for
+// external secret stores shell out to the vendor CLI, so no vendor SDK is a
+// dependency of dbh.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrUnknownScheme is returned for a reference whose scheme has no
+	// registered Resolver.
+	ErrUnknownScheme = errors.New("unknown secret scheme")
+	// ErrNotFound is returned when a reference does not name a secret.
+	ErrNotFound = errors.New("secret not found")
+)
+
+// Resolver returns the secret named by ref, the text after "scheme:" in a
+// ${scheme:ref} reference.
+type Resolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// ResolverFunc adapts a function to the Resolver interface.
+type ResolverFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve calls f(ctx, ref).
+func (f ResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// Set maps reference schemes to their resolvers.
+type Set map[string]Resolver
+
+// Default returns the built-in resolvers:
+//
+//	${env:NAME}               environment variable NAME
+//	${vault:path#field}       vault kv get -field=field path
+//	${op://vault/item/field}  op read op://vault/item/field
+func Default() Set {
+	return Set{
+		"env":   EnvResolver{},
+		"vault": VaultResolver{},
+		"op":    OnePasswordResolver{},
+	}
+}
+
+var referencePattern = regexp.MustCompile(`\$\{([a-z][a-z0-9]*):([^}]*)\}`)
+
+// HasReference reports whether value contains a ${scheme:ref} reference.
+func HasReference(value string) bool {
+	return referencePattern.MatchString(value)
+}
+
+// IsReference reports whether value is exactly one ${scheme:ref} reference,
+// with no literal text around it.
+func IsReference(value string) bool {
+	loc := referencePattern.FindStringIndex(value)
+	return loc != nil && loc[0] == 0 && loc[1] == len(value)
+}
+
+// Expand replaces every ${scheme:ref} reference in value with its secret.
+// Text outside references is kept as is, so "postgres://${env:USER}@db"
+// expands in place.
+func (s Set) Expand(ctx context.Context, value string) (string, error) {
+	matches := referencePattern.FindAllStringSubmatchIndex(value, -1)
+	if len(matches) == 0 {
+		return value, nil
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, match := range matches {
+		scheme := value[match[2]:match[3]]
+		ref := value[match[4]:match[5]]
+
+		resolver, ok := s[scheme]
+		if !ok {
+			return "", fmt.Errorf("%w %q in ${%s:...}", ErrUnknownScheme, scheme, scheme)
+		}
+		secret, err := resolver.Resolve(ctx, ref)
+		if err != nil {
+			return "", fmt.Errorf("resolve ${%s:%s}: %w", scheme, ref, err)
+		}
+
+		out.WriteString(value[last:match[0]])
+		out.WriteString(secret)
+		last = match[1]
+	}
+	out.WriteString(value[last:])
+	return out.String(), nil
+}
+
+// EnvResolver reads environment variables. An unset variable is an error;
+// a variable set to the empty string resolves to "".
+type EnvResolver struct{}
+
+// Resolve returns the value of the environment variable ref.
+func (EnvResolver) Resolve(_ context.Context, ref string) (string, error) {
+	name := strings.TrimSpace(ref)
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("%w: environment variable %s is not set", ErrNotFound, name)
+	}
+	return value, nil
+}
+
+// VaultResolver reads a field of a HashiCorp Vault KV secret with the vault
+// CLI, which uses its usual VAULT_ADDR and token settings. ref is
+// "path#field".
+type VaultResolver struct{}
+
+// Resolve runs vault kv get -field=<field> <path>.
+func (VaultResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok || strings.TrimSpace(path) == "" || strings.TrimSpace(field) == "" {
+		return "", fmt.Errorf("vault reference must be path#field, got %q", ref)
+	}
+	return runCLI(ctx, "vault", "kv", "get", "-field="+strings.TrimSpace(field), strings.TrimSpace(path))
+}
+
+// OnePasswordResolver reads a 1Password secret with the op CLI. ref is the
+// rest of an op:// secret reference, e.g. "//vault/item/field".
+type OnePasswordResolver struct{}
+
+// Resolve runs op read op:<ref>.
+func (OnePasswordResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	if !strings.HasPrefix(ref, "//") {
+		return "", fmt.Errorf("1Password reference must look like op://vault/item/field, got %q", "op:"+ref)
+	}
+	return runCLI(ctx, "op", "read", "--no-newline", "op:"+ref)
+}
+
+// runCLI runs a secret manager CLI and returns its stdout without the
+// trailing newline. The CLI's stderr is included in the error.
+var runCLI = func(ctx context.Context, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("%s CLI not found in PATH: %w", name, err)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
@@ -0,0 +1,99 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSetExpand(t *testing.T) {
+	t.Setenv("DBH_TEST_USER", "analyst")
+	t.Setenv("DBH_TEST_EMPTY", "")
+
+	set := Set{
+		"env": EnvResolver{},
+		"fake": ResolverFunc(func(_ context.Context, ref string) (string, error) {
+			return "<" + ref + ">", nil
+		}),
+	}
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "plain-password", want: "plain-password"},
+		{value: "${env:DBH_TEST_USER}", want: "analyst"},
+		{value: "${env:DBH_TEST_EMPTY}", want: ""},
+		{value: "user=${env:DBH_TEST_USER};key=${fake:a/b#c}", want: "user=analyst;key=<a/b#c>"},
+		{value: "$env:DBH_TEST_USER", want: "$env:DBH_TEST_USER"},
+	}
+	for _, tt := range tests {
+		got, err := set.Expand(context.Background(), tt.value)
+		if err != nil {
+			t.Fatalf("Expand(%q) error = %v", tt.value, err)
+		}
+		if got != tt.want {
+			t.Fatalf("Expand(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestIsReference(t *testing.T) {
+	tests := map[string]bool{
+		"${env:PGPASSWORD}":       true,
+		"${vault:secret/db#pass}": true,
+		"pre${env:PGPASSWORD}":    false,
+		"${env:A}${env:B}":        false,
+		"plain-password":          false,
+		"":                        false,
+	}
+	for value, want := range tests {
+		if got := IsReference(value); got != want {
+			t.Fatalf("IsReference(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestSetExpandErrors(t *testing.T) {
+	set := Default()
+
+	if _, err := set.Expand(context.Background(), "${evn:HOME}"); !errors.Is(err, ErrUnknownScheme) {
+		t.Fatalf("Expand(unknown scheme) error = %v, want ErrUnknownScheme", err)
+	}
+	if _, err := set.Expand(context.Background(), "${env:DBH_TEST_SURELY_UNSET}"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expand(unset env) error = %v, want ErrNotFound", err)
+	}
+	if _, err := set.Expand(context.Background(), "${vault:secret/db}"); err == nil {
+		t.Fatal("Expand(vault without field) error = nil, want error")
+	}
+}
+
+func TestCLIResolvers(t *testing.T) {
+	var calls [][]string
+	original := runCLI
+	runCLI = func(_ context.Context, name string, args ...string) (string, error) {
+		calls = append(calls, append([]string{name}, args...))
+		return "s3cret", nil
+	}
+	defer func() { runCLI = original }()
+
+	set := Default()
+	for _, value := range []string{"${vault:secret/data/db#password}", "${op://Engineering/warehouse/password}"} {
+		got, err := set.Expand(context.Background(), value)
+		if err != nil {
+			t.Fatalf("Expand(%q) error = %v", value, err)
+		}
+		if got != "s3cret" {
+			t.Fatalf("Expand(%q) = %q, want s3cret", value, got)
+		}
+	}
+
+	want := [][]string{
+		{"vault", "kv", "get", "-field=password", "secret/data/db"},
+		{"op", "read", "--no-newline", "op://Engineering/warehouse/password"},
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("CLI calls = %v, want %v", calls, want)
+	}
+}
@@ -0,0 +1,253 @@
+package template
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidTemplate is returned by Validate when a template is missing part
+// of the required .dbharness structure.
+var ErrInvalidTemplate = errors.New("invalid template")
+
+// maxTarballSize caps how much of a downloaded template tarball is read.
+const maxTarballSize = 64 << 20
+
+// Fetched is a template fetched by Fetch. Root is the directory holding the
+// template's .dbharness contents; call Close to remove any temporary copy.
+type Fetched struct {
+	Root    string
+	cleanup func()
+}
+
+// Close removes temporary files created by Fetch.
+func (f *Fetched) Close() {
+	if f.cleanup != nil {
+		f.cleanup()
+	}
+}
+
+// Fetch makes a template available on disk. source is one of:
+//
+//   - a local directory
+//   - a local or http(s) .tar.gz / .tgz archive
+//   - a git URL (anything else with a scheme, git@host:repo, or a .git
+//     suffix), cloned with the git CLI
+//
+// In every case the template is the .dbharness directory inside the source
+// when there is one, and the source itself otherwise.
+func Fetch(ctx context.Context, source string) (*Fetched, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, fmt.Errorf("template source is required")
+	}
+
+	if info, err := os.Stat(source); err == nil {
+		if info.IsDir() {
+			return &Fetched{Root: templateRoot(source)}, nil
+		}
+		if isTarball(source) {
+			return fetchTarball(ctx, source, func() (io.ReadCloser, error) { return os.Open(source) })
+		}
+		return nil, fmt.Errorf("template %s is not a directory or .tar.gz archive", source)
+	}
+
+	if isHTTPURL(source) && isTarball(source) {
+		return fetchTarball(ctx, source, func() (io.ReadCloser, error) { return download(ctx, source) })
+	}
+	if isGitURL(source) {
+		return fetchGit(ctx, source)
+	}
+	return nil, fmt.Errorf("template %s: not a local path, tarball URL, or git URL", source)
+}
+
+// Validate reports whether fsys has the structure dbh init installs: a
+// config.json with a connections list and a context directory.
+func Validate(fsys fs.FS) error {
+	data, err := fs.ReadFile(fsys, "config.json")
+	if err != nil {
+		return fmt.Errorf("%w: missing config.json", ErrInvalidTemplate)
+	}
+	var cfg struct {
+		Connections *[]json.RawMessage `json:"connections"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("%w: parse config.json: %v", ErrInvalidTemplate, err)
+	}
+	if cfg.Connections == nil {
+		return fmt.Errorf("%w: config.json has no connections list", ErrInvalidTemplate)
+	}
+
+	info, err := fs.Stat(fsys, "context")
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("%w: missing context directory", ErrInvalidTemplate)
+	}
+	return nil
+}
+
+func templateRoot(dir string) string {
+	nested := filepath.Join(dir, ".dbharness")
+	if info, err := os.Stat(nested); err == nil && info.IsDir() {
+		return nested
+	}
+	return dir
+}
+
+func isTarball(source string) bool {
+	lower := strings.ToLower(source)
+	if i := strings.IndexAny(lower, "?#"); i >= 0 && isHTTPURL(source) {
+		lower = lower[:i]
+	}
+	return strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz")
+}
+
+func isHTTPURL(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+func isGitURL(source string) bool {
+	return strings.Contains(source, "://") ||
+		strings.HasPrefix(source, "git@") ||
+		strings.HasSuffix(source, ".git")
+}
+
+func fetchGit(ctx context.Context, source string) (*Fetched, error) {
+	dir, err := os.MkdirTemp("", "dbh-template-")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir: %w", err)
+	}
+	fetched := &Fetched{cleanup: func() { os.RemoveAll(dir) }}
+
+	cmd := exec.CommandContext(ctx, "git", "clone", "--quiet", "--depth", "1", "--", source, dir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		fetched.Close()
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return nil, fmt.Errorf("git clone %s: %w: %s", source, err, msg)
+		}
+		return nil, fmt.Errorf("git clone %s: %w", source, err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, ".git")); err != nil {
+		fetched.Close()
+		return nil, fmt.Errorf("remove .git from template: %w", err)
+	}
+
+	fetched.Root = templateRoot(dir)
+	return fetched, nil
+}
+
+func download(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func fetchTarball(ctx context.Context, source string, open func() (io.ReadCloser, error)) (*Fetched, error) {
+	reader, err := open()
+	if err != nil {
+		return nil, fmt.Errorf("open template %s: %w", source, err)
+	}
+	defer reader.Close()
+
+	dir, err := os.MkdirTemp("", "dbh-template-")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir: %w", err)
+	}
+	fetched := &Fetched{cleanup: func() { os.RemoveAll(dir) }}
+
+	if err := extractTarball(ctx, io.LimitReader(reader, maxTarballSize), dir); err != nil {
+		fetched.Close()
+		return nil, fmt.Errorf("extract template %s: %w", source, err)
+	}
+
+	fetched.Root = templateRoot(singleTopLevelDir(dir))
+	return fetched, nil
+}
+
+// extractTarball writes the regular files and directories of a gzipped tar
+// stream under dir. Entries that would land outside dir are rejected.
+func extractTarball(ctx context.Context, r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if name == "." {
+			continue
+		}
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("archive entry %q escapes the template directory", header.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+			if err != nil {
+				return err
+			}
+			_, copyErr := io.Copy(file, tr)
+			closeErr := file.Close()
+			if copyErr != nil {
+				return copyErr
+			}
+			if closeErr != nil {
+				return closeErr
+			}
+		default:
+			// Links and special files are not part of a template.
+		}
+	}
+}
+
+// singleTopLevelDir returns the only entry of dir when it is a directory,
+// as in GitHub archives (repo-main/...), and dir otherwise.
+func singleTopLevelDir(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() || entries[0].Name() == ".dbharness" {
+		return dir
+	}
+	return filepath.Join(dir, entries[0].Name())
+}
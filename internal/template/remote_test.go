@@ -0,0 +1,114 @@
+package template
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestValidate(t *testing.T) {
+	valid := fstest.MapFS{
+		"config.json":       {Data: []byte(`{"connections":[]}`)},
+		"context/README.md": {Data: []byte("# Context\n")},
+	}
+	if err := Validate(valid); err != nil {
+		t.Fatalf("Validate(valid) error = %v", err)
+	}
+
+	invalid := map[string]fstest.MapFS{
+		"missing config":      {"context/README.md": {Data: []byte("x")}},
+		"config not json":     {"config.json": {Data: []byte("{")}, "context/README.md": {Data: []byte("x")}},
+		"no connections list": {"config.json": {Data: []byte(`{}`)}, "context/README.md": {Data: []byte("x")}},
+		"missing context":     {"config.json": {Data: []byte(`{"connections":[]}`)}},
+	}
+	for name, fsys := range invalid {
+		if err := Validate(fsys); !errors.Is(err, ErrInvalidTemplate) {
+			t.Fatalf("Validate(%s) error = %v, want ErrInvalidTemplate", name, err)
+		}
+	}
+}
+
+func TestFetchLocalDirectoryUsesNestedDbharness(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, ".dbharness")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	fetched, err := Fetch(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	defer fetched.Close()
+	if fetched.Root != nested {
+		t.Fatalf("Fetch().Root = %q, want %q", fetched.Root, nested)
+	}
+}
+
+func TestFetchTarball(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "template.tar.gz")
+	writeTestTarball(t, archive, map[string]string{
+		"team-template-main/.dbharness/config.json":       `{"connections":[]}`,
+		"team-template-main/.dbharness/AGENTS.md":         "# Team agents\n",
+		"team-template-main/.dbharness/context/README.md": "# Context\n",
+	})
+
+	fetched, err := Fetch(context.Background(), archive)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	root := fetched.Root
+	data, err := os.ReadFile(filepath.Join(root, "AGENTS.md"))
+	if err != nil || string(data) != "# Team agents\n" {
+		t.Fatalf("AGENTS.md = %q, %v; want the archived file", data, err)
+	}
+	if err := Validate(os.DirFS(root)); err != nil {
+		t.Fatalf("Validate(fetched) error = %v", err)
+	}
+
+	fetched.Close()
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Fatalf("Close() left %s behind (stat error = %v)", root, err)
+	}
+}
+
+func TestFetchTarballRejectsEscapingEntries(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "evil.tgz")
+	writeTestTarball(t, archive, map[string]string{"../outside.txt": "nope"})
+
+	if _, err := Fetch(context.Background(), archive); err == nil {
+		t.Fatal("Fetch() error = nil, want an error for an entry outside the template")
+	}
+}
+
+func writeTestTarball(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		header := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("write tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("write tar entry: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+}
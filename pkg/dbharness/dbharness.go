@@ -0,0 +1,106 @@
+// Package dbharness lets Go programs discover schemas and write dbharness
+// context files without running the dbh CLI.
+//
+// The types are aliases of the ones the CLI uses, so files written through
+// this package are the same as those written by dbh schemas.
+package dbharness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/genesisdayrit/dbharness/internal/contextgen"
+	"github.com/genesisdayrit/dbharness/internal/discovery"
+)
+
+// DatabaseConfig holds the settings for connecting to a database. Type is
+// one of postgres, redshift, mysql, snowflake, bigquery, or sqlite.
+type DatabaseConfig = discovery.DatabaseConfig
+
+// SchemaInfo is a discovered schema and its tables. For SQLite each attached
+// database is reported as a schema.
+type SchemaInfo = discovery.SchemaInfo
+
+// TableInfo is a discovered table or view.
+type TableInfo = discovery.TableInfo
+
+// Options selects where context files are written. ConnectionName and
+// BaseDir (for example ".dbharness") are required.
+type Options = contextgen.Options
+
+// ColumnsFormat is the on-disk format of enriched columns files.
+type ColumnsFormat = contextgen.ColumnsFormat
+
+// DiscoverSchemas connects to the database in cfg and returns its schemas
+// and tables.
+func DiscoverSchemas(cfg DatabaseConfig) ([]SchemaInfo, error) {
+	return DiscoverSchemasContext(context.Background(), cfg)
+}
+
+// DiscoverSchemasContext is DiscoverSchemas with a context that bounds the
+// discovery queries.
+func DiscoverSchemasContext(ctx context.Context, cfg DatabaseConfig) ([]SchemaInfo, error) {
+	schemas, _, err := DiscoverSchemasWithFallback(ctx, cfg)
+	return schemas, err
+}
+
+// DiscoverSchemasWithFallback is DiscoverSchemasContext that also returns
+// the catalog read instead of information_schema when access to it was
+// denied, as CatalogFallback does.
+func DiscoverSchemasWithFallback(ctx context.Context, cfg DatabaseConfig) ([]SchemaInfo, string, error) {
+	disc, err := discovery.New(cfg)
+	if err != nil {
+		return nil, "", fmt.Errorf("connect: %w", err)
+	}
+	defer disc.Close()
+
+	schemas, err := disc.Discover(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("discover schemas: %w", err)
+	}
+	return schemas, CatalogFallback(disc), nil
+}
+
+// CatalogFallback returns the catalog that v, a discoverer or database
+// lister, read metadata from because information_schema access was denied,
+// such as "pg_catalog", or "" when it needed no fallback.
+func CatalogFallback(v any) string {
+	reporter, ok := v.(discovery.CatalogFallbackReporter)
+	if !ok {
+		return ""
+	}
+	return reporter.CatalogFallback()
+}
+
+// Generate writes _databases.yml, _schemas.yml, and a _tables.yml per schema
+// for schemas that were already discovered.
+func Generate(schemas []SchemaInfo, opts Options) error {
+	if strings.TrimSpace(opts.ConnectionName) == "" {
+		return errors.New("generate context files: Options.ConnectionName is required")
+	}
+	if err := contextgen.Generate(schemas, opts); err != nil {
+		return fmt.Errorf("generate context files: %w", err)
+	}
+	return nil
+}
+
+// GenerateContext discovers the schemas of the database in cfg and writes
+// their context files, like dbh schemas. An empty opts.DatabaseType or
+// opts.DatabaseName is taken from cfg; for SQLite, where cfg.Database is the
+// file path, the database name defaults to "main".
+func GenerateContext(cfg DatabaseConfig, opts Options) error {
+	if strings.TrimSpace(opts.DatabaseType) == "" {
+		opts.DatabaseType = cfg.Type
+	}
+	if strings.TrimSpace(opts.DatabaseName) == "" && !strings.EqualFold(cfg.Type, "sqlite") {
+		opts.DatabaseName = cfg.Database
+	}
+
+	schemas, err := DiscoverSchemas(cfg)
+	if err != nil {
+		return err
+	}
+	return Generate(schemas, opts)
+}
@@ -0,0 +1,87 @@
+package dbharness
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func createTestDatabase(t *testing.T) string {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "app.db")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT)`); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	return dbPath
+}
+
+func TestDiscoverSchemas(t *testing.T) {
+	schemas, err := DiscoverSchemas(DatabaseConfig{Type: "sqlite", Database: createTestDatabase(t)})
+	if err != nil {
+		t.Fatalf("DiscoverSchemas() error = %v", err)
+	}
+	if len(schemas) != 1 || schemas[0].Name != "main" {
+		t.Fatalf("DiscoverSchemas() = %+v, want the main schema", schemas)
+	}
+	if len(schemas[0].Tables) != 1 || schemas[0].Tables[0].Name != "users" {
+		t.Fatalf("main tables = %+v, want users", schemas[0].Tables)
+	}
+}
+
+type fallbackReporter string
+
+func (r fallbackReporter) CatalogFallback() string { return string(r) }
+
+func TestDiscoverSchemasWithFallback(t *testing.T) {
+	schemas, fallback, err := DiscoverSchemasWithFallback(context.Background(), DatabaseConfig{Type: "sqlite", Database: createTestDatabase(t)})
+	if err != nil {
+		t.Fatalf("DiscoverSchemasWithFallback() error = %v", err)
+	}
+	if len(schemas) != 1 || fallback != "" {
+		t.Fatalf("DiscoverSchemasWithFallback() = %+v, %q, want the main schema and no fallback", schemas, fallback)
+	}
+
+	if got := CatalogFallback(fallbackReporter("pg_catalog")); got != "pg_catalog" {
+		t.Fatalf("CatalogFallback() = %q, want pg_catalog", got)
+	}
+	if got := CatalogFallback(struct{}{}); got != "" {
+		t.Fatalf("CatalogFallback() of a non-reporter = %q, want empty", got)
+	}
+}
+
+func TestGenerateContext(t *testing.T) {
+	baseDir := t.TempDir()
+	cfg := DatabaseConfig{Type: "sqlite", Database: createTestDatabase(t)}
+
+	if err := GenerateContext(cfg, Options{ConnectionName: "app", BaseDir: baseDir}); err != nil {
+		t.Fatalf("GenerateContext() error = %v", err)
+	}
+
+	databaseDir := filepath.Join(baseDir, "context", "connections", "app", "databases")
+	for _, path := range []string{
+		filepath.Join(databaseDir, "_databases.yml"),
+		filepath.Join(databaseDir, "main", "schemas", "_schemas.yml"),
+		filepath.Join(databaseDir, "main", "schemas", "main", "_tables.yml"),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("expected %s: %v", path, err)
+		}
+	}
+}
+
+func TestGenerateRequiresConnectionName(t *testing.T) {
+	if err := Generate(nil, Options{BaseDir: t.TempDir()}); err == nil {
+		t.Fatal("Generate() without ConnectionName succeeded, want an error")
+	}
+}